@@ -12,6 +12,8 @@ import (
 	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/routes"
 	"github.com/gofiber/fiber/v2"
 	"github.com/spf13/cobra"
+
+	pMetrics "git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/pkg/prometheus"
 )
 
 // GetAPICommandDef runs app
@@ -22,16 +24,18 @@ func GetAPICommandDef(cfg config.AppConfig, logger *zap.Logger) cobra.Command {
 		Long:  `To start api`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 
-			// Create fiber app
-			app := fiber.New(fiber.Config{})
+			// Create fiber app, bodies over the limit are streamed to handlers such as imports
+			app := fiber.New(fiber.Config{StreamRequestBody: true})
 
 			db, err := database.Connect(cfg.DB)
 			if err != nil {
 				return err
 			}
 
+			promMetrics := pMetrics.InitPrometheusMetrics()
+
 			// setup routes
-			err = routes.Setup(app, db, logger, cfg)
+			err = routes.Setup(app, db, logger, cfg, promMetrics)
 			if err != nil {
 				return err
 			}
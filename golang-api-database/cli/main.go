@@ -11,8 +11,9 @@ func Init(cfg config.AppConfig, logger *zap.Logger) error {
 	migrationCmd := GetMigrationCommandDef(cfg)
 	seedCmd := GetSeedCommandDef(cfg, logger)
 	apiCmd := GetAPICommandDef(cfg, logger)
+	purgeCmd := GetPurgeCommandDef(cfg, logger)
 
 	rootCmd := &cobra.Command{Use: "golang-api"}
-	rootCmd.AddCommand(&migrationCmd, &seedCmd, &apiCmd)
+	rootCmd.AddCommand(&migrationCmd, &seedCmd, &apiCmd, &purgeCmd)
 	return rootCmd.Execute()
 }
@@ -2,7 +2,15 @@ package cli
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/config"
 	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/database"
@@ -11,69 +19,315 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// migrationName matches the names migrate create accepts
+var migrationName = regexp.MustCompile(`^[a-z0-9_]+$`)
+
 // GetMigrationCommandDef initialize migration command
 func GetMigrationCommandDef(cfg config.AppConfig) cobra.Command {
+	var upSteps, downSteps int
+	var all, dryRun bool
+
 	migrateCmd := cobra.Command{
 		Use:   "migrate [sub command]",
 		Short: "To run db migrate",
 		Long: `This command is used to run database migration.
-	It has up and down sub commands`,
+	It has up, down, redo, status and create sub commands. A step is a migration, both of its up
+	and down files.`,
 		Args: cobra.MinimumNArgs(1),
 	}
 
 	migrateUp := cobra.Command{
 		Use:   "up",
 		Short: "It will apply migration(s)",
-		Long:  `It will run all remaining migration(s)`,
+		Long:  `It will run all remaining migration(s), or the first --steps of them`,
 		Args:  cobra.MinimumNArgs(0),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			switch cfg.DB.Dialect {
-			case database.POSTGRES:
-				return runPostgresMigration(cfg, "UP")
-			}
-			return nil
+			return runMigration(cfg, cmd.OutOrStdout(), migrate.Up, upSteps, dryRun)
 		},
 	}
+	migrateUp.Flags().IntVar(&upSteps, "steps", 0, "number of migrations to apply, all of them when 0")
 
 	migrateDown := cobra.Command{
 		Use:   "down",
 		Short: "It will revert migration(s)",
-		Long:  `It will run all remaining migration(s)`,
+		Long:  `It will revert the last --steps applied migration(s), or all of them with --all`,
 		Args:  cobra.MinimumNArgs(0),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			switch cfg.DB.Dialect {
-			case database.POSTGRES:
-				return runPostgresMigration(cfg, "DOWN")
+			if all {
+				downSteps = 0
+			} else if downSteps < 1 {
+				return errors.New("--steps must be at least 1, use --all to revert every migration")
 			}
-			return nil
+			return runMigration(cfg, cmd.OutOrStdout(), migrate.Down, downSteps, dryRun)
+		},
+	}
+	migrateDown.Flags().IntVar(&downSteps, "steps", 1, "number of migrations to revert")
+	migrateDown.Flags().BoolVar(&all, "all", false, "revert every migration applied")
+
+	migrateRedo := cobra.Command{
+		Use:   "redo",
+		Short: "It will revert and apply the last migration again",
+		Long:  `It will revert the last applied migration and apply it again`,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRedo(cfg, cmd.OutOrStdout(), dryRun)
+		},
+	}
+
+	for _, cmd := range []*cobra.Command{&migrateUp, &migrateDown, &migrateRedo} {
+		cmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the SQL of the migrations rather than run it")
+	}
+
+	migrateStatus := cobra.Command{
+		Use:   "status",
+		Short: "It will list migration(s)",
+		Long:  `It will list every migration along with when it was applied`,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runStatus(cfg, cmd.OutOrStdout())
 		},
 	}
-	migrateCmd.AddCommand(&migrateUp, &migrateDown)
-	// Migration commands up, down
+
+	migrateCreate := cobra.Command{
+		Use:   "create <name>",
+		Short: "It will create a migration",
+		Long:  `It will create the up and down files of a migration, named after the time and name, in the migration directory`,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createMigration(cfg.DB.MigrationDir, cmd.OutOrStdout(), args[0], time.Now())
+		},
+	}
+
+	migrateCmd.AddCommand(&migrateUp, &migrateDown, &migrateRedo, &migrateStatus, &migrateCreate)
+	// Migration commands up, down, redo, status and create
 
 	return migrateCmd
 }
 
-func runPostgresMigration(cfg config.AppConfig, migrationType string) error {
+func openMigrationDB(cfg config.AppConfig) (*sql.DB, error) {
+	if cfg.DB.Dialect != database.POSTGRES {
+		return nil, errors.New("no suitable dialect found")
+	}
+	return sql.Open(database.POSTGRES, fmt.Sprintf("postgres://%s:%s@%s:%d/%s?%s", cfg.DB.Username, cfg.DB.Password, cfg.DB.Host, cfg.DB.Port, cfg.DB.Db, cfg.DB.QueryString))
+}
+
+// runMigration applies or reverts steps migrations, all of them when steps is 0, or only prints
+// their SQL when dryRun is set
+func runMigration(cfg config.AppConfig, out io.Writer, dir migrate.MigrationDirection, steps int, dryRun bool) error {
+	db, err := openMigrationDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
 	migrations := migrate.FileMigrationSource{
 		Dir: cfg.DB.MigrationDir,
 	}
+	planned, err := planMigrationSteps(db, migrations, dir, steps)
+	if err != nil {
+		return err
+	}
+	if len(planned) == 0 {
+		fmt.Fprintln(out, "No migrations to run")
+		return nil
+	}
+
+	if dryRun {
+		printMigrations(out, planned, dir)
+		return nil
+	}
+	n, err := migrate.ExecMax(db, database.POSTGRES, migrations, dir, len(planned))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "%s %d migration file(s)\n", directionVerb(dir), n)
+	return nil
+}
+
+// runRedo reverts the last migration applied and applies it again
+func runRedo(cfg config.AppConfig, out io.Writer, dryRun bool) error {
+	db, err := openMigrationDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrations := migrate.FileMigrationSource{
+		Dir: cfg.DB.MigrationDir,
+	}
+	planned, err := planMigrationSteps(db, migrations, migrate.Down, 1)
+	if err != nil {
+		return err
+	}
+	if len(planned) == 0 {
+		fmt.Fprintln(out, "No migration to redo")
+		return nil
+	}
+
+	if dryRun {
+		printMigrations(out, planned, migrate.Down)
+		// The migration is applied again in the order its files were first applied
+		reapplied := make([]*migrate.PlannedMigration, len(planned))
+		for i, m := range planned {
+			reapplied[len(planned)-1-i] = &migrate.PlannedMigration{Migration: m.Migration, Queries: m.Up}
+		}
+		printMigrations(out, reapplied, migrate.Up)
+		return nil
+	}
+	if _, err := migrate.ExecMax(db, database.POSTGRES, migrations, migrate.Down, len(planned)); err != nil {
+		return err
+	}
+	if _, err := migrate.ExecMax(db, database.POSTGRES, migrations, migrate.Up, len(planned)); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Redone %s\n", stepName(planned[0].Id))
+	return nil
+}
+
+// planMigrationSteps plans the files of the next steps migrations in dir, all of them when steps
+// is 0. sql-migrate runs the up and down files of a migration as migrations of their own, so a
+// step spans every file of the same name.
+func planMigrationSteps(db *sql.DB, migrations migrate.MigrationSource, dir migrate.MigrationDirection, steps int) ([]*migrate.PlannedMigration, error) {
+	planned, _, err := migrate.PlanMigration(db, database.POSTGRES, migrations, dir, 0)
+	if err != nil {
+		return nil, err
+	}
+	if steps <= 0 {
+		return planned, nil
+	}
+
+	taken := 0
+	for i, m := range planned {
+		if i == 0 || stepName(m.Id) != stepName(planned[i-1].Id) {
+			taken++
+		}
+		if taken > steps {
+			return planned[:i], nil
+		}
+	}
+	return planned, nil
+}
 
-	db, err := sql.Open(database.POSTGRES, fmt.Sprintf("postgres://%s:%s@%s:%d/%s?%s", cfg.DB.Username, cfg.DB.Password, cfg.DB.Host, cfg.DB.Port, cfg.DB.Db, cfg.DB.QueryString))
+// printMigrations writes the SQL of planned migrations run in dir
+func printMigrations(out io.Writer, planned []*migrate.PlannedMigration, dir migrate.MigrationDirection) {
+	for _, m := range planned {
+		fmt.Fprintf(out, "-- %s %s\n", directionName(dir), m.Id)
+		for _, query := range m.Queries {
+			fmt.Fprintln(out, strings.TrimSpace(query))
+		}
+		fmt.Fprintln(out)
+	}
+}
+
+// runStatus lists every migration by name, with the time its files were applied at
+func runStatus(cfg config.AppConfig, out io.Writer) error {
+	db, err := openMigrationDB(cfg)
 	if err != nil {
 		return err
 	}
+	defer db.Close()
 
-	if migrationType == "UP" {
-		_, err = migrate.Exec(db, database.POSTGRES, migrations, migrate.Up)
+	migrations, err := migrate.FileMigrationSource{Dir: cfg.DB.MigrationDir}.FindMigrations()
+	if err != nil {
+		return err
+	}
+	records, err := migrate.GetMigrationRecords(db, database.POSTGRES)
+	if err != nil {
+		return err
+	}
+	appliedAt := make(map[string]time.Time, len(records))
+	for _, record := range records {
+		appliedAt[record.Id] = record.AppliedAt
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "MIGRATION\tAPPLIED AT")
+	for i := 0; i < len(migrations); {
+		name := stepName(migrations[i].Id)
+		var latest time.Time
+		applied, pending := 0, 0
+		for ; i < len(migrations) && stepName(migrations[i].Id) == name; i++ {
+			at, ok := appliedAt[migrations[i].Id]
+			delete(appliedAt, migrations[i].Id)
+			if !ok {
+				pending++
+				continue
+			}
+			applied++
+			if at.After(latest) {
+				latest = at
+			}
+		}
+
+		switch {
+		case pending == 0:
+			fmt.Fprintf(w, "%s\t%s\n", name, latest.Format(time.RFC3339))
+		case applied == 0:
+			fmt.Fprintf(w, "%s\tpending\n", name)
+		default:
+			fmt.Fprintf(w, "%s\tpartially applied\n", name)
+		}
+	}
+	// Migrations applied whose files were removed since
+	for _, record := range records {
+		if _, ok := appliedAt[record.Id]; ok {
+			fmt.Fprintf(w, "%s\t%s (file missing)\n", record.Id, record.AppliedAt.Format(time.RFC3339))
+		}
+	}
+	return w.Flush()
+}
+
+// createMigration writes empty up and down files of the migration name to dir, prefixed with now
+func createMigration(dir string, out io.Writer, name string, now time.Time) error {
+	if !migrationName.MatchString(name) {
+		return fmt.Errorf("invalid migration name %q, use lower case letters, digits and underscores", name)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	base := now.UTC().Format("20060102150405") + "_" + name
+	files := []struct {
+		suffix, content string
+	}{
+		{".up.sql", "-- +migrate Up\n"},
+		{".down.sql", "-- +migrate Down\n"},
+	}
+	for _, f := range files {
+		path := filepath.Join(dir, base+f.suffix)
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
 		if err != nil {
 			return err
 		}
-	} else {
-		_, err = migrate.Exec(db, database.POSTGRES, migrations, migrate.Down)
+		_, err = file.WriteString(f.content)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
 		if err != nil {
 			return err
 		}
+		fmt.Fprintf(out, "Created %s\n", path)
 	}
 	return nil
 }
+
+// stepName is the name of the migration a file belongs to, without its direction and extension
+func stepName(id string) string {
+	name := strings.TrimSuffix(id, ".sql")
+	name = strings.TrimSuffix(name, ".up")
+	return strings.TrimSuffix(name, ".down")
+}
+
+func directionName(dir migrate.MigrationDirection) string {
+	if dir == migrate.Down {
+		return "down"
+	}
+	return "up"
+}
+
+func directionVerb(dir migrate.MigrationDirection) string {
+	if dir == migrate.Down {
+		return "Reverted"
+	}
+	return "Applied"
+}
@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/config"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/database"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/models"
+	"github.com/spf13/cobra"
+)
+
+// GetPurgeCommandDef removes soft deleted movies, ratings, cast and crew for good
+func GetPurgeCommandDef(cfg config.AppConfig, logger *zap.Logger) cobra.Command {
+	var olderThan time.Duration
+
+	purgeCommand := cobra.Command{
+		Use:   "purge",
+		Short: "To remove soft deleted data for good",
+		Long:  `To remove the movies, ratings, cast and crew deleted longer than --older-than ago for good`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			db, err := database.Connect(cfg.DB)
+			if err != nil {
+				logger.Error("Database connection error", zap.Error(err))
+				return err
+			}
+
+			movieModel, err := models.InitMovieModel(db)
+			if err != nil {
+				return err
+			}
+
+			counts, err := movieModel.PurgeDeleted(time.Now().Add(-olderThan))
+			if err != nil {
+				logger.Error("Failed to purge deleted data", zap.Error(err))
+				return err
+			}
+
+			logger.Info("Purged deleted data",
+				zap.Duration("olderThan", olderThan),
+				zap.Int64("movies", counts.Movies),
+				zap.Int64("ratings", counts.Ratings),
+				zap.Int64("casts", counts.Casts),
+				zap.Int64("crew", counts.Crew),
+			)
+			return nil
+		},
+	}
+	purgeCommand.Flags().DurationVar(&olderThan, "older-than", 30*24*time.Hour, "purge data deleted longer ago than this")
+
+	return purgeCommand
+}
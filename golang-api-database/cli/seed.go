@@ -1,41 +1,78 @@
 package cli
 
 import (
-	"encoding/csv"
+	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
-	"regexp"
-	"slices"
-	"strconv"
-	"strings"
+	"path/filepath"
 	"time"
 
 	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/config"
 	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/database"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/database/seed"
 	"github.com/doug-martin/goqu/v9"
 	_ "github.com/lib/pq" // for postgres dialect
+	"github.com/samber/lo"
 	"github.com/spf13/cobra"
 	"go.uber.org/zap"
 )
 
+// SeedOptions select what a seed loads and how
+type SeedOptions struct {
+	// Only holds the kinds seeded, all of them when empty
+	Only []string
+	// Upsert updates the rows present already rather than leaving them as they are
+	Upsert bool
+	// Restart starts the seeds over rather than resuming them
+	Restart bool
+	// QuarantineDir receives the rows rejected from the file of every kind, as <kind>.ndjson
+	QuarantineDir string
+	// Report is the file the quality report is written to
+	Report string
+}
+
+// seedReport is the quality report of a seed, written as JSON
+type seedReport struct {
+	GeneratedAt time.Time        `json:"generated_at"`
+	Files       []seedFileReport `json:"files"`
+}
+
+// seedFileReport counts the rows of the file of a kind, rows_failed are the rows quarantined
+type seedFileReport struct {
+	Kind       string `json:"kind"`
+	File       string `json:"file"`
+	Quarantine string `json:"quarantine,omitempty"`
+	Error      string `json:"error,omitempty"`
+	seed.Stats
+}
+
 // GetSeedCommandDef initialize migration command
 func GetSeedCommandDef(cfg config.AppConfig, logger *zap.Logger) cobra.Command {
+	var opts SeedOptions
+
 	seedCmd := cobra.Command{
 		Use:   "seed",
 		Short: "To run db seed",
-		Long:  `This command is used to run seeding for database.`,
-		Args:  cobra.MinimumNArgs(0),
+		Long: `This command is used to run seeding for database. Every batch of rows is committed on its own,
+a seed which did not finish resumes after the last batch committed when run again on the same files.`,
+		Args: cobra.MinimumNArgs(0),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runPostgresSeed(cfg, logger)
+			return runPostgresSeed(cfg, opts, logger)
 		},
 	}
+	seedCmd.Flags().StringSliceVar(&opts.Only, "only", nil, "seed only these of movies, ratings and credits")
+	seedCmd.Flags().BoolVar(&opts.Upsert, "upsert", false, "update the rows present already rather than leave them as they are")
+	seedCmd.Flags().BoolVar(&opts.Restart, "restart", false, "start over rather than resume the seeds which did not finish")
+	seedCmd.Flags().StringVar(&opts.QuarantineDir, "quarantine-dir", "quarantine", "directory the rejected rows are written to, none when empty")
+	seedCmd.Flags().StringVar(&opts.Report, "report", "seed-report.json", "file the quality report is written to, none when empty")
 
 	return seedCmd
 }
 
-func runPostgresSeed(cfg config.AppConfig, logger *zap.Logger) error {
+func runPostgresSeed(cfg config.AppConfig, opts SeedOptions, logger *zap.Logger) error {
 	db, err := database.Connect(cfg.DB)
 
 	if err != nil {
@@ -43,43 +80,57 @@ func runPostgresSeed(cfg config.AppConfig, logger *zap.Logger) error {
 		return err
 	}
 
-	return SeedAllCSVs(cfg, db, logger)
+	return SeedAllCSVs(cfg, db, opts, logger)
 }
 
-func SeedAllCSVs(cfg config.AppConfig, db *goqu.Database, logger *zap.Logger) error {
-	tx, err := db.Begin()
-	if err != nil {
-		logger.Error("Error starting transaction", zap.Error(err))
-		return err
+// SeedAllCSVs seeds the files of cfg selected by opts, movies first as ratings and credits need
+// them. A summary of the rows written to every table is logged and the quality report written,
+// even when a seed fails.
+func SeedAllCSVs(cfg config.AppConfig, db *goqu.Database, opts SeedOptions, logger *zap.Logger) error {
+	for _, kind := range opts.Only {
+		if err := seed.CheckKind(kind); err != nil {
+			return err
+		}
+	}
+
+	seeds := []struct {
+		kind, path string
+	}{
+		{seed.Movies, cfg.Movies},
+		{seed.Ratings, cfg.Ratings},
+		{seed.Credits, cfg.Credits},
 	}
 
+	report := seedReport{Files: []seedFileReport{}}
 	defer func() {
-		if err != nil {
-			if rollbackErr := tx.Rollback(); rollbackErr != nil {
-				logger.Error("Error rolling back transaction", zap.Error(rollbackErr))
-			} else {
-				logger.Info("Transaction rolled back successfully")
-			}
-		} else {
-			if commitErr := tx.Commit(); commitErr != nil {
-				logger.Error("Error committing transaction", zap.Error(commitErr))
+		for _, file := range report.Files {
+			logSeedSummary(file.Kind, file.Stats, logger)
+		}
+		if opts.Report != "" {
+			if err := writeSeedReport(opts.Report, report); err != nil {
+				logger.Error("Error writing seed report", zap.Error(err))
 			}
 		}
 	}()
 
-	seeds := []struct {
-		name, path string
-		fn         func(*goqu.TxDatabase, string, *zap.Logger) error
-	}{
-		{"movies", cfg.Movies, SeedMoviesMetadata},
-		{"ratings", cfg.Ratings, SeedRatings},
-		{"credits", cfg.Credits, SeedCredits},
-	}
+	for _, s := range seeds {
+		if len(opts.Only) > 0 && !lo.Contains(opts.Only, s.kind) {
+			continue
+		}
 
-	for _, seed := range seeds {
-		logger.Info("Seeding " + seed.name)
-		if err = seed.fn(tx, seed.path, logger); err != nil {
-			logger.Error("Error seeding table "+seed.name, zap.Error(err))
+		logger.Info("Seeding " + s.kind)
+		file := seedFileReport{Kind: s.kind, File: s.path}
+		if opts.QuarantineDir != "" {
+			file.Quarantine = filepath.Join(opts.QuarantineDir, s.kind+".ndjson")
+		}
+		stats, err := seedFile(db, s.kind, s.path, file.Quarantine, opts, logger)
+		file.Stats = stats
+		if err != nil {
+			file.Error = err.Error()
+		}
+		report.Files = append(report.Files, file)
+		if err != nil {
+			logger.Error("Error seeding table "+s.kind, zap.Error(err))
 			return err
 		}
 	}
@@ -88,369 +139,170 @@ func SeedAllCSVs(cfg config.AppConfig, db *goqu.Database, logger *zap.Logger) er
 	return nil
 }
 
-func readCSVFile(filePath string) (*csv.Reader, *os.File, []string, error) {
+// seedFile seeds kind from the file at filePath, writing the rows rejected to the file at
+// quarantinePath unless it is empty
+func seedFile(db *goqu.Database, kind, filePath, quarantinePath string, opts SeedOptions, logger *zap.Logger) (seed.Stats, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("error opening file %s: %w", filePath, err)
-	}
-	reader := csv.NewReader(file)
-	headers, err := reader.Read()
-	if err != nil {
-		file.Close()
-		return nil, nil, nil, fmt.Errorf("error reading headers in %s: %w", filePath, err)
-	}
-	return reader, file, headers, nil
-}
-
-func parseValue(val string) interface{} {
-	if val == "" {
-		return nil
+		logger.Error("csv load error", zap.Error(err))
+		return seed.Stats{}, fmt.Errorf("error opening file %s: %w", filePath, err)
 	}
-	val = strings.TrimSpace(val)
+	defer file.Close()
 
-	switch strings.ToLower(val) {
-	case "inf", "+inf", "-inf", "infinity":
-		return val
-	}
-	if i, err := strconv.Atoi(val); err == nil {
-		return i
-	}
-	if f, err := strconv.ParseFloat(val, 64); err == nil {
-		return f
-	}
-	if t, err := time.Parse("2006-01-02", val); err == nil {
-		return t
+	// The file an unfinished seed read is told apart by its path, size and modification time
+	info, err := file.Stat()
+	if err != nil {
+		return seed.Stats{}, fmt.Errorf("error reading %s: %w", filePath, err)
 	}
-	if strings.HasPrefix(val, "{") || strings.HasPrefix(val, "[") {
-		val = strings.ReplaceAll(val, "'", "\"")
-		var v interface{}
-		_ = json.Unmarshal([]byte(val), &v)
-		b, _ := json.Marshal(v)
-		return string(b)
+	path, err := filepath.Abs(filePath)
+	if err != nil {
+		return seed.Stats{}, fmt.Errorf("error reading %s: %w", filePath, err)
 	}
-	return val
-}
+	source := fmt.Sprintf("%s:%d:%d", path, info.Size(), info.ModTime().Unix())
 
-func insertRecords(tx *goqu.TxDatabase, table string, rows []interface{}, logger *zap.Logger) error {
-	if len(rows) == 0 {
-		logger.Info("No records to insert", zap.String("table", table))
-		return nil
+	if kind == seed.Credits {
+		if err := seed.ReserveCreditIDs(db, file, seed.FormatCSV); err != nil {
+			return seed.Stats{}, fmt.Errorf("error reading %s: %w", filePath, err)
+		}
 	}
 
-	start := time.Now()
-	_, err := tx.Insert(table).Rows(rows...).OnConflict(goqu.DoNothing()).Executor().Exec()
-	logger.Info("Inserted", zap.String("table", table), zap.Int("rows", len(rows)), zap.Duration("duration", time.Since(start)))
-	return err
-}
-
-func SeedMoviesMetadata(tx *goqu.TxDatabase, filePath string, logger *zap.Logger) error {
-	reader, file, headers, err := readCSVFile(filePath)
+	reader, err := seed.NewReader(file, seed.FormatCSV)
 	if err != nil {
 		logger.Error("csv load error", zap.Error(err))
-		return err
+		return seed.Stats{}, fmt.Errorf("error reading %s: %w", filePath, err)
 	}
-	defer file.Close()
 
-	var (
-		movieInserts, movieGenres, movieLanguages []interface{}
-		genreMap                                  = make(map[int]string)
-		languageMap                               = make(map[string]string)
-	)
+	quarantine := &quarantineFile{path: quarantinePath}
+	defer quarantine.Close()
 
-	for {
-		record, err := reader.Read()
-		if err == io.EOF {
-			break
-		} else if err != nil || len(record) != len(headers) {
-			continue
-		}
-
-		row := make(map[string]interface{})
-		var movieID interface{}
-		var genreJSONRaw, spokenLangJSONRaw string
-
-		for i, col := range headers {
-			val := record[i]
-			switch col {
-			case "id":
-				movieID = parseValue(val)
-				row["id"] = movieID
-			case "original_language":
-				code := val
-				if code == "" {
-					code = "xx"
-				}
-				row["original_language"] = code
-				if _, exists := languageMap[code]; !exists {
-					languageMap[code] = "Unknown"
-				}
-			case "genres":
-				genreJSONRaw = val
-			case "spoken_languages":
-				spokenLangJSONRaw = val
-			default:
-				row[col] = parseValue(val)
+	return seed.Seed(db, kind, source, reader, opts.Restart, seed.Options{
+		Upsert: opts.Upsert,
+		OnStart: func(resumed seed.Stats) {
+			if resumed.Read == 0 {
+				return
 			}
-		}
-
-		if movieID == nil {
-			logger.Warn("missing movie ID", zap.String("filePath", filePath))
-			continue
-		}
-
-		movieInserts = append(movieInserts, row)
-		extractGenres(genreJSONRaw, movieID, genreMap, &movieGenres)
-		extractSpokenLanguages(spokenLangJSONRaw, movieID, languageMap, &movieLanguages)
-	}
-
-	var genreRecords, languageRecords []interface{}
-	for id, name := range genreMap {
-		genreRecords = append(genreRecords, map[string]interface{}{
-			"id":   id,
-			"name": name,
-		})
-	}
-	for iso, name := range languageMap {
-		languageRecords = append(languageRecords, map[string]interface{}{
-			"iso_code": iso,
-			"name":     name,
-		})
-	}
-
-	if err := insertRecords(tx, "genres", genreRecords, logger); err != nil {
-		return fmt.Errorf("inserting genres: %w", err)
-	}
-
-	if err := insertRecords(tx, "languages", languageRecords, logger); err != nil {
-		return fmt.Errorf("inserting languages: %w", err)
-	}
-
-	if err := insertRecords(tx, "movies", movieInserts, logger); err != nil {
-		return fmt.Errorf("inserting movies: %w", err)
-	}
-	if err := insertRecords(tx, "movie_genres", movieGenres, logger); err != nil {
-		return fmt.Errorf("inserting movie_genres: %w", err)
-	}
-	if err := insertRecords(tx, "movie_languages", movieLanguages, logger); err != nil {
-		return fmt.Errorf("inserting movie_languages: %w", err)
-	}
-
-	return nil
-}
-
-func extractGenres(raw string, movieID interface{}, genreMap map[int]string, out *[]interface{}) {
-	jsonStr := cleanJSONValue(raw)
-	var genres []map[string]interface{}
-	if err := json.Unmarshal([]byte(jsonStr), &genres); err != nil {
-		return
-	}
-	for _, g := range genres {
-		id := parseValue(fmt.Sprintf("%.0f", g["id"])).(int)
-		name := g["name"].(string)
-		genreMap[id] = name
-		*out = append(*out, map[string]interface{}{
-			"movieid": movieID,
-			"genreid": id,
-		})
-	}
-}
-
-func extractSpokenLanguages(raw string, movieID interface{}, languageMap map[string]string, out *[]interface{}) {
-	jsonStr := cleanJSONValue(raw)
-	var langs []map[string]interface{}
-	if err := json.Unmarshal([]byte(jsonStr), &langs); err != nil {
-		return
-	}
-	for _, l := range langs {
-		iso := l["iso_639_1"].(string)
-		name := l["name"].(string)
-		languageMap[iso] = name
-		*out = append(*out, map[string]interface{}{
-			"movieid":       movieID,
-			"language_code": iso,
-		})
-	}
-}
-
-func cleanJSONValue(raw string) string {
-	raw = strings.ReplaceAll(raw, "None", "null")
-	clean := strings.ReplaceAll(raw, "'", "\"")
-	re := regexp.MustCompile(`\\x[0-9a-fA-F]{2}`)
-	return re.ReplaceAllString(clean, "")
+			if err := quarantine.resume(resumed.Failed); err != nil {
+				logger.Error("Error resuming quarantine", zap.String("path", quarantinePath), zap.Error(err))
+			}
+		},
+		OnRowError: func(rowErr *seed.RowError) {
+			logger.Debug("Skipping bad record", zap.String("filePath", filePath), zap.Error(rowErr))
+			if err := quarantine.Write(rowErr); err != nil {
+				logger.Error("Error quarantining record", zap.String("path", quarantinePath), zap.Error(err))
+			}
+		},
+	}, logger)
 }
 
-func movieExists(tx *goqu.TxDatabase, movieID int) (bool, error) {
-	var count int
-	// Query the movies table to check if the movieId exists
-	ds := tx.From("movies").
-		Where(goqu.C("id").Eq(movieID)).Select(goqu.COUNT("*")) // Assuming "id" is the primary key of the movies table
-
-	_, err := ds.ScanVal(&count)
-	if err != nil {
-		return false, err
-	}
-
-	return count > 0, nil
+// quarantineFile writes rejected rows as JSON objects on separate lines, along with the reason
+// they were rejected for. The file is created with the first row, unless append is set it
+// replaces the file of a former seed. A resumed seed keeps the rows rejected before its checkpoint.
+type quarantineFile struct {
+	path    string
+	append  bool
+	file    *os.File
+	encoder *json.Encoder
 }
 
-func SeedRatings(tx *goqu.TxDatabase, filePath string, logger *zap.Logger) error {
-	reader, file, headers, err := readCSVFile(filePath)
-	if err != nil {
-		logger.Error("csv load error", zap.Error(err))
-		return err
+func (q *quarantineFile) Write(rowErr *seed.RowError) error {
+	if q.path == "" {
+		return nil
 	}
-	defer file.Close()
-
-	var rows []interface{}
-
-	for {
-		record, err := reader.Read()
-		if err == io.EOF {
-			break
-		} else if err != nil || len(record) != len(headers) {
-			continue
+	if q.file == nil {
+		if err := os.MkdirAll(filepath.Dir(q.path), 0o755); err != nil {
+			return err
 		}
-
-		movieID := parseValue(record[slices.Index(headers, "movieId")]).(int)
-		if exists, err := movieExists(tx, movieID); err != nil || !exists {
-			continue
+		flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+		if q.append {
+			flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
 		}
-
-		row := make(map[string]interface{}, len(headers))
-		for i, col := range headers {
-			val := record[i]
-			if col == "movieId" {
-				row["movie_id"] = parseValue(val)
-			} else if col == "userId" {
-				row["user_id"] = parseValue(val)
-
-			} else if col == "timestamp" {
-				ts, _ := strconv.ParseInt(val, 10, 64)
-				row[col] = time.Unix(ts, 0)
-			} else {
-				row[col] = parseValue(val)
-			}
+		file, err := os.OpenFile(q.path, flags, 0o644)
+		if err != nil {
+			return err
 		}
-		rows = append(rows, row)
+		q.file, q.encoder = file, json.NewEncoder(file)
 	}
+	return q.encoder.Encode(rowErr)
+}
 
-	if err := insertRecords(tx, "ratings", rows, logger); err != nil {
-		return fmt.Errorf("inserting ratings: %w", err)
+// resume keeps the first keep rows of the file, the rows rejected by the batches committed
+// before the checkpoint a seed resumes from. The rows after them were rejected by the batch which did not
+// commit, they are cut off as the seed reads and rejects them again.
+func (q *quarantineFile) resume(keep int64) error {
+	q.append = true
+	if q.path == "" {
+		return nil
 	}
-	return nil
-}
 
-func SeedCredits(tx *goqu.TxDatabase, filePath string, logger *zap.Logger) error {
-	reader, file, headers, err := readCSVFile(filePath)
+	file, err := os.OpenFile(q.path, os.O_RDWR, 0o644)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
 	if err != nil {
-		logger.Error("csv load error", zap.Error(err))
 		return err
 	}
-	defer file.Close()
 
-	creditsMap := make(map[string]goqu.Record)
-	castMap := make(map[string]goqu.Record)
-	crewMap := make(map[string]goqu.Record)
-
-	for {
-		record, err := reader.Read()
+	// A torn last line is not a row, it is cut off along
+	var offset int64
+	reader := bufio.NewReader(file)
+	for kept := int64(0); kept < keep; kept++ {
+		line, err := reader.ReadBytes('\n')
 		if err == io.EOF {
 			break
-		} else if err != nil || len(record) != len(headers) {
-			logger.Warn("Skipping bad record", zap.Error(err))
-			continue
-		}
-
-		movieID := parseValue(record[slices.Index(headers, "id")]).(int)
-		if exists, err := movieExists(tx, movieID); err != nil || !exists {
-			continue
 		}
-
-		var castRaw, crewRaw string
-		row := make(map[string]interface{}, len(headers))
-
-		for i, col := range headers {
-			val := record[i]
-			switch col {
-			case "cast":
-				castRaw = record[i]
-			case "crew":
-				crewRaw = record[i]
-			default:
-				row[col] = parseValue(val)
-			}
+		if err != nil {
+			file.Close()
+			return err
 		}
-
-		extractCreditsRecords(movieID, castRaw, "cast", creditsMap, castMap)
-		extractCreditsRecords(movieID, crewRaw, "crew", creditsMap, crewMap)
+		offset += int64(len(line))
 	}
 
-	// Insert into tables
-	if err := insertRecords(tx, "credits", valuesFromMap(creditsMap), logger); err != nil {
-		return fmt.Errorf("inserting people: %w", err)
-	}
-	if err := insertRecords(tx, "movie_casts", valuesFromMap(castMap), logger); err != nil {
-		return fmt.Errorf("inserting movie_casts: %w", err)
+	if err := file.Truncate(offset); err != nil {
+		file.Close()
+		return err
 	}
-	if err := insertRecords(tx, "movie_crew", valuesFromMap(crewMap), logger); err != nil {
-		return fmt.Errorf("inserting movie_crew: %w", err)
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return err
 	}
-
+	q.file, q.encoder = file, json.NewEncoder(file)
 	return nil
 }
 
-func valuesFromMap(m map[string]goqu.Record) []interface{} {
-	out := make([]interface{}, 0, len(m))
-	for _, v := range m {
-		out = append(out, v)
+func (q *quarantineFile) Close() error {
+	if q.file == nil {
+		return nil
 	}
-	return out
+	return q.file.Close()
 }
 
-func extractCreditsRecords(movieID interface{}, rawJSON string, role string, creditsMap map[string]goqu.Record, roleMap map[string]goqu.Record) {
-	cleanedJSON := cleanJSONValue(rawJSON)
-	var creditsList []map[string]interface{}
-
-	if err := json.Unmarshal([]byte(cleanedJSON), &creditsList); err != nil {
-		return
+// writeSeedReport writes report as JSON to the file at path
+func writeSeedReport(path string, report seedReport) error {
+	report.GeneratedAt = time.Now().UTC()
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
 	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
 
-	for _, person := range creditsList {
-		rawID, hasID := person["id"].(float64)
-		if !hasID {
-			continue
-		}
-		personID := fmt.Sprintf("%.0f", rawID)
-		creditID, _ := person["credit_id"].(string)
-
-		// Add person to peopleMap if not already present
-		if _, exists := creditsMap[personID]; !exists {
-			creditsMap[personID] = goqu.Record{
-				"id":           personID,
-				"name":         person["name"],
-				"gender":       int(person["gender"].(float64)),
-				"profile_path": person["profile_path"],
-			}
-		}
-
-		// Add role-specific entry to roleMap if not already present
-		if _, exists := roleMap[creditID]; !exists {
-			roleRecord := goqu.Record{
-				"movie_id":  movieID,
-				"person_id": personID,
-				"credit_id": creditID,
-			}
-
-			if role == "cast" {
-				roleRecord["cast_id"] = int(person["cast_id"].(float64))
-				roleRecord["character"] = person["character"]
-				roleRecord["cast_order"] = int(person["order"].(float64))
-			} else if role == "crew" {
-				roleRecord["department"] = person["department"]
-				roleRecord["job"] = person["job"]
-			}
-
-			roleMap[creditID] = roleRecord
-		}
+// logSeedSummary logs the rows read from the file of kind and the rows written to every table
+func logSeedSummary(kind string, stats seed.Stats, logger *zap.Logger) {
+	logger.Info("Seed summary",
+		zap.String("kind", kind),
+		zap.Int64("read", stats.Read),
+		zap.Int64("loaded", stats.Loaded),
+		zap.Int64("invalid", stats.Failed),
+		zap.Any("rejected", stats.Rejected),
+	)
+	for _, table := range stats.Tables {
+		logger.Info("Seed summary",
+			zap.String("kind", kind),
+			zap.String("table", table.Table),
+			zap.Int64("inserted", table.Inserted),
+			zap.Int64("updated", table.Updated),
+			zap.Int64("skipped", table.Skipped),
+			zap.Int64("deleted", table.Deleted),
+		)
 	}
 }
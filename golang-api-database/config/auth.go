@@ -0,0 +1,10 @@
+package config
+
+// AuthConfig type of auth config object. Requests authenticate with a JWT signed by the key
+// in JWTKeyFile or with one of APIKeys, given as comma separated key:subject:role entries.
+type AuthConfig struct {
+	Enabled      bool   `envconfig:"AUTH_ENABLED" default:"true"`
+	JWTAlgorithm string `envconfig:"AUTH_JWT_ALGORITHM" default:"HS256"`
+	JWTKeyFile   string `envconfig:"AUTH_JWT_KEY_FILE"`
+	APIKeys      string `envconfig:"AUTH_API_KEYS"`
+}
@@ -18,9 +18,16 @@ type AppConfig struct {
 	Env           string `envconfig:"APP_ENV"`
 	Port          string `envconfig:"APP_PORT"`
 	DB            DBConfig
+	Auth          AuthConfig
+	RateLimit     RateLimitConfig
 	Movies        string `envconfig:"MOVIES"`
 	Credits       string `envconfig:"CREDITS"`
 	Ratings       string `envconfig:"RATINGS"`
+
+	// Movie scores are weighted as if every movie had RatingPriorVotes more ratings of
+	// RatingPriorMean, the mean of all ratings when it is 0
+	RatingPriorVotes float64 `envconfig:"RATING_PRIOR_VOTES" default:"10"`
+	RatingPriorMean  float64 `envconfig:"RATING_PRIOR_MEAN" default:"0"`
 }
 
 // GetConfig Collects all configs
@@ -2,48 +2,103 @@ package constants
 
 // params
 const (
-	ParamMid = "movieId"
-	CastId   = "castId"
-	UserId   = "userId"
-	CreditId = "creditId"
+	ParamMid     = "movieId"
+	CastId       = "castId"
+	UserId       = "userId"
+	CreditId     = "creditId"
+	ParamKind    = "kind"
+	JobId        = "jobId"
+	CompanyId    = "companyId"
+	CountryCode  = "countryCode"
+	CollectionId = "collectionId"
+	PersonId     = "personId"
 )
 
 // Success messages
 const (
-	DeleteMovieSuccess  = "movie deleted successfully"
-	DeleteRatingSuccess = "ratings deleted successfully"
-	AddMovieSuccess     = "movie added successfully"
-	AddRatingSuccess    = "rating added successfully"
-	AddMovieCrewSuccess = "movie crew added successfully"
-	AddMovieCastSuccess = "movie cast added successfully"
-	UpdateMovieSuccess  = "movie updated successfully"
-	UpdateRatingSuccess = "ratings updated successfully"
+	DeleteMovieSuccess      = "movie deleted successfully"
+	RestoreMovieSuccess     = "movie restored successfully"
+	DeleteRatingSuccess     = "ratings deleted successfully"
+	AddMovieSuccess         = "movie added successfully"
+	AddRatingSuccess        = "rating added successfully"
+	AddMovieCrewSuccess     = "movie crew added successfully"
+	AddMovieCastSuccess     = "movie cast added successfully"
+	UpdateMovieSuccess      = "movie updated successfully"
+	UpdateRatingSuccess     = "ratings updated successfully"
+	AttachCollectionSuccess = "movie added to collection successfully"
+	DetachCollectionSuccess = "movie removed from collection successfully"
+	UpdatePersonSuccess     = "person updated successfully"
+	DeletePersonSuccess     = "person deleted successfully"
 )
 
 // Fail messages
 const (
-	MovieNotExist      = "movie does not exists"
-	CastsNotExist      = "casts does not exists for given movie"
-	ActorNotExist      = "actor does not exists"
-	RatingNotExist     = "rating does not exists for given movie and user"
-	InvalidPageOrLimit = "invalid page or limit value"
-	InvalidRequestBody = "invalid request values"
-	ValidationFailed   = "invalid input"
+	MovieNotExist        = "movie does not exists"
+	CastsNotExist        = "casts does not exists for given movie"
+	ActorNotExist        = "actor does not exists"
+	RatingNotExist       = "rating does not exists for given movie and user"
+	InvalidPageOrLimit   = "invalid page or limit value"
+	InvalidCursorOrLimit = "invalid cursor or limit value"
+	InvalidRequestBody   = "invalid request values"
+	ValidationFailed     = "invalid input"
+	SearchQueryRequired  = "search query q is required"
+	UserRatingsNotExist  = "ratings does not exists for given user"
+	InvalidTopRatedLimit = "limit must be between 1 and 100"
+	MovieNotDeleted      = "movie is not deleted"
+	InvalidAuditSince    = "since must be an RFC 3339 time"
+	InvalidAuditLimit    = "limit must be between 1 and 1000"
+	InvalidImportKind    = "kind must be movies, ratings or credits"
+	InvalidImportFormat  = "format must be csv, json or ndjson"
+	ImportJobNotExist    = "import job does not exists"
+	InvalidExportKind    = "kind must be movies, ratings or credits"
+	InvalidExportFormat  = "format must be csv, ndjson or parquet"
+	CompanyNotExist      = "company does not exists"
+	CountryNotExist      = "country does not exists"
+	CollectionNotExist   = "collection does not exists"
+	PersonNotExist       = "person does not exists"
+	PersonCredited       = "person is credited on movies, delete with cascade=true to delete their credits along"
+	PreconditionFailed   = "resource was changed, fetch it again before retrying"
+)
+
+// Auth fail messages
+const (
+	InvalidCredentials     = "invalid credentials"
+	AuthenticationRequired = "authentication required"
+	PermissionDenied       = "permission denied"
+	RatingOwnerError       = "ratings of other users cannot be changed"
+	RateLimitExceeded      = "rate limit exceeded, retry later"
 )
 
 // Error messages
 const (
-	ErrHealthCheckDb = "error while checking health of database"
-	ErrGetMovie      = "error while get movie"
-	ErrGetRatings    = "error while get ratings"
-	ErrGetCasts      = "error while get movie casts"
-	ErrGetCrew       = "error while get movie crew"
-	ErrAddMovie      = "error while adding movie"
-	ErrAddRating     = "error while adding movie ratings"
-	ErrAddMovieCrew  = "error while adding movie crew member"
-	ErrAddMovieCast  = "error while adding movie cast"
-	UpdateMovieError = "error while updating movie"
-	ErrUpdateRating  = "error while updating rating"
-	ErrDeleteRating  = "error while delete rating"
-	ErrDeleteMovie   = "error while deleting move"
+	ErrHealthCheckDb    = "error while checking health of database"
+	ErrGetMovie         = "error while get movie"
+	ErrGetRatings       = "error while get ratings"
+	ErrGetCasts         = "error while get movie casts"
+	ErrGetCrew          = "error while get movie crew"
+	ErrAddMovie         = "error while adding movie"
+	ErrAddRating        = "error while adding movie ratings"
+	ErrAddMovieCrew     = "error while adding movie crew member"
+	ErrAddMovieCast     = "error while adding movie cast"
+	UpdateMovieError    = "error while updating movie"
+	ErrUpdateRating     = "error while updating rating"
+	ErrDeleteRating     = "error while delete rating"
+	ErrDeleteMovie      = "error while deleting move"
+	ErrRestoreMovie     = "error while restoring movie"
+	ErrSearchMovies     = "error while searching movies"
+	ErrGetUserRatings   = "error while get ratings of user"
+	ErrGetUserStats     = "error while get rating stats of user"
+	ErrGetTopRated      = "error while get top rated movies"
+	ErrGetAudit         = "error while get audit log"
+	ErrRecordAudit      = "error while recording audit entry"
+	ErrSaveImport       = "error while saving import upload"
+	ErrExport           = "error while exporting"
+	ErrGetCompany       = "error while get companies"
+	ErrGetCountry       = "error while get country"
+	ErrGetCollection    = "error while get collections"
+	ErrUpdateCollection = "error while updating collection of movie"
+	ErrGetPerson        = "error while get person"
+	ErrAddPerson        = "error while adding person"
+	ErrUpdatePerson     = "error while updating person"
+	ErrDeletePerson     = "error while deleting person"
 )
@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/constants"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/middlewares"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/models"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// anonymousActor is the actor of writes made without credentials, which auth disabled allows
+const anonymousActor = "anonymous"
+
+// Auditor records the writes made through the controllers in the audit log
+type Auditor struct {
+	db         *goqu.Database
+	auditModel *models.AuditModel
+	logger     *zap.Logger
+}
+
+// NewAuditor is to intialize Auditor
+func NewAuditor(goqu *goqu.Database, logger *zap.Logger) (*Auditor, error) {
+	model, err := models.InitAuditModel(goqu)
+	if err != nil {
+		return nil, err
+	}
+	return &Auditor{
+		db:         goqu,
+		auditModel: model,
+		logger:     logger,
+	}, nil
+}
+
+// auditedWrite is a write to record in the audit log. It changed the entity having ID from Before
+// into After, a nil Before or After standing for an entity which did not exist.
+type auditedWrite struct {
+	Entity    string
+	ID        string
+	Operation string
+	Before    interface{}
+	After     interface{}
+}
+
+// write runs write, which makes its changes through models bound to tx with WithTx, and records
+// it in the audit log in the same transaction. The write and its entry are committed together:
+// when the entry cannot be recorded the write is rolled back and the error returned, so the
+// request fails. Errors of write are returned as is.
+func (a *Auditor) write(c *fiber.Ctx, write func(tx *goqu.TxDatabase) (auditedWrite, error)) error {
+	actor := actorOf(c)
+	return a.db.WithTx(func(tx *goqu.TxDatabase) error {
+		audited, err := write(tx)
+		if err != nil {
+			return err
+		}
+		return a.recordIn(tx, actor, audited)
+	})
+}
+
+// recordIn adds the write made by actor to the audit log in tx, the transaction of the write, which
+// is to be rolled back when the entry cannot be recorded. Writes finished after their request, as
+// import jobs, record their entry with it before they commit.
+func (a *Auditor) recordIn(tx *goqu.TxDatabase, actor string, audited auditedWrite) error {
+	entry, err := models.NewAuditEntry(actor, audited.Entity, audited.ID, audited.Operation, audited.Before, audited.After)
+	if err == nil {
+		err = a.auditModel.WithTx(tx).Record(&entry)
+	}
+	if err != nil {
+		a.logger.Error(constants.ErrRecordAudit, zap.String("entity", audited.Entity), zap.String("id", audited.ID), zap.Error(err))
+		return fmt.Errorf("%s: %w", constants.ErrRecordAudit, err)
+	}
+	return nil
+}
+
+// found returns entity, or nil when reading it found nothing, as audited writes take the state
+// of an entity which does not exist
+func found[T any](entity T, err error) (interface{}, error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return entity, nil
+}
+
+// actorOf is the actor of the writes made by the caller of c
+func actorOf(c *fiber.Ctx) string {
+	if principal := middlewares.CurrentPrincipal(c); principal != nil && principal.Subject != "" {
+		return principal.Subject
+	}
+	return anonymousActor
+}
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/constants"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/models"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/utils"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// Number of audit entries returned when the limit query is missing, and at most
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// AuditController for auditModel controllers
+type AuditController struct {
+	auditModel *models.AuditModel
+	logger     *zap.Logger
+}
+
+// NewAuditController is to intialize AuditController
+func NewAuditController(goqu *goqu.Database, logger *zap.Logger) (*AuditController, error) {
+	model, err := models.InitAuditModel(goqu)
+	if err != nil {
+		return nil, err
+	}
+	return &AuditController{
+		auditModel: model,
+		logger:     logger,
+	}, nil
+}
+
+// ListAudit lists the audit log
+// swagger:route GET /audit Audit ListAudit
+//
+// Retrieves the writes made to movies, ratings, cast and crew, oldest first.
+//
+// Produces:
+// - application/json
+//
+// Parameters:
+// - RequestListAudit
+//
+// Responses:
+//
+//	200: ResponseListAudit
+//	400: GenericResFailBadRequest
+//	401: GenericResFailUnauthorized
+//	403: GenericResFailForbidden
+//	500: GenericResError
+func (ctrl *AuditController) ListAudit(c *fiber.Ctx) error {
+	filter := models.AuditFilter{
+		Entity:   c.Query("entity"),
+		EntityID: c.Query("id"),
+		Limit:    defaultAuditLimit,
+	}
+
+	if since := c.Query("since"); since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidAuditSince)
+		}
+		filter.Since = t
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > maxAuditLimit {
+			return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidAuditLimit)
+		}
+		filter.Limit = uint(n)
+	}
+
+	entries, err := ctrl.auditModel.ListAudit(filter)
+	if err != nil {
+		ctrl.logger.Error(constants.ErrGetAudit, zap.Error(err))
+		return utils.JSONError(c, http.StatusInternalServerError, constants.ErrGetAudit)
+	}
+
+	return utils.JSONSuccess(c, http.StatusOK, entries)
+}
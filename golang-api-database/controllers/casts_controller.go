@@ -18,6 +18,7 @@ import (
 
 type CastController struct {
 	castModel *models.CastsModel
+	auditor   *Auditor
 	logger    *zap.Logger
 }
 
@@ -26,8 +27,13 @@ func NewCastController(goqu *goqu.Database, logger *zap.Logger) (*CastController
 	if err != nil {
 		return nil, err
 	}
+	auditor, err := NewAuditor(goqu, logger)
+	if err != nil {
+		return nil, err
+	}
 	return &CastController{
 		castModel: model,
+		auditor:   auditor,
 		logger:    logger,
 	}, nil
 }
@@ -49,21 +55,40 @@ func NewCastController(goqu *goqu.Database, logger *zap.Logger) (*CastController
 // Responses:
 //
 //	200: ResponseListCastMembers
+//	304: description: Not Modified
+//	400: GenericResFailBadRequest
 //	404: GenericResFailNotFound
 //	500: GenericResError
 func (ctrl *CastController) ListCastMembers(c *fiber.Ctx) error {
 	movieId := c.Params(constants.ParamMid)
 
-	casts, err := ctrl.castModel.ListCasts(movieId)
+	page, err := CursorQuery(c)
+	if err != nil {
+		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidCursorOrLimit)
+	}
+
+	casts, err := ctrl.castModel.ListCasts(movieId, page)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return utils.JSONFail(c, http.StatusNotFound, constants.CastsNotExist)
 		}
+		if errors.Is(err, models.ErrInvalidCursor) {
+			return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidCursorOrLimit)
+		}
 		ctrl.logger.Error("error while get casts of movie by id", zap.Any("id", movieId), zap.Error(err))
 		return utils.JSONError(c, http.StatusInternalServerError, constants.ErrGetCasts)
 	}
 
-	return utils.JSONSuccess(c, http.StatusOK, casts)
+	fresh, err := notModified(c, casts)
+	if err != nil {
+		ctrl.logger.Error("error while tagging casts of movie", zap.Any("id", movieId), zap.Error(err))
+		return utils.JSONError(c, http.StatusInternalServerError, constants.ErrGetCasts)
+	}
+	if fresh {
+		return c.SendStatus(http.StatusNotModified)
+	}
+
+	return utils.JSONPage(c, http.StatusOK, casts.Items, casts)
 }
 
 // ListMoviesByCastId retrieves all movie titles in which actor had played role
@@ -83,21 +108,30 @@ func (ctrl *CastController) ListCastMembers(c *fiber.Ctx) error {
 // Responses:
 //
 //	200: ResponseListMoviesByCastId
+//	400: GenericResFailBadRequest
 //	404: GenericResFailNotFound
 //	500: GenericResError
 func (ctrl *CastController) ListMoviesByCastId(c *fiber.Ctx) error {
 	castId := c.Params(constants.CastId)
 
-	movies, err := ctrl.castModel.ListMoviesByCastId(castId)
+	page, err := CursorQuery(c)
+	if err != nil {
+		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidCursorOrLimit)
+	}
+
+	actor, movies, err := ctrl.castModel.ListMoviesByCastId(castId, page)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return utils.JSONFail(c, http.StatusNotFound, constants.ActorNotExist)
 		}
+		if errors.Is(err, models.ErrInvalidCursor) {
+			return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidCursorOrLimit)
+		}
 		ctrl.logger.Error("error while get movies by cast id", zap.Any("id", castId), zap.Error(err))
 		return utils.JSONError(c, http.StatusInternalServerError, constants.ErrGetMovie)
 	}
 
-	return utils.JSONSuccess(c, http.StatusOK, movies)
+	return utils.JSONPage(c, http.StatusOK, actor, movies)
 }
 
 // AddMovieCastsMember add a cast member of a movie
@@ -117,7 +151,9 @@ func (ctrl *CastController) ListMoviesByCastId(c *fiber.Ctx) error {
 // Responses:
 //
 //	200: GenericResOk
-//	401: GenericResFailBadRequest
+//	400: GenericResFailBadRequest
+//	401: GenericResFailUnauthorized
+//	403: GenericResFailForbidden
 //	404: GenericResFailNotFound
 //	500: GenericResError
 func (ctrl *CastController) AddMovieCastMember(c *fiber.Ctx) error {
@@ -157,7 +193,13 @@ func (ctrl *CastController) AddMovieCastMember(c *fiber.Ctx) error {
 		return utils.JSONFail(c, http.StatusBadRequest, err.Error())
 	}
 
-	if err := ctrl.castModel.AddMovieCasts(&cast); err != nil {
+	err = ctrl.auditor.write(c, func(tx *goqu.TxDatabase) (auditedWrite, error) {
+		if err := ctrl.castModel.WithTx(tx).AddMovieCasts(&cast); err != nil {
+			return auditedWrite{}, err
+		}
+		return auditedWrite{Entity: models.AuditCast, ID: models.AuditEntityID(movieid, cast.CreditID), Operation: models.AuditCreate, After: cast}, nil
+	})
+	if err != nil {
 		if errors.Is(err, models.ErrMovieNotFound) || errors.Is(err, models.ErrCreditNotFound) {
 			return utils.JSONFail(c, http.StatusNotFound, "Movie or credit not found")
 		}
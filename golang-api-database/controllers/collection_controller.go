@@ -0,0 +1,225 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/constants"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/models"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/utils"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/go-playground/validator"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// CollectionController for collectionModel controllers
+type CollectionController struct {
+	collectionModel *models.CollectionModel
+	movieModel      *models.MovieModel
+	auditor         *Auditor
+	logger          *zap.Logger
+}
+
+// NewCollectionController is to intialize CollectionController
+func NewCollectionController(goqu *goqu.Database, logger *zap.Logger) (*CollectionController, error) {
+	collectionModel, err := models.InitCollectionModel(goqu)
+	if err != nil {
+		return nil, err
+	}
+	movieModel, err := models.InitMovieModel(goqu)
+	if err != nil {
+		return nil, err
+	}
+	auditor, err := NewAuditor(goqu, logger)
+	if err != nil {
+		return nil, err
+	}
+	return &CollectionController{
+		collectionModel: collectionModel,
+		movieModel:      movieModel,
+		auditor:         auditor,
+		logger:          logger,
+	}, nil
+}
+
+// ListCollections lists movie collections with pagination
+// swagger:route GET /collections Collections ListCollections
+//
+// Retrieves a paginated list of movie collections along with their film count, box office and
+// average rating.
+//
+// Produces:
+// - application/json
+//
+// Parameters:
+// - RequestListCollections
+//
+// Responses:
+//
+//	200: ResponseListCollections
+//	400: GenericResFailBadRequest
+//	500: GenericResError
+func (ctrl *CollectionController) ListCollections(c *fiber.Ctx) error {
+	page, err := CursorQuery(c)
+	if err != nil {
+		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidCursorOrLimit)
+	}
+
+	collections, err := ctrl.collectionModel.ListCollections(c.Query("name"), page)
+	if errors.Is(err, models.ErrInvalidCursor) {
+		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidCursorOrLimit)
+	}
+	if err != nil {
+		ctrl.logger.Error(constants.ErrGetCollection, zap.Error(err))
+		return utils.JSONError(c, http.StatusInternalServerError, constants.ErrGetCollection)
+	}
+
+	return utils.JSONPage(c, http.StatusOK, collections.Items, collections)
+}
+
+// GetCollection retrieves a movie collection by ID
+// swagger:route GET /collections/{collectionId} Collections GetCollection
+//
+// Retrieves a movie collection along with its film count, box office, average rating and its
+// movies in release order.
+//
+// Produces:
+// - application/json
+//
+// Parameters:
+// - RequestGetCollection
+//
+// Responses:
+//
+//	200: ResponseGetCollection
+//	400: GenericResFailBadRequest
+//	404: GenericResFailNotFound
+//	500: GenericResError
+func (ctrl *CollectionController) GetCollection(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params(constants.CollectionId))
+	if err != nil {
+		return utils.JSONFail(c, http.StatusBadRequest, "collection ID must be a valid integer")
+	}
+
+	collection, err := ctrl.collectionModel.GetCollection(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return utils.JSONFail(c, http.StatusNotFound, constants.CollectionNotExist)
+		}
+		ctrl.logger.Error(constants.ErrGetCollection, zap.Int("id", id), zap.Error(err))
+		return utils.JSONError(c, http.StatusInternalServerError, constants.ErrGetCollection)
+	}
+
+	return utils.JSONSuccess(c, http.StatusOK, collection)
+}
+
+// AttachMovieCollection adds a movie to a collection
+// swagger:route PUT /movies/{movieId}/collection Collections AttachMovieCollection
+//
+// Makes a movie a member of a collection, in place of the collection it belonged to.
+//
+// Consumes:
+// - application/json
+//
+// Produces:
+// - application/json
+//
+// Parameters:
+// - RequestAttachMovieCollection
+//
+// Responses:
+//
+//	200: GenericResOk
+//	400: GenericResFailBadRequest
+//	404: GenericResFailNotFound
+//	500: GenericResError
+func (ctrl *CollectionController) AttachMovieCollection(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params(constants.ParamMid))
+	if err != nil {
+		return utils.JSONFail(c, http.StatusBadRequest, "movie ID must be a valid integer")
+	}
+
+	var member models.CollectionMember
+	if err := json.Unmarshal(c.Body(), &member); err != nil {
+		ctrl.logger.Error(constants.InvalidRequestBody, zap.Error(err))
+		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidRequestBody)
+	}
+	if err := validator.New().Struct(member); err != nil {
+		ctrl.logger.Error(constants.ValidationFailed, zap.Error(err))
+		return utils.JSONFail(c, http.StatusBadRequest, err.Error())
+	}
+
+	err = ctrl.setCollection(c, id, func(collections *models.CollectionModel) error {
+		return collections.AttachMovie(id, member.CollectionID)
+	})
+	if err != nil {
+		if errors.Is(err, models.ErrCollectionNotFound) {
+			return utils.JSONFail(c, http.StatusNotFound, constants.CollectionNotExist)
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.JSONFail(c, http.StatusNotFound, constants.MovieNotExist)
+		}
+		ctrl.logger.Error(constants.ErrUpdateCollection, zap.Int("id", id), zap.Error(err))
+		return utils.JSONError(c, http.StatusInternalServerError, constants.ErrUpdateCollection)
+	}
+
+	return utils.JSONSuccess(c, http.StatusOK, constants.AttachCollectionSuccess)
+}
+
+// DetachMovieCollection removes a movie from its collection
+// swagger:route DELETE /movies/{movieId}/collection Collections DetachMovieCollection
+//
+// Removes a movie from the collection it belongs to.
+//
+// Produces:
+// - application/json
+//
+// Parameters:
+// - RequestDetachMovieCollection
+//
+// Responses:
+//
+//	200: GenericResOk
+//	400: GenericResFailBadRequest
+//	404: GenericResFailNotFound
+//	500: GenericResError
+func (ctrl *CollectionController) DetachMovieCollection(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params(constants.ParamMid))
+	if err != nil {
+		return utils.JSONFail(c, http.StatusBadRequest, "movie ID must be a valid integer")
+	}
+
+	err = ctrl.setCollection(c, id, func(collections *models.CollectionModel) error {
+		return collections.DetachMovie(id)
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.JSONFail(c, http.StatusNotFound, constants.MovieNotExist)
+		}
+		ctrl.logger.Error(constants.ErrUpdateCollection, zap.Int("id", id), zap.Error(err))
+		return utils.JSONError(c, http.StatusInternalServerError, constants.ErrUpdateCollection)
+	}
+
+	return utils.JSONSuccess(c, http.StatusOK, constants.DetachCollectionSuccess)
+}
+
+// setCollection changes the collection of the movie having id with set and records the update of
+// the movie in the audit log, in one transaction
+func (ctrl *CollectionController) setCollection(c *fiber.Ctx, id int, set func(collections *models.CollectionModel) error) error {
+	return ctrl.auditor.write(c, func(tx *goqu.TxDatabase) (auditedWrite, error) {
+		movies := ctrl.movieModel.WithTx(tx)
+		before, err := currentMovie(movies, id)
+		if err != nil {
+			return auditedWrite{}, err
+		}
+		if err := set(ctrl.collectionModel.WithTx(tx)); err != nil {
+			return auditedWrite{}, err
+		}
+		after, err := currentMovie(movies, id)
+		return auditedWrite{Entity: models.AuditMovie, ID: strconv.Itoa(id), Operation: models.AuditUpdate, Before: before, After: after}, err
+	})
+}
@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"errors"
+
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+var errPreconditionFailed = errors.New("precondition failed")
+
+// notModified tags the response with the ETag of data, the resource it renders, and reports
+// whether the If-None-Match header of the request lists it. The caller then answers with a 304
+// instead of data.
+func notModified(c *fiber.Ctx, data interface{}) (bool, error) {
+	etag, err := utils.DataETag(data)
+	if err != nil {
+		return false, err
+	}
+	c.Set(fiber.HeaderETag, etag)
+
+	header := c.Get(fiber.HeaderIfNoneMatch)
+	return header != "" && utils.MatchETag(header, etag), nil
+}
+
+// checkIfMatch evaluates the If-Match header of a write against the ETag of the resource returned
+// by current, nil when it does not exist, as notModified tags it when the resource is read. It runs
+// in the auditor.write closure of the write and first takes lock, which locks the rows of the
+// resource in the transaction, so no other write comes between the check and the write. It returns
+// errPreconditionFailed when none of the listed ETags match, a missing resource matching none.
+func checkIfMatch(c *fiber.Ctx, lock func() error, current func() (interface{}, error)) error {
+	header := c.Get(fiber.HeaderIfMatch)
+	if header == "" {
+		return nil
+	}
+
+	if err := lock(); err != nil {
+		return err
+	}
+	data, err := current()
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		return errPreconditionFailed
+	}
+
+	etag, err := utils.DataETag(data)
+	if err != nil {
+		return err
+	}
+	if !utils.MatchETag(header, etag) {
+		return errPreconditionFailed
+	}
+	return nil
+}
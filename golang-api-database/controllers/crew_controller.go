@@ -18,6 +18,7 @@ import (
 
 type CrewController struct {
 	crewModel *models.CrewModel
+	auditor   *Auditor
 	logger    *zap.Logger
 }
 
@@ -26,8 +27,13 @@ func NewCrewController(goqu *goqu.Database, logger *zap.Logger) (*CrewController
 	if err != nil {
 		return nil, err
 	}
+	auditor, err := NewAuditor(goqu, logger)
+	if err != nil {
+		return nil, err
+	}
 	return &CrewController{
 		crewModel: model,
+		auditor:   auditor,
 		logger:    logger,
 	}, nil
 }
@@ -49,21 +55,40 @@ func NewCrewController(goqu *goqu.Database, logger *zap.Logger) (*CrewController
 // Responses:
 //
 //	200: ResponseListCrewMembers
+//	304: description: Not Modified
+//	400: GenericResFailBadRequest
 //	404: GenericResFailNotFound
 //	500: GenericResError
 func (ctrl *CrewController) ListCrewMembers(c *fiber.Ctx) error {
 	movieId := c.Params(constants.ParamMid)
 
-	crew, err := ctrl.crewModel.ListCrew(movieId)
+	page, err := CursorQuery(c)
+	if err != nil {
+		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidCursorOrLimit)
+	}
+
+	crew, err := ctrl.crewModel.ListCrew(movieId, page)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return utils.JSONFail(c, http.StatusNotFound, constants.MovieNotExist)
 		}
+		if errors.Is(err, models.ErrInvalidCursor) {
+			return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidCursorOrLimit)
+		}
 		ctrl.logger.Error("error while get crew of movie by id", zap.Any("id", movieId), zap.Error(err))
 		return utils.JSONError(c, http.StatusInternalServerError, constants.ErrGetCrew)
 	}
 
-	return utils.JSONSuccess(c, http.StatusOK, crew)
+	fresh, err := notModified(c, crew)
+	if err != nil {
+		ctrl.logger.Error("error while tagging crew of movie", zap.Any("id", movieId), zap.Error(err))
+		return utils.JSONError(c, http.StatusInternalServerError, constants.ErrGetCrew)
+	}
+	if fresh {
+		return c.SendStatus(http.StatusNotModified)
+	}
+
+	return utils.JSONPage(c, http.StatusOK, crew.Items, crew)
 }
 
 // AddMovieCrewMember add a crew member of a movie
@@ -83,7 +108,9 @@ func (ctrl *CrewController) ListCrewMembers(c *fiber.Ctx) error {
 // Responses:
 //
 //	200: GenericResOk
-//	401: GenericResFailBadRequest
+//	400: GenericResFailBadRequest
+//	401: GenericResFailUnauthorized
+//	403: GenericResFailForbidden
 //	404: GenericResFailNotFound
 //	500: GenericResError
 func (ctrl *CrewController) AddMovieCrewMember(c *fiber.Ctx) error {
@@ -123,7 +150,13 @@ func (ctrl *CrewController) AddMovieCrewMember(c *fiber.Ctx) error {
 		return utils.JSONFail(c, http.StatusBadRequest, err.Error())
 	}
 
-	if err := ctrl.crewModel.AddMovieCrew(&crew); err != nil {
+	err = ctrl.auditor.write(c, func(tx *goqu.TxDatabase) (auditedWrite, error) {
+		if err := ctrl.crewModel.WithTx(tx).AddMovieCrew(&crew); err != nil {
+			return auditedWrite{}, err
+		}
+		return auditedWrite{Entity: models.AuditCrew, ID: models.AuditEntityID(movieid, crew.CreditID), Operation: models.AuditCreate, After: crew}, nil
+	})
+	if err != nil {
 		if errors.Is(err, models.ErrMovieNotFound) || errors.Is(err, models.ErrCreditNotFound) {
 			return utils.JSONFail(c, http.StatusNotFound, "Movie or credit not found")
 		}
@@ -0,0 +1,146 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/models"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/pkg/parquet"
+)
+
+// Formats of exports
+const (
+	exportCSV     = "csv"
+	exportNDJSON  = "ndjson"
+	exportParquet = "parquet"
+)
+
+// exportContentTypes are the content types of the formats of exports
+var exportContentTypes = map[string]string{
+	exportCSV:     "text/csv; charset=utf-8",
+	exportNDJSON:  "application/x-ndjson",
+	exportParquet: "application/vnd.apache.parquet",
+}
+
+// exportEncoder writes the rows of an export in a format, Close writing what is left of it
+type exportEncoder interface {
+	Write(row []interface{}) error
+	Close() error
+}
+
+func newExportEncoder(format string, w io.Writer, columns []models.ExportColumn) (exportEncoder, error) {
+	switch format {
+	case exportCSV:
+		return newCSVEncoder(w, columns)
+	case exportNDJSON:
+		return &ndjsonEncoder{w: w, columns: columns}, nil
+	case exportParquet:
+		return newParquetEncoder(w, columns)
+	}
+	return nil, fmt.Errorf("unknown export format %q", format)
+}
+
+// csvEncoder writes rows like the Kaggle files read by seed: a header row, booleans as True and
+// False and NULL as an empty field
+type csvEncoder struct {
+	w      *csv.Writer
+	fields []string
+}
+
+func newCSVEncoder(w io.Writer, columns []models.ExportColumn) (*csvEncoder, error) {
+	enc := &csvEncoder{w: csv.NewWriter(w), fields: make([]string, len(columns))}
+	for i, column := range columns {
+		enc.fields[i] = column.Name
+	}
+	return enc, enc.w.Write(enc.fields)
+}
+
+func (enc *csvEncoder) Write(row []interface{}) error {
+	for i, value := range row {
+		switch v := value.(type) {
+		case nil:
+			enc.fields[i] = ""
+		case bool:
+			enc.fields[i] = "False"
+			if v {
+				enc.fields[i] = "True"
+			}
+		case int64:
+			enc.fields[i] = strconv.FormatInt(v, 10)
+		case float64:
+			enc.fields[i] = strconv.FormatFloat(v, 'f', -1, 64)
+		case string:
+			enc.fields[i] = v
+		}
+	}
+	return enc.w.Write(enc.fields)
+}
+
+func (enc *csvEncoder) Close() error {
+	enc.w.Flush()
+	return enc.w.Error()
+}
+
+// ndjsonEncoder writes rows as JSON objects on separate lines, keeping the order of the columns
+// and JSON columns as nested values
+type ndjsonEncoder struct {
+	w       io.Writer
+	columns []models.ExportColumn
+	buf     bytes.Buffer
+}
+
+func (enc *ndjsonEncoder) Write(row []interface{}) error {
+	enc.buf.Reset()
+	enc.buf.WriteByte('{')
+	for i, value := range row {
+		if i > 0 {
+			enc.buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(enc.columns[i].Name)
+		enc.buf.Write(name)
+		enc.buf.WriteByte(':')
+
+		if f, ok := value.(float64); ok && (math.IsNaN(f) || math.IsInf(f, 0)) {
+			// JSON has no infinite numbers
+			value = nil
+		}
+		if s, ok := value.(string); ok && enc.columns[i].Type == models.ExportJSON && json.Valid([]byte(s)) {
+			enc.buf.WriteString(s)
+			continue
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		enc.buf.Write(encoded)
+	}
+	enc.buf.WriteString("}\n")
+	_, err := enc.w.Write(enc.buf.Bytes())
+	return err
+}
+
+func (enc *ndjsonEncoder) Close() error {
+	return nil
+}
+
+// parquetTypes are the parquet types of the values of export columns, JSON columns being strings
+var parquetTypes = map[models.ExportType]parquet.Type{
+	models.ExportBool:   parquet.Boolean,
+	models.ExportInt:    parquet.Int64,
+	models.ExportFloat:  parquet.Double,
+	models.ExportString: parquet.String,
+	models.ExportJSON:   parquet.String,
+}
+
+func newParquetEncoder(w io.Writer, columns []models.ExportColumn) (*parquet.Writer, error) {
+	parquetColumns := make([]parquet.Column, len(columns))
+	for i, column := range columns {
+		parquetColumns[i] = parquet.Column{Name: column.Name, Type: parquetTypes[column.Type]}
+	}
+	return parquet.NewWriter(w, parquetColumns)
+}
@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/constants"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/models"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/utils"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// ExportController for exportModel controllers
+type ExportController struct {
+	exportModel *models.ExportModel
+	logger      *zap.Logger
+}
+
+// NewExportController is to intialize ExportController
+func NewExportController(goqu *goqu.Database, logger *zap.Logger) (*ExportController, error) {
+	model, err := models.InitExportModel(goqu)
+	if err != nil {
+		return nil, err
+	}
+	return &ExportController{
+		exportModel: model,
+		logger:      logger,
+	}, nil
+}
+
+// Export exports the catalog
+// swagger:route GET /export/{kind} Export Export
+//
+// Streams every movie, rating or credit matching the filters of ListMovies. CSV exports have the
+// columns of the Kaggle files, so they can be seeded or imported back.
+//
+// Produces:
+// - text/csv
+// - application/x-ndjson
+// - application/vnd.apache.parquet
+//
+// Parameters:
+// - RequestExport
+//
+// Responses:
+//
+//	200: ResponseExport
+//	400: GenericResFailBadRequest
+//	401: GenericResFailUnauthorized
+//	403: GenericResFailForbidden
+//	500: GenericResError
+func (ctrl *ExportController) Export(c *fiber.Ctx) error {
+	kind := c.Params(constants.ParamKind)
+	format := c.Query("format", exportCSV)
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidExportFormat)
+	}
+
+	filters := make(map[string]string, len(movieFilterKeys))
+	for _, key := range movieFilterKeys {
+		filters[key] = c.Query(key)
+	}
+
+	export, err := ctrl.exportModel.Export(kind, filters)
+	if errors.Is(err, models.ErrUnknownExport) {
+		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidExportKind)
+	}
+	if errors.Is(err, models.ErrInvalidFilter) {
+		return utils.JSONFail(c, http.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		ctrl.logger.Error(constants.ErrExport, zap.String("kind", kind), zap.Error(err))
+		return utils.JSONError(c, http.StatusInternalServerError, constants.ErrExport)
+	}
+
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s.%s"`, kind, format))
+
+	// Rows are encoded as the response is written, the status being sent already errors past this
+	// point can only be logged and end the response early
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer export.Close()
+
+		enc, err := newExportEncoder(format, w, export.Columns)
+		if err == nil {
+			err = export.Each(enc.Write)
+		}
+		if err == nil {
+			err = enc.Close()
+		}
+		if err == nil {
+			err = w.Flush()
+		}
+		if err != nil {
+			ctrl.logger.Error(constants.ErrExport, zap.String("kind", kind), zap.Error(err))
+		}
+	})
+	return nil
+}
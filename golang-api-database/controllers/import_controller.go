@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/constants"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/database/seed"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/models"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/utils"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// importFormats are the formats of uploads by content type, when the format query is missing
+var importFormats = map[string]string{
+	"text/csv":             seed.FormatCSV,
+	"application/json":     seed.FormatJSON,
+	"application/x-ndjson": seed.FormatNDJSON,
+	"application/ndjson":   seed.FormatNDJSON,
+}
+
+// ImportController for import jobs controllers
+type ImportController struct {
+	jobs    *seed.Jobs
+	auditor *Auditor
+	logger  *zap.Logger
+}
+
+// NewImportController is to intialize ImportController
+func NewImportController(goqu *goqu.Database, logger *zap.Logger) (*ImportController, error) {
+	auditor, err := NewAuditor(goqu, logger)
+	if err != nil {
+		return nil, err
+	}
+	return &ImportController{
+		jobs:    seed.NewJobs(goqu, logger),
+		auditor: auditor,
+		logger:  logger,
+	}, nil
+}
+
+// StartImport starts an import job
+// swagger:route POST /import/{kind} Import StartImport
+//
+// Uploads movies, ratings or credits and imports them in the background, parsed like the seed files.
+//
+// Consumes:
+// - text/csv
+// - application/json
+// - application/x-ndjson
+//
+// Produces:
+// - application/json
+//
+// Parameters:
+// - RequestStartImport
+//
+// Responses:
+//
+//	202: ResponseImportJob
+//	400: GenericResFailBadRequest
+//	401: GenericResFailUnauthorized
+//	403: GenericResFailForbidden
+//	500: GenericResError
+func (ctrl *ImportController) StartImport(c *fiber.Ctx) error {
+	kind := c.Params(constants.ParamKind)
+	if err := seed.CheckKind(kind); err != nil {
+		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidImportKind)
+	}
+
+	format := c.Query("format")
+	if format == "" {
+		contentType, _, _ := mime.ParseMediaType(c.Get(fiber.HeaderContentType))
+		format = importFormats[contentType]
+	}
+	if err := seed.CheckFormat(format); err != nil {
+		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidImportFormat)
+	}
+
+	path, err := ctrl.saveUpload(c)
+	if err != nil {
+		ctrl.logger.Error(constants.ErrSaveImport, zap.Error(err))
+		return utils.JSONError(c, http.StatusInternalServerError, constants.ErrSaveImport)
+	}
+
+	actor := actorOf(c)
+	job, err := ctrl.jobs.Start(kind, format, c.QueryBool("dry_run"), path, func(tx *goqu.TxDatabase, job seed.Job) error {
+		return ctrl.auditor.recordIn(tx, actor, auditedWrite{Entity: models.AuditImport, ID: job.ID,
+			Operation: models.AuditCreate, After: job})
+	})
+	if err != nil {
+		os.Remove(path)
+		ctrl.logger.Error(constants.ErrSaveImport, zap.Error(err))
+		return utils.JSONError(c, http.StatusInternalServerError, constants.ErrSaveImport)
+	}
+
+	c.Location(fmt.Sprintf("/import/jobs/%s", job.ID))
+	return utils.JSONSuccess(c, http.StatusAccepted, job)
+}
+
+// saveUpload writes the request body to a temporary file read by the job, so large uploads are
+// streamed to disk rather than held in memory
+func (ctrl *ImportController) saveUpload(c *fiber.Ctx) (string, error) {
+	body := c.Context().RequestBodyStream()
+	if body == nil {
+		body = bytes.NewReader(c.Body())
+	}
+
+	file, err := os.CreateTemp("", "import-*")
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(file, body); err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
+
+// GetImportJob retrieves an import job
+// swagger:route GET /import/jobs/{jobId} Import GetImportJob
+//
+// Retrieves the progress of an import job and the rows it could not import.
+//
+// Produces:
+// - application/json
+//
+// Parameters:
+// - RequestGetImportJob
+//
+// Responses:
+//
+//	200: ResponseImportJob
+//	401: GenericResFailUnauthorized
+//	403: GenericResFailForbidden
+//	404: GenericResFailNotFound
+func (ctrl *ImportController) GetImportJob(c *fiber.Ctx) error {
+	job, ok := ctrl.jobs.Get(c.Params(constants.JobId))
+	if !ok {
+		return utils.JSONFail(c, http.StatusNotFound, constants.ImportJobNotExist)
+	}
+	return utils.JSONSuccess(c, http.StatusOK, job)
+}
@@ -0,0 +1,26 @@
+package controllers
+
+import (
+	pMetrics "git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/pkg/prometheus"
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
+	"go.uber.org/zap"
+)
+
+type MetricsController struct {
+	logger   *zap.Logger
+	pMetrics *pMetrics.PrometheusMetrics
+}
+
+func InitMetricsController(logger *zap.Logger, pMetrics *pMetrics.PrometheusMetrics) (*MetricsController, error) {
+	return &MetricsController{
+		logger:   logger,
+		pMetrics: pMetrics,
+	}, nil
+}
+
+func (mc *MetricsController) Metrics(ctx *fiber.Ctx) error {
+	fasthttpadaptor.NewFastHTTPHandler(promhttp.Handler())(ctx.Context())
+	return nil
+}
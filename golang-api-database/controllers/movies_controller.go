@@ -3,8 +3,11 @@ package controllers
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/constants"
 	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/models"
@@ -18,6 +21,7 @@ import (
 // MovieController for movieModel controllers
 type MovieController struct {
 	movieModel *models.MovieModel
+	auditor    *Auditor
 	logger     *zap.Logger
 }
 
@@ -27,12 +31,22 @@ func NewMovieController(goqu *goqu.Database, logger *zap.Logger) (*MovieControll
 	if err != nil {
 		return nil, err
 	}
+	auditor, err := NewAuditor(goqu, logger)
+	if err != nil {
+		return nil, err
+	}
 	return &MovieController{
 		movieModel: model,
+		auditor:    auditor,
 		logger:     logger,
 	}, nil
 }
 
+// currentMovie reads the movie having id for the audit log through movies
+func currentMovie(movies *models.MovieModel, id int) (interface{}, error) {
+	return found(movies.GetMovie(strconv.Itoa(id)))
+}
+
 // PaginationQuery is to handle page and limit query
 func PaginationQuery(c *fiber.Ctx) (uint, uint, error) {
 	pageStr := c.Query("page", "1")    // Default: 1
@@ -51,6 +65,26 @@ func PaginationQuery(c *fiber.Ctx) (uint, uint, error) {
 	return uint(page), uint(limit), nil
 }
 
+// CursorQuery is to handle cursor, limit and total query of keyset paginated lists
+func CursorQuery(c *fiber.Ctx) (models.PageRequest, error) {
+	limit, err := strconv.Atoi(c.Query("limit", "10")) // Default: 10
+	if err != nil {
+		return models.PageRequest{}, err
+	}
+	if limit <= 0 {
+		return models.PageRequest{}, fmt.Errorf("limit must be positive")
+	}
+
+	page := models.PageRequest{Limit: uint(limit), Total: c.QueryBool("total")}
+	if token := c.Query("cursor"); token != "" {
+		page.Cursor, err = models.DecodeCursor(token)
+		if err != nil {
+			return models.PageRequest{}, err
+		}
+	}
+	return page, nil
+}
+
 // GetMovieByID retrieves a movie by ID
 // swagger:route GET /movies/{movieId} Movies GetMovieByID
 //
@@ -68,6 +102,7 @@ func PaginationQuery(c *fiber.Ctx) (uint, uint, error) {
 // Responses:
 //
 //	200: ResponseGetMovieByID
+//	304: description: Not Modified
 //	404: GenericResFailNotFound
 //	500: GenericResError
 func (ctrl *MovieController) GetMovieByID(c *fiber.Ctx) error {
@@ -81,13 +116,31 @@ func (ctrl *MovieController) GetMovieByID(c *fiber.Ctx) error {
 		return utils.JSONError(c, http.StatusInternalServerError, constants.ErrGetMovie)
 	}
 
+	fresh, err := notModified(c, movie)
+	if err != nil {
+		ctrl.logger.Error("error while tagging movie", zap.Any("id", movieId), zap.Error(err))
+		return utils.JSONError(c, http.StatusInternalServerError, constants.ErrGetMovie)
+	}
+	if fresh {
+		return c.SendStatus(http.StatusNotModified)
+	}
+
 	return utils.JSONSuccess(c, http.StatusOK, movie)
 }
 
+// movieFilterKeys are the query parameters of ListMovies passed on to the movie model
+var movieFilterKeys = []string{
+	"name", "genre", "genre_mode", "language", "status", "sort",
+	"release_date_min", "release_date_max", "runtime_min", "runtime_max",
+	"popularity_min", "popularity_max", "vote_average_min", "vote_average_max",
+	"vote_count_min", "vote_count_max", "budget_min", "budget_max", "revenue_min", "revenue_max",
+	"adult", "video", "collection", "company", "country",
+}
+
 // ListMovies lists all movies with pagination
 // swagger:route GET /movies Movies ListMovies
 //
-// Retrieves a paginated list of movies.
+// Retrieves a paginated list of movies, filtered and sorted by the query parameters.
 //
 // Consumes:
 // - application/json
@@ -101,26 +154,71 @@ func (ctrl *MovieController) GetMovieByID(c *fiber.Ctx) error {
 // Responses:
 //
 //	200: ResponseListMovies
-//	401: GenericResFailBadRequest
+//	400: GenericResFailBadRequest
 //	500: GenericResError
 func (ctrl *MovieController) ListMovies(c *fiber.Ctx) error {
 	// Extract query parameters
-	filters := map[string]string{
-		"name":     c.Query("name"),
-		"genre":    c.Query("genre"),
-		"language": c.Query("language"),
+	filters := make(map[string]string, len(movieFilterKeys))
+	for _, key := range movieFilterKeys {
+		filters[key] = c.Query(key)
 	}
 
-	page, limit, err := PaginationQuery(c)
+	page, err := CursorQuery(c)
 	if err != nil {
-		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidPageOrLimit)
+		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidCursorOrLimit)
 	}
 
-	movies, err := ctrl.movieModel.ListMovies(filters, page, limit)
+	movies, err := ctrl.movieModel.ListMovies(filters, page)
+	if errors.Is(err, models.ErrInvalidFilter) {
+		return utils.JSONFail(c, http.StatusBadRequest, err.Error())
+	}
+	if errors.Is(err, models.ErrInvalidCursor) {
+		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidCursorOrLimit)
+	}
 	if err != nil {
+		ctrl.logger.Error("error while list movies", zap.Error(err))
 		return utils.JSONError(c, http.StatusInternalServerError, constants.ErrGetMovie)
 	}
 
+	return utils.JSONPage(c, http.StatusOK, movies.Items, movies)
+}
+
+// SearchMovies runs a ranked full-text search over movies
+// swagger:route GET /movies/search Movies SearchMovies
+//
+// Searches titles, original titles, taglines and overviews of movies, best matches first.
+//
+// Consumes:
+// - application/json
+//
+// Produces:
+// - application/json
+//
+// Parameters:
+// - RequestSearchMovies
+//
+// Responses:
+//
+//	200: ResponseSearchMovies
+//	400: GenericResFailBadRequest
+//	500: GenericResError
+func (ctrl *MovieController) SearchMovies(c *fiber.Ctx) error {
+	query := c.Query("q")
+	if strings.TrimSpace(query) == "" {
+		return utils.JSONFail(c, http.StatusBadRequest, constants.SearchQueryRequired)
+	}
+
+	page, limit, err := PaginationQuery(c)
+	if err != nil || page == 0 || limit == 0 {
+		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidPageOrLimit)
+	}
+
+	movies, err := ctrl.movieModel.SearchMovies(query, page, limit)
+	if err != nil {
+		ctrl.logger.Error("error while searching movies", zap.String("query", query), zap.Error(err))
+		return utils.JSONError(c, http.StatusInternalServerError, constants.ErrSearchMovies)
+	}
+
 	return utils.JSONSuccess(c, http.StatusOK, movies)
 }
 
@@ -141,8 +239,11 @@ func (ctrl *MovieController) ListMovies(c *fiber.Ctx) error {
 // Responses:
 //
 //	200: GenericResOk
-//	401: GenericResFailBadRequest
+//	400: GenericResFailBadRequest
+//	401: GenericResFailUnauthorized
+//	403: GenericResFailForbidden
 //	404: GenericResFailNotFound
+//	412: GenericResFailPrecondition
 //	500: GenericResError
 func (ctrl *MovieController) DeleteMovieById(c *fiber.Ctx) error {
 	movieId := c.Params(constants.ParamMid)
@@ -152,9 +253,25 @@ func (ctrl *MovieController) DeleteMovieById(c *fiber.Ctx) error {
 		return utils.JSONFail(c, http.StatusBadRequest, "movie ID must be a valid integer")
 	}
 
-	err = ctrl.movieModel.DeleteMovie(id)
+	err = ctrl.auditor.write(c, func(tx *goqu.TxDatabase) (auditedWrite, error) {
+		movies := ctrl.movieModel.WithTx(tx)
+		if err := checkIfMatch(c, func() error { return movies.LockMovie(id) }, func() (interface{}, error) {
+			return currentMovie(movies, id)
+		}); err != nil {
+			return auditedWrite{}, err
+		}
+		before, err := currentMovie(movies, id)
+		if err != nil {
+			return auditedWrite{}, err
+		}
+		err = movies.DeleteMovie(id)
+		return auditedWrite{Entity: models.AuditMovie, ID: strconv.Itoa(id), Operation: models.AuditDelete, Before: before}, err
+	})
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, errPreconditionFailed) {
+			return utils.JSONFail(c, http.StatusPreconditionFailed, constants.PreconditionFailed)
+		}
+		if errors.Is(err, sql.ErrNoRows) {
 			return utils.JSONFail(c, http.StatusNotFound, constants.MovieNotExist)
 		}
 		ctrl.logger.Error(constants.ErrDeleteMovie, zap.Error(err))
@@ -164,6 +281,54 @@ func (ctrl *MovieController) DeleteMovieById(c *fiber.Ctx) error {
 	return utils.JSONSuccess(c, http.StatusOK, constants.DeleteMovieSuccess)
 }
 
+// RestoreMovieById restores a soft deleted movie by ID
+// swagger:route POST /movies/{movieId}/restore Movies RestoreMovieById
+//
+// Restores a deleted movie along with the ratings, cast and crew deleted with it.
+//
+// Produces:
+// - application/json
+//
+// Parameters:
+// - RequestRestoreMovieByID
+//
+// Responses:
+//
+//	200: GenericResOk
+//	400: GenericResFailBadRequest
+//	401: GenericResFailUnauthorized
+//	403: GenericResFailForbidden
+//	404: GenericResFailNotFound
+//	409: GenericResFailConflict
+//	500: GenericResError
+func (ctrl *MovieController) RestoreMovieById(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params(constants.ParamMid))
+	if err != nil {
+		return utils.JSONFail(c, http.StatusBadRequest, "movie ID must be a valid integer")
+	}
+
+	err = ctrl.auditor.write(c, func(tx *goqu.TxDatabase) (auditedWrite, error) {
+		movies := ctrl.movieModel.WithTx(tx)
+		if err := movies.RestoreMovie(id); err != nil {
+			return auditedWrite{}, err
+		}
+		after, err := currentMovie(movies, id)
+		return auditedWrite{Entity: models.AuditMovie, ID: strconv.Itoa(id), Operation: models.AuditRestore, After: after}, err
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.JSONFail(c, http.StatusNotFound, constants.MovieNotExist)
+		}
+		if errors.Is(err, models.ErrMovieNotDeleted) {
+			return utils.JSONFail(c, http.StatusConflict, constants.MovieNotDeleted)
+		}
+		ctrl.logger.Error(constants.ErrRestoreMovie, zap.Error(err))
+		return utils.JSONError(c, http.StatusInternalServerError, constants.ErrRestoreMovie)
+	}
+
+	return utils.JSONSuccess(c, http.StatusOK, constants.RestoreMovieSuccess)
+}
+
 // AddMovie adds a new movie
 // swagger:route POST /movies Movies AddMovie
 //
@@ -182,6 +347,8 @@ func (ctrl *MovieController) DeleteMovieById(c *fiber.Ctx) error {
 //
 //	200: GenericResOk
 //	400: GenericResFailBadRequest
+//	401: GenericResFailUnauthorized
+//	403: GenericResFailForbidden
 //	500: GenericResError
 func (ctrl *MovieController) AddMovie(c *fiber.Ctx) error {
 	var validate = validator.New()
@@ -200,7 +367,16 @@ func (ctrl *MovieController) AddMovie(c *fiber.Ctx) error {
 		return utils.JSONFail(c, http.StatusBadRequest, err.Error())
 	}
 
-	if err := ctrl.movieModel.AddMovie(&movie); err != nil {
+	err := ctrl.auditor.write(c, func(tx *goqu.TxDatabase) (auditedWrite, error) {
+		movies := ctrl.movieModel.WithTx(tx)
+		id, err := movies.AddMovie(&movie)
+		if err != nil {
+			return auditedWrite{}, err
+		}
+		after, err := currentMovie(movies, int(id))
+		return auditedWrite{Entity: models.AuditMovie, ID: strconv.FormatInt(id, 10), Operation: models.AuditCreate, After: after}, err
+	})
+	if err != nil {
 		ctrl.logger.Error(constants.ErrAddMovie, zap.Error(err))
 		return utils.JSONError(c, http.StatusInternalServerError, constants.ErrAddMovie)
 	}
@@ -225,8 +401,11 @@ func (ctrl *MovieController) AddMovie(c *fiber.Ctx) error {
 // Responses:
 //
 //	200: GenericResOk
-//	401: GenericResFailBadRequest
+//	400: GenericResFailBadRequest
+//	401: GenericResFailUnauthorized
+//	403: GenericResFailForbidden
 //	404: GenericResFailNotFound
+//	412: GenericResFailPrecondition
 //	500: GenericResError
 func (ctrl *MovieController) UpdateMovie(c *fiber.Ctx) error {
 	movieId := c.Params(constants.ParamMid)
@@ -251,8 +430,28 @@ func (ctrl *MovieController) UpdateMovie(c *fiber.Ctx) error {
 		return utils.JSONFail(c, http.StatusBadRequest, err.Error())
 	}
 
-	if err := ctrl.movieModel.UpdateMovie(id, &movie); err != nil {
-		if err == sql.ErrNoRows {
+	err = ctrl.auditor.write(c, func(tx *goqu.TxDatabase) (auditedWrite, error) {
+		movies := ctrl.movieModel.WithTx(tx)
+		if err := checkIfMatch(c, func() error { return movies.LockMovie(id) }, func() (interface{}, error) {
+			return currentMovie(movies, id)
+		}); err != nil {
+			return auditedWrite{}, err
+		}
+		before, err := currentMovie(movies, id)
+		if err != nil {
+			return auditedWrite{}, err
+		}
+		if err := movies.UpdateMovie(id, &movie); err != nil {
+			return auditedWrite{}, err
+		}
+		after, err := currentMovie(movies, id)
+		return auditedWrite{Entity: models.AuditMovie, ID: strconv.Itoa(id), Operation: models.AuditUpdate, Before: before, After: after}, err
+	})
+	if err != nil {
+		if errors.Is(err, errPreconditionFailed) {
+			return utils.JSONFail(c, http.StatusPreconditionFailed, constants.PreconditionFailed)
+		}
+		if errors.Is(err, sql.ErrNoRows) {
 			return utils.JSONFail(c, http.StatusNotFound, constants.MovieNotExist)
 		}
 		ctrl.logger.Error(constants.UpdateMovieError, zap.Error(err))
@@ -0,0 +1,293 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/constants"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/models"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/utils"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/go-playground/validator"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// PeopleController for peopleModel controllers
+type PeopleController struct {
+	peopleModel *models.PeopleModel
+	auditor     *Auditor
+	logger      *zap.Logger
+}
+
+// NewPeopleController is to intialize PeopleController
+func NewPeopleController(goqu *goqu.Database, logger *zap.Logger) (*PeopleController, error) {
+	peopleModel, err := models.InitPeopleModel(goqu)
+	if err != nil {
+		return nil, err
+	}
+	auditor, err := NewAuditor(goqu, logger)
+	if err != nil {
+		return nil, err
+	}
+	return &PeopleController{
+		peopleModel: peopleModel,
+		auditor:     auditor,
+		logger:      logger,
+	}, nil
+}
+
+// currentPerson reads the person having id for the audit log through people
+func currentPerson(people *models.PeopleModel, id int) (interface{}, error) {
+	return found(people.GetPersonByID(id))
+}
+
+// ListPeople lists people with pagination
+// swagger:route GET /people People ListPeople
+//
+// Retrieves a paginated list of the people credited on movies, by id.
+//
+// Produces:
+// - application/json
+//
+// Parameters:
+// - RequestListPeople
+//
+// Responses:
+//
+//	200: ResponseListPeople
+//	400: GenericResFailBadRequest
+//	500: GenericResError
+func (ctrl *PeopleController) ListPeople(c *fiber.Ctx) error {
+	page, err := CursorQuery(c)
+	if err != nil {
+		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidCursorOrLimit)
+	}
+
+	people, err := ctrl.peopleModel.ListPeople(c.Query("name"), page)
+	if errors.Is(err, models.ErrInvalidCursor) {
+		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidCursorOrLimit)
+	}
+	if err != nil {
+		ctrl.logger.Error(constants.ErrGetPerson, zap.Error(err))
+		return utils.JSONError(c, http.StatusInternalServerError, constants.ErrGetPerson)
+	}
+
+	return utils.JSONPage(c, http.StatusOK, people.Items, people)
+}
+
+// GetPerson retrieves a person along with their filmography
+// swagger:route GET /people/{personId} People GetPerson
+//
+// Retrieves a person along with a paginated filmography merging their cast and crew credits,
+// with the release year and rating of every movie.
+//
+// Produces:
+// - application/json
+//
+// Parameters:
+// - RequestGetPerson
+//
+// Responses:
+//
+//	200: ResponseGetPerson
+//	400: GenericResFailBadRequest
+//	404: GenericResFailNotFound
+//	500: GenericResError
+func (ctrl *PeopleController) GetPerson(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params(constants.PersonId))
+	if err != nil {
+		return utils.JSONFail(c, http.StatusBadRequest, "person ID must be a valid integer")
+	}
+
+	page, err := CursorQuery(c)
+	if err != nil {
+		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidCursorOrLimit)
+	}
+
+	filters := map[string]string{
+		"role":       c.Query("role"),
+		"department": c.Query("department"),
+		"sort":       c.Query("sort"),
+	}
+	person, filmography, err := ctrl.peopleModel.GetPerson(id, filters, page)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return utils.JSONFail(c, http.StatusNotFound, constants.PersonNotExist)
+		}
+		if errors.Is(err, models.ErrInvalidFilter) {
+			return utils.JSONFail(c, http.StatusBadRequest, err.Error())
+		}
+		if errors.Is(err, models.ErrInvalidCursor) {
+			return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidCursorOrLimit)
+		}
+		ctrl.logger.Error(constants.ErrGetPerson, zap.Int("id", id), zap.Error(err))
+		return utils.JSONError(c, http.StatusInternalServerError, constants.ErrGetPerson)
+	}
+
+	return utils.JSONPage(c, http.StatusOK, person, filmography)
+}
+
+// AddPerson adds a person
+// swagger:route POST /people People AddPerson
+//
+// Adds a person who can then be credited on movies, the id of the person is allocated by the
+// server.
+//
+// Consumes:
+// - application/json
+//
+// Produces:
+// - application/json
+//
+// Parameters:
+// - RequestAddPerson
+//
+// Responses:
+//
+//	201: ResponseAddPerson
+//	400: GenericResFailBadRequest
+//	401: GenericResFailUnauthorized
+//	403: GenericResFailForbidden
+//	500: GenericResError
+func (ctrl *PeopleController) AddPerson(c *fiber.Ctx) error {
+	var person models.Person
+	if err := json.Unmarshal(c.Body(), &person); err != nil {
+		ctrl.logger.Error(constants.InvalidRequestBody, zap.Error(err))
+		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidRequestBody)
+	}
+	if err := validator.New().Struct(person); err != nil {
+		ctrl.logger.Error(constants.ValidationFailed, zap.Error(err))
+		return utils.JSONFail(c, http.StatusBadRequest, err.Error())
+	}
+
+	err := ctrl.auditor.write(c, func(tx *goqu.TxDatabase) (auditedWrite, error) {
+		people := ctrl.peopleModel.WithTx(tx)
+		if err := people.AddPerson(&person); err != nil {
+			return auditedWrite{}, err
+		}
+		after, err := currentPerson(people, person.ID)
+		return auditedWrite{Entity: models.AuditPerson, ID: strconv.Itoa(person.ID), Operation: models.AuditCreate, After: after}, err
+	})
+	if err != nil {
+		ctrl.logger.Error(constants.ErrAddPerson, zap.Error(err))
+		return utils.JSONError(c, http.StatusInternalServerError, constants.ErrAddPerson)
+	}
+
+	return utils.JSONSuccess(c, http.StatusCreated, person)
+}
+
+// UpdatePerson updates a person by ID
+// swagger:route PUT /people/{personId} People UpdatePerson
+//
+// Replaces the name, gender and profile path of a person.
+//
+// Consumes:
+// - application/json
+//
+// Produces:
+// - application/json
+//
+// Parameters:
+// - RequestUpdatePerson
+//
+// Responses:
+//
+//	200: GenericResOk
+//	400: GenericResFailBadRequest
+//	401: GenericResFailUnauthorized
+//	403: GenericResFailForbidden
+//	404: GenericResFailNotFound
+//	500: GenericResError
+func (ctrl *PeopleController) UpdatePerson(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params(constants.PersonId))
+	if err != nil {
+		return utils.JSONFail(c, http.StatusBadRequest, "person ID must be a valid integer")
+	}
+
+	var person models.Person
+	if err := json.Unmarshal(c.Body(), &person); err != nil {
+		ctrl.logger.Error(constants.InvalidRequestBody, zap.Error(err))
+		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidRequestBody)
+	}
+	if err := validator.New().Struct(person); err != nil {
+		ctrl.logger.Error(constants.ValidationFailed, zap.Error(err))
+		return utils.JSONFail(c, http.StatusBadRequest, err.Error())
+	}
+
+	err = ctrl.auditor.write(c, func(tx *goqu.TxDatabase) (auditedWrite, error) {
+		people := ctrl.peopleModel.WithTx(tx)
+		before, err := currentPerson(people, id)
+		if err != nil {
+			return auditedWrite{}, err
+		}
+		if err := people.UpdatePerson(id, person); err != nil {
+			return auditedWrite{}, err
+		}
+		after, err := currentPerson(people, id)
+		return auditedWrite{Entity: models.AuditPerson, ID: strconv.Itoa(id), Operation: models.AuditUpdate, Before: before, After: after}, err
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.JSONFail(c, http.StatusNotFound, constants.PersonNotExist)
+		}
+		ctrl.logger.Error(constants.ErrUpdatePerson, zap.Int("id", id), zap.Error(err))
+		return utils.JSONError(c, http.StatusInternalServerError, constants.ErrUpdatePerson)
+	}
+
+	return utils.JSONSuccess(c, http.StatusOK, constants.UpdatePersonSuccess)
+}
+
+// DeletePerson deletes a person by ID
+// swagger:route DELETE /people/{personId} People DeletePerson
+//
+// Deletes a person. A person credited on movies is deleted only with cascade=true, which deletes
+// their cast and crew credits along.
+//
+// Produces:
+// - application/json
+//
+// Parameters:
+// - RequestDeletePerson
+//
+// Responses:
+//
+//	200: GenericResOk
+//	400: GenericResFailBadRequest
+//	401: GenericResFailUnauthorized
+//	403: GenericResFailForbidden
+//	404: GenericResFailNotFound
+//	409: GenericResFailConflict
+//	500: GenericResError
+func (ctrl *PeopleController) DeletePerson(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params(constants.PersonId))
+	if err != nil {
+		return utils.JSONFail(c, http.StatusBadRequest, "person ID must be a valid integer")
+	}
+
+	cascade := c.QueryBool("cascade")
+	err = ctrl.auditor.write(c, func(tx *goqu.TxDatabase) (auditedWrite, error) {
+		people := ctrl.peopleModel.WithTx(tx)
+		before, err := currentPerson(people, id)
+		if err != nil {
+			return auditedWrite{}, err
+		}
+		err = people.DeletePerson(id, cascade)
+		return auditedWrite{Entity: models.AuditPerson, ID: strconv.Itoa(id), Operation: models.AuditDelete, Before: before}, err
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.JSONFail(c, http.StatusNotFound, constants.PersonNotExist)
+		}
+		if errors.Is(err, models.ErrPersonCredited) {
+			return utils.JSONFail(c, http.StatusConflict, constants.PersonCredited)
+		}
+		ctrl.logger.Error(constants.ErrDeletePerson, zap.Int("id", id), zap.Error(err))
+		return utils.JSONError(c, http.StatusInternalServerError, constants.ErrDeletePerson)
+	}
+
+	return utils.JSONSuccess(c, http.StatusOK, constants.DeletePersonSuccess)
+}
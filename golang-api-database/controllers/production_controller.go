@@ -0,0 +1,205 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/constants"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/models"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/utils"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// ProductionController for productionModel controllers
+type ProductionController struct {
+	productionModel *models.ProductionModel
+	movieModel      *models.MovieModel
+	logger          *zap.Logger
+}
+
+// NewProductionController is to intialize ProductionController
+func NewProductionController(goqu *goqu.Database, logger *zap.Logger) (*ProductionController, error) {
+	productionModel, err := models.InitProductionModel(goqu)
+	if err != nil {
+		return nil, err
+	}
+	movieModel, err := models.InitMovieModel(goqu)
+	if err != nil {
+		return nil, err
+	}
+	return &ProductionController{
+		productionModel: productionModel,
+		movieModel:      movieModel,
+		logger:          logger,
+	}, nil
+}
+
+// ListCompanies lists production companies with pagination
+// swagger:route GET /companies Companies ListCompanies
+//
+// Retrieves a paginated list of production companies along with their film count, total revenue
+// and average rating.
+//
+// Produces:
+// - application/json
+//
+// Parameters:
+// - RequestListCompanies
+//
+// Responses:
+//
+//	200: ResponseListCompanies
+//	400: GenericResFailBadRequest
+//	500: GenericResError
+func (ctrl *ProductionController) ListCompanies(c *fiber.Ctx) error {
+	page, err := CursorQuery(c)
+	if err != nil {
+		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidCursorOrLimit)
+	}
+
+	companies, err := ctrl.productionModel.ListCompanies(c.Query("name"), page)
+	if errors.Is(err, models.ErrInvalidCursor) {
+		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidCursorOrLimit)
+	}
+	if err != nil {
+		ctrl.logger.Error(constants.ErrGetCompany, zap.Error(err))
+		return utils.JSONError(c, http.StatusInternalServerError, constants.ErrGetCompany)
+	}
+
+	return utils.JSONPage(c, http.StatusOK, companies.Items, companies)
+}
+
+// GetCompany retrieves a production company by ID
+// swagger:route GET /companies/{companyId} Companies GetCompany
+//
+// Retrieves a production company along with its film count, total revenue and average rating.
+//
+// Produces:
+// - application/json
+//
+// Parameters:
+// - RequestGetCompany
+//
+// Responses:
+//
+//	200: ResponseGetCompany
+//	400: GenericResFailBadRequest
+//	404: GenericResFailNotFound
+//	500: GenericResError
+func (ctrl *ProductionController) GetCompany(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params(constants.CompanyId))
+	if err != nil {
+		return utils.JSONFail(c, http.StatusBadRequest, "company ID must be a valid integer")
+	}
+
+	company, err := ctrl.productionModel.GetCompany(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return utils.JSONFail(c, http.StatusNotFound, constants.CompanyNotExist)
+		}
+		ctrl.logger.Error(constants.ErrGetCompany, zap.Int("id", id), zap.Error(err))
+		return utils.JSONError(c, http.StatusInternalServerError, constants.ErrGetCompany)
+	}
+
+	return utils.JSONSuccess(c, http.StatusOK, company)
+}
+
+// ListCompanyMovies lists the movies of a production company with pagination
+// swagger:route GET /companies/{companyId}/movies Companies ListCompanyMovies
+//
+// Retrieves a paginated list of the movies a company produced, filtered and sorted like ListMovies.
+//
+// Produces:
+// - application/json
+//
+// Parameters:
+// - RequestListCompanyMovies
+//
+// Responses:
+//
+//	200: ResponseListMovies
+//	400: GenericResFailBadRequest
+//	404: GenericResFailNotFound
+//	500: GenericResError
+func (ctrl *ProductionController) ListCompanyMovies(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params(constants.CompanyId))
+	if err != nil {
+		return utils.JSONFail(c, http.StatusBadRequest, "company ID must be a valid integer")
+	}
+
+	if _, err := ctrl.productionModel.GetCompany(id); err != nil {
+		if err == sql.ErrNoRows {
+			return utils.JSONFail(c, http.StatusNotFound, constants.CompanyNotExist)
+		}
+		ctrl.logger.Error(constants.ErrGetCompany, zap.Int("id", id), zap.Error(err))
+		return utils.JSONError(c, http.StatusInternalServerError, constants.ErrGetCompany)
+	}
+
+	return ctrl.listMovies(c, "company", strconv.Itoa(id))
+}
+
+// ListCountryMovies lists the movies produced in a country with pagination
+// swagger:route GET /countries/{countryCode}/movies Countries ListCountryMovies
+//
+// Retrieves a paginated list of the movies produced in a country, filtered and sorted like
+// ListMovies.
+//
+// Produces:
+// - application/json
+//
+// Parameters:
+// - RequestListCountryMovies
+//
+// Responses:
+//
+//	200: ResponseListMovies
+//	400: GenericResFailBadRequest
+//	404: GenericResFailNotFound
+//	500: GenericResError
+func (ctrl *ProductionController) ListCountryMovies(c *fiber.Ctx) error {
+	code := c.Params(constants.CountryCode)
+
+	country, err := ctrl.productionModel.GetCountry(code)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return utils.JSONFail(c, http.StatusNotFound, constants.CountryNotExist)
+		}
+		ctrl.logger.Error(constants.ErrGetCountry, zap.String("code", code), zap.Error(err))
+		return utils.JSONError(c, http.StatusInternalServerError, constants.ErrGetCountry)
+	}
+
+	return ctrl.listMovies(c, "country", country.ISOCode)
+}
+
+// listMovies writes the page of movies matching the filters of the query along with the filter
+// key set to value
+func (ctrl *ProductionController) listMovies(c *fiber.Ctx, key, value string) error {
+	filters := make(map[string]string, len(movieFilterKeys))
+	for _, name := range movieFilterKeys {
+		filters[name] = c.Query(name)
+	}
+	filters[key] = value
+
+	page, err := CursorQuery(c)
+	if err != nil {
+		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidCursorOrLimit)
+	}
+
+	movies, err := ctrl.movieModel.ListMovies(filters, page)
+	if errors.Is(err, models.ErrInvalidFilter) {
+		return utils.JSONFail(c, http.StatusBadRequest, err.Error())
+	}
+	if errors.Is(err, models.ErrInvalidCursor) {
+		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidCursorOrLimit)
+	}
+	if err != nil {
+		ctrl.logger.Error("error while list movies", zap.String(key, value), zap.Error(err))
+		return utils.JSONError(c, http.StatusInternalServerError, constants.ErrGetMovie)
+	}
+
+	return utils.JSONPage(c, http.StatusOK, movies.Items, movies)
+}
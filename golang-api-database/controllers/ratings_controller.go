@@ -3,10 +3,12 @@ package controllers
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/constants"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/middlewares"
 	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/models"
 	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/utils"
 	"github.com/doug-martin/goqu/v9"
@@ -18,25 +20,37 @@ import (
 // RatingsController for ratingModel and movieModel controllers
 type RatingsController struct {
 	ratingModel *models.RatingModel
+	auditor     *Auditor
 	logger      *zap.Logger
 }
 
-// NewRatingsController is to initialize RatingsController
-func NewRatingsController(goqu *goqu.Database, logger *zap.Logger) (*RatingsController, error) {
-	model, err := models.InitRatingsModel(goqu)
+// NewRatingsController is to initialize RatingsController, prior weights the scores of movies
+func NewRatingsController(goqu *goqu.Database, logger *zap.Logger, prior models.RatingPrior) (*RatingsController, error) {
+	model, err := models.InitRatingsModel(goqu, prior)
+	if err != nil {
+		return nil, err
+	}
+	auditor, err := NewAuditor(goqu, logger)
 	if err != nil {
 		return nil, err
 	}
 	return &RatingsController{
 		ratingModel: model,
+		auditor:     auditor,
 		logger:      logger,
 	}, nil
 }
 
+// currentRating reads the rating of userId for movieId for the audit log through ratings, nil
+// when there is none
+func currentRating(ratings *models.RatingModel, movieId, userId int) (interface{}, error) {
+	return found(ratings.GetUserRating(movieId, userId))
+}
+
 // ListAllMovieRatings displays average ratings of all movies
 // swagger:route GET /ratings/movies Ratings ListAllMovieRatings
 //
-// Retrieves a paginated ratings of movies.
+// Retrieves a paginated list of the rating stats of movies.
 //
 // Consumes:
 // - application/json
@@ -50,20 +64,69 @@ func NewRatingsController(goqu *goqu.Database, logger *zap.Logger) (*RatingsCont
 // Responses:
 //
 //	200: ResponseListAllMovieRatings
-//	401: GenericResFailBadRequest
+//	400: GenericResFailBadRequest
 //	500: GenericResError
 func (ctrl *RatingsController) ListAllMovieRatings(c *fiber.Ctx) error {
-	page, limit, err := PaginationQuery(c)
+	page, err := CursorQuery(c)
 	if err != nil {
-		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidPageOrLimit)
+		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidCursorOrLimit)
 	}
 
-	ratings, err := ctrl.ratingModel.ListRatings(page, limit)
+	ratings, err := ctrl.ratingModel.ListRatings(page)
+	if errors.Is(err, models.ErrInvalidCursor) {
+		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidCursorOrLimit)
+	}
 	if err != nil {
 		ctrl.logger.Error(constants.ErrGetRatings, zap.Error(err))
 		return utils.JSONError(c, http.StatusInternalServerError, constants.ErrGetRatings)
 	}
 
+	return utils.JSONPage(c, http.StatusOK, ratings.Items, ratings)
+}
+
+// topRatedLimit caps the number of movies of the top rated leaderboard
+const topRatedLimit = 100
+
+// TopRatedMovies lists the movies with the best weighted rating scores
+// swagger:route GET /ratings/top Ratings TopRatedMovies
+//
+// Retrieves the top rated movies, filtered by genre, language and release year.
+//
+// Consumes:
+// - application/json
+//
+// Produces:
+// - application/json
+//
+// Parameters:
+// - RequestTopRatedMovies
+//
+// Responses:
+//
+//	200: ResponseTopRatedMovies
+//	400: GenericResFailBadRequest
+//	500: GenericResError
+func (ctrl *RatingsController) TopRatedMovies(c *fiber.Ctx) error {
+	limit, err := strconv.Atoi(c.Query("limit", "10")) // Default: 10
+	if err != nil || limit <= 0 || limit > topRatedLimit {
+		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidTopRatedLimit)
+	}
+
+	filters := map[string]string{
+		"genre":    c.Query("genre"),
+		"language": c.Query("language"),
+		"year":     c.Query("year"),
+	}
+
+	ratings, err := ctrl.ratingModel.TopRatedMovies(filters, uint(limit))
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidFilter) {
+			return utils.JSONFail(c, http.StatusBadRequest, err.Error())
+		}
+		ctrl.logger.Error(constants.ErrGetTopRated, zap.Error(err))
+		return utils.JSONError(c, http.StatusInternalServerError, constants.ErrGetTopRated)
+	}
+
 	return utils.JSONSuccess(c, http.StatusOK, ratings)
 }
 
@@ -101,6 +164,56 @@ func (ctrl *RatingsController) GetRatingByMovieId(c *fiber.Ctx) error {
 	return utils.JSONSuccess(c, http.StatusOK, rating)
 }
 
+// GetUserRating retrieves the rating a user gave to a movie
+// swagger:route GET /ratings/movies/{movieId}/user/{userId}/ratings Ratings GetUserRating
+//
+// Retrieves the rating a user gave to a movie, tagged with the ETag its updates and deletes take
+// in their If-Match header.
+//
+// Produces:
+// - application/json
+//
+// Parameters:
+// - RequestGetUserRating
+//
+// Responses:
+//
+//	200: ResponseGetUserRating
+//	304: description: Not Modified
+//	400: GenericResFailBadRequest
+//	404: GenericResFailNotFound
+//	500: GenericResError
+func (ctrl *RatingsController) GetUserRating(c *fiber.Ctx) error {
+	movieid, err := strconv.Atoi(c.Params(constants.ParamMid))
+	if err != nil {
+		return utils.JSONFail(c, http.StatusBadRequest, "movie ID must be a valid integer")
+	}
+	userid, err := strconv.Atoi(c.Params(constants.UserId))
+	if err != nil {
+		return utils.JSONFail(c, http.StatusBadRequest, "user ID must be a valid integer")
+	}
+
+	rating, err := ctrl.ratingModel.GetUserRating(movieid, userid)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return utils.JSONFail(c, http.StatusNotFound, constants.RatingNotExist)
+		}
+		ctrl.logger.Error(constants.ErrGetRatings, zap.Int("movieId", movieid), zap.Int("userId", userid), zap.Error(err))
+		return utils.JSONError(c, http.StatusInternalServerError, constants.ErrGetRatings)
+	}
+
+	fresh, err := notModified(c, rating)
+	if err != nil {
+		ctrl.logger.Error(constants.ErrGetRatings, zap.Int("movieId", movieid), zap.Int("userId", userid), zap.Error(err))
+		return utils.JSONError(c, http.StatusInternalServerError, constants.ErrGetRatings)
+	}
+	if fresh {
+		return c.SendStatus(http.StatusNotModified)
+	}
+
+	return utils.JSONSuccess(c, http.StatusOK, rating)
+}
+
 // DeleteRating deletes ratings of a movie
 // swagger:route DELETE /ratings/movies/{movieId}/user/{userId}/ratings Ratings DeleteRating
 //
@@ -118,11 +231,18 @@ func (ctrl *RatingsController) GetRatingByMovieId(c *fiber.Ctx) error {
 // Responses:
 //
 //	200: GenericResOk
-//	401: GenericResFailBadRequest
+//	400: GenericResFailBadRequest
+//	401: GenericResFailUnauthorized
+//	403: GenericResFailForbidden
 //	404: GenericResFailNotFound
+//	412: GenericResFailPrecondition
 //	500: GenericResError
 func (ctrl *RatingsController) DeleteRating(c *fiber.Ctx) error {
 	userId := c.Params(constants.UserId)
+
+	if !ownsRating(c, userId) {
+		return utils.JSONFail(c, http.StatusForbidden, constants.RatingOwnerError)
+	}
 	movieId := c.Params(constants.ParamMid)
 
 	movieid, err := strconv.Atoi(movieId)
@@ -134,9 +254,25 @@ func (ctrl *RatingsController) DeleteRating(c *fiber.Ctx) error {
 	if err != nil {
 		return utils.JSONFail(c, http.StatusBadRequest, "user ID must be a valid integer")
 	}
-	err = ctrl.ratingModel.DeleteRatings(movieid, userid)
+	err = ctrl.auditor.write(c, func(tx *goqu.TxDatabase) (auditedWrite, error) {
+		ratings := ctrl.ratingModel.WithTx(tx)
+		if err := checkIfMatch(c, func() error { return ratings.LockRating(movieid, userid) }, func() (interface{}, error) {
+			return currentRating(ratings, movieid, userid)
+		}); err != nil {
+			return auditedWrite{}, err
+		}
+		before, err := currentRating(ratings, movieid, userid)
+		if err != nil {
+			return auditedWrite{}, err
+		}
+		err = ratings.DeleteRatings(movieid, userid)
+		return auditedWrite{Entity: models.AuditRating, ID: models.AuditEntityID(movieid, userId), Operation: models.AuditDelete, Before: before}, err
+	})
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, errPreconditionFailed) {
+			return utils.JSONFail(c, http.StatusPreconditionFailed, constants.PreconditionFailed)
+		}
+		if errors.Is(err, sql.ErrNoRows) {
 			return utils.JSONFail(c, http.StatusNotFound, constants.RatingNotExist)
 		}
 		ctrl.logger.Error(constants.ErrDeleteRating, zap.Error(err))
@@ -163,11 +299,18 @@ func (ctrl *RatingsController) DeleteRating(c *fiber.Ctx) error {
 // Responses:
 //
 //	200: GenericResOk
-//	401: GenericResFailBadRequest
+//	400: GenericResFailBadRequest
+//	401: GenericResFailUnauthorized
+//	403: GenericResFailForbidden
 //	404: GenericResFailNotFound
+//	412: GenericResFailPrecondition
 //	500: GenericResError
 func (ctrl *RatingsController) UpdateRating(c *fiber.Ctx) error {
 	userId := c.Params(constants.UserId)
+
+	if !ownsRating(c, userId) {
+		return utils.JSONFail(c, http.StatusForbidden, constants.RatingOwnerError)
+	}
 	movieId := c.Params(constants.ParamMid)
 
 	movieid, err := strconv.Atoi(movieId)
@@ -188,9 +331,28 @@ func (ctrl *RatingsController) UpdateRating(c *fiber.Ctx) error {
 		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidRequestBody)
 	}
 
-	err = ctrl.ratingModel.UpdateRatings(userid, movieid, updateData.Rating)
+	err = ctrl.auditor.write(c, func(tx *goqu.TxDatabase) (auditedWrite, error) {
+		ratings := ctrl.ratingModel.WithTx(tx)
+		if err := checkIfMatch(c, func() error { return ratings.LockRating(movieid, userid) }, func() (interface{}, error) {
+			return currentRating(ratings, movieid, userid)
+		}); err != nil {
+			return auditedWrite{}, err
+		}
+		before, err := currentRating(ratings, movieid, userid)
+		if err != nil {
+			return auditedWrite{}, err
+		}
+		if err := ratings.UpdateRatings(userid, movieid, updateData.Rating); err != nil {
+			return auditedWrite{}, err
+		}
+		after, err := currentRating(ratings, movieid, userid)
+		return auditedWrite{Entity: models.AuditRating, ID: models.AuditEntityID(movieid, userId), Operation: models.AuditUpdate, Before: before, After: after}, err
+	})
 	if err != nil {
-		if err == sql.ErrNoRows {
+		if errors.Is(err, errPreconditionFailed) {
+			return utils.JSONFail(c, http.StatusPreconditionFailed, constants.PreconditionFailed)
+		}
+		if errors.Is(err, sql.ErrNoRows) {
 			return utils.JSONFail(c, http.StatusNotFound, constants.MovieNotExist)
 		}
 		ctrl.logger.Error(constants.ErrUpdateRating, zap.Error(err))
@@ -217,11 +379,17 @@ func (ctrl *RatingsController) UpdateRating(c *fiber.Ctx) error {
 // Responses:
 //
 //	200: GenericResOk
-//	401: GenericResFailBadRequest
+//	400: GenericResFailBadRequest
+//	401: GenericResFailUnauthorized
+//	403: GenericResFailForbidden
 //	500: GenericResError
 func (ctrl *RatingsController) AddRating(c *fiber.Ctx) error {
 	userId := c.Params(constants.UserId)
 
+	if !ownsRating(c, userId) {
+		return utils.JSONFail(c, http.StatusForbidden, constants.RatingOwnerError)
+	}
+
 	userid, err := strconv.Atoi(userId)
 	if err != nil {
 		return utils.JSONFail(c, http.StatusBadRequest, "user ID must be a valid integer")
@@ -251,10 +419,35 @@ func (ctrl *RatingsController) AddRating(c *fiber.Ctx) error {
 		return utils.JSONFail(c, http.StatusBadRequest, constants.ValidationFailed)
 	}
 
-	if err := ctrl.ratingModel.AddorUpdateRatings(&rating); err != nil {
+	err = ctrl.auditor.write(c, func(tx *goqu.TxDatabase) (auditedWrite, error) {
+		ratings := ctrl.ratingModel.WithTx(tx)
+		before, err := currentRating(ratings, rating.MovieId, userid)
+		if err != nil {
+			return auditedWrite{}, err
+		}
+		if err := ratings.AddorUpdateRatings(&rating); err != nil {
+			return auditedWrite{}, err
+		}
+		after, err := currentRating(ratings, rating.MovieId, userid)
+
+		// Rating a movie again replaces the rating
+		operation := models.AuditUpdate
+		if before == nil {
+			operation = models.AuditCreate
+		}
+		return auditedWrite{Entity: models.AuditRating, ID: models.AuditEntityID(rating.MovieId, userId), Operation: operation, Before: before, After: after}, err
+	})
+	if err != nil {
 		ctrl.logger.Error(constants.ErrAddRating, zap.Error(err))
 		return utils.JSONError(c, http.StatusInternalServerError, constants.ErrAddRating)
 	}
 
 	return utils.JSONSuccess(c, http.StatusOK, constants.AddRatingSuccess)
 }
+
+// ownsRating reports whether the caller may change the ratings of userId, only admins may
+// change the ratings of other users
+func ownsRating(c *fiber.Ctx, userId string) bool {
+	principal := middlewares.CurrentPrincipal(c)
+	return principal != nil && (principal.Subject == userId || principal.Has(middlewares.RoleAdmin))
+}
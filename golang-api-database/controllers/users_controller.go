@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/constants"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/models"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/utils"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// UsersController for userModel controllers
+type UsersController struct {
+	userModel *models.UserModel
+	logger    *zap.Logger
+}
+
+// NewUsersController is to initialize UsersController
+func NewUsersController(goqu *goqu.Database, logger *zap.Logger) (*UsersController, error) {
+	model, err := models.InitUsersModel(goqu)
+	if err != nil {
+		return nil, err
+	}
+	return &UsersController{
+		userModel: model,
+		logger:    logger,
+	}, nil
+}
+
+// ListUserRatings lists the ratings given by a user
+// swagger:route GET /users/{userId}/ratings Users ListUserRatings
+//
+// Retrieves a paginated list of the ratings given by a user.
+//
+// Consumes:
+// - application/json
+//
+// Produces:
+// - application/json
+//
+// Parameters:
+// - RequestListUserRatings
+//
+// Responses:
+//
+//	200: ResponseListUserRatings
+//	400: GenericResFailBadRequest
+//	404: GenericResFailNotFound
+//	500: GenericResError
+func (ctrl *UsersController) ListUserRatings(c *fiber.Ctx) error {
+	userId, err := strconv.Atoi(c.Params(constants.UserId))
+	if err != nil {
+		return utils.JSONFail(c, http.StatusBadRequest, "user ID must be a valid integer")
+	}
+
+	page, err := CursorQuery(c)
+	if err != nil {
+		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidCursorOrLimit)
+	}
+
+	ratings, err := ctrl.userModel.ListUserRatings(userId, c.Query("sort"), page)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return utils.JSONFail(c, http.StatusNotFound, constants.UserRatingsNotExist)
+		}
+		if errors.Is(err, models.ErrInvalidCursor) {
+			return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidCursorOrLimit)
+		}
+		if errors.Is(err, models.ErrInvalidSort) {
+			return utils.JSONFail(c, http.StatusBadRequest, err.Error())
+		}
+		ctrl.logger.Error(constants.ErrGetUserRatings, zap.Any("id", userId), zap.Error(err))
+		return utils.JSONError(c, http.StatusInternalServerError, constants.ErrGetUserRatings)
+	}
+
+	return utils.JSONPage(c, http.StatusOK, ratings.Items, ratings)
+}
+
+// GetUserStats summarizes the ratings given by a user
+// swagger:route GET /users/{userId}/stats Users GetUserStats
+//
+// Retrieves the rating count, mean, distribution and favourite genres of a user.
+//
+// Consumes:
+// - application/json
+//
+// Produces:
+// - application/json
+//
+// Parameters:
+// - RequestGetUserStats
+//
+// Responses:
+//
+//	200: ResponseGetUserStats
+//	400: GenericResFailBadRequest
+//	404: GenericResFailNotFound
+//	500: GenericResError
+func (ctrl *UsersController) GetUserStats(c *fiber.Ctx) error {
+	userId, err := strconv.Atoi(c.Params(constants.UserId))
+	if err != nil {
+		return utils.JSONFail(c, http.StatusBadRequest, "user ID must be a valid integer")
+	}
+
+	stats, err := ctrl.userModel.GetUserStats(userId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return utils.JSONFail(c, http.StatusNotFound, constants.UserRatingsNotExist)
+		}
+		ctrl.logger.Error(constants.ErrGetUserStats, zap.Any("id", userId), zap.Error(err))
+		return utils.JSONError(c, http.StatusInternalServerError, constants.ErrGetUserStats)
+	}
+
+	return utils.JSONSuccess(c, http.StatusOK, stats)
+}
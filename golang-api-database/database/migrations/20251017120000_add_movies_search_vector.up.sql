@@ -0,0 +1,9 @@
+-- +migrate Up
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(original_title, '')), 'B') ||
+    setweight(to_tsvector('english', coalesce(tagline, '')), 'C') ||
+    setweight(to_tsvector('english', coalesce(overview, '')), 'D')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_movies_search_vector ON movies USING GIN (search_vector);
@@ -0,0 +1,9 @@
+-- +migrate Down
+DROP INDEX IF EXISTS idx_movie_crew_deleted_at;
+DROP INDEX IF EXISTS idx_movie_casts_deleted_at;
+DROP INDEX IF EXISTS idx_ratings_deleted_at;
+DROP INDEX IF EXISTS idx_movies_deleted_at;
+ALTER TABLE movie_crew DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE movie_casts DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE ratings DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE movies DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,7 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS seed_checkpoints (
+    kind TEXT PRIMARY KEY,
+    source TEXT NOT NULL,
+    stats JSONB NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,5 @@
+-- +migrate Down
+DROP TABLE IF EXISTS movie_countries;
+DROP TABLE IF EXISTS movie_companies;
+DROP TABLE IF EXISTS countries;
+DROP TABLE IF EXISTS companies;
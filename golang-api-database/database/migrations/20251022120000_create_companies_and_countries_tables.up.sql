@@ -0,0 +1,54 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS companies (id INTEGER PRIMARY KEY, name VARCHAR(255) NOT NULL);
+CREATE TABLE IF NOT EXISTS countries (iso_code VARCHAR(2) PRIMARY KEY, name VARCHAR(255) NOT NULL);
+
+CREATE TABLE IF NOT EXISTS movie_companies (
+    movieid INTEGER REFERENCES movies (id) on delete cascade,
+    companyid INTEGER REFERENCES companies (id) on delete cascade,
+    PRIMARY KEY (movieid, companyid)
+);
+CREATE INDEX IF NOT EXISTS idx_movie_companies_companyid ON movie_companies (companyid);
+
+CREATE TABLE IF NOT EXISTS movie_countries (
+    movieid INTEGER REFERENCES movies (id) on delete cascade,
+    country_code VARCHAR(2) REFERENCES countries (iso_code) on delete cascade,
+    PRIMARY KEY (movieid, country_code)
+);
+CREATE INDEX IF NOT EXISTS idx_movie_countries_country_code ON movie_countries (country_code);
+
+-- Movies seeded before are linked from their JSONB lists, skipping the values of another shape
+INSERT INTO companies (id, name)
+SELECT DISTINCT ON (company_id) company_id, company_name
+FROM (
+    SELECT (company->>'id')::numeric::integer AS company_id, left(company->>'name', 255) AS company_name
+    FROM movies,
+        jsonb_array_elements(CASE WHEN jsonb_typeof(production_companies) = 'array' THEN production_companies ELSE '[]' END) AS company
+    WHERE jsonb_typeof(company->'id') = 'number' AND jsonb_typeof(company->'name') = 'string'
+) AS listed
+ORDER BY company_id
+ON CONFLICT DO NOTHING;
+
+INSERT INTO movie_companies (movieid, companyid)
+SELECT DISTINCT movies.id, (company->>'id')::numeric::integer
+FROM movies,
+    jsonb_array_elements(CASE WHEN jsonb_typeof(production_companies) = 'array' THEN production_companies ELSE '[]' END) AS company
+WHERE jsonb_typeof(company->'id') = 'number' AND jsonb_typeof(company->'name') = 'string'
+ON CONFLICT DO NOTHING;
+
+INSERT INTO countries (iso_code, name)
+SELECT DISTINCT ON (country_code) country_code, country_name
+FROM (
+    SELECT country->>'iso_3166_1' AS country_code, left(COALESCE(country->>'name', country->>'iso_3166_1'), 255) AS country_name
+    FROM movies,
+        jsonb_array_elements(CASE WHEN jsonb_typeof(production_countries) = 'array' THEN production_countries ELSE '[]' END) AS country
+    WHERE jsonb_typeof(country->'iso_3166_1') = 'string' AND length(country->>'iso_3166_1') = 2
+) AS listed
+ORDER BY country_code
+ON CONFLICT DO NOTHING;
+
+INSERT INTO movie_countries (movieid, country_code)
+SELECT DISTINCT movies.id, country->>'iso_3166_1'
+FROM movies,
+    jsonb_array_elements(CASE WHEN jsonb_typeof(production_countries) = 'array' THEN production_countries ELSE '[]' END) AS country
+WHERE jsonb_typeof(country->'iso_3166_1') = 'string' AND length(country->>'iso_3166_1') = 2
+ON CONFLICT DO NOTHING;
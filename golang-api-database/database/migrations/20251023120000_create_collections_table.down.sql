@@ -0,0 +1,3 @@
+-- +migrate Down
+ALTER TABLE movies DROP COLUMN IF EXISTS collection_id;
+DROP TABLE IF EXISTS collections;
@@ -0,0 +1,29 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS collections (
+    id INTEGER PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    poster_path VARCHAR(255),
+    backdrop_path VARCHAR(255)
+);
+
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS collection_id INTEGER REFERENCES collections (id) on delete set null;
+CREATE INDEX IF NOT EXISTS idx_movies_collection_id ON movies (collection_id);
+
+-- Movies seeded before are linked from their JSONB collection, skipping the values of another shape
+INSERT INTO collections (id, name, poster_path, backdrop_path)
+SELECT DISTINCT ON ((belongs_to_collection->>'id')::numeric::integer)
+    (belongs_to_collection->>'id')::numeric::integer,
+    left(belongs_to_collection->>'name', 255),
+    left(belongs_to_collection->>'poster_path', 255),
+    left(belongs_to_collection->>'backdrop_path', 255)
+FROM movies
+WHERE jsonb_typeof(belongs_to_collection) = 'object'
+    AND jsonb_typeof(belongs_to_collection->'id') = 'number'
+    AND jsonb_typeof(belongs_to_collection->'name') = 'string'
+ORDER BY (belongs_to_collection->>'id')::numeric::integer
+ON CONFLICT DO NOTHING;
+
+UPDATE movies SET collection_id = (belongs_to_collection->>'id')::numeric::integer
+WHERE jsonb_typeof(belongs_to_collection) = 'object'
+    AND jsonb_typeof(belongs_to_collection->'id') = 'number'
+    AND jsonb_typeof(belongs_to_collection->'name') = 'string';
@@ -0,0 +1,3 @@
+-- +migrate Down
+ALTER TABLE credits ALTER COLUMN id DROP DEFAULT;
+DROP SEQUENCE IF EXISTS credits_id_seq;
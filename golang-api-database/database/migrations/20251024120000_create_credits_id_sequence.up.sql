@@ -0,0 +1,5 @@
+-- +migrate Up
+-- People added through the API take their ids from a sequence, started past the seeded ids
+CREATE SEQUENCE IF NOT EXISTS credits_id_seq OWNED BY credits.id;
+SELECT setval('credits_id_seq', COALESCE(MAX(id), 0) + 1, false) FROM credits;
+ALTER TABLE credits ALTER COLUMN id SET DEFAULT nextval('credits_id_seq');
@@ -0,0 +1,128 @@
+package seed
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/doug-martin/goqu/v9"
+	"go.uber.org/zap"
+)
+
+// checkpointTable holds the progress of the seeds which did not finish, by kind
+const checkpointTable = "seed_checkpoints"
+
+// Seed loads the rows of kind read from r like Load, but commits every batch along with a
+// checkpoint, so a failure only loses the batch it happened in. source identifies the file r
+// reads, such as by its path, size and modification time: a seed of the same source which did not
+// finish resumes after its last committed batch, unless restart is set.
+func Seed(db *goqu.Database, kind, source string, r Reader, restart bool, opts Options, logger *zap.Logger) (Stats, error) {
+	l, err := newLoader(kind, opts, logger)
+	if err != nil {
+		return Stats{}, err
+	}
+
+	var stats Stats
+	if !restart {
+		if stats, err = resume(db, kind, source, r); err != nil {
+			return Stats{}, err
+		}
+		if stats.Read > 0 {
+			logger.Info("Resuming seed", zap.String("kind", kind), zap.Int64("rows", stats.Read))
+		}
+	}
+
+	if l.tx, err = db.Begin(); err != nil {
+		return stats, err
+	}
+	stats, err = l.run(r, stats, func(stats Stats, done bool) error {
+		if err := saveCheckpoint(l.tx, kind, source, stats, done); err != nil {
+			return err
+		}
+		tx := l.tx
+		l.tx = nil
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+		next, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		l.tx = next
+		return nil
+	})
+	if err != nil && l.tx != nil {
+		if rollbackErr := l.tx.Rollback(); rollbackErr != nil {
+			logger.Error("Error rolling back transaction", zap.Error(rollbackErr))
+		}
+	}
+	return stats, err
+}
+
+// resume returns the stats of the unfinished seed of kind from source, having skipped the rows
+// of r it loaded. The seed starts over when the checkpoint is of another source.
+func resume(db *goqu.Database, kind, source string, r Reader) (Stats, error) {
+	var checkpoint struct {
+		Source string `db:"source"`
+		Stats  []byte `db:"stats"`
+	}
+	found, err := db.From(checkpointTable).Select("source", "stats").
+		Where(goqu.C("kind").Eq(kind)).ScanStruct(&checkpoint)
+	if err != nil {
+		return Stats{}, fmt.Errorf("reading checkpoint of %s: %w", kind, err)
+	}
+	if !found || checkpoint.Source != source {
+		return Stats{}, nil
+	}
+
+	var stats Stats
+	if err := json.Unmarshal(checkpoint.Stats, &stats); err != nil {
+		return Stats{}, fmt.Errorf("decoding checkpoint of %s: %w", kind, err)
+	}
+	var rowErr *RowError
+	for i := int64(0); i < stats.Read; i++ {
+		_, err := r.Read()
+		if err == io.EOF {
+			return Stats{}, fmt.Errorf("checkpoint of %s is past the end of the file", kind)
+		}
+		if err != nil && !errors.As(err, &rowErr) {
+			return Stats{}, err
+		}
+	}
+	return stats, nil
+}
+
+// saveCheckpoint records stats as the progress of the seed of kind from source within tx, or
+// removes the checkpoint once done
+func saveCheckpoint(tx *goqu.TxDatabase, kind, source string, stats Stats, done bool) error {
+	if done {
+		_, err := tx.Delete(checkpointTable).Where(goqu.C("kind").Eq(kind)).Executor().Exec()
+		if err != nil {
+			return fmt.Errorf("removing checkpoint of %s: %w", kind, err)
+		}
+		return nil
+	}
+
+	encoded, err := json.Marshal(stats)
+	if err != nil {
+		return fmt.Errorf("encoding checkpoint of %s: %w", kind, err)
+	}
+	_, err = tx.Insert(checkpointTable).Rows(goqu.Record{
+		"kind":       kind,
+		"source":     source,
+		"stats":      string(encoded),
+		"updated_at": goqu.L("now()"),
+	}).OnConflict(goqu.DoUpdate("kind", goqu.Record{
+		"source":     goqu.I("excluded.source"),
+		"stats":      goqu.I("excluded.stats"),
+		"updated_at": goqu.I("excluded.updated_at"),
+	})).Executor().Exec()
+	if err != nil {
+		return fmt.Errorf("saving checkpoint of %s: %w", kind, err)
+	}
+	return nil
+}
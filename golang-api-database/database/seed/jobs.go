@@ -0,0 +1,237 @@
+package seed
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// Statuses of import jobs
+const (
+	JobPending   = "pending"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// Bounds of the row errors kept per job and of the finished jobs kept
+const (
+	maxJobRowErrors = 1000
+	maxFinishedJobs = 100
+)
+
+// Job is an import of a file into the database. A job loads the whole file in one transaction,
+// which is rolled back when it fails or is a dry run, so a job imports either every valid row or
+// nothing.
+type Job struct {
+	ID     string `json:"id"`
+	Kind   string `json:"kind"`
+	Format string `json:"format"`
+	DryRun bool   `json:"dry_run"`
+	Status string `json:"status"`
+	Stats
+	// RowErrors holds the first rows which could not be loaded
+	RowErrors  []RowError `json:"row_errors"`
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// Finished reports whether the job is over
+func (j Job) Finished() bool {
+	return j.Status == JobSucceeded || j.Status == JobFailed
+}
+
+// Jobs runs import jobs in the background, one at a time, and keeps their progress
+type Jobs struct {
+	db     *goqu.Database
+	logger *zap.Logger
+
+	mu    sync.Mutex
+	jobs  map[string]*Job
+	order []string
+	// running is held by the job loading its file
+	running sync.Mutex
+}
+
+// NewJobs is to initialize Jobs
+func NewJobs(db *goqu.Database, logger *zap.Logger) *Jobs {
+	return &Jobs{
+		db:     db,
+		logger: logger,
+		jobs:   make(map[string]*Job),
+	}
+}
+
+// Start queues the import of the rows of kind held by the file at path, which is removed once the
+// job is over. Unless the job is a dry run, onCommit is called with its transaction and the job as
+// it finishes once committed, right before the commit. The job fails and imports nothing when
+// onCommit returns an error.
+func (j *Jobs) Start(kind, format string, dryRun bool, path string, onCommit func(tx *goqu.TxDatabase, job Job) error) (Job, error) {
+	if err := CheckKind(kind); err != nil {
+		return Job{}, err
+	}
+	if err := CheckFormat(format); err != nil {
+		return Job{}, err
+	}
+
+	job := &Job{
+		ID:        primitive.NewObjectID().Hex(),
+		Kind:      kind,
+		Format:    format,
+		DryRun:    dryRun,
+		Status:    JobPending,
+		RowErrors: []RowError{},
+		CreatedAt: time.Now().UTC(),
+	}
+
+	j.mu.Lock()
+	j.jobs[job.ID] = job
+	j.order = append(j.order, job.ID)
+	j.evict()
+	snapshot := *job
+	j.mu.Unlock()
+
+	go j.run(job, path, onCommit)
+	return snapshot, nil
+}
+
+// Get returns the job having id
+func (j *Jobs) Get(id string) (Job, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	job, ok := j.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	snapshot := *job
+	snapshot.RowErrors = append([]RowError{}, job.RowErrors...)
+	return snapshot, true
+}
+
+// evict forgets the oldest finished jobs beyond maxFinishedJobs
+func (j *Jobs) evict() {
+	finished := 0
+	for _, id := range j.order {
+		if j.jobs[id].Finished() {
+			finished++
+		}
+	}
+
+	kept := j.order[:0]
+	for _, id := range j.order {
+		if finished > maxFinishedJobs && j.jobs[id].Finished() {
+			delete(j.jobs, id)
+			finished--
+			continue
+		}
+		kept = append(kept, id)
+	}
+	j.order = kept
+}
+
+// update changes the job under the lock
+func (j *Jobs) update(job *Job, change func(job *Job)) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	change(job)
+}
+
+func (j *Jobs) run(job *Job, path string, onCommit func(tx *goqu.TxDatabase, job Job) error) {
+	defer os.Remove(path)
+
+	j.running.Lock()
+	defer j.running.Unlock()
+
+	logger := j.logger.With(zap.String("job", job.ID), zap.String("kind", job.Kind))
+	j.update(job, func(job *Job) {
+		now := time.Now().UTC()
+		job.Status = JobRunning
+		job.StartedAt = &now
+	})
+
+	stats, err := j.load(job, path, onCommit, logger)
+
+	j.update(job, func(job *Job) {
+		now := time.Now().UTC()
+		job.Stats = stats
+		job.FinishedAt = &now
+		job.Status = JobSucceeded
+		if err != nil {
+			job.Status = JobFailed
+			job.Error = err.Error()
+		}
+	})
+
+	if err != nil {
+		logger.Error("Import failed", zap.Error(err))
+	} else {
+		logger.Info("Import finished", zap.Int64("rows", stats.Loaded), zap.Int64("failed", stats.Failed), zap.Bool("dryRun", job.DryRun))
+	}
+}
+
+// load loads the file of job in a transaction, committed along with the writes of onCommit unless
+// the job is a dry run
+func (j *Jobs) load(job *Job, path string, onCommit func(tx *goqu.TxDatabase, job Job) error, logger *zap.Logger) (Stats, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Stats{}, err
+	}
+	defer file.Close()
+
+	// People added through the API while the job runs must not take the ids it loads
+	if job.Kind == Credits && !job.DryRun {
+		if err := ReserveCreditIDs(j.db, file, job.Format); err != nil {
+			return Stats{}, err
+		}
+	}
+
+	reader, err := NewReader(file, job.Format)
+	if err != nil {
+		return Stats{}, err
+	}
+
+	tx, err := j.db.Begin()
+	if err != nil {
+		return Stats{}, err
+	}
+
+	stats, err := Load(tx, job.Kind, reader, Options{
+		OnRowError: func(rowErr *RowError) {
+			j.update(job, func(job *Job) {
+				job.Failed++
+				if len(job.RowErrors) < maxJobRowErrors {
+					job.RowErrors = append(job.RowErrors, *rowErr)
+				}
+			})
+		},
+		OnProgress: func(stats Stats) {
+			j.update(job, func(job *Job) {
+				job.Stats = stats
+			})
+		},
+	}, logger)
+
+	if err == nil && !job.DryRun && onCommit != nil {
+		snapshot, _ := j.Get(job.ID)
+		now := time.Now().UTC()
+		snapshot.Stats = stats
+		snapshot.Status = JobSucceeded
+		snapshot.FinishedAt = &now
+		err = onCommit(tx, snapshot)
+	}
+
+	if err != nil || job.DryRun {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			logger.Error("Error rolling back transaction", zap.Error(rollbackErr))
+		}
+		return stats, err
+	}
+	return stats, tx.Commit()
+}
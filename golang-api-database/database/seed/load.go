@@ -0,0 +1,868 @@
+package seed
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// Kinds of data which can be seeded and imported
+const (
+	Movies  = "movies"
+	Ratings = "ratings"
+	Credits = "credits"
+)
+
+var ErrUnknownKind = errors.New("unknown kind")
+
+// CheckKind returns ErrUnknownKind unless kind can be loaded
+func CheckKind(kind string) error {
+	switch kind {
+	case Movies, Ratings, Credits:
+		return nil
+	}
+	return fmt.Errorf("%w %q", ErrUnknownKind, kind)
+}
+
+// DefaultBatchSize is the number of rows loaded together when Options leave it out
+const DefaultBatchSize = 1000
+
+// tableSpec describes a table loads write to. Its rows are unique by keys, which upserts
+// conflict on. The rows of tables having an owner belong to the movie in that column.
+type tableSpec struct {
+	keys  []string
+	owner string
+}
+
+var tableSpecs = map[string]tableSpec{
+	"genres":          {keys: []string{"id"}},
+	"languages":       {keys: []string{"iso_code"}},
+	"companies":       {keys: []string{"id"}},
+	"countries":       {keys: []string{"iso_code"}},
+	"collections":     {keys: []string{"id"}},
+	"movies":          {keys: []string{"id"}},
+	"movie_genres":    {keys: []string{"movieid", "genreid"}, owner: "movieid"},
+	"movie_languages": {keys: []string{"movieid", "language_code"}, owner: "movieid"},
+	"movie_companies": {keys: []string{"movieid", "companyid"}, owner: "movieid"},
+	"movie_countries": {keys: []string{"movieid", "country_code"}, owner: "movieid"},
+	"ratings":         {keys: []string{"user_id", "movie_id"}},
+	"credits":         {keys: []string{"id"}},
+	"movie_casts":     {keys: []string{"movie_id", "person_id"}, owner: "movie_id"},
+	"movie_crew":      {keys: []string{"movie_id", "person_id"}, owner: "movie_id"},
+}
+
+// Stats counts the rows of a load. Read rows were either loaded or failed, Rejected counting the
+// failed rows by reason. Tables counts the rows written to every table: inserted, updated, left as
+// they were (skipped) and, in upsert mode, deleted.
+type Stats struct {
+	Read     int64            `json:"rows_read"`
+	Loaded   int64            `json:"rows_loaded"`
+	Failed   int64            `json:"rows_failed"`
+	Rejected map[string]int64 `json:"rejected,omitempty"`
+	Tables   []TableStats     `json:"tables,omitempty"`
+}
+
+// TableStats counts the rows written to a table
+type TableStats struct {
+	Table    string `json:"table"`
+	Inserted int64  `json:"inserted"`
+	Updated  int64  `json:"updated"`
+	Skipped  int64  `json:"skipped"`
+	Deleted  int64  `json:"deleted"`
+}
+
+// clone copies s, so the copy is not changed along with s
+func (s Stats) clone() Stats {
+	rejected := s.Rejected
+	s.Rejected = make(map[string]int64, len(rejected))
+	for reason, count := range rejected {
+		s.Rejected[reason] = count
+	}
+	s.Tables = append([]TableStats(nil), s.Tables...)
+	return s
+}
+
+// table returns the counts of the table having name, adding them when missing
+func (s *Stats) table(name string) *TableStats {
+	for i := range s.Tables {
+		if s.Tables[i].Table == name {
+			return &s.Tables[i]
+		}
+	}
+	s.Tables = append(s.Tables, TableStats{Table: name})
+	return &s.Tables[len(s.Tables)-1]
+}
+
+// Options of a load
+type Options struct {
+	// BatchSize is the number of rows loaded together, DefaultBatchSize when 0
+	BatchSize int
+	// Upsert updates the rows present already rather than leaving them as they are. The genres,
+	// languages, companies, countries, cast and crew of a loaded movie which the file no longer
+	// lists are deleted.
+	Upsert bool
+	// OnStart is called before the first row is read, with the stats of the rows loaded already
+	// by the seed resumed
+	OnStart func(Stats)
+	// OnRowError is called for every row which cannot be loaded, the load goes on with the next
+	OnRowError func(*RowError)
+	// OnProgress is called after every batch was written
+	OnProgress func(Stats)
+}
+
+// Load loads the rows of kind read from r within tx. Rows which cannot be loaded are skipped,
+// any other error stops the load.
+func Load(tx *goqu.TxDatabase, kind string, r Reader, opts Options, logger *zap.Logger) (Stats, error) {
+	l, err := newLoader(kind, opts, logger)
+	if err != nil {
+		return Stats{}, err
+	}
+	l.tx = tx
+	return l.run(r, Stats{}, nil)
+}
+
+// run loads the rows read from r, counting them on top of stats. commit is called after every
+// batch was written, with done set after the last one.
+func (l *loader) run(r Reader, stats Stats, commit func(stats Stats, done bool) error) (Stats, error) {
+	for _, t := range l.tables {
+		stats.table(t.name)
+	}
+	if l.opts.OnStart != nil {
+		l.opts.OnStart(stats.clone())
+	}
+
+	pending := 0
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+
+		var rowErr *RowError
+		if err == nil {
+			stats.Read++
+			err = l.convert(l, record)
+			if errors.As(err, &rowErr) {
+				rowErr.Row = stats.Read
+				rowErr.Record = record
+			}
+		} else if errors.As(err, &rowErr) {
+			stats.Read++
+		}
+		if rowErr != nil {
+			stats.Failed++
+			if stats.Rejected == nil {
+				stats.Rejected = make(map[string]int64)
+			}
+			stats.Rejected[rowErr.Reason]++
+			if l.opts.OnRowError != nil {
+				l.opts.OnRowError(rowErr)
+			}
+			continue
+		}
+		if err != nil {
+			return stats, err
+		}
+
+		pending++
+		if pending == l.opts.BatchSize {
+			if err := l.flush(&stats); err != nil {
+				return stats, err
+			}
+			stats.Loaded += int64(pending)
+			pending = 0
+			if commit != nil {
+				if err := commit(stats.clone(), false); err != nil {
+					return stats, err
+				}
+			}
+			if l.opts.OnProgress != nil {
+				l.opts.OnProgress(stats.clone())
+			}
+		}
+	}
+
+	if err := l.flush(&stats); err != nil {
+		return stats, err
+	}
+	stats.Loaded += int64(pending)
+	if commit != nil {
+		if err := commit(stats.clone(), true); err != nil {
+			return stats, err
+		}
+	}
+	if l.opts.OnProgress != nil {
+		l.opts.OnProgress(stats.clone())
+	}
+	return stats, nil
+}
+
+// table holds the rows of a table waiting to be written, keyed to drop duplicates
+type table struct {
+	name  string
+	spec  tableSpec
+	order []string
+	rows  map[string]goqu.Record
+	// owners are the movies whose rows were all added, in upsert mode their other rows are deleted
+	owners []interface{}
+}
+
+// add adds row unless a row having key was added already
+func (t *table) add(key string, row goqu.Record) {
+	if _, exists := t.rows[key]; !exists {
+		t.order = append(t.order, key)
+		t.rows[key] = row
+	}
+}
+
+// set adds row, replacing the row having key
+func (t *table) set(key string, row goqu.Record) {
+	if _, exists := t.rows[key]; !exists {
+		t.order = append(t.order, key)
+	}
+	t.rows[key] = row
+}
+
+// own records that every row of the movie having id was added
+func (t *table) own(id int) {
+	t.owners = append(t.owners, id)
+}
+
+// columns are the columns of the rows waiting, sorted. Rows missing a column which others have
+// are written with NULL.
+func (t *table) columns() []string {
+	var columns []string
+	seen := make(map[string]bool)
+	for _, key := range t.order {
+		for column := range t.rows[key] {
+			if !seen[column] {
+				seen[column] = true
+				columns = append(columns, column)
+			}
+		}
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+type loader struct {
+	tx      *goqu.TxDatabase
+	opts    Options
+	logger  *zap.Logger
+	tables  []*table
+	convert func(l *loader, record Record) error
+	// movies holds the ids of the movies in the movies table, fetched when first needed
+	movies map[int]bool
+}
+
+func newLoader(kind string, opts Options, logger *zap.Logger) (*loader, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultBatchSize
+	}
+	l := &loader{opts: opts, logger: logger}
+	switch kind {
+	case Movies:
+		l.convert = (*loader).movie
+		l.addTables("genres", "languages", "companies", "countries", "collections", "movies",
+			"movie_genres", "movie_languages", "movie_companies", "movie_countries")
+	case Ratings:
+		l.convert = (*loader).rating
+		l.addTables("ratings")
+	case Credits:
+		l.convert = (*loader).credit
+		l.addTables("credits", "movie_casts", "movie_crew")
+	default:
+		return nil, CheckKind(kind)
+	}
+	return l, nil
+}
+
+func (l *loader) addTables(names ...string) {
+	for _, name := range names {
+		l.tables = append(l.tables, &table{name: name, spec: tableSpecs[name], rows: make(map[string]goqu.Record)})
+	}
+}
+
+func (l *loader) table(name string) *table {
+	for _, t := range l.tables {
+		if t.name == name {
+			return t
+		}
+	}
+	panic("seed: unknown table " + name)
+}
+
+// flush writes the rows waiting, table by table. Rows are copied into a temporary table first
+// and inserted from there, which is how COPY is made to skip or update the rows present already.
+func (l *loader) flush(stats *Stats) error {
+	for _, t := range l.tables {
+		if len(t.order) == 0 && len(t.owners) == 0 {
+			continue
+		}
+
+		start := time.Now()
+		counts := stats.table(t.name)
+		stage := "seed_" + t.name
+		_, err := l.tx.Exec(fmt.Sprintf("CREATE TEMP TABLE IF NOT EXISTS %s (LIKE %s) ON COMMIT DROP",
+			pq.QuoteIdentifier(stage), pq.QuoteIdentifier(t.name)))
+		if err != nil {
+			return fmt.Errorf("staging %s: %w", t.name, err)
+		}
+
+		columns := t.columns()
+		if err := l.copyRows(t, stage, columns); err != nil {
+			return fmt.Errorf("copying %s: %w", t.name, err)
+		}
+
+		if l.opts.Upsert && t.spec.owner != "" && len(t.owners) > 0 {
+			deleted, err := l.deleteStale(t, stage)
+			if err != nil {
+				return fmt.Errorf("deleting %s: %w", t.name, err)
+			}
+			counts.Deleted += deleted
+		}
+
+		if len(columns) > 0 {
+			cols := make([]interface{}, len(columns))
+			for i, column := range columns {
+				cols[i] = column
+			}
+			// xmax is 0 for the rows inserted, the rows updated having been locked
+			var inserted []bool
+			err = l.tx.Insert(t.name).Cols(cols...).
+				FromQuery(l.tx.From(stage).Select(cols...)).
+				OnConflict(l.conflict(t, columns)).
+				Returning(goqu.L("xmax = 0")).
+				Executor().ScanVals(&inserted)
+			if err != nil {
+				return fmt.Errorf("inserting %s: %w", t.name, err)
+			}
+			for _, isNew := range inserted {
+				if isNew {
+					counts.Inserted++
+				} else {
+					counts.Updated++
+				}
+			}
+			counts.Skipped += int64(len(t.order) - len(inserted))
+
+			if _, err := l.tx.Truncate(stage).Executor().Exec(); err != nil {
+				return fmt.Errorf("clearing staged %s: %w", t.name, err)
+			}
+		}
+		if t.name == "credits" {
+			// People added through the API take their ids from the sequence, it is moved past the
+			// seeded ids so they are not handed out again. ReserveCreditIDs moves it past the ids
+			// of a whole file before its first batch.
+			_, err := l.tx.Exec("SELECT setval('credits_id_seq', GREATEST(MAX(id), (SELECT last_value FROM credits_id_seq))) FROM credits")
+			if err != nil {
+				return fmt.Errorf("advancing credits id sequence: %w", err)
+			}
+		}
+		l.logger.Info("Loaded", zap.String("table", t.name), zap.Int("rows", len(t.order)), zap.Duration("duration", time.Since(start)))
+
+		t.order = t.order[:0]
+		t.rows = make(map[string]goqu.Record)
+		t.owners = t.owners[:0]
+	}
+	return nil
+}
+
+// copyRows copies the rows waiting in t into the table stage with COPY
+func (l *loader) copyRows(t *table, stage string, columns []string) error {
+	if len(columns) == 0 {
+		return nil
+	}
+	stmt, err := l.tx.Prepare(pq.CopyIn(stage, columns...))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	values := make([]interface{}, len(columns))
+	for _, key := range t.order {
+		row := t.rows[key]
+		for i, column := range columns {
+			values[i] = row[column]
+		}
+		if _, err := stmt.Exec(values...); err != nil {
+			return err
+		}
+	}
+	_, err = stmt.Exec()
+	return err
+}
+
+// deleteStale deletes the rows of the owners of t which are not in the table stage
+func (l *loader) deleteStale(t *table, stage string) (int64, error) {
+	keys := make([]interface{}, len(t.spec.keys))
+	for i, key := range t.spec.keys {
+		keys[i] = goqu.C(key)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(keys)), ", ")
+
+	res, err := l.tx.Delete(t.name).Where(
+		goqu.C(t.spec.owner).In(t.owners...),
+		goqu.L("("+placeholders+") NOT IN ?", append(keys, l.tx.From(stage).Select(keys...))...),
+	).Executor().Exec()
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// conflict is what becomes of the rows of t present already. They are left as they are, unless
+// in upsert mode where the columns other than the keys are updated when any of them changed. The
+// name of a language first seen as the original language of a movie is always filled in later.
+func (l *loader) conflict(t *table, columns []string) exp.ConflictExpression {
+	target := strings.Join(t.spec.keys, ", ")
+	if t.name == "languages" {
+		known := goqu.I("excluded.name").Neq(unknownLanguage)
+		if !l.opts.Upsert {
+			known = goqu.I("languages.name").Eq(unknownLanguage)
+		}
+		return goqu.DoUpdate(target, goqu.Record{"name": goqu.I("excluded.name")}).
+			Where(known, goqu.I("languages.name").Neq(goqu.I("excluded.name")))
+	}
+	if !l.opts.Upsert {
+		return goqu.DoNothing()
+	}
+
+	isKey := make(map[string]bool)
+	for _, key := range t.spec.keys {
+		isKey[key] = true
+	}
+	update := goqu.Record{}
+	var current, excluded []interface{}
+	for _, column := range columns {
+		if isKey[column] {
+			continue
+		}
+		update[column] = goqu.I("excluded." + column)
+		current = append(current, goqu.T(t.name).Col(column))
+		excluded = append(excluded, goqu.I("excluded."+column))
+	}
+	if len(update) == 0 {
+		return goqu.DoNothing()
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(current)), ", ")
+	return goqu.DoUpdate(target, update).
+		Where(goqu.L("ROW("+placeholders+") IS DISTINCT FROM ROW("+placeholders+")", append(current, excluded...)...))
+}
+
+// movieExists reports whether the movie having id is in the movies table
+func (l *loader) movieExists(id int) (bool, error) {
+	if l.movies == nil {
+		var ids []int
+		if err := l.tx.From("movies").Select("id").ScanVals(&ids); err != nil {
+			return false, err
+		}
+		l.movies = make(map[int]bool, len(ids))
+		for _, movieID := range ids {
+			l.movies[movieID] = true
+		}
+	}
+	return l.movies[id], nil
+}
+
+// movieOf returns the id of the movie of a rating or credits row held by column, which must exist
+func (l *loader) movieOf(record Record, column string) (int, error) {
+	id, err := parseID(record, column)
+	if err != nil {
+		return 0, err
+	}
+	exists, err := l.movieExists(id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to check movie existence: %w", err)
+	}
+	if !exists {
+		return 0, reject(ReasonMissingMovie, "movie %d does not exist", id)
+	}
+	return id, nil
+}
+
+const unknownLanguage = "Unknown"
+
+func (l *loader) movie(record Record) error {
+	movieID, err := parseID(record, "id")
+	if err != nil {
+		return err
+	}
+
+	row := goqu.Record{"id": movieID}
+	for _, col := range movieColumns {
+		if val, ok := record[col.name]; ok {
+			if row[col.name], err = col.parse(val); err != nil {
+				return err
+			}
+		}
+	}
+	genres, err := parseObjects(record, "genres")
+	if err != nil {
+		return err
+	}
+	langs, err := parseObjects(record, "spoken_languages")
+	if err != nil {
+		return err
+	}
+	companies, err := parseObjects(record, "production_companies")
+	if err != nil {
+		return err
+	}
+	countries, err := parseObjects(record, "production_countries")
+	if err != nil {
+		return err
+	}
+	collection, err := parseObject(record, "belongs_to_collection")
+	if err != nil {
+		return err
+	}
+
+	code, _ := row["original_language"].(string)
+	if code == "" {
+		code = "xx"
+	}
+	row["original_language"] = code
+
+	// Nested values are all checked before any row is added, so a rejected row adds none
+	genreRows := make([]goqu.Record, 0, len(genres))
+	for _, genre := range genres {
+		id, err := genre.id("id")
+		if err != nil {
+			return err
+		}
+		name, err := genre.string("name", 50)
+		if err != nil {
+			return err
+		}
+		if name == nil {
+			return reject(ReasonTypeMismatch, "%s.name is missing", genre.path)
+		}
+		genreRows = append(genreRows, goqu.Record{"id": id, "name": name})
+	}
+	langRows := make([]goqu.Record, 0, len(langs))
+	for _, lang := range langs {
+		iso, err := lang.string("iso_639_1", 2)
+		if err != nil {
+			return err
+		}
+		if iso == nil {
+			return reject(ReasonMissingID, "%s.iso_639_1 is missing", lang.path)
+		}
+		name, err := lang.string("name", 50)
+		if err != nil {
+			return err
+		}
+		langRows = append(langRows, goqu.Record{"iso_code": iso, "name": name})
+	}
+	companyRows := make([]goqu.Record, 0, len(companies))
+	for _, company := range companies {
+		id, err := company.id("id")
+		if err != nil {
+			return err
+		}
+		name, err := company.string("name", 255)
+		if err != nil {
+			return err
+		}
+		if name == nil {
+			return reject(ReasonTypeMismatch, "%s.name is missing", company.path)
+		}
+		companyRows = append(companyRows, goqu.Record{"id": id, "name": name})
+	}
+	countryRows := make([]goqu.Record, 0, len(countries))
+	for _, country := range countries {
+		iso, err := country.string("iso_3166_1", 2)
+		if err != nil {
+			return err
+		}
+		if iso == nil {
+			return reject(ReasonMissingID, "%s.iso_3166_1 is missing", country.path)
+		}
+		name, err := country.string("name", 255)
+		if err != nil {
+			return err
+		}
+		if name == nil {
+			name = iso
+		}
+		countryRows = append(countryRows, goqu.Record{"iso_code": iso, "name": name})
+	}
+
+	var collectionRow goqu.Record
+	if collection != nil {
+		id, err := collection.id("id")
+		if err != nil {
+			return err
+		}
+		name, err := collection.string("name", 255)
+		if err != nil {
+			return err
+		}
+		if name == nil {
+			return reject(ReasonTypeMismatch, "%s.name is missing", collection.path)
+		}
+		collectionRow = goqu.Record{"id": id, "name": name}
+		for _, key := range []string{"poster_path", "backdrop_path"} {
+			if collectionRow[key], err = collection.string(key, 255); err != nil {
+				return err
+			}
+		}
+	}
+
+	l.table("languages").add(code, goqu.Record{"iso_code": code, "name": unknownLanguage})
+	if collectionRow != nil {
+		l.table("collections").add(fmt.Sprint(collectionRow["id"]), collectionRow)
+		row["collection_id"] = collectionRow["id"]
+	} else if _, ok := record["belongs_to_collection"]; ok {
+		row["collection_id"] = nil
+	}
+	l.table("movies").add(strconv.Itoa(movieID), row)
+
+	if genres != nil {
+		l.table("movie_genres").own(movieID)
+	}
+	for _, genre := range genreRows {
+		l.table("genres").add(fmt.Sprint(genre["id"]), genre)
+		l.table("movie_genres").add(fmt.Sprintf("%d/%v", movieID, genre["id"]), goqu.Record{
+			"movieid": movieID,
+			"genreid": genre["id"],
+		})
+	}
+
+	if langs != nil {
+		l.table("movie_languages").own(movieID)
+	}
+	for _, lang := range langRows {
+		iso := lang["iso_code"].(string)
+		if lang["name"] != nil {
+			l.table("languages").set(iso, lang)
+		} else {
+			l.table("languages").add(iso, goqu.Record{"iso_code": iso, "name": unknownLanguage})
+		}
+		l.table("movie_languages").add(fmt.Sprintf("%d/%s", movieID, iso), goqu.Record{
+			"movieid":       movieID,
+			"language_code": iso,
+		})
+	}
+
+	if companies != nil {
+		l.table("movie_companies").own(movieID)
+	}
+	for _, company := range companyRows {
+		l.table("companies").add(fmt.Sprint(company["id"]), company)
+		l.table("movie_companies").add(fmt.Sprintf("%d/%v", movieID, company["id"]), goqu.Record{
+			"movieid":   movieID,
+			"companyid": company["id"],
+		})
+	}
+
+	if countries != nil {
+		l.table("movie_countries").own(movieID)
+	}
+	for _, country := range countryRows {
+		l.table("countries").add(fmt.Sprint(country["iso_code"]), country)
+		l.table("movie_countries").add(fmt.Sprintf("%d/%v", movieID, country["iso_code"]), goqu.Record{
+			"movieid":      movieID,
+			"country_code": country["iso_code"],
+		})
+	}
+	return nil
+}
+
+func (l *loader) rating(record Record) error {
+	movieID, err := l.movieOf(record, "movieId")
+	if err != nil {
+		return err
+	}
+	userID, err := parseID(record, "userId")
+	if err != nil {
+		return err
+	}
+	rating, err := strconv.ParseFloat(strings.TrimSpace(record["rating"]), 64)
+	if err != nil {
+		return reject(ReasonTypeMismatch, "rating must be a number, got %q", record["rating"])
+	}
+	if rating < 0 || rating > 10 {
+		return reject(ReasonOutOfRange, "rating must be between 0 and 10, got %v", rating)
+	}
+
+	row := goqu.Record{"movie_id": movieID, "user_id": userID, "rating": rating}
+	if val := strings.TrimSpace(record["timestamp"]); val != "" {
+		ts, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return reject(ReasonTypeMismatch, "timestamp must be a unix time, got %q", val)
+		}
+		row["timestamp"] = time.Unix(ts, 0)
+	}
+
+	l.table("ratings").add(fmt.Sprintf("%d/%d", userID, movieID), row)
+	return nil
+}
+
+func (l *loader) credit(record Record) error {
+	movieID, err := l.movieOf(record, "id")
+	if err != nil {
+		return err
+	}
+
+	cast, err := parseObjects(record, "cast")
+	if err != nil {
+		return err
+	}
+	crew, err := parseObjects(record, "crew")
+	if err != nil {
+		return err
+	}
+
+	// Nested values are all checked before any row is added, so a rejected row adds none
+	castRows, err := creditRows(movieID, cast, "cast")
+	if err != nil {
+		return err
+	}
+	crewRows, err := creditRows(movieID, crew, "crew")
+	if err != nil {
+		return err
+	}
+
+	l.addCredits(movieID, cast != nil, castRows, l.table("movie_casts"))
+	l.addCredits(movieID, crew != nil, crewRows, l.table("movie_crew"))
+	return nil
+}
+
+// creditRow is a person credited for a movie, as the rows of the credits table and of the table
+// of the role
+type creditRow struct {
+	person goqu.Record
+	role   goqu.Record
+}
+
+// creditRows checks the people credited for the role of cast or crew of a movie
+func creditRows(movieID int, people []object, role string) ([]creditRow, error) {
+	rows := make([]creditRow, 0, len(people))
+	for _, person := range people {
+		personID, err := person.id("id")
+		if err != nil {
+			return nil, err
+		}
+		creditID, err := person.string("credit_id", 50)
+		if err != nil {
+			return nil, err
+		}
+		if creditID == nil {
+			return nil, reject(ReasonMissingID, "%s.credit_id is missing", person.path)
+		}
+		gender, err := person.int("gender")
+		if err != nil {
+			return nil, err
+		}
+		name, err := person.string("name", 255)
+		if err != nil {
+			return nil, err
+		}
+		profilePath, err := person.string("profile_path", 255)
+		if err != nil {
+			return nil, err
+		}
+
+		roleRecord := goqu.Record{
+			"movie_id":  movieID,
+			"person_id": personID,
+			"credit_id": creditID,
+		}
+		if role == "cast" {
+			if roleRecord["cast_id"], err = person.int("cast_id"); err != nil {
+				return nil, err
+			}
+			if roleRecord["character"], err = person.string("character", 350); err != nil {
+				return nil, err
+			}
+			if roleRecord["cast_order"], err = person.int("order"); err != nil {
+				return nil, err
+			}
+		} else {
+			if roleRecord["department"], err = person.string("department", 100); err != nil {
+				return nil, err
+			}
+			if roleRecord["job"], err = person.string("job", 100); err != nil {
+				return nil, err
+			}
+		}
+
+		rows = append(rows, creditRow{
+			person: goqu.Record{"id": personID, "name": name, "gender": gender, "profile_path": profilePath},
+			role:   roleRecord,
+		})
+	}
+	return rows, nil
+}
+
+// ReserveCreditIDs moves credits_id_seq past the largest person id of the credits in file, held
+// in format, before a seed or import of them writes its first batch. People added through the API
+// meanwhile then take ids the file does not credit. The file is read through once and rewound,
+// rows which cannot be read are skipped, the seed or import rejects them.
+func ReserveCreditIDs(db *goqu.Database, file io.ReadSeeker, format string) error {
+	r, err := NewReader(file, format)
+	if err != nil {
+		return err
+	}
+
+	maxID := 0
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		var rowErr *RowError
+		if errors.As(err, &rowErr) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, column := range []string{"cast", "crew"} {
+			people, err := parseObjects(record, column)
+			if err != nil {
+				continue
+			}
+			for _, person := range people {
+				if id, err := person.id("id"); err == nil && id > maxID {
+					maxID = id
+				}
+			}
+		}
+	}
+
+	if maxID > 0 {
+		_, err := db.Exec("SELECT setval('credits_id_seq', GREATEST($1, (SELECT last_value FROM credits_id_seq)))", maxID)
+		if err != nil {
+			return fmt.Errorf("reserving credits ids: %w", err)
+		}
+	}
+	_, err = file.Seek(0, io.SeekStart)
+	return err
+}
+
+// addCredits adds the people credited for a role of a movie, every one of them when listed
+func (l *loader) addCredits(movieID int, listed bool, rows []creditRow, roleTable *table) {
+	if listed {
+		roleTable.own(movieID)
+	}
+	for _, row := range rows {
+		personID := fmt.Sprint(row.person["id"])
+		l.table("credits").add(personID, row.person)
+		// A person is credited once per movie and role, the first credit is kept
+		roleTable.add(fmt.Sprintf("%d/%s", movieID, personID), row.role)
+	}
+}
@@ -0,0 +1,121 @@
+package seed
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// The functions below are kept identical, down to their comments, in
+// golang-api/utils/python_literal.go and golang-api-database/database/seed/python_literal.go, so
+// the CSV and postgres backends read the same values alike. Change both copies together.
+
+// pythonToJSON rewrites a Python literal as JSON: strings quoted either way with their escapes,
+// None, True and False
+func pythonToJSON(raw string) (string, error) {
+	var out strings.Builder
+	for i := 0; i < len(raw); {
+		c := raw[i]
+		switch {
+		case c == '\'' || c == '"':
+			s, n, err := pythonString(raw[i:])
+			if err != nil {
+				return "", err
+			}
+			quoted, _ := json.Marshal(s)
+			out.Write(quoted)
+			i += n
+		case c == '-' || c == '.' || isDigit(c):
+			// Numbers are copied whole, so their exponents are not read as words
+			j := i + 1
+			for j < len(raw) && (isDigit(raw[j]) || strings.IndexByte(".eE+-", raw[j]) >= 0) {
+				j++
+			}
+			out.WriteString(raw[i:j])
+			i = j
+		case isLetter(c):
+			j := i
+			for j < len(raw) && (isLetter(raw[j]) || isDigit(raw[j])) {
+				j++
+			}
+			switch word := raw[i:j]; word {
+			case "None":
+				out.WriteString("null")
+			case "True":
+				out.WriteString("true")
+			case "False":
+				out.WriteString("false")
+			default:
+				return "", fmt.Errorf("unexpected %q at offset %d", word, i)
+			}
+			i = j
+		default:
+			out.WriteByte(c)
+			i++
+		}
+	}
+	return out.String(), nil
+}
+
+// pythonString decodes the Python string literal s starts with, returning it and its length
+func pythonString(s string) (string, int, error) {
+	quote := s[0]
+	var out strings.Builder
+	for i := 1; i < len(s); {
+		c := s[i]
+		if c == quote {
+			return out.String(), i + 1, nil
+		}
+		if c != '\\' || i+1 == len(s) {
+			out.WriteByte(c)
+			i++
+			continue
+		}
+
+		escape := s[i+1]
+		i += 2
+		switch escape {
+		case '\\', '\'', '"':
+			out.WriteByte(escape)
+		case 'n':
+			out.WriteByte('\n')
+		case 't':
+			out.WriteByte('\t')
+		case 'r':
+			out.WriteByte('\r')
+		case 'b':
+			out.WriteByte('\b')
+		case 'f':
+			out.WriteByte('\f')
+		case '\n':
+			// A line continuation
+		case 'x', 'u', 'U':
+			digits := map[byte]int{'x': 2, 'u': 4, 'U': 8}[escape]
+			if i+digits > len(s) {
+				return "", 0, errors.New("truncated escape in string")
+			}
+			code, err := strconv.ParseUint(s[i:i+digits], 16, 32)
+			if err != nil || !utf8.ValidRune(rune(code)) {
+				return "", 0, fmt.Errorf("invalid escape \\%c%s in string", escape, s[i:i+digits])
+			}
+			out.WriteRune(rune(code))
+			i += digits
+		default:
+			// Python keeps unknown escapes as they are
+			out.WriteByte('\\')
+			out.WriteByte(escape)
+		}
+	}
+	return "", 0, errors.New("unterminated string")
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
+}
@@ -0,0 +1,228 @@
+package seed
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Formats of the files which can be seeded and imported
+const (
+	FormatCSV    = "csv"
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+)
+
+var ErrUnknownFormat = errors.New("unknown format")
+
+// CheckFormat returns ErrUnknownFormat unless files written in format can be read
+func CheckFormat(format string) error {
+	switch format {
+	case FormatCSV, FormatJSON, FormatNDJSON:
+		return nil
+	}
+	return fmt.Errorf("%w %q", ErrUnknownFormat, format)
+}
+
+// Record is a row of a file keyed by column. Values which are not strings in JSON files hold
+// their JSON encoding, the way nested values are quoted in CSV files.
+type Record map[string]string
+
+// Reasons rows are rejected for
+const (
+	// ReasonParse is a row which cannot be read, such as a CSV row having too many columns
+	ReasonParse = "parse_error"
+	// ReasonMissingID is a row, or a nested value of it, missing its id
+	ReasonMissingID = "missing_id"
+	// ReasonBrokenJSON is a nested value which is neither JSON nor a Python literal
+	ReasonBrokenJSON = "broken_json"
+	// ReasonTypeMismatch is a value of the wrong type, such as a title where a number belongs
+	ReasonTypeMismatch = "type_mismatch"
+	// ReasonOutOfRange is a value the column cannot hold, such as a text too long or a rating
+	// above 10
+	ReasonOutOfRange = "out_of_range"
+	// ReasonMissingMovie is a rating or credits row of a movie which does not exist
+	ReasonMissingMovie = "missing_movie"
+)
+
+// RowError is a row which cannot be read or loaded, the rows after it still can be. Rows are
+// numbered from 1, not counting the header of CSV files. Record holds the row when it could be
+// read, Raw the text of it otherwise.
+type RowError struct {
+	Row    int64  `json:"row"`
+	Reason string `json:"reason"`
+	Err    string `json:"error"`
+	Record Record `json:"record,omitempty"`
+	Raw    string `json:"raw,omitempty"`
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("row %d: %s: %s", e.Row, e.Reason, e.Err)
+}
+
+// Reader reads the rows of a CSV file having a header, a JSON array of objects or JSON objects on
+// separate lines (NDJSON)
+type Reader interface {
+	// Read returns the next row, a *RowError for a row which cannot be read and io.EOF at the end
+	Read() (Record, error)
+}
+
+// NewReader reads the rows of r written in format
+func NewReader(r io.Reader, format string) (Reader, error) {
+	switch format {
+	case FormatCSV:
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		headers, err := reader.Read()
+		if err != nil {
+			return nil, fmt.Errorf("error reading headers: %w", err)
+		}
+		return &csvReader{reader: reader, headers: headers}, nil
+	case FormatJSON:
+		decoder := json.NewDecoder(r)
+		decoder.UseNumber()
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("error reading json array: %w", err)
+		}
+		if token != json.Delim('[') {
+			return nil, errors.New("json file must hold an array of objects")
+		}
+		return &jsonReader{decoder: decoder}, nil
+	case FormatNDJSON:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		return &ndjsonReader{scanner: scanner}, nil
+	}
+	return nil, CheckFormat(format)
+}
+
+type csvReader struct {
+	reader  *csv.Reader
+	headers []string
+	row     int64
+}
+
+func (r *csvReader) Read() (Record, error) {
+	values, err := r.reader.Read()
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+	r.row++
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return nil, &RowError{Row: r.row, Reason: ReasonParse, Err: parseErr.Error()}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(values) != len(r.headers) {
+		return nil, &RowError{
+			Row:    r.row,
+			Reason: ReasonParse,
+			Err:    fmt.Sprintf("expected %d columns, got %d", len(r.headers), len(values)),
+			Raw:    csvLine(values),
+		}
+	}
+
+	record := make(Record, len(values))
+	for i, header := range r.headers {
+		record[header] = values[i]
+	}
+	return record, nil
+}
+
+type jsonReader struct {
+	decoder *json.Decoder
+	row     int64
+}
+
+func (r *jsonReader) Read() (Record, error) {
+	if !r.decoder.More() {
+		if _, err := r.decoder.Token(); err != nil {
+			return nil, fmt.Errorf("error reading end of json array: %w", err)
+		}
+		return nil, io.EOF
+	}
+	r.row++
+
+	// A value read whole leaves the decoder after it, so the array goes on even when the value is
+	// not an object
+	var raw json.RawMessage
+	if err := r.decoder.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("error reading row %d: %w", r.row, err)
+	}
+	return decodeObject(r.row, raw)
+}
+
+type ndjsonReader struct {
+	scanner *bufio.Scanner
+	row     int64
+}
+
+func (r *ndjsonReader) Read() (Record, error) {
+	for r.scanner.Scan() {
+		line := strings.TrimSpace(r.scanner.Text())
+		if line == "" {
+			continue
+		}
+		r.row++
+
+		return decodeObject(r.row, []byte(line))
+	}
+	if err := r.scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading row %d: %w", r.row+1, err)
+	}
+	return nil, io.EOF
+}
+
+// decodeObject is the record of the JSON object data
+func decodeObject(row int64, data []byte) (Record, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var object map[string]interface{}
+	err := decoder.Decode(&object)
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) || (err == nil && object == nil) {
+		return nil, &RowError{Row: row, Reason: ReasonParse, Err: "row must be a json object", Raw: string(data)}
+	}
+	if err != nil {
+		return nil, &RowError{Row: row, Reason: ReasonParse, Err: err.Error(), Raw: string(data)}
+	}
+
+	record := make(Record, len(object))
+	for key, value := range object {
+		switch value := value.(type) {
+		case nil:
+			record[key] = ""
+		case string:
+			record[key] = value
+		case json.Number:
+			record[key] = value.String()
+		case bool:
+			record[key] = strconv.FormatBool(value)
+		default:
+			nested, err := json.Marshal(value)
+			if err != nil {
+				return nil, &RowError{Row: row, Reason: ReasonParse, Err: err.Error(), Raw: string(data)}
+			}
+			record[key] = string(nested)
+		}
+	}
+	return record, nil
+}
+
+// csvLine is the CSV text of the fields of a row
+func csvLine(fields []string) string {
+	var line strings.Builder
+	w := csv.NewWriter(&line)
+	w.Write(fields)
+	w.Flush()
+	return strings.TrimSuffix(line.String(), "\n")
+}
@@ -0,0 +1,231 @@
+package seed
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Types of the values of columns
+const (
+	typeString = iota
+	typeBool
+	typeInt
+	typeNumber
+	typeDate
+	typeJSON
+)
+
+// column is a column of a file loaded into the column of the same name, maxLen bounding the
+// characters of strings
+type column struct {
+	name   string
+	kind   int
+	maxLen int
+}
+
+// movieColumns are the columns of the movies table filled from the columns of the same name,
+// id is checked on its own and genres and spoken_languages fill tables of their own
+var movieColumns = []column{
+	{name: "adult", kind: typeBool},
+	{name: "belongs_to_collection", kind: typeJSON},
+	{name: "budget", kind: typeNumber},
+	{name: "homepage", kind: typeString, maxLen: 255},
+	{name: "imdb_id", kind: typeString, maxLen: 9},
+	{name: "original_language", kind: typeString, maxLen: 2},
+	{name: "original_title", kind: typeString, maxLen: 255},
+	{name: "overview", kind: typeString},
+	{name: "popularity", kind: typeNumber},
+	{name: "poster_path", kind: typeString, maxLen: 255},
+	{name: "production_companies", kind: typeJSON},
+	{name: "production_countries", kind: typeJSON},
+	{name: "release_date", kind: typeDate},
+	{name: "revenue", kind: typeNumber},
+	{name: "runtime", kind: typeNumber},
+	{name: "status", kind: typeString, maxLen: 20},
+	{name: "tagline", kind: typeString},
+	{name: "title", kind: typeString, maxLen: 255},
+	{name: "video", kind: typeBool},
+	{name: "vote_average", kind: typeNumber},
+	{name: "vote_count", kind: typeInt},
+}
+
+// reject is the error of a row which cannot be loaded for reason
+func reject(reason, format string, args ...interface{}) *RowError {
+	return &RowError{Reason: reason, Err: fmt.Sprintf(format, args...)}
+}
+
+// parse converts the value of c in a row, an empty value being NULL
+func (c column) parse(val string) (interface{}, error) {
+	val = strings.TrimSpace(val)
+	if val == "" {
+		return nil, nil
+	}
+
+	switch c.kind {
+	case typeBool:
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, reject(ReasonTypeMismatch, "%s must be True or False, got %q", c.name, val)
+		}
+		return b, nil
+	case typeInt:
+		// Counts are written as floats in some files
+		f, err := strconv.ParseFloat(val, 64)
+		if err != nil || f != math.Trunc(f) {
+			return nil, reject(ReasonTypeMismatch, "%s must be an integer, got %q", c.name, val)
+		}
+		if f < math.MinInt32 || f > math.MaxInt32 {
+			return nil, reject(ReasonOutOfRange, "%s is out of range, got %q", c.name, val)
+		}
+		return int64(f), nil
+	case typeNumber:
+		f, err := strconv.ParseFloat(val, 64)
+		if err != nil && !errors.Is(err, strconv.ErrRange) {
+			return nil, reject(ReasonTypeMismatch, "%s must be a number, got %q", c.name, val)
+		}
+		// inf and NaN parse as floats but are no amount, REAL would hold them as they are
+		if err != nil || math.IsInf(f, 0) || math.IsNaN(f) || math.Abs(f) > math.MaxFloat32 {
+			return nil, reject(ReasonOutOfRange, "%s is out of range, got %q", c.name, val)
+		}
+		return f, nil
+	case typeDate:
+		if _, err := time.Parse("2006-01-02", val); err != nil {
+			return nil, reject(ReasonTypeMismatch, "%s must be a date as YYYY-MM-DD, got %q", c.name, val)
+		}
+		return val, nil
+	case typeJSON:
+		var v interface{}
+		if err := decodeJSONValue(val, &v); err != nil {
+			return nil, reject(ReasonBrokenJSON, "%s: %v", c.name, err)
+		}
+		encoded, _ := json.Marshal(v)
+		return string(encoded), nil
+	}
+
+	if c.maxLen > 0 && utf8.RuneCountInString(val) > c.maxLen {
+		return nil, reject(ReasonOutOfRange, "%s is longer than %d characters", c.name, c.maxLen)
+	}
+	return val, nil
+}
+
+// parseID returns the integer id held by column of a row
+func parseID(record Record, column string) (int, error) {
+	val := strings.TrimSpace(record[column])
+	if val == "" {
+		return 0, reject(ReasonMissingID, "%s is missing", column)
+	}
+	id, err := strconv.Atoi(val)
+	if err != nil {
+		return 0, reject(ReasonTypeMismatch, "%s must be an integer, got %q", column, val)
+	}
+	return id, nil
+}
+
+// parseObjects decodes the list of JSON objects held by column of a row, nil when it is empty
+func parseObjects(record Record, column string) ([]object, error) {
+	raw := strings.TrimSpace(record[column])
+	if raw == "" {
+		return nil, nil
+	}
+	var values []interface{}
+	if err := decodeJSONValue(raw, &values); err != nil {
+		return nil, reject(ReasonBrokenJSON, "%s: %v", column, err)
+	}
+
+	objects := make([]object, 0, len(values))
+	for i, value := range values {
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, reject(ReasonTypeMismatch, "%s[%d] must be an object", column, i)
+		}
+		objects = append(objects, object{path: fmt.Sprintf("%s[%d]", column, i), fields: fields})
+	}
+	return objects, nil
+}
+
+// parseObject decodes the JSON object held by column of a row, nil when it is empty or null
+func parseObject(record Record, column string) (*object, error) {
+	raw := strings.TrimSpace(record[column])
+	if raw == "" {
+		return nil, nil
+	}
+	var value interface{}
+	if err := decodeJSONValue(raw, &value); err != nil {
+		return nil, reject(ReasonBrokenJSON, "%s: %v", column, err)
+	}
+	if value == nil {
+		return nil, nil
+	}
+	fields, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, reject(ReasonTypeMismatch, "%s must be an object", column)
+	}
+	return &object{path: column, fields: fields}, nil
+}
+
+// object is a JSON object nested in a row, path locating it in errors
+type object struct {
+	path   string
+	fields map[string]interface{}
+}
+
+// id returns the integer held by key, which must be present
+func (o object) id(key string) (int, error) {
+	value, err := o.int(key)
+	if err != nil {
+		return 0, err
+	}
+	if value == nil {
+		return 0, reject(ReasonMissingID, "%s.%s is missing", o.path, key)
+	}
+	return int(value.(int64)), nil
+}
+
+// int returns the integer held by key as an int64, nil when it is missing or null
+func (o object) int(key string) (interface{}, error) {
+	switch value := o.fields[key].(type) {
+	case nil:
+		return nil, nil
+	case float64:
+		if value != math.Trunc(value) || value < math.MinInt32 || value > math.MaxInt32 {
+			return nil, reject(ReasonTypeMismatch, "%s.%s must be an integer, got %v", o.path, key, value)
+		}
+		return int64(value), nil
+	default:
+		return nil, reject(ReasonTypeMismatch, "%s.%s must be an integer, got %T", o.path, key, value)
+	}
+}
+
+// string returns the string held by key, nil when it is missing or null
+func (o object) string(key string, maxLen int) (interface{}, error) {
+	switch value := o.fields[key].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		if maxLen > 0 && utf8.RuneCountInString(value) > maxLen {
+			return nil, reject(ReasonOutOfRange, "%s.%s is longer than %d characters", o.path, key, maxLen)
+		}
+		return value, nil
+	default:
+		return nil, reject(ReasonTypeMismatch, "%s.%s must be a string, got %T", o.path, key, value)
+	}
+}
+
+// decodeJSONValue decodes a nested value, written either as JSON or as the Python literal found in
+// the Kaggle files
+func decodeJSONValue(raw string, v interface{}) error {
+	if err := json.Unmarshal([]byte(raw), v); err == nil {
+		return nil
+	}
+	converted, err := pythonToJSON(raw)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(converted), v)
+}
@@ -0,0 +1,330 @@
+package middlewares
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/config"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/constants"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Roles of callers, every role is granted the permissions of the roles before it
+const (
+	RoleReader = "reader"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+var roleRanks = map[string]int{RoleReader: 1, RoleEditor: 2, RoleAdmin: 3}
+
+// HeaderAPIKey carries static API keys
+const HeaderAPIKey = "X-API-Key"
+
+// principalKey is the fiber local holding the Principal of a request
+const principalKey = "principal"
+
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// Principal is the authenticated caller of a request, Subject is the id of the user
+type Principal struct {
+	Subject string
+	Role    string
+}
+
+// Has reports whether p was granted the permissions of role
+func (p *Principal) Has(role string) bool {
+	return p != nil && roleRanks[p.Role] >= roleRanks[role]
+}
+
+// Authenticator verifies one kind of credentials. It returns a nil Principal when the request
+// does not carry its kind of credentials and ErrInvalidCredentials when they do not verify.
+type Authenticator interface {
+	Authenticate(c *fiber.Ctx) (*Principal, error)
+}
+
+// Auth authenticates requests with its authenticators and checks the roles required by routes
+type Auth struct {
+	enabled        bool
+	authenticators []Authenticator
+}
+
+// NewAuth builds the authenticators configured by cfg. With auth disabled every caller is an
+// anonymous admin.
+func NewAuth(cfg config.AuthConfig) (*Auth, error) {
+	auth := &Auth{enabled: cfg.Enabled}
+	if !cfg.Enabled {
+		return auth, nil
+	}
+
+	if cfg.APIKeys != "" {
+		keys, err := NewAPIKeyAuthenticator(cfg.APIKeys)
+		if err != nil {
+			return nil, err
+		}
+		auth.authenticators = append(auth.authenticators, keys)
+	}
+
+	if cfg.JWTKeyFile != "" {
+		jwt, err := NewJWTAuthenticator(cfg.JWTAlgorithm, cfg.JWTKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		auth.authenticators = append(auth.authenticators, jwt)
+	}
+
+	return auth, nil
+}
+
+// Use adds authenticator to the authenticators of a
+func (a *Auth) Use(authenticator Authenticator) {
+	a.authenticators = append(a.authenticators, authenticator)
+}
+
+// Authenticate stores the Principal of every request carrying valid credentials, requests
+// with invalid credentials are rejected and requests without any go on anonymously
+func (a *Auth) Authenticate() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !a.enabled {
+			c.Locals(principalKey, &Principal{Role: RoleAdmin})
+			return c.Next()
+		}
+
+		for _, authenticator := range a.authenticators {
+			principal, err := authenticator.Authenticate(c)
+			if err != nil {
+				return utils.JSONFail(c, http.StatusUnauthorized, constants.InvalidCredentials)
+			}
+			if principal != nil {
+				c.Locals(principalKey, principal)
+				break
+			}
+		}
+		return c.Next()
+	}
+}
+
+// Require rejects requests whose caller was not granted role
+func (a *Auth) Require(role string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		principal := CurrentPrincipal(c)
+		if principal == nil {
+			return utils.JSONFail(c, http.StatusUnauthorized, constants.AuthenticationRequired)
+		}
+		if !principal.Has(role) {
+			return utils.JSONFail(c, http.StatusForbidden, constants.PermissionDenied)
+		}
+		return c.Next()
+	}
+}
+
+// CurrentPrincipal returns the caller of the request, nil for anonymous callers
+func CurrentPrincipal(c *fiber.Ctx) *Principal {
+	principal, _ := c.Locals(principalKey).(*Principal)
+	return principal
+}
+
+// APIKeyAuthenticator accepts static API keys sent in the X-API-Key header
+type APIKeyAuthenticator struct {
+	keys map[string]Principal
+}
+
+// NewAPIKeyAuthenticator parses comma separated key:subject:role entries
+func NewAPIKeyAuthenticator(entries string) (*APIKeyAuthenticator, error) {
+	authenticator := &APIKeyAuthenticator{keys: make(map[string]Principal)}
+	for _, entry := range strings.Split(entries, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.Split(entry, ":")
+		if len(parts) != 3 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("API key entries must be key:subject:role")
+		}
+		if _, ok := roleRanks[parts[2]]; !ok {
+			return nil, fmt.Errorf("unknown role %q of API key of %s", parts[2], parts[1])
+		}
+		authenticator.keys[parts[0]] = Principal{Subject: parts[1], Role: parts[2]}
+	}
+	return authenticator, nil
+}
+
+// Authenticate looks up the API key of the request
+func (a *APIKeyAuthenticator) Authenticate(c *fiber.Ctx) (*Principal, error) {
+	key := c.Get(HeaderAPIKey)
+	if key == "" {
+		return nil, nil
+	}
+
+	// Every key is compared, so the time taken does not tell how close a guess was
+	var found *Principal
+	for known, principal := range a.keys {
+		if subtle.ConstantTimeCompare([]byte(known), []byte(key)) == 1 {
+			principal := principal
+			found = &principal
+		}
+	}
+	if found == nil {
+		return nil, ErrInvalidCredentials
+	}
+	return found, nil
+}
+
+// JWTAuthenticator accepts HS256 or RS256 JWTs sent as bearer tokens. The subject is the sub
+// claim and the role the role claim, exp and nbf are checked when present.
+type JWTAuthenticator struct {
+	algorithm string
+	secret    []byte
+	publicKey *rsa.PublicKey
+}
+
+// NewJWTAuthenticator loads the key of algorithm from keyFile, the shared secret for HS256 and
+// a PEM encoded public key or certificate for RS256
+func NewJWTAuthenticator(algorithm, keyFile string) (*JWTAuthenticator, error) {
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading JWT key file: %w", err)
+	}
+
+	authenticator := &JWTAuthenticator{algorithm: algorithm}
+	switch algorithm {
+	case "HS256":
+		authenticator.secret = []byte(strings.TrimSpace(string(data)))
+		if len(authenticator.secret) == 0 {
+			return nil, fmt.Errorf("JWT key file %s is empty", keyFile)
+		}
+	case "RS256":
+		authenticator.publicKey, err = parseRSAPublicKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing JWT key file %s: %w", keyFile, err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported JWT algorithm %q, use HS256 or RS256", algorithm)
+	}
+	return authenticator, nil
+}
+
+func parseRSAPublicKey(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	switch block.Type {
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		if key, ok := cert.PublicKey.(*rsa.PublicKey); ok {
+			return key, nil
+		}
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		if key, ok := key.(*rsa.PublicKey); ok {
+			return key, nil
+		}
+	}
+	return nil, errors.New("not an RSA public key")
+}
+
+type jwtClaims struct {
+	Subject   string `json:"sub"`
+	Role      string `json:"role"`
+	ExpiresAt *int64 `json:"exp"`
+	NotBefore *int64 `json:"nbf"`
+}
+
+// Authenticate verifies the bearer token of the request
+func (a *JWTAuthenticator) Authenticate(c *fiber.Ctx) (*Principal, error) {
+	token, ok := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+	if !ok {
+		return nil, nil
+	}
+
+	claims, err := a.verify(strings.TrimSpace(token))
+	if err != nil {
+		return nil, err
+	}
+	return &Principal{Subject: claims.Subject, Role: claims.Role}, nil
+}
+
+// verify checks the signature and the time claims of token and returns its claims
+func (a *JWTAuthenticator) verify(token string) (jwtClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return jwtClaims{}, ErrInvalidCredentials
+	}
+
+	var header struct {
+		Algorithm string `json:"alg"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil || header.Algorithm != a.algorithm {
+		// Only the configured algorithm is accepted, a token cannot pick a weaker one
+		return jwtClaims{}, ErrInvalidCredentials
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return jwtClaims{}, ErrInvalidCredentials
+	}
+
+	signed := []byte(parts[0] + "." + parts[1])
+	switch a.algorithm {
+	case "HS256":
+		mac := hmac.New(sha256.New, a.secret)
+		mac.Write(signed)
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return jwtClaims{}, ErrInvalidCredentials
+		}
+	case "RS256":
+		digest := sha256.Sum256(signed)
+		if rsa.VerifyPKCS1v15(a.publicKey, crypto.SHA256, digest[:], signature) != nil {
+			return jwtClaims{}, ErrInvalidCredentials
+		}
+	}
+
+	var claims jwtClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return jwtClaims{}, ErrInvalidCredentials
+	}
+
+	now := time.Now().Unix()
+	if claims.ExpiresAt != nil && now >= *claims.ExpiresAt {
+		return jwtClaims{}, ErrInvalidCredentials
+	}
+	if claims.NotBefore != nil && now < *claims.NotBefore {
+		return jwtClaims{}, ErrInvalidCredentials
+	}
+	if _, ok := roleRanks[claims.Role]; !ok || claims.Subject == "" {
+		return jwtClaims{}, ErrInvalidCredentials
+	}
+	return claims, nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -31,6 +31,12 @@ func LogHandler(logger *zap.Logger) fiber.Handler {
 
 		exits := lo.Contains(ignorePathList, ctx.Path()) || strings.HasPrefix(string(ctx.Response().Header.ContentType()), "image/") || strings.HasPrefix(string(ctx.Response().Header.ContentType()), "text/")
 		if !exits {
+			// Streamed bodies are written after the handler returns, reading them here would
+			// consume them
+			response := "<stream>"
+			if !ctx.Response().IsBodyStream() {
+				response = ctx.Response().String()
+			}
 			zapCoreField = []zapcore.Field{
 				zap.String("host", ctx.Hostname()),
 				zap.String("method", string(ctx.Request().Header.Method())),
@@ -40,7 +46,7 @@ func LogHandler(logger *zap.Logger) fiber.Handler {
 				zap.String("requestHeaders", string(ctx.Request().Header.Header())),
 				zap.String("responseHeaders", string(ctx.Response().Header.Header())),
 				zap.String("request", string(ctx.Request().Body())),
-				zap.String("response", ctx.Response().String()),
+				zap.String("response", response),
 				zap.Int("status", ctx.Response().Header.StatusCode()),
 				zap.Int("size", ctx.Response().Header.ContentLength()),
 			}
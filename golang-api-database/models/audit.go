@@ -0,0 +1,212 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+var AuditLogTable = "audit_log"
+
+// Entities whose writes are recorded in the audit log
+const (
+	AuditMovie  = "movie"
+	AuditRating = "rating"
+	AuditCast   = "cast"
+	AuditCrew   = "crew"
+	AuditPerson = "person"
+	AuditImport = "import"
+)
+
+// Operations recorded in the audit log
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+)
+
+// AuditEntry records one write to an entity. Changes holds the fields of the entity which the
+// write changed, all of them when it was created or deleted.
+type AuditEntry struct {
+	ID        int64                  `json:"id"`
+	Time      time.Time              `json:"timestamp"`
+	Actor     string                 `json:"actor"`
+	Entity    string                 `json:"entity"`
+	EntityID  string                 `json:"entity_id"`
+	Operation string                 `json:"operation"`
+	Changes   map[string]AuditChange `json:"changes"`
+}
+
+// AuditChange is the value of a field before and after a write, a missing value meaning the
+// field did not exist
+type AuditChange struct {
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+// AuditFilter selects audit entries. EntityID matches the entity id and, for a movie id, the ids
+// of the ratings and credits of the movie. Zero fields match every entry.
+type AuditFilter struct {
+	Entity   string
+	EntityID string
+	Since    time.Time
+	Limit    uint
+}
+
+type auditRow struct {
+	ID        int64     `db:"id"`
+	CreatedAt time.Time `db:"created_at"`
+	Actor     string    `db:"actor"`
+	Entity    string    `db:"entity"`
+	EntityID  string    `db:"entity_id"`
+	Operation string    `db:"operation"`
+	Changes   []byte    `db:"changes"`
+}
+
+type AuditModel struct {
+	db Session
+}
+
+func InitAuditModel(goqu *goqu.Database) (*AuditModel, error) {
+	return &AuditModel{
+		db: goqu,
+	}, nil
+}
+
+// WithTx returns the model running its statements in tx
+func (a *AuditModel) WithTx(tx *goqu.TxDatabase) *AuditModel {
+	return &AuditModel{db: tx}
+}
+
+// AuditEntityID is the id of an entity in the audit log. Ratings and credits are identified by
+// their movie followed by the user or the credit, so they are found along with the movie.
+func AuditEntityID(movieId int, ids ...string) string {
+	return strings.Join(append([]string{fmt.Sprint(movieId)}, ids...), "/")
+}
+
+// NewAuditEntry builds the entry of the write of actor which changed the entity having entityID
+// from before into after, nil standing for an entity which did not exist
+func NewAuditEntry(actor, entity, entityID, operation string, before, after interface{}) (AuditEntry, error) {
+	from, err := auditFields(before)
+	if err != nil {
+		return AuditEntry{}, err
+	}
+	to, err := auditFields(after)
+	if err != nil {
+		return AuditEntry{}, err
+	}
+
+	changes := make(map[string]AuditChange)
+	for field, value := range from {
+		if !reflect.DeepEqual(value, to[field]) {
+			changes[field] = AuditChange{Before: value, After: to[field]}
+		}
+	}
+	for field, value := range to {
+		if _, ok := from[field]; !ok {
+			changes[field] = AuditChange{After: value}
+		}
+	}
+
+	return AuditEntry{
+		Actor:     actor,
+		Entity:    entity,
+		EntityID:  entityID,
+		Operation: operation,
+		Changes:   changes,
+	}, nil
+}
+
+// auditFields are the fields of the JSON encoding of an entity
+func auditFields(entity interface{}) (map[string]interface{}, error) {
+	if entity == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(entity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode audited entity: %w", err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to decode audited entity: %w", err)
+	}
+	return fields, nil
+}
+
+// Record appends entry to the audit log, setting its id and time
+func (a *AuditModel) Record(entry *AuditEntry) error {
+	changes, err := json.Marshal(entry.Changes)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit changes: %w", err)
+	}
+
+	var row auditRow
+	_, err = a.db.Insert(AuditLogTable).Rows(goqu.Record{
+		"actor":     entry.Actor,
+		"entity":    entry.Entity,
+		"entity_id": entry.EntityID,
+		"operation": entry.Operation,
+		"changes":   string(changes),
+	}).Returning("id", "created_at").Executor().ScanStruct(&row)
+	if err != nil {
+		return fmt.Errorf("failed to insert audit entry: %w", err)
+	}
+
+	entry.ID = row.ID
+	entry.Time = row.CreatedAt
+	return nil
+}
+
+// ListAudit lists the entries matching filter, oldest first
+func (a *AuditModel) ListAudit(filter AuditFilter) ([]AuditEntry, error) {
+	ds := a.db.From(AuditLogTable).
+		Select("id", "created_at", "actor", "entity", "entity_id", "operation", "changes").
+		Order(goqu.C("id").Asc())
+	if filter.Entity != "" {
+		ds = ds.Where(goqu.C("entity").Eq(filter.Entity))
+	}
+	if filter.EntityID != "" {
+		ds = ds.Where(goqu.Or(
+			goqu.C("entity_id").Eq(filter.EntityID),
+			goqu.C("entity_id").Like(escapeLike(filter.EntityID)+"/%"),
+		))
+	}
+	if !filter.Since.IsZero() {
+		ds = ds.Where(goqu.C("created_at").Gte(filter.Since))
+	}
+	if filter.Limit > 0 {
+		ds = ds.Limit(filter.Limit)
+	}
+
+	var rows []auditRow
+	if err := ds.ScanStructs(&rows); err != nil {
+		return nil, fmt.Errorf("failed to fetch audit log: %w", err)
+	}
+
+	entries := make([]AuditEntry, 0, len(rows))
+	for _, row := range rows {
+		entry := AuditEntry{
+			ID:        row.ID,
+			Time:      row.CreatedAt,
+			Actor:     row.Actor,
+			Entity:    row.Entity,
+			EntityID:  row.EntityID,
+			Operation: row.Operation,
+		}
+		if err := json.Unmarshal(row.Changes, &entry.Changes); err != nil {
+			return nil, fmt.Errorf("failed to decode audit entry %d: %w", row.ID, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
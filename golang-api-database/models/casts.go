@@ -23,7 +23,7 @@ type MovieCast struct {
 }
 
 type CastsModel struct {
-	db *goqu.Database
+	db Session
 }
 
 func InitCastsModel(goqu *goqu.Database) (*CastsModel, error) {
@@ -32,29 +32,55 @@ func InitCastsModel(goqu *goqu.Database) (*CastsModel, error) {
 	}, nil
 }
 
-func (c *CastsModel) ListCasts(id string) ([]MovieCast, error) {
+// WithTx returns the model running its statements in tx
+func (c *CastsModel) WithTx(tx *goqu.TxDatabase) *CastsModel {
+	return &CastsModel{db: tx}
+}
+
+// ListCasts lists a page of the cast of a movie in billing order
+func (c *CastsModel) ListCasts(id string, page PageRequest) (Page[MovieCast], error) {
 	var casts []MovieCast
 
 	movieID, err := strconv.Atoi(id)
 	if err != nil {
-		return nil, fmt.Errorf("invalid movie ID: %w", err)
+		return Page[MovieCast]{}, fmt.Errorf("invalid movie ID: %w", err)
+	}
+	if err := page.CheckKey(2); err != nil {
+		return Page[MovieCast]{}, err
 	}
 
-	err = c.db.From(CastTable).
+	ds := c.db.From(CastTable).
 		Select("person_id", "movie_id", "name", "credit_id", "cast_id", "character", "cast_order").
 		Join(goqu.T("credits"), goqu.On(goqu.T(CastTable).Col("person_id").Eq(goqu.T("credits").Col("id")))).
-		Where(goqu.T(CastTable).Col("movie_id").Eq(movieID)).
-		ScanStructs(&casts)
+		Where(goqu.T(CastTable).Col("movie_id").Eq(movieID), live(CastTable))
+
+	var total *int64
+	if page.Total {
+		count, err := ds.Count()
+		if err != nil {
+			return Page[MovieCast]{}, fmt.Errorf("failed to count cast: %w", err)
+		}
+		total = &count
+	}
 
+	columns := []keysetColumn{
+		{expr: goqu.T(CastTable).Col("cast_order")},
+		{expr: goqu.T(CastTable).Col("credit_id")},
+	}
+	err = keysetPage(ds, columns, page).ScanStructs(&casts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch cast: %w", err)
+		return Page[MovieCast]{}, fmt.Errorf("failed to fetch cast: %w", err)
 	}
 
-	if len(casts) == 0 {
-		return nil, sql.ErrNoRows
+	if len(casts) == 0 && page.Cursor == nil {
+		return Page[MovieCast]{}, sql.ErrNoRows
 	}
 
-	return casts, nil
+	result := keysetRows(casts, page, func(cast MovieCast) []string {
+		return []string{strconv.Itoa(cast.Order), cast.CreditID}
+	})
+	result.Total = total
+	return result, nil
 }
 
 type ActorWithMovies struct {
@@ -62,29 +88,58 @@ type ActorWithMovies struct {
 	Movies    []string `json:"movies"`
 }
 
-func (c *CastsModel) ListMoviesByCastId(id string) (*ActorWithMovies, error) {
+type actorMovie struct {
+	MovieID int    `db:"movie_id"`
+	Title   string `db:"title"`
+}
+
+// ListMoviesByCastId lists the actor along with a page of the titles of their movies, by movie id
+func (c *CastsModel) ListMoviesByCastId(id string, page PageRequest) (*ActorWithMovies, Page[string], error) {
 	var actorName string
-	var movies []string
+	var rows []actorMovie
 
 	castID, err := strconv.Atoi(id)
 	if err != nil {
-		return nil, fmt.Errorf("invalid cast ID: %w", err)
+		return nil, Page[string]{}, fmt.Errorf("invalid cast ID: %w", err)
+	}
+	if err := page.CheckKey(1); err != nil {
+		return nil, Page[string]{}, err
 	}
 
 	dsActor := c.db.From("credits").Select("name").Where(goqu.C("id").Eq(castID))
 	if found, err := dsActor.ScanVal(&actorName); err != nil || !found {
-		return nil, sql.ErrNoRows
+		return nil, Page[string]{}, sql.ErrNoRows
 	}
 
-	dsMovies := c.db.From(CastTable).Select("movies.title").
+	dsMovies := c.db.From(CastTable).Select(goqu.T(CastTable).Col("movie_id"), "movies.title").
 		Join(goqu.T(MovieTable), goqu.On(goqu.T(CastTable).Col("movie_id").Eq(goqu.T(MovieTable).Col("id")))).
-		Where(goqu.T(CastTable).Col("person_id").Eq(castID))
+		Where(goqu.T(CastTable).Col("person_id").Eq(castID), live(CastTable), live(MovieTable))
+
+	var total *int64
+	if page.Total {
+		count, err := dsMovies.Count()
+		if err != nil {
+			return nil, Page[string]{}, fmt.Errorf("failed to count movies for actor: %w", err)
+		}
+		total = &count
+	}
+
+	columns := []keysetColumn{{expr: goqu.T(CastTable).Col("movie_id")}}
+	if err := keysetPage(dsMovies, columns, page).ScanStructs(&rows); err != nil {
+		return nil, Page[string]{}, fmt.Errorf("failed to fetch movies for actor: %w", err)
+	}
+
+	moviesPage := keysetRows(rows, page, func(movie actorMovie) []string {
+		return []string{strconv.Itoa(movie.MovieID)}
+	})
 
-	if err := dsMovies.ScanVals(&movies); err != nil {
-		return nil, fmt.Errorf("failed to fetch movies for actor: %w", err)
+	titles := make([]string, 0, len(moviesPage.Items))
+	for _, movie := range moviesPage.Items {
+		titles = append(titles, movie.Title)
 	}
 
-	return &ActorWithMovies{ActorName: actorName, Movies: movies}, nil
+	result := Page[string]{Items: titles, Limit: moviesPage.Limit, Next: moviesPage.Next, Prev: moviesPage.Prev, Total: total}
+	return &ActorWithMovies{ActorName: actorName, Movies: titles}, result, nil
 }
 
 var ErrCastAlreadyExists = errors.New("cast already exists")
@@ -93,7 +148,7 @@ func (c *CastsModel) AddMovieCasts(cast *MovieCast) error {
 	var moviecount int
 	_, err := c.db.From(MovieTable).
 		Select(goqu.COUNT("*")).
-		Where(goqu.Ex{"id": cast.MovieID}).
+		Where(goqu.Ex{"id": cast.MovieID, "deleted_at": nil}).
 		ScanVal(&moviecount)
 
 	if err != nil {
@@ -118,12 +173,13 @@ func (c *CastsModel) AddMovieCasts(cast *MovieCast) error {
 		return ErrCreditNotFound
 	}
 
+	cast.CastID = generateIntegerID()
 	insert := c.db.Insert(CastTable).Rows(
 		goqu.Record{
 			"movie_id":   cast.MovieID,
 			"person_id":  cast.PersonID,
 			"credit_id":  GenerateID(),
-			"cast_id":    generateIntegerID(),
+			"cast_id":    cast.CastID,
 			"character":  cast.Character,
 			"cast_order": cast.Order,
 		},
@@ -138,6 +194,7 @@ func (c *CastsModel) AddMovieCasts(cast *MovieCast) error {
 		return ErrCastAlreadyExists
 	}
 
+	cast.CreditID = insertedId
 	return nil
 }
 
@@ -0,0 +1,207 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+// CollectionTable represent table name
+const CollectionTable = "collections"
+
+// ErrCollectionNotFound is returned when attaching a movie to a collection which does not exist
+var ErrCollectionNotFound = errors.New("collection not found")
+
+// CollectionStats aggregates the live movies of a collection, the box office being the sum of
+// their budgets and revenues. AverageRating is the mean of the ratings of its movies, 0 when none
+// were rated.
+type CollectionStats struct {
+	ID            int     `db:"id" json:"id"`
+	Name          string  `db:"name" json:"name"`
+	PosterPath    string  `db:"poster_path" json:"poster_path,omitempty"`
+	BackdropPath  string  `db:"backdrop_path" json:"backdrop_path,omitempty"`
+	FilmCount     int64   `db:"film_count" json:"film_count"`
+	TotalBudget   float64 `db:"total_budget" json:"total_budget"`
+	TotalRevenue  float64 `db:"total_revenue" json:"total_revenue"`
+	AverageRating float64 `db:"average_rating" json:"average_rating"`
+	RatingCount   int64   `db:"rating_count" json:"rating_count"`
+}
+
+// CollectionWithMovies is a collection along with its movies in release order
+type CollectionWithMovies struct {
+	CollectionStats
+	Movies []Movie `json:"movies"`
+}
+
+// CollectionMember is the request attaching a movie to a collection
+type CollectionMember struct {
+	CollectionID int `json:"collection_id" validate:"required,gt=0"`
+}
+
+// CollectionModel reads the collections of movies and attaches movies to them
+type CollectionModel struct {
+	db Session
+}
+
+func InitCollectionModel(goqu *goqu.Database) (*CollectionModel, error) {
+	return &CollectionModel{
+		db: goqu,
+	}, nil
+}
+
+// WithTx returns the model running its statements in tx
+func (m *CollectionModel) WithTx(tx *goqu.TxDatabase) *CollectionModel {
+	return &CollectionModel{db: tx}
+}
+
+// collectionColumns select the columns of collections read into Collection
+func collectionColumns() []interface{} {
+	return []interface{}{
+		"id", "name",
+		goqu.COALESCE(goqu.C("poster_path"), "").As("poster_path"),
+		goqu.COALESCE(goqu.C("backdrop_path"), "").As("backdrop_path"),
+	}
+}
+
+// collectionStats selects the collections along with the aggregates of their movies
+func (m *CollectionModel) collectionStats() *goqu.SelectDataset {
+	// movies selects the live movies of the collection of the outer query
+	movies := func() *goqu.SelectDataset {
+		return m.db.From(MovieTable).
+			Where(goqu.T(MovieTable).Col("collection_id").Eq(goqu.T(CollectionTable).Col("id")), live(MovieTable))
+	}
+	ratings := func() *goqu.SelectDataset {
+		return movies().
+			Join(goqu.T(RatingsTable), goqu.On(goqu.T(RatingsTable).Col("movie_id").Eq(goqu.T(MovieTable).Col("id")))).
+			Where(live(RatingsTable))
+	}
+
+	return m.db.From(CollectionTable).Select(
+		goqu.T(CollectionTable).Col("id"),
+		goqu.T(CollectionTable).Col("name"),
+		goqu.COALESCE(goqu.T(CollectionTable).Col("poster_path"), "").As("poster_path"),
+		goqu.COALESCE(goqu.T(CollectionTable).Col("backdrop_path"), "").As("backdrop_path"),
+		movies().Select(goqu.COUNT(goqu.Star())).As("film_count"),
+		movies().Select(goqu.COALESCE(goqu.SUM(goqu.T(MovieTable).Col("budget")), 0)).As("total_budget"),
+		movies().Select(goqu.COALESCE(goqu.SUM(goqu.T(MovieTable).Col("revenue")), 0)).As("total_revenue"),
+		ratings().Select(goqu.L("COALESCE(ROUND(AVG(?)::numeric, 2), 0)", goqu.T(RatingsTable).Col("rating"))).As("average_rating"),
+		ratings().Select(goqu.COUNT(goqu.Star())).As("rating_count"),
+	)
+}
+
+// ListCollections lists the collections whose name contains name, all of them when it is empty,
+// along with the aggregates of their movies, by id
+func (m *CollectionModel) ListCollections(name string, page PageRequest) (Page[CollectionStats], error) {
+	var collections []CollectionStats
+	if err := page.CheckKey(1); err != nil {
+		return Page[CollectionStats]{}, err
+	}
+
+	ds := m.collectionStats()
+	if name = strings.TrimSpace(name); name != "" {
+		ds = ds.Where(goqu.T(CollectionTable).Col("name").ILike("%" + name + "%"))
+	}
+
+	var total *int64
+	if page.Total {
+		count, err := ds.Count()
+		if err != nil {
+			return Page[CollectionStats]{}, fmt.Errorf("error counting collections: %w", err)
+		}
+		total = &count
+	}
+
+	columns := []keysetColumn{{expr: goqu.T(CollectionTable).Col("id")}}
+	if err := keysetPage(ds, columns, page).ScanStructs(&collections); err != nil {
+		return Page[CollectionStats]{}, fmt.Errorf("error fetching collections: %w", err)
+	}
+
+	result := keysetRows(collections, page, func(collection CollectionStats) []string {
+		return []string{strconv.Itoa(collection.ID)}
+	})
+	result.Total = total
+	return result, nil
+}
+
+// GetCollection returns the collection having id along with the aggregates of its movies and the
+// movies themselves, the unreleased ones last
+func (m *CollectionModel) GetCollection(id int) (CollectionWithMovies, error) {
+	var collection CollectionWithMovies
+	found, err := m.collectionStats().Where(goqu.T(CollectionTable).Col("id").Eq(id)).ScanStruct(&collection.CollectionStats)
+	if err != nil {
+		return CollectionWithMovies{}, fmt.Errorf("error fetching collection: %w", err)
+	}
+	if !found {
+		return CollectionWithMovies{}, sql.ErrNoRows
+	}
+
+	var movieDBs []MovieDB
+	err = m.db.From(MovieTable).Select(selectMovieColumns()...).
+		Where(goqu.T(MovieTable).Col("collection_id").Eq(id), live(MovieTable)).
+		Order(goqu.T(MovieTable).Col("release_date").Asc().NullsLast(), goqu.T(MovieTable).Col("id").Asc()).
+		ScanStructs(&movieDBs)
+	if err != nil {
+		return CollectionWithMovies{}, fmt.Errorf("error fetching movies of collection: %w", err)
+	}
+
+	collection.Movies = make([]Movie, 0, len(movieDBs))
+	for _, movieDB := range movieDBs {
+		collection.Movies = append(collection.Movies, ConvertMovieDBToMovie(movieDB))
+	}
+	return collection, nil
+}
+
+// AttachMovie makes the live movie having movieID a member of the collection having
+// collectionID, in place of the collection it belonged to. It returns sql.ErrNoRows when the
+// movie does not exist.
+func (m *CollectionModel) AttachMovie(movieID, collectionID int) error {
+	var collection Collection
+	found, err := m.db.From(CollectionTable).
+		Select(collectionColumns()...).
+		Where(goqu.C("id").Eq(collectionID)).
+		ScanStruct(&collection)
+	if err != nil {
+		return fmt.Errorf("error fetching collection: %w", err)
+	}
+	if !found {
+		return ErrCollectionNotFound
+	}
+
+	// The JSONB column is kept in step, it is what movies are read with
+	encoded, err := json.Marshal(collection)
+	if err != nil {
+		return fmt.Errorf("failed to encode collection: %w", err)
+	}
+	return m.setCollection(movieID, goqu.Record{
+		"collection_id":         collectionID,
+		"belongs_to_collection": string(encoded),
+	})
+}
+
+// DetachMovie removes the live movie having movieID from its collection. It returns
+// sql.ErrNoRows when the movie does not exist.
+func (m *CollectionModel) DetachMovie(movieID int) error {
+	return m.setCollection(movieID, goqu.Record{
+		"collection_id":         nil,
+		"belongs_to_collection": nil,
+	})
+}
+
+func (m *CollectionModel) setCollection(movieID int, record goqu.Record) error {
+	result, err := m.db.Update(MovieTable).
+		Set(record).
+		Where(goqu.C("id").Eq(movieID), goqu.C("deleted_at").IsNull()).
+		Executor().Exec()
+	if err != nil {
+		return fmt.Errorf("failed to update collection of movie: %w", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
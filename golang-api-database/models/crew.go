@@ -23,7 +23,7 @@ type MovieCrew struct {
 }
 
 type CrewModel struct {
-	db *goqu.Database
+	db Session
 }
 
 func InitCrewModel(goqu *goqu.Database) (*CrewModel, error) {
@@ -32,29 +32,52 @@ func InitCrewModel(goqu *goqu.Database) (*CrewModel, error) {
 	}, nil
 }
 
-func (c *CrewModel) ListCrew(id string) ([]MovieCrew, error) {
+// WithTx returns the model running its statements in tx
+func (c *CrewModel) WithTx(tx *goqu.TxDatabase) *CrewModel {
+	return &CrewModel{db: tx}
+}
+
+// ListCrew lists a page of the crew of a movie by credit
+func (c *CrewModel) ListCrew(id string, page PageRequest) (Page[MovieCrew], error) {
 	var crew []MovieCrew
 
 	movieID, err := strconv.Atoi(id)
 	if err != nil {
-		return nil, fmt.Errorf("invalid movie ID: %w", err)
+		return Page[MovieCrew]{}, fmt.Errorf("invalid movie ID: %w", err)
+	}
+	if err := page.CheckKey(1); err != nil {
+		return Page[MovieCrew]{}, err
 	}
 
-	err = c.db.From(CrewTable).
+	ds := c.db.From(CrewTable).
 		Select("person_id", "movie_id", "name", "credit_id", "job", "department").
 		Join(goqu.T(CreditsTable), goqu.On(goqu.T(CrewTable).Col("person_id").Eq(goqu.T(CreditsTable).Col("id")))).
-		Where(goqu.T(CrewTable).Col("movie_id").Eq(movieID)).
-		ScanStructs(&crew)
+		Where(goqu.T(CrewTable).Col("movie_id").Eq(movieID), live(CrewTable))
+
+	var total *int64
+	if page.Total {
+		count, err := ds.Count()
+		if err != nil {
+			return Page[MovieCrew]{}, fmt.Errorf("failed to count crew: %w", err)
+		}
+		total = &count
+	}
 
+	columns := []keysetColumn{{expr: goqu.T(CrewTable).Col("credit_id")}}
+	err = keysetPage(ds, columns, page).ScanStructs(&crew)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch cast: %w", err)
+		return Page[MovieCrew]{}, fmt.Errorf("failed to fetch cast: %w", err)
 	}
 
-	if len(crew) == 0 {
-		return nil, sql.ErrNoRows
+	if len(crew) == 0 && page.Cursor == nil {
+		return Page[MovieCrew]{}, sql.ErrNoRows
 	}
 
-	return crew, nil
+	result := keysetRows(crew, page, func(member MovieCrew) []string {
+		return []string{member.CreditID}
+	})
+	result.Total = total
+	return result, nil
 }
 
 func GenerateID() string {
@@ -71,7 +94,7 @@ func (c *CrewModel) AddMovieCrew(crew *MovieCrew) error {
 	var moviecount int
 	_, err := c.db.From(MovieTable).
 		Select(goqu.COUNT("*")).
-		Where(goqu.Ex{"id": crew.MovieID}).
+		Where(goqu.Ex{"id": crew.MovieID, "deleted_at": nil}).
 		ScanVal(&moviecount)
 
 	if err != nil {
@@ -115,5 +138,6 @@ func (c *CrewModel) AddMovieCrew(crew *MovieCrew) error {
 		return ErrCrewAlreadyExists
 	}
 
+	crew.CreditID = insertedId
 	return nil
 }
@@ -0,0 +1,224 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+)
+
+// Kinds of exports, named after the Kaggle files they are written like
+const (
+	ExportMovies  = "movies"
+	ExportRatings = "ratings"
+	ExportCredits = "credits"
+)
+
+var ErrUnknownExport = errors.New("unknown export")
+
+// ExportType is the type of the values of an export column
+type ExportType int
+
+// Types of export columns. Their values are given to Export.Each as bool, int64, float64 and
+// string, or nil for NULL. JSON columns hold the JSON encoding of nested values as a string.
+const (
+	ExportBool ExportType = iota
+	ExportInt
+	ExportFloat
+	ExportString
+	ExportJSON
+)
+
+// ExportColumn is a column of an export, named like the column of the Kaggle file
+type ExportColumn struct {
+	Name string
+	Type ExportType
+	expr interface{}
+}
+
+// Export is a running export, whose rows are read from the database as they are iterated
+type Export struct {
+	Columns []ExportColumn
+	rows    *sql.Rows
+}
+
+// movieExportColumns are the columns of movies_metadata.csv
+var movieExportColumns = []ExportColumn{
+	{"adult", ExportBool, goqu.T(MovieTable).Col("adult")},
+	{"belongs_to_collection", ExportJSON, jsonText(goqu.T(MovieTable).Col("belongs_to_collection"))},
+	{"budget", ExportFloat, goqu.T(MovieTable).Col("budget")},
+	{"genres", ExportJSON, goqu.L(`(SELECT COALESCE(json_agg(json_build_object('id', genres.id, 'name', genres.name) ORDER BY genres.id), '[]')::text
+		FROM movie_genres JOIN genres ON genres.id = movie_genres.genreid WHERE movie_genres.movieid = movies.id)`)},
+	{"homepage", ExportString, goqu.T(MovieTable).Col("homepage")},
+	{"id", ExportInt, goqu.T(MovieTable).Col("id")},
+	{"imdb_id", ExportString, goqu.T(MovieTable).Col("imdb_id")},
+	{"original_language", ExportString, goqu.T(MovieTable).Col("original_language")},
+	{"original_title", ExportString, goqu.T(MovieTable).Col("original_title")},
+	{"overview", ExportString, goqu.T(MovieTable).Col("overview")},
+	{"popularity", ExportFloat, goqu.T(MovieTable).Col("popularity")},
+	{"poster_path", ExportString, goqu.T(MovieTable).Col("poster_path")},
+	{"production_companies", ExportJSON, jsonText(goqu.T(MovieTable).Col("production_companies"))},
+	{"production_countries", ExportJSON, jsonText(goqu.T(MovieTable).Col("production_countries"))},
+	{"release_date", ExportString, goqu.L("to_char(?, 'YYYY-MM-DD')", goqu.T(MovieTable).Col("release_date"))},
+	{"revenue", ExportFloat, goqu.T(MovieTable).Col("revenue")},
+	{"runtime", ExportFloat, goqu.T(MovieTable).Col("runtime")},
+	{"spoken_languages", ExportJSON, goqu.L(`(SELECT COALESCE(json_agg(json_build_object('iso_639_1', languages.iso_code, 'name', languages.name) ORDER BY languages.iso_code), '[]')::text
+		FROM movie_languages JOIN languages ON languages.iso_code = movie_languages.language_code WHERE movie_languages.movieid = movies.id)`)},
+	{"status", ExportString, goqu.T(MovieTable).Col("status")},
+	{"tagline", ExportString, goqu.T(MovieTable).Col("tagline")},
+	{"title", ExportString, goqu.T(MovieTable).Col("title")},
+	{"video", ExportBool, goqu.T(MovieTable).Col("video")},
+	{"vote_average", ExportFloat, goqu.T(MovieTable).Col("vote_average")},
+	{"vote_count", ExportInt, goqu.T(MovieTable).Col("vote_count")},
+}
+
+// ratingExportColumns are the columns of ratings.csv, timestamp being a unix time
+var ratingExportColumns = []ExportColumn{
+	{"userId", ExportInt, goqu.T(RatingsTable).Col("user_id")},
+	{"movieId", ExportInt, goqu.T(RatingsTable).Col("movie_id")},
+	{"rating", ExportFloat, goqu.T(RatingsTable).Col("rating")},
+	{"timestamp", ExportInt, goqu.L("EXTRACT(EPOCH FROM ?)::bigint", goqu.T(RatingsTable).Col("timestamp"))},
+}
+
+// creditExportColumns are the columns of credits.csv, the cast and crew of a movie being JSON
+// lists with the keys of the Kaggle file
+var creditExportColumns = []ExportColumn{
+	{"cast", ExportJSON, goqu.L(`(SELECT COALESCE(json_agg(json_build_object(
+			'cast_id', movie_casts.cast_id, 'character', movie_casts.character, 'credit_id', movie_casts.credit_id,
+			'gender', credits.gender, 'id', credits.id, 'name', credits.name, 'order', movie_casts.cast_order,
+			'profile_path', credits.profile_path) ORDER BY movie_casts.cast_order, movie_casts.credit_id), '[]')::text
+		FROM movie_casts JOIN credits ON credits.id = movie_casts.person_id
+		WHERE movie_casts.movie_id = movies.id AND movie_casts.deleted_at IS NULL)`)},
+	{"crew", ExportJSON, goqu.L(`(SELECT COALESCE(json_agg(json_build_object(
+			'credit_id', movie_crew.credit_id, 'department', movie_crew.department, 'gender', credits.gender,
+			'id', credits.id, 'job', movie_crew.job, 'name', credits.name,
+			'profile_path', credits.profile_path) ORDER BY movie_crew.credit_id), '[]')::text
+		FROM movie_crew JOIN credits ON credits.id = movie_crew.person_id
+		WHERE movie_crew.movie_id = movies.id AND movie_crew.deleted_at IS NULL)`)},
+	{"id", ExportInt, goqu.T(MovieTable).Col("id")},
+}
+
+// jsonText selects a JSONB column as its text
+func jsonText(col exp.IdentifierExpression) exp.LiteralExpression {
+	return goqu.L("?::text", col)
+}
+
+type ExportModel struct {
+	db *goqu.Database
+}
+
+func InitExportModel(goqu *goqu.Database) (*ExportModel, error) {
+	return &ExportModel{
+		db: goqu,
+	}, nil
+}
+
+// Export starts the export of kind, restricted to the movies matching the filters of ListMovies
+// and the ratings and credits of those movies. Rows are ordered by movie, ratings by user first;
+// sort is ignored. The export must be closed once iterated.
+func (e *ExportModel) Export(kind string, filters map[string]string) (*Export, error) {
+	filter, err := parseMovieFilter(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		columns []ExportColumn
+		ds      *goqu.SelectDataset
+		movieID exp.IdentifierExpression
+	)
+	switch kind {
+	case ExportMovies:
+		columns = movieExportColumns
+		movieID = goqu.T(MovieTable).Col("id")
+		ds = e.db.From(MovieTable).Where(live(MovieTable)).Order(movieID.Asc())
+	case ExportCredits:
+		columns = creditExportColumns
+		movieID = goqu.T(MovieTable).Col("id")
+		ds = e.db.From(MovieTable).Where(live(MovieTable)).Order(movieID.Asc())
+	case ExportRatings:
+		columns = ratingExportColumns
+		movieID = goqu.T(RatingsTable).Col("movie_id")
+		ds = e.db.From(RatingsTable).Where(live(RatingsTable)).
+			Order(goqu.T(RatingsTable).Col("user_id").Asc(), movieID.Asc())
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownExport, kind)
+	}
+
+	if !filter.matchesAll() {
+		ds = ds.Where(movieID.In(filteredMovies(e.db, filter).Select(goqu.DISTINCT(goqu.T(MovieTable).Col("id")))))
+	}
+
+	exprs := make([]interface{}, 0, len(columns))
+	for _, column := range columns {
+		exprs = append(exprs, column.expr)
+	}
+	query, args, err := ds.Select(exprs...).ToSQL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build %s export: %w", kind, err)
+	}
+
+	// Rows are fetched as they are scanned rather than scanned into structs all at once
+	rows, err := e.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export %s: %w", kind, err)
+	}
+	return &Export{Columns: columns, rows: rows}, nil
+}
+
+// Each calls fn with the values of every row of the export in turn, stopping at the first error.
+// The row is reused between calls.
+func (x *Export) Each(fn func(row []interface{}) error) error {
+	dest := make([]interface{}, len(x.Columns))
+	for i, column := range x.Columns {
+		switch column.Type {
+		case ExportBool:
+			dest[i] = new(sql.NullBool)
+		case ExportInt:
+			dest[i] = new(sql.NullInt64)
+		case ExportFloat:
+			dest[i] = new(sql.NullFloat64)
+		default:
+			dest[i] = new(sql.NullString)
+		}
+	}
+
+	row := make([]interface{}, len(x.Columns))
+	for x.rows.Next() {
+		if err := x.rows.Scan(dest...); err != nil {
+			return fmt.Errorf("failed to scan export row: %w", err)
+		}
+		for i, value := range dest {
+			row[i] = nil
+			switch v := value.(type) {
+			case *sql.NullBool:
+				if v.Valid {
+					row[i] = v.Bool
+				}
+			case *sql.NullInt64:
+				if v.Valid {
+					row[i] = v.Int64
+				}
+			case *sql.NullFloat64:
+				if v.Valid {
+					row[i] = v.Float64
+				}
+			case *sql.NullString:
+				if v.Valid {
+					row[i] = v.String
+				}
+			}
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return x.rows.Err()
+}
+
+// Close ends the export, releasing its connection
+func (x *Export) Close() error {
+	return x.rows.Close()
+}
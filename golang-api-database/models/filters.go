@@ -0,0 +1,220 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidFilter = errors.New("invalid movie filter")
+
+// Columns of movies accepted by the range filters and by sort, range filters are given as
+// <column>_min and <column>_max
+var (
+	movieRangeColumns = []string{"release_date", "runtime", "popularity", "vote_average", "vote_count", "budget", "revenue"}
+	movieSortColumns  = map[string]bool{
+		"id": true, "title": true, "release_date": true, "runtime": true,
+		"popularity": true, "vote_average": true, "vote_count": true, "budget": true, "revenue": true,
+	}
+)
+
+type movieRange struct {
+	column   string
+	min, max interface{}
+}
+
+type movieSort struct {
+	column string
+	desc   bool
+}
+
+// movieFilter is the parsed form of the filters given to ListMovies:
+//
+//	name, language, status       name matches a part of the original title
+//	genre                        comma separated genres
+//	genre_mode                   "or" (default) matches any of the genres, "and" all of them
+//	adult, video                 true or false
+//	collection, company          the id of the collection or of a production company
+//	country                      the ISO 3166-1 code of a production country
+//	<column>_min, <column>_max   inclusive ranges, release_date is given as YYYY-MM-DD
+//	sort                         comma separated columns, prefixed with "-" for descending order,
+//	                             movies are always sorted by id last
+type movieFilter struct {
+	name       string
+	language   string
+	status     string
+	genres     []string
+	allGenres  bool
+	adult      *bool
+	video      *bool
+	collection int
+	company    int
+	country    string
+	ranges     []movieRange
+	sort       []movieSort
+}
+
+func parseMovieFilter(filters map[string]string) (movieFilter, error) {
+	filter := movieFilter{
+		name:     strings.TrimSpace(filters["name"]),
+		language: strings.TrimSpace(filters["language"]),
+		status:   strings.TrimSpace(filters["status"]),
+		country:  strings.ToUpper(strings.TrimSpace(filters["country"])),
+	}
+
+	for _, genre := range strings.Split(filters["genre"], ",") {
+		if genre = strings.TrimSpace(genre); genre != "" {
+			filter.genres = append(filter.genres, strings.ToLower(genre))
+		}
+	}
+
+	switch mode := strings.ToLower(filters["genre_mode"]); mode {
+	case "", "or":
+	case "and":
+		filter.allGenres = true
+	default:
+		return movieFilter{}, fmt.Errorf("%w: genre_mode must be and or or, got %q", ErrInvalidFilter, mode)
+	}
+
+	for _, flag := range []struct {
+		key   string
+		value **bool
+	}{{"adult", &filter.adult}, {"video", &filter.video}} {
+		raw := strings.TrimSpace(filters[flag.key])
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return movieFilter{}, fmt.Errorf("%w: %s must be true or false, got %q", ErrInvalidFilter, flag.key, raw)
+		}
+		*flag.value = &value
+	}
+
+	for _, id := range []struct {
+		key   string
+		value *int
+	}{{"collection", &filter.collection}, {"company", &filter.company}} {
+		raw := strings.TrimSpace(filters[id.key])
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.Atoi(raw)
+		if err != nil || value <= 0 {
+			return movieFilter{}, fmt.Errorf("%w: %s must be a positive id, got %q", ErrInvalidFilter, id.key, raw)
+		}
+		*id.value = value
+	}
+
+	for _, column := range movieRangeColumns {
+		bounds := movieRange{column: column}
+		for _, bound := range []struct {
+			suffix string
+			value  *interface{}
+		}{{"_min", &bounds.min}, {"_max", &bounds.max}} {
+			raw := strings.TrimSpace(filters[column+bound.suffix])
+			if raw == "" {
+				continue
+			}
+			value, err := parseRangeValue(column, raw)
+			if err != nil {
+				return movieFilter{}, fmt.Errorf("%w: %s%s: %v", ErrInvalidFilter, column, bound.suffix, err)
+			}
+			*bound.value = value
+		}
+		if bounds.min != nil || bounds.max != nil {
+			filter.ranges = append(filter.ranges, bounds)
+		}
+	}
+
+	for _, key := range strings.Split(filters["sort"], ",") {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		order := movieSort{column: strings.TrimPrefix(key, "-"), desc: strings.HasPrefix(key, "-")}
+		if !movieSortColumns[order.column] {
+			return movieFilter{}, fmt.Errorf("%w: cannot sort by %q", ErrInvalidFilter, order.column)
+		}
+		filter.sort = append(filter.sort, order)
+		if order.column == "id" {
+			// ids are unique, later columns never decide the order
+			return filter, nil
+		}
+	}
+
+	// Ties are broken by id, so movies have a total order to paginate over
+	filter.sort = append(filter.sort, movieSort{column: "id"})
+
+	return filter, nil
+}
+
+// parseTopRatedFilter parses the filters of the top rated movies: genre and language as for
+// ListMovies and year, the release year
+func parseTopRatedFilter(filters map[string]string) (movieFilter, error) {
+	movieFilters := map[string]string{"genre": filters["genre"], "language": filters["language"]}
+	if year := strings.TrimSpace(filters["year"]); year != "" {
+		if _, err := time.Parse("2006", year); err != nil {
+			return movieFilter{}, fmt.Errorf("%w: year must be a four digit year, got %q", ErrInvalidFilter, year)
+		}
+		movieFilters["release_date_min"] = year + "-01-01"
+		movieFilters["release_date_max"] = year + "-12-31"
+	}
+	return parseMovieFilter(movieFilters)
+}
+
+// matchesAll tells whether f matches every movie, sort aside
+func (f movieFilter) matchesAll() bool {
+	return f.name == "" && f.language == "" && f.status == "" && len(f.genres) == 0 && len(f.ranges) == 0 &&
+		f.adult == nil && f.video == nil && f.collection == 0 && f.company == 0 && f.country == ""
+}
+
+// key returns the values of movie the sort of f is made of, as carried in cursors where NULL
+// is an empty string
+func (f movieFilter) key(movie MovieDB) []string {
+	key := make([]string, len(f.sort))
+	for i, order := range f.sort {
+		switch order.column {
+		case "id":
+			key[i] = strconv.Itoa(movie.ID)
+		case "title":
+			key[i] = movie.Title
+		case "release_date":
+			key[i] = movie.ReleaseDate.String
+		case "runtime":
+			if movie.Runtime.Valid {
+				key[i] = strconv.FormatFloat(movie.Runtime.Float64, 'f', -1, 64)
+			}
+		case "popularity":
+			key[i] = strconv.FormatFloat(movie.Popularity, 'f', -1, 64)
+		case "vote_average":
+			key[i] = strconv.FormatFloat(movie.Vote_average, 'f', -1, 64)
+		case "vote_count":
+			key[i] = strconv.FormatInt(movie.Vote_count, 10)
+		case "budget":
+			if movie.Budget.Valid {
+				key[i] = strconv.FormatFloat(movie.Budget.Float64, 'f', -1, 64)
+			}
+		case "revenue":
+			if movie.Revenue.Valid {
+				key[i] = strconv.FormatFloat(movie.Revenue.Float64, 'f', -1, 64)
+			}
+		}
+	}
+	return key
+}
+
+func parseRangeValue(column, value string) (interface{}, error) {
+	switch column {
+	case "release_date":
+		if _, err := time.Parse("2006-01-02", value); err != nil {
+			return nil, err
+		}
+		return value, nil
+	case "vote_count":
+		return strconv.ParseInt(value, 10, 64)
+	}
+	return strconv.ParseFloat(value, 64)
+}
@@ -2,64 +2,160 @@ package models
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
 	"github.com/go-playground/validator"
 )
 
 // MovieTable represent table name
 const MovieTable = "movies"
 
+// ErrMovieNotDeleted is returned when restoring a movie that is not soft deleted
+var ErrMovieNotDeleted = errors.New("movie is not deleted")
+
+// live matches the rows of table that are not soft deleted
+func live(table string) exp.BooleanExpression {
+	return goqu.T(table).Col("deleted_at").IsNull()
+}
+
+// PurgeCounts are the number of rows removed for good by PurgeDeleted
+type PurgeCounts struct {
+	Movies  int64
+	Ratings int64
+	Casts   int64
+	Crew    int64
+}
+
+// movieColumns are the columns of movies read into MovieDB
+var movieColumns = []string{
+	"id", "imdb_id", "original_language", "original_title", "title", "status", "vote_average", "vote_count",
+	"popularity", "release_date", "tagline", "overview", "runtime", "adult", "video", "budget", "revenue",
+	"homepage", "poster_path", "belongs_to_collection", "production_companies", "production_countries",
+}
+
+// selectMovieColumns selects movieColumns of the movies table
+func selectMovieColumns() []interface{} {
+	columns := make([]interface{}, len(movieColumns))
+	for i, column := range movieColumns {
+		columns[i] = goqu.T(MovieTable).Col(column)
+	}
+	return columns
+}
+
 type MovieDB struct {
-	ID               int             `db:"id"`
-	IMDB_ID          sql.NullString  `db:"imdb_id"`
-	OriginalTitle    string          `db:"original_title"`
-	OriginalLanguage string          `db:"original_language"`
-	Title            string          `db:"title"`
-	Tagline          sql.NullString  `db:"tagline"`
-	Overview         sql.NullString  `db:"overview"`
-	Popularity       float64         `db:"popularity"`
-	Status           sql.NullString  `db:"status"`
-	ReleaseDate      sql.NullString  `db:"release_date"`
-	Runtime          sql.NullFloat64 `db:"runtime"`
-	Vote_average     float64         `db:"vote_average"`
-	Vote_count       int64           `db:"vote_count"`
+	ID                  int             `db:"id"`
+	IMDB_ID             sql.NullString  `db:"imdb_id"`
+	OriginalTitle       string          `db:"original_title"`
+	OriginalLanguage    string          `db:"original_language"`
+	Title               string          `db:"title"`
+	Tagline             sql.NullString  `db:"tagline"`
+	Overview            sql.NullString  `db:"overview"`
+	Popularity          float64         `db:"popularity"`
+	Status              sql.NullString  `db:"status"`
+	ReleaseDate         sql.NullString  `db:"release_date"`
+	Runtime             sql.NullFloat64 `db:"runtime"`
+	Vote_average        float64         `db:"vote_average"`
+	Vote_count          int64           `db:"vote_count"`
+	Adult               sql.NullBool    `db:"adult"`
+	Video               sql.NullBool    `db:"video"`
+	Budget              sql.NullFloat64 `db:"budget"`
+	Revenue             sql.NullFloat64 `db:"revenue"`
+	Homepage            sql.NullString  `db:"homepage"`
+	PosterPath          sql.NullString  `db:"poster_path"`
+	BelongsToCollection []byte          `db:"belongs_to_collection"`
+	ProductionCompanies []byte          `db:"production_companies"`
+	ProductionCountries []byte          `db:"production_countries"`
+}
+
+// Collection is a series of movies, such as the sequels of a movie
+type Collection struct {
+	ID           int    `db:"id" json:"id" validate:"required,gt=0"`
+	Name         string `db:"name" json:"name" validate:"required,max=255"`
+	PosterPath   string `db:"poster_path" json:"poster_path,omitempty" validate:"omitempty,max=255"`
+	BackdropPath string `db:"backdrop_path" json:"backdrop_path,omitempty" validate:"omitempty,max=255"`
+}
+
+// ProductionCompany is a company which produced a movie
+type ProductionCompany struct {
+	ID   int    `json:"id" validate:"required,gt=0"`
+	Name string `json:"name" validate:"required,max=255"`
+}
+
+// ProductionCountry is a country a movie was produced in, by its ISO 3166-1 code
+type ProductionCountry struct {
+	ISO3166_1 string `json:"iso_3166_1" validate:"required,len=2,uppercase"`
+	Name      string `json:"name" validate:"max=255"`
 }
 
 type Movie struct {
-	ID               int     `json:"id"`
-	IMDB_ID          string  `json:"imdb_id"`
-	OriginalTitle    string  `json:"original_title"`
-	OriginalLanguage string  `json:"original_language"`
-	Title            string  `json:"title"`
-	Tagline          string  `json:"tagline,omitempty"`
-	Overview         string  `json:"overview,omitempty"`
-	Popularity       float64 `json:"popularity"`
-	Status           string  `json:"status"`
-	ReleaseDate      string  `json:"release_date,omitempty"`
-	Runtime          float64 `json:"runtime"`
-	Vote_average     float64 `json:"vote_average"`
-	Vote_count       int64   `json:"vote_count"`
+	ID                  int                 `json:"id"`
+	IMDB_ID             string              `json:"imdb_id"`
+	OriginalTitle       string              `json:"original_title"`
+	OriginalLanguage    string              `json:"original_language"`
+	Title               string              `json:"title"`
+	Tagline             string              `json:"tagline,omitempty"`
+	Overview            string              `json:"overview,omitempty"`
+	Popularity          float64             `json:"popularity"`
+	Status              string              `json:"status"`
+	ReleaseDate         string              `json:"release_date,omitempty"`
+	Runtime             float64             `json:"runtime"`
+	Vote_average        float64             `json:"vote_average"`
+	Vote_count          int64               `json:"vote_count"`
+	Adult               bool                `json:"adult"`
+	Video               bool                `json:"video"`
+	Budget              float64             `json:"budget"`
+	Revenue             float64             `json:"revenue"`
+	Homepage            string              `json:"homepage,omitempty"`
+	PosterPath          string              `json:"poster_path,omitempty"`
+	BelongsToCollection *Collection         `json:"belongs_to_collection"`
+	ProductionCompanies []ProductionCompany `json:"production_companies"`
+	ProductionCountries []ProductionCountry `json:"production_countries"`
 }
 
 func ConvertMovieDBToMovie(m MovieDB) Movie {
-	return Movie{
-		ID:               m.ID,
-		IMDB_ID:          nullStringToString(m.IMDB_ID),
-		OriginalTitle:    m.OriginalTitle,
-		OriginalLanguage: m.OriginalLanguage,
-		Title:            m.Title,
-		Tagline:          nullStringToString(m.Tagline),
-		Overview:         nullStringToString(m.Overview),
-		Popularity:       m.Popularity,
-		Status:           nullStringToString(m.Status),
-		ReleaseDate:      nullStringToString(m.ReleaseDate),
-		Runtime:          nullFloatToFloat(m.Runtime),
-		Vote_average:     m.Vote_average,
-		Vote_count:       m.Vote_count,
+	movie := Movie{
+		ID:                  m.ID,
+		IMDB_ID:             nullStringToString(m.IMDB_ID),
+		OriginalTitle:       m.OriginalTitle,
+		OriginalLanguage:    m.OriginalLanguage,
+		Title:               m.Title,
+		Tagline:             nullStringToString(m.Tagline),
+		Overview:            nullStringToString(m.Overview),
+		Popularity:          m.Popularity,
+		Status:              nullStringToString(m.Status),
+		ReleaseDate:         nullStringToString(m.ReleaseDate),
+		Runtime:             nullFloatToFloat(m.Runtime),
+		Vote_average:        m.Vote_average,
+		Vote_count:          m.Vote_count,
+		Adult:               m.Adult.Bool,
+		Video:               m.Video.Bool,
+		Budget:              nullFloatToFloat(m.Budget),
+		Revenue:             nullFloatToFloat(m.Revenue),
+		Homepage:            nullStringToString(m.Homepage),
+		PosterPath:          nullStringToString(m.PosterPath),
+		ProductionCompanies: []ProductionCompany{},
+		ProductionCountries: []ProductionCountry{},
+	}
+
+	// Seeded values of another shape, such as the collection being a list, are left out
+	var collection Collection
+	if json.Unmarshal(m.BelongsToCollection, &collection) == nil && collection.ID != 0 {
+		movie.BelongsToCollection = &collection
 	}
+	var companies []ProductionCompany
+	if json.Unmarshal(m.ProductionCompanies, &companies) == nil && companies != nil {
+		movie.ProductionCompanies = companies
+	}
+	var countries []ProductionCountry
+	if json.Unmarshal(m.ProductionCountries, &countries) == nil && countries != nil {
+		movie.ProductionCountries = countries
+	}
+	return movie
 }
 
 func nullFloatToFloat(ns sql.NullFloat64) float64 {
@@ -77,7 +173,7 @@ func nullStringToString(ns sql.NullString) string {
 }
 
 type MovieModel struct {
-	db *goqu.Database
+	db Session
 }
 
 func InitMovieModel(goqu *goqu.Database) (*MovieModel, error) {
@@ -86,10 +182,25 @@ func InitMovieModel(goqu *goqu.Database) (*MovieModel, error) {
 	}, nil
 }
 
+// WithTx returns the model running its statements in tx
+func (m *MovieModel) WithTx(tx *goqu.TxDatabase) *MovieModel {
+	return &MovieModel{db: tx}
+}
+
+// LockMovie locks the row of the movie having id until the transaction of the model ends, so the
+// movie read after it stays as it is until the writes of the transaction
+func (m *MovieModel) LockMovie(id int) error {
+	_, err := m.db.From(MovieTable).Select("id").Where(goqu.C("id").Eq(id)).ForUpdate(exp.Wait).Executor().Exec()
+	if err != nil {
+		return fmt.Errorf("failed to lock movie: %w", err)
+	}
+	return nil
+}
+
 func (m *MovieModel) GetMovie(id string) (Movie, error) {
 	var movieDB MovieDB
-	found, err := m.db.From(MovieTable).Where(goqu.Ex{"id": id}).
-		Select("id", "imdb_id", "original_title", "original_language", "title", "status", "vote_average", "vote_count", "popularity", "release_date", "tagline", "overview", "runtime").
+	found, err := m.db.From(MovieTable).Where(goqu.Ex{"id": id, "deleted_at": nil}).
+		Select(selectMovieColumns()...).
 		ScanStruct(&movieDB)
 	if err != nil {
 		return Movie{}, err
@@ -102,61 +213,225 @@ func (m *MovieModel) GetMovie(id string) (Movie, error) {
 	return ConvertMovieDBToMovie(movieDB), nil
 }
 
-func (m *MovieModel) ListMovies(filters map[string]string, page, limit uint) ([]Movie, error) {
-	var movieDBs []MovieDB
-	var movies []Movie
+// filteredMovies selects the live movies matching filter, along with their genres and languages
+// joined in, so a movie is selected once per genre and language
+func filteredMovies(db Session, filter movieFilter) *goqu.SelectDataset {
+	ds := db.From(MovieTable).
+		Join(goqu.T("movie_genres"), goqu.On(goqu.T("movies").Col("id").Eq(goqu.T("movie_genres").Col("movieid")))).
+		Join(goqu.T("genres"), goqu.On(goqu.T("movie_genres").Col("genreid").Eq(goqu.T("genres").Col("id")))).
+		Join(goqu.T("movie_languages"), goqu.On(goqu.T(MovieTable).Col("id").Eq(goqu.T("movie_languages").Col("movieid")))).
+		Where(live(MovieTable))
 
-	ds := m.db.From(MovieTable).
-		Select(goqu.DISTINCT("movies.id"), "imdb_id", "original_language", "original_title", "title", "status", "vote_average", "vote_count", "popularity", "release_date", "tagline", "overview", "runtime")
+	if filter.language != "" {
+		ds = ds.Where(goqu.T("movie_languages").Col("language_code").Eq(filter.language))
+	}
 
-	ds = ds.Join(goqu.T("movie_genres"), goqu.On(goqu.T("movies").Col("id").Eq(goqu.T("movie_genres").Col("movieid")))).
-		Join(goqu.T("genres"), goqu.On(goqu.T("movie_genres").Col("genreid").Eq(goqu.T("genres").Col("id")))).
-		Join(goqu.T("movie_languages"), goqu.On(goqu.T(MovieTable).Col("id").Eq(goqu.T("movie_languages").Col("movieid"))))
+	if len(filter.genres) > 0 {
+		if filter.allGenres {
+			// Movies linked to every genre of the filter
+			ds = ds.Where(goqu.T(MovieTable).Col("id").In(
+				db.From("movie_genres").
+					Select(goqu.T("movie_genres").Col("movieid")).
+					Join(goqu.T("genres"), goqu.On(goqu.T("movie_genres").Col("genreid").Eq(goqu.T("genres").Col("id")))).
+					Where(goqu.Func("LOWER", goqu.T("genres").Col("name")).In(filter.genres)).
+					GroupBy(goqu.T("movie_genres").Col("movieid")).
+					Having(goqu.L("COUNT(DISTINCT LOWER(?))", goqu.T("genres").Col("name")).Eq(len(filter.genres))),
+			))
+		} else {
+			ds = ds.Where(goqu.Func("LOWER", goqu.T("genres").Col("name")).In(filter.genres))
+		}
+	}
+
+	if filter.name != "" {
+		ds = ds.Where(goqu.T(MovieTable).Col("original_title").ILike("%" + filter.name + "%"))
+	}
 
-	if languageFilter, ok := filters["language"]; ok && languageFilter != "" {
-		ds = ds.Where(goqu.T("movie_languages").Col("language_code").Eq(languageFilter))
+	if filter.status != "" {
+		ds = ds.Where(goqu.L("LOWER(?) = LOWER(?)", goqu.T(MovieTable).Col("status"), filter.status))
+	}
+
+	if filter.adult != nil {
+		ds = ds.Where(goqu.T(MovieTable).Col("adult").Eq(*filter.adult))
+	}
+	if filter.video != nil {
+		ds = ds.Where(goqu.T(MovieTable).Col("video").Eq(*filter.video))
+	}
+
+	if filter.collection != 0 {
+		ds = ds.Where(goqu.T(MovieTable).Col("collection_id").Eq(filter.collection))
+	}
+	if filter.company != 0 {
+		ds = ds.Where(goqu.T(MovieTable).Col("id").In(
+			db.From(MovieCompanyTable).Select("movieid").Where(goqu.C("companyid").Eq(filter.company)),
+		))
+	}
+	if filter.country != "" {
+		ds = ds.Where(goqu.T(MovieTable).Col("id").In(
+			db.From(MovieCountryTable).Select("movieid").Where(goqu.C("country_code").Eq(filter.country)),
+		))
+	}
+
+	for _, bounds := range filter.ranges {
+		if bounds.min != nil {
+			ds = ds.Where(goqu.T(MovieTable).Col(bounds.column).Gte(bounds.min))
+		}
+		if bounds.max != nil {
+			ds = ds.Where(goqu.T(MovieTable).Col(bounds.column).Lte(bounds.max))
+		}
 	}
 
-	if genreFilter, ok := filters["genre"]; ok && genreFilter != "" {
-		ds = ds.Where(goqu.L("LOWER(?) = LOWER(?)", goqu.T("genres").Col("name"), genreFilter))
+	return ds
+}
+
+func (m *MovieModel) ListMovies(filters map[string]string, page PageRequest) (Page[Movie], error) {
+	var movieDBs []MovieDB
+
+	filter, err := parseMovieFilter(filters)
+	if err != nil {
+		return Page[Movie]{}, err
+	}
+	if err := page.CheckKey(len(filter.sort)); err != nil {
+		return Page[Movie]{}, err
 	}
 
-	if nameFilter, ok := filters["name"]; ok && nameFilter != "" {
-		ds = ds.Where(goqu.T(MovieTable).Col("original_title").ILike("%" + nameFilter + "%"))
+	ds := filteredMovies(m.db, filter).Select(selectMovieColumns()...).Distinct()
+
+	var total *int64
+	if page.Total {
+		var count int64
+		_, err := ds.ClearSelect().Select(goqu.COUNT(goqu.DISTINCT(goqu.T(MovieTable).Col("id")))).ScanVal(&count)
+		if err != nil {
+			return Page[Movie]{}, fmt.Errorf("error counting movies: %w", err)
+		}
+		total = &count
 	}
 
-	ds = ds.Offset((page - 1) * limit).Limit(limit)
+	// Sorted columns are part of the DISTINCT select list
+	columns := make([]keysetColumn, 0, len(filter.sort))
+	for _, order := range filter.sort {
+		columns = append(columns, keysetColumn{expr: goqu.T(MovieTable).Col(order.column), desc: order.desc})
+	}
 
 	// Scan into MovieDB structs
-	err := ds.ScanStructs(&movieDBs)
+	err = keysetPage(ds, columns, page).ScanStructs(&movieDBs)
 	if err != nil {
-		return nil, fmt.Errorf("error fetching movies: %w", err)
+		return Page[Movie]{}, fmt.Errorf("error fetching movies: %w", err)
 	}
 
+	rows := keysetRows(movieDBs, page, filter.key)
+
 	// Convert each MovieDB to Movie
-	for _, mdb := range movieDBs {
+	movies := make([]Movie, 0, len(rows.Items))
+	for _, mdb := range rows.Items {
 		movies = append(movies, ConvertMovieDBToMovie(mdb))
 	}
 
-	return movies, nil
+	return Page[Movie]{Items: movies, Limit: rows.Limit, Next: rows.Next, Prev: rows.Prev, Total: total}, nil
 }
 
+// DeleteMovie soft deletes the movie having id along with its ratings, cast and crew. They are
+// all stamped with the start time of the transaction, which is how RestoreMovie tells them apart
+// from the ratings deleted before.
 func (m *MovieModel) DeleteMovie(id int) error {
-	res, err := m.db.From(MovieTable).
-		Delete().
-		Where(goqu.Ex{"id": id}).
-		Executor().
-		Exec()
+	return inTx(m.db, func(tx *goqu.TxDatabase) error {
+		res, err := tx.Update(MovieTable).
+			Set(goqu.Record{"deleted_at": goqu.L("now()")}).
+			Where(goqu.Ex{"id": id, "deleted_at": nil}).
+			Executor().
+			Exec()
+		if err != nil {
+			return fmt.Errorf("failed to delete movie: %w", err)
+		}
+		if rowsAffected, _ := res.RowsAffected(); rowsAffected == 0 {
+			return sql.ErrNoRows
+		}
 
-	if err != nil {
-		return fmt.Errorf("failed to delete movie: %w", err)
-	}
+		for _, table := range []string{RatingsTable, CastTable, CrewTable} {
+			_, err = tx.Update(table).
+				Set(goqu.Record{"deleted_at": goqu.L("now()")}).
+				Where(goqu.Ex{"movie_id": id, "deleted_at": nil}).
+				Executor().
+				Exec()
+			if err != nil {
+				return fmt.Errorf("failed to delete %s of movie: %w", table, err)
+			}
+		}
+		return nil
+	})
+}
+
+// RestoreMovie brings back the soft deleted movie having id along with the ratings, cast and
+// crew deleted with it
+func (m *MovieModel) RestoreMovie(id int) error {
+	return inTx(m.db, func(tx *goqu.TxDatabase) error {
+		var deletedAt sql.NullTime
+		found, err := tx.From(MovieTable).
+			Select("deleted_at").
+			Where(goqu.Ex{"id": id}).
+			ForUpdate(exp.Wait).
+			ScanVal(&deletedAt)
+		if err != nil {
+			return fmt.Errorf("failed to fetch movie: %w", err)
+		}
+		if !found {
+			return sql.ErrNoRows
+		}
+		if !deletedAt.Valid {
+			return ErrMovieNotDeleted
+		}
+
+		for _, table := range []string{RatingsTable, CastTable, CrewTable} {
+			_, err = tx.Update(table).
+				Set(goqu.Record{"deleted_at": nil}).
+				Where(goqu.Ex{"movie_id": id, "deleted_at": deletedAt.Time}).
+				Executor().
+				Exec()
+			if err != nil {
+				return fmt.Errorf("failed to restore %s of movie: %w", table, err)
+			}
+		}
+
+		_, err = tx.Update(MovieTable).
+			Set(goqu.Record{"deleted_at": nil}).
+			Where(goqu.Ex{"id": id}).
+			Executor().
+			Exec()
+		if err != nil {
+			return fmt.Errorf("failed to restore movie: %w", err)
+		}
+		return nil
+	})
+}
 
-	rowsAffected, _ := res.RowsAffected()
-	if rowsAffected == 0 {
-		return sql.ErrNoRows
+// PurgeDeleted removes for good the movies, ratings and credits soft deleted before before
+func (m *MovieModel) PurgeDeleted(before time.Time) (PurgeCounts, error) {
+	var counts PurgeCounts
+	err := inTx(m.db, func(tx *goqu.TxDatabase) error {
+		// Children go first, purging a movie cascades to rows deleted after it otherwise
+		for _, purge := range []struct {
+			table string
+			count *int64
+		}{
+			{RatingsTable, &counts.Ratings},
+			{CastTable, &counts.Casts},
+			{CrewTable, &counts.Crew},
+			{MovieTable, &counts.Movies},
+		} {
+			res, err := tx.Delete(purge.table).
+				Where(goqu.C("deleted_at").Lt(before)).
+				Executor().
+				Exec()
+			if err != nil {
+				return fmt.Errorf("failed to purge %s: %w", purge.table, err)
+			}
+			*purge.count, _ = res.RowsAffected()
+		}
+		return nil
+	})
+	if err != nil {
+		return PurgeCounts{}, err
 	}
-	return nil
+	return counts, nil
 }
 
 func ValidateReleaseDate(fl validator.FieldLevel) bool {
@@ -166,18 +441,78 @@ func ValidateReleaseDate(fl validator.FieldLevel) bool {
 }
 
 type MovieWithMetadata struct {
-	OriginalTitle    string   `json:"original_title" validate:"required"`
-	OriginalLanguage string   `json:"original_language" validate:"required"`
-	Title            string   `json:"title" validate:"required"`
-	Overview         string   `json:"overview"`
-	Popularity       float64  `json:"popularity" validate:"required,gte=0"`
-	Status           string   `json:"status"`
-	ReleaseDate      string   `json:"release_date" validate:"required,releaseDateFormat"`
-	Runtime          float64  `json:"runtime" validate:"gte=0"`
-	Vote_average     float64  `json:"vote_average" validate:"required,gte=0"`
-	Vote_count       int64    `json:"vote_count" validate:"required,gte=0"`
-	Genres           []string `json:"genres"`
-	Languages        []string `json:"languages"`
+	OriginalTitle       string              `json:"original_title" validate:"required"`
+	OriginalLanguage    string              `json:"original_language" validate:"required"`
+	Title               string              `json:"title" validate:"required"`
+	Overview            string              `json:"overview"`
+	Popularity          float64             `json:"popularity" validate:"required,gte=0"`
+	Status              string              `json:"status"`
+	ReleaseDate         string              `json:"release_date" validate:"required,releaseDateFormat"`
+	Runtime             float64             `json:"runtime" validate:"gte=0"`
+	Vote_average        float64             `json:"vote_average" validate:"required,gte=0"`
+	Vote_count          int64               `json:"vote_count" validate:"required,gte=0"`
+	Adult               bool                `json:"adult"`
+	Video               bool                `json:"video"`
+	Budget              float64             `json:"budget" validate:"gte=0"`
+	Revenue             float64             `json:"revenue" validate:"gte=0"`
+	Homepage            string              `json:"homepage" validate:"omitempty,url,max=255"`
+	PosterPath          string              `json:"poster_path" validate:"omitempty,startswith=/,max=255"`
+	BelongsToCollection *Collection         `json:"belongs_to_collection"`
+	ProductionCompanies []ProductionCompany `json:"production_companies" validate:"dive"`
+	ProductionCountries []ProductionCountry `json:"production_countries" validate:"dive"`
+	Genres              []string            `json:"genres"`
+	Languages           []string            `json:"languages"`
+}
+
+// movieRecord returns the columns of movies written from movie, the collection, companies and
+// countries being JSON
+func movieRecord(movie *MovieWithMetadata) (goqu.Record, error) {
+	record := goqu.Record{
+		"original_title":    movie.OriginalTitle,
+		"original_language": movie.OriginalLanguage,
+		"title":             movie.Title,
+		"overview":          movie.Overview,
+		"popularity":        movie.Popularity,
+		"status":            movie.Status,
+		"release_date":      movie.ReleaseDate,
+		"runtime":           movie.Runtime,
+		"vote_average":      movie.Vote_average,
+		"vote_count":        movie.Vote_count,
+		"adult":             movie.Adult,
+		"video":             movie.Video,
+		"budget":            movie.Budget,
+		"revenue":           movie.Revenue,
+		"homepage":          movie.Homepage,
+		"poster_path":       movie.PosterPath,
+	}
+
+	record["belongs_to_collection"] = nil
+	record["collection_id"] = nil
+	if movie.BelongsToCollection != nil {
+		collection, err := json.Marshal(movie.BelongsToCollection)
+		if err != nil {
+			return nil, err
+		}
+		record["belongs_to_collection"] = string(collection)
+		record["collection_id"] = movie.BelongsToCollection.ID
+	}
+	// Movies without companies or countries have empty lists, as in the Kaggle files
+	companies, countries := movie.ProductionCompanies, movie.ProductionCountries
+	if companies == nil {
+		companies = []ProductionCompany{}
+	}
+	if countries == nil {
+		countries = []ProductionCountry{}
+	}
+	for column, value := range map[string]interface{}{"production_companies": companies, "production_countries": countries} {
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		record[column] = string(encoded)
+	}
+
+	return record, nil
 }
 
 func getNextID(tx *goqu.TxDatabase, tableName string) (int64, error) {
@@ -192,56 +527,45 @@ func getNextID(tx *goqu.TxDatabase, tableName string) (int64, error) {
 	return maxID + 1, nil
 }
 
-func (m *MovieModel) AddMovie(movie *MovieWithMetadata) error {
-	tx, err := m.db.Begin()
-	if err != nil {
-		return err
-	}
-
-	defer func() {
+// AddMovie adds movie along with its genres and languages, returning the id given to it
+func (m *MovieModel) AddMovie(movie *MovieWithMetadata) (int64, error) {
+	var movieID int64
+	err := inTx(m.db, func(tx *goqu.TxDatabase) error {
+		var err error
+		movieID, err = getNextID(tx, MovieTable)
 		if err != nil {
-			tx.Rollback()
+			return fmt.Errorf("failed to get next movie ID: %w", err)
 		}
-	}()
-
-	movieID, err := getNextID(tx, MovieTable)
-	if err != nil {
-		return fmt.Errorf("failed to get next movie ID: %w", err)
-	}
-
-	_, err = tx.Insert(MovieTable).Rows(goqu.Record{
-		"id":                movieID,
-		"original_title":    movie.OriginalTitle,
-		"original_language": movie.OriginalLanguage,
-		"title":             movie.Title,
-		"overview":          movie.Overview,
-		"popularity":        movie.Popularity,
-		"status":            movie.Status,
-		"release_date":      movie.ReleaseDate,
-		"runtime":           movie.Runtime,
-		"vote_average":      movie.Vote_average,
-		"vote_count":        movie.Vote_count,
-	}).Executor().Exec()
 
-	if err != nil {
-		return fmt.Errorf("failed to insert movie: %w", err)
-	}
+		record, err := movieRecord(movie)
+		if err != nil {
+			return fmt.Errorf("failed to encode movie: %w", err)
+		}
+		record["id"] = movieID
 
-	err = m.handleGenres(tx, movieID, movie.Genres)
-	if err != nil {
-		return err
-	}
+		if err := m.handleCollection(tx, record, movie.BelongsToCollection); err != nil {
+			return err
+		}
 
-	err = m.handleLanguages(tx, movieID, movie.Languages)
-	if err != nil {
-		return err
-	}
+		if _, err := tx.Insert(MovieTable).Rows(record).Executor().Exec(); err != nil {
+			return fmt.Errorf("failed to insert movie: %w", err)
+		}
 
-	err = tx.Commit()
+		if err := m.handleGenres(tx, movieID, movie.Genres); err != nil {
+			return err
+		}
+		if err := m.handleLanguages(tx, movieID, movie.Languages); err != nil {
+			return err
+		}
+		if err := m.handleCompanies(tx, movieID, movie.ProductionCompanies); err != nil {
+			return err
+		}
+		return m.handleCountries(tx, movieID, movie.ProductionCountries)
+	})
 	if err != nil {
-		return err
+		return 0, err
 	}
-	return nil
+	return movieID, nil
 }
 
 func (m *MovieModel) handleGenres(tx *goqu.TxDatabase, movieID int64, genres []string) error {
@@ -304,57 +628,134 @@ func (m *MovieModel) handleLanguages(tx *goqu.TxDatabase, movieID int64, languag
 	return nil
 }
 
-func (m *MovieModel) UpdateMovie(movieID int, movie *MovieWithMetadata) error {
-	tx, err := m.db.Begin()
-	if err != nil {
-		return err
-	}
-	defer func() {
+// handleCompanies links the movie to companies, adding the companies not known yet. Known
+// companies keep their names.
+func (m *MovieModel) handleCompanies(tx *goqu.TxDatabase, movieID int64, companies []ProductionCompany) error {
+	for _, company := range companies {
+		_, err := tx.Insert(CompanyTable).
+			Rows(goqu.Record{"id": company.ID, "name": company.Name}).
+			OnConflict(goqu.DoNothing()).
+			Executor().Exec()
 		if err != nil {
-			tx.Rollback()
-		}
-	}()
-
-	row, err := tx.Update(MovieTable).
-		Set(goqu.Record{
-			"original_title":    movie.OriginalTitle,
-			"original_language": movie.OriginalLanguage,
-			"title":             movie.Title,
-			"overview":          movie.Overview,
-			"popularity":        movie.Popularity,
-			"status":            movie.Status,
-			"release_date":      movie.ReleaseDate,
-			"runtime":           movie.Runtime,
-			"vote_average":      movie.Vote_average,
-			"vote_count":        movie.Vote_count,
-		}).
-		Where(goqu.C("id").Eq(movieID)).
-		Executor().Exec()
-	if err != nil {
-		return fmt.Errorf("failed to update movie: %w", err)
-	}
-	if rowsAffected, _ := row.RowsAffected(); rowsAffected == 0 {
-		return sql.ErrNoRows
+			return fmt.Errorf("failed to insert company: %w", err)
+		}
+		_, err = tx.Insert(MovieCompanyTable).Rows(goqu.Record{
+			"movieid":   movieID,
+			"companyid": company.ID,
+		}).OnConflict(goqu.DoNothing()).Executor().Exec()
+		if err != nil {
+			return fmt.Errorf("failed to link movie and company: %w", err)
+		}
 	}
+	return nil
+}
 
-	if _, err = tx.Delete("movie_genres").Where(goqu.C("movieid").Eq(movieID)).Executor().Exec(); err != nil {
-		return fmt.Errorf("failed to delete old genres: %w", err)
+// handleCollection adds the collection of record unless it is known already, known collections
+// keep their names and images, which the belongs_to_collection column of record then holds
+func (m *MovieModel) handleCollection(tx *goqu.TxDatabase, record goqu.Record, collection *Collection) error {
+	if collection == nil {
+		return nil
 	}
-	if err = m.handleGenres(tx, int64(movieID), movie.Genres); err != nil {
-		return err
+	row := goqu.Record{"id": collection.ID, "name": collection.Name, "poster_path": nil, "backdrop_path": nil}
+	if collection.PosterPath != "" {
+		row["poster_path"] = collection.PosterPath
 	}
-
-	if _, err = tx.Delete("movie_languages").Where(goqu.C("movieid").Eq(movieID)).Executor().Exec(); err != nil {
-		return fmt.Errorf("failed to delete old languages: %w", err)
+	if collection.BackdropPath != "" {
+		row["backdrop_path"] = collection.BackdropPath
 	}
-	if err = m.handleLanguages(tx, int64(movieID), movie.Languages); err != nil {
-		return err
+	_, err := tx.Insert(CollectionTable).Rows(row).OnConflict(goqu.DoNothing()).Executor().Exec()
+	if err != nil {
+		return fmt.Errorf("failed to insert collection: %w", err)
 	}
 
-	err = tx.Commit()
+	var stored Collection
+	_, err = tx.From(CollectionTable).
+		Select(collectionColumns()...).
+		Where(goqu.C("id").Eq(collection.ID)).
+		ScanStruct(&stored)
+	if err != nil {
+		return fmt.Errorf("error fetching collection: %w", err)
+	}
+	encoded, err := json.Marshal(stored)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to encode collection: %w", err)
 	}
+	record["belongs_to_collection"] = string(encoded)
+	return nil
+}
 
+// handleCountries links the movie to countries, adding the countries not known yet, named after
+// their code when the name is left out
+func (m *MovieModel) handleCountries(tx *goqu.TxDatabase, movieID int64, countries []ProductionCountry) error {
+	for _, country := range countries {
+		name := country.Name
+		if name == "" {
+			name = country.ISO3166_1
+		}
+		_, err := tx.Insert(CountryTable).
+			Rows(goqu.Record{"iso_code": country.ISO3166_1, "name": name}).
+			OnConflict(goqu.DoNothing()).
+			Executor().Exec()
+		if err != nil {
+			return fmt.Errorf("failed to insert country: %w", err)
+		}
+		_, err = tx.Insert(MovieCountryTable).Rows(goqu.Record{
+			"movieid":      movieID,
+			"country_code": country.ISO3166_1,
+		}).OnConflict(goqu.DoNothing()).Executor().Exec()
+		if err != nil {
+			return fmt.Errorf("failed to link movie and country: %w", err)
+		}
+	}
 	return nil
 }
+
+func (m *MovieModel) UpdateMovie(movieID int, movie *MovieWithMetadata) error {
+	return inTx(m.db, func(tx *goqu.TxDatabase) error {
+		record, err := movieRecord(movie)
+		if err != nil {
+			return fmt.Errorf("failed to encode movie: %w", err)
+		}
+
+		if err := m.handleCollection(tx, record, movie.BelongsToCollection); err != nil {
+			return err
+		}
+
+		row, err := tx.Update(MovieTable).
+			Set(record).
+			Where(goqu.C("id").Eq(movieID), goqu.C("deleted_at").IsNull()).
+			Executor().Exec()
+		if err != nil {
+			return fmt.Errorf("failed to update movie: %w", err)
+		}
+		if rowsAffected, _ := row.RowsAffected(); rowsAffected == 0 {
+			return sql.ErrNoRows
+		}
+
+		if _, err := tx.Delete("movie_genres").Where(goqu.C("movieid").Eq(movieID)).Executor().Exec(); err != nil {
+			return fmt.Errorf("failed to delete old genres: %w", err)
+		}
+		if err := m.handleGenres(tx, int64(movieID), movie.Genres); err != nil {
+			return err
+		}
+
+		if _, err := tx.Delete("movie_languages").Where(goqu.C("movieid").Eq(movieID)).Executor().Exec(); err != nil {
+			return fmt.Errorf("failed to delete old languages: %w", err)
+		}
+		if err := m.handleLanguages(tx, int64(movieID), movie.Languages); err != nil {
+			return err
+		}
+
+		if _, err := tx.Delete(MovieCompanyTable).Where(goqu.C("movieid").Eq(movieID)).Executor().Exec(); err != nil {
+			return fmt.Errorf("failed to delete old companies: %w", err)
+		}
+		if err := m.handleCompanies(tx, int64(movieID), movie.ProductionCompanies); err != nil {
+			return err
+		}
+
+		if _, err := tx.Delete(MovieCountryTable).Where(goqu.C("movieid").Eq(movieID)).Executor().Exec(); err != nil {
+			return fmt.Errorf("failed to delete old countries: %w", err)
+		}
+		return m.handleCountries(tx, int64(movieID), movie.ProductionCountries)
+	})
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// ErrInvalidCursor is returned for a cursor token that cannot be decoded or does not fit the list
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is an opaque position in a sorted list, it holds the sort key of the row a page
+// starts after, or ends before when Before is set
+type Cursor struct {
+	Key    []string `json:"k"`
+	Before bool     `json:"b,omitempty"`
+}
+
+// Encode returns the token of the cursor handed out to clients
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a token returned by Cursor.Encode
+func DecodeCursor(token string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || len(cursor.Key) == 0 {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// PageRequest asks for the page of a list next to Cursor, or the first page without a cursor
+type PageRequest struct {
+	Cursor *Cursor
+	Limit  uint
+	// Total asks for the number of rows of the whole list
+	Total bool
+}
+
+// CheckKey validates the cursor of the request against a list sorted on size key columns
+func (r PageRequest) CheckKey(size int) error {
+	if r.Cursor != nil && len(r.Cursor.Key) != size {
+		return ErrInvalidCursor
+	}
+	return nil
+}
+
+// Page is a page of a list along with the cursors of its neighbours
+type Page[T any] struct {
+	Items []T
+	Limit uint
+	Next  *Cursor
+	Prev  *Cursor
+	Total *int64
+}
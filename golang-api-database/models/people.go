@@ -0,0 +1,368 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+)
+
+// Roles of a person in the filmography
+const (
+	RoleCast = "cast"
+	RoleCrew = "crew"
+)
+
+// actingDepartment is the department of cast credits, as crew departments are named
+const actingDepartment = "Acting"
+
+// filmographyTable is the alias of the cast and crew credits of a person
+const filmographyTable = "filmography"
+
+// ErrPersonCredited is returned when deleting a person credited on movies without deleting their
+// credits along
+var ErrPersonCredited = errors.New("person is credited on movies")
+
+// Person is a member of the cast or crew of movies. Gender is 0 when unknown, then 1 for female,
+// 2 for male and 3 for non-binary, as in the dataset.
+type Person struct {
+	ID          int    `db:"id" json:"id"`
+	Name        string `db:"name" json:"name" validate:"required,max=255"`
+	Gender      int    `db:"gender" json:"gender" validate:"gte=0,lte=3"`
+	ProfilePath string `db:"profile_path" json:"profile_path,omitempty" validate:"max=255"`
+}
+
+// FilmographyEntry is a credit of a person on a live movie: the character played in the cast or
+// the job held in the crew. Rating is the mean of the ratings of the movie, 0 when it was not
+// rated.
+type FilmographyEntry struct {
+	CreditID    string  `db:"credit_id" json:"credit_id"`
+	MovieID     int     `db:"movie_id" json:"movie_id"`
+	Title       string  `db:"title" json:"title"`
+	Role        string  `db:"role" json:"role"`
+	Character   string  `db:"character" json:"character,omitempty"`
+	Job         string  `db:"job" json:"job,omitempty"`
+	Department  string  `db:"department" json:"department"`
+	ReleaseYear *int64  `db:"release_year" json:"release_year"`
+	Rating      float64 `db:"rating" json:"rating"`
+	RatingCount int64   `db:"rating_count" json:"rating_count"`
+}
+
+// PersonWithFilmography is a person along with a page of their filmography
+type PersonWithFilmography struct {
+	Person
+	Filmography []FilmographyEntry `json:"filmography"`
+}
+
+// filmographySortColumns are the columns of the filmography accepted by sort
+var filmographySortColumns = map[string]string{
+	"year":   "release_year",
+	"title":  "title",
+	"rating": "rating",
+}
+
+// filmographyFilter is the parsed form of the filters given to GetPerson:
+//
+//	role         cast or crew
+//	department   the department of the credits, Acting being the one of cast credits
+//	sort         comma separated year, title and rating, prefixed with "-" for descending
+//	             order, -year by default. Credits are always sorted by credit id last.
+type filmographyFilter struct {
+	role       string
+	department string
+	sort       []movieSort
+}
+
+func parseFilmographyFilter(filters map[string]string) (filmographyFilter, error) {
+	filter := filmographyFilter{
+		role:       strings.ToLower(strings.TrimSpace(filters["role"])),
+		department: strings.TrimSpace(filters["department"]),
+	}
+	if filter.role != "" && filter.role != RoleCast && filter.role != RoleCrew {
+		return filmographyFilter{}, fmt.Errorf("%w: role must be cast or crew, got %q", ErrInvalidFilter, filter.role)
+	}
+
+	sort := filters["sort"]
+	if strings.TrimSpace(sort) == "" {
+		sort = "-year"
+	}
+	for _, key := range strings.Split(sort, ",") {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		column, ok := filmographySortColumns[strings.TrimPrefix(key, "-")]
+		if !ok {
+			return filmographyFilter{}, fmt.Errorf("%w: cannot sort by %q", ErrInvalidFilter, strings.TrimPrefix(key, "-"))
+		}
+		filter.sort = append(filter.sort, movieSort{column: column, desc: strings.HasPrefix(key, "-")})
+	}
+	// Ties are broken by credit id, so credits have a total order to paginate over
+	filter.sort = append(filter.sort, movieSort{column: "credit_id"})
+
+	return filter, nil
+}
+
+// key returns the values of entry the sort of f is made of, as carried in cursors where NULL is
+// an empty string
+func (f filmographyFilter) key(entry FilmographyEntry) []string {
+	key := make([]string, len(f.sort))
+	for i, order := range f.sort {
+		switch order.column {
+		case "release_year":
+			if entry.ReleaseYear != nil {
+				key[i] = strconv.FormatInt(*entry.ReleaseYear, 10)
+			}
+		case "title":
+			key[i] = entry.Title
+		case "rating":
+			key[i] = strconv.FormatFloat(entry.Rating, 'f', -1, 64)
+		case "credit_id":
+			key[i] = entry.CreditID
+		}
+	}
+	return key
+}
+
+// PeopleModel reads the people credited on movies
+type PeopleModel struct {
+	db Session
+}
+
+func InitPeopleModel(goqu *goqu.Database) (*PeopleModel, error) {
+	return &PeopleModel{
+		db: goqu,
+	}, nil
+}
+
+// WithTx returns the model running its statements in tx
+func (m *PeopleModel) WithTx(tx *goqu.TxDatabase) *PeopleModel {
+	return &PeopleModel{db: tx}
+}
+
+// personColumns select the columns of credits read into Person
+func personColumns() []interface{} {
+	return []interface{}{
+		"id",
+		goqu.COALESCE(goqu.C("name"), "").As("name"),
+		goqu.COALESCE(goqu.C("gender"), 0).As("gender"),
+		goqu.COALESCE(goqu.C("profile_path"), "").As("profile_path"),
+	}
+}
+
+// personRecord is the row of credits holding person, an empty profile path being NULL
+func personRecord(person Person) goqu.Record {
+	record := goqu.Record{
+		"name":         person.Name,
+		"gender":       person.Gender,
+		"profile_path": nil,
+	}
+	if person.ProfilePath != "" {
+		record["profile_path"] = person.ProfilePath
+	}
+	return record
+}
+
+// ListPeople lists the people whose name contains name, all of them when it is empty, by id
+func (m *PeopleModel) ListPeople(name string, page PageRequest) (Page[Person], error) {
+	var people []Person
+	if err := page.CheckKey(1); err != nil {
+		return Page[Person]{}, err
+	}
+
+	ds := m.db.From(CreditsTable).Select(personColumns()...)
+	if name = strings.TrimSpace(name); name != "" {
+		ds = ds.Where(goqu.C("name").ILike("%" + name + "%"))
+	}
+
+	var total *int64
+	if page.Total {
+		count, err := ds.Count()
+		if err != nil {
+			return Page[Person]{}, fmt.Errorf("error counting people: %w", err)
+		}
+		total = &count
+	}
+
+	columns := []keysetColumn{{expr: goqu.T(CreditsTable).Col("id")}}
+	if err := keysetPage(ds, columns, page).ScanStructs(&people); err != nil {
+		return Page[Person]{}, fmt.Errorf("error fetching people: %w", err)
+	}
+
+	result := keysetRows(people, page, func(person Person) []string {
+		return []string{strconv.Itoa(person.ID)}
+	})
+	result.Total = total
+	return result, nil
+}
+
+// GetPersonByID returns the person having id, sql.ErrNoRows when there is none
+func (m *PeopleModel) GetPersonByID(id int) (Person, error) {
+	var person Person
+	found, err := m.db.From(CreditsTable).Select(personColumns()...).Where(goqu.C("id").Eq(id)).ScanStruct(&person)
+	if err != nil {
+		return Person{}, fmt.Errorf("error fetching person: %w", err)
+	}
+	if !found {
+		return Person{}, sql.ErrNoRows
+	}
+	return person, nil
+}
+
+// AddPerson adds person, whose id is taken from the credits id sequence so concurrent adds never
+// get the same one, and sets it on person
+func (m *PeopleModel) AddPerson(person *Person) error {
+	var id int
+	_, err := m.db.Insert(CreditsTable).Rows(personRecord(*person)).
+		Returning("id").Executor().ScanVal(&id)
+	if err != nil {
+		return fmt.Errorf("failed to insert person: %w", err)
+	}
+	person.ID = id
+	return nil
+}
+
+// UpdatePerson replaces the name, gender and profile path of the person having id. It returns
+// sql.ErrNoRows when the person does not exist.
+func (m *PeopleModel) UpdatePerson(id int, person Person) error {
+	result, err := m.db.Update(CreditsTable).Set(personRecord(person)).
+		Where(goqu.C("id").Eq(id)).
+		Executor().Exec()
+	if err != nil {
+		return fmt.Errorf("failed to update person: %w", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeletePerson deletes the person having id. A person credited on movies, deleted ones included,
+// is deleted only when cascade is set, their cast and crew credits going along, and
+// ErrPersonCredited is returned otherwise. It returns sql.ErrNoRows when the person does not
+// exist.
+func (m *PeopleModel) DeletePerson(id int, cascade bool) error {
+	return inTx(m.db, func(tx *goqu.TxDatabase) error {
+		// The lock holds back credits added meanwhile, they would be deleted unchecked
+		var locked int
+		found, err := tx.From(CreditsTable).Select("id").Where(goqu.C("id").Eq(id)).ForUpdate(exp.Wait).ScanVal(&locked)
+		if err != nil {
+			return fmt.Errorf("failed to lock person: %w", err)
+		}
+		if !found {
+			return sql.ErrNoRows
+		}
+
+		if !cascade {
+			for _, table := range []string{CastTable, CrewTable} {
+				var credited bool
+				_, err := tx.Select(goqu.L("EXISTS ?", tx.From(table).Where(goqu.C("person_id").Eq(id)))).ScanVal(&credited)
+				if err != nil {
+					return fmt.Errorf("failed to check credits of person: %w", err)
+				}
+				if credited {
+					return ErrPersonCredited
+				}
+			}
+		}
+
+		// The cast and crew credits of the person are deleted along by their foreign keys
+		if _, err := tx.Delete(CreditsTable).Where(goqu.C("id").Eq(id)).Executor().Exec(); err != nil {
+			return fmt.Errorf("failed to delete person: %w", err)
+		}
+		return nil
+	})
+}
+
+// credits selects the live credits of table held by personID on live movies, role and details
+// being the columns telling cast and crew credits apart
+func (m *PeopleModel) credits(table string, personID int, role string, details ...interface{}) *goqu.SelectDataset {
+	ratings := func() *goqu.SelectDataset {
+		return m.db.From(RatingsTable).
+			Where(goqu.T(RatingsTable).Col("movie_id").Eq(goqu.T(MovieTable).Col("id")), live(RatingsTable))
+	}
+
+	columns := []interface{}{
+		goqu.T(table).Col("credit_id"),
+		goqu.T(table).Col("movie_id"),
+		goqu.T(MovieTable).Col("title"),
+		goqu.V(role).As("role"),
+	}
+	columns = append(columns, details...)
+	columns = append(columns,
+		goqu.L("EXTRACT(YEAR FROM ?)::integer", goqu.T(MovieTable).Col("release_date")).As("release_year"),
+		ratings().Select(goqu.L("COALESCE(ROUND(AVG(?)::numeric, 2), 0)", goqu.T(RatingsTable).Col("rating"))).As("rating"),
+		ratings().Select(goqu.COUNT(goqu.Star())).As("rating_count"),
+	)
+
+	return m.db.From(table).Select(columns...).
+		Join(goqu.T(MovieTable), goqu.On(goqu.T(table).Col("movie_id").Eq(goqu.T(MovieTable).Col("id")))).
+		Where(goqu.T(table).Col("person_id").Eq(personID), live(table), live(MovieTable))
+}
+
+// GetPerson returns the person having id along with a page of their filmography, cast and crew
+// credits merged, matching filters
+func (m *PeopleModel) GetPerson(id int, filters map[string]string, page PageRequest) (*PersonWithFilmography, Page[FilmographyEntry], error) {
+	filter, err := parseFilmographyFilter(filters)
+	if err != nil {
+		return nil, Page[FilmographyEntry]{}, err
+	}
+	if err := page.CheckKey(len(filter.sort)); err != nil {
+		return nil, Page[FilmographyEntry]{}, err
+	}
+
+	person, err := m.GetPersonByID(id)
+	if err != nil {
+		return nil, Page[FilmographyEntry]{}, err
+	}
+
+	cast := m.credits(CastTable, id, RoleCast,
+		goqu.COALESCE(goqu.T(CastTable).Col("character"), "").As("character"),
+		goqu.V("").As("job"),
+		goqu.V(actingDepartment).As("department"),
+	)
+	crew := m.credits(CrewTable, id, RoleCrew,
+		goqu.V("").As("character"),
+		goqu.COALESCE(goqu.T(CrewTable).Col("job"), "").As("job"),
+		goqu.COALESCE(goqu.T(CrewTable).Col("department"), "").As("department"),
+	)
+
+	ds := m.db.From(cast.UnionAll(crew).As(filmographyTable))
+	if filter.role != "" {
+		ds = ds.Where(goqu.T(filmographyTable).Col("role").Eq(filter.role))
+	}
+	if filter.department != "" {
+		ds = ds.Where(goqu.L("LOWER(?) = LOWER(?)", goqu.T(filmographyTable).Col("department"), filter.department))
+	}
+
+	var total *int64
+	if page.Total {
+		count, err := ds.Count()
+		if err != nil {
+			return nil, Page[FilmographyEntry]{}, fmt.Errorf("error counting filmography: %w", err)
+		}
+		total = &count
+	}
+
+	columns := make([]keysetColumn, 0, len(filter.sort))
+	for _, order := range filter.sort {
+		columns = append(columns, keysetColumn{expr: goqu.T(filmographyTable).Col(order.column), desc: order.desc})
+	}
+	var entries []FilmographyEntry
+	if err := keysetPage(ds, columns, page).ScanStructs(&entries); err != nil {
+		return nil, Page[FilmographyEntry]{}, fmt.Errorf("error fetching filmography: %w", err)
+	}
+
+	result := keysetRows(entries, page, filter.key)
+	result.Total = total
+
+	filmography := result.Items
+	if filmography == nil {
+		filmography = []FilmographyEntry{}
+	}
+	return &PersonWithFilmography{Person: person, Filmography: filmography}, result, nil
+}
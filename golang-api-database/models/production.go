@@ -0,0 +1,132 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+// Tables of the production companies and countries of movies
+const (
+	CompanyTable      = "companies"
+	CountryTable      = "countries"
+	MovieCompanyTable = "movie_companies"
+	MovieCountryTable = "movie_countries"
+)
+
+// CompanyStats aggregates the live movies of a production company. AverageRating is the mean
+// of the ratings of its movies, 0 when none were rated.
+type CompanyStats struct {
+	ID            int     `db:"id" json:"id"`
+	Name          string  `db:"name" json:"name"`
+	FilmCount     int64   `db:"film_count" json:"film_count"`
+	TotalRevenue  float64 `db:"total_revenue" json:"total_revenue"`
+	AverageRating float64 `db:"average_rating" json:"average_rating"`
+	RatingCount   int64   `db:"rating_count" json:"rating_count"`
+}
+
+// Country is a production country, by its ISO 3166-1 code
+type Country struct {
+	ISOCode string `db:"iso_code" json:"iso_3166_1"`
+	Name    string `db:"name" json:"name"`
+}
+
+// ProductionModel reads the production companies and countries of movies
+type ProductionModel struct {
+	db *goqu.Database
+}
+
+func InitProductionModel(goqu *goqu.Database) (*ProductionModel, error) {
+	return &ProductionModel{
+		db: goqu,
+	}, nil
+}
+
+// companyStats selects the companies along with the aggregates of their movies
+func (m *ProductionModel) companyStats() *goqu.SelectDataset {
+	// movies selects the live movies of the company of the outer query
+	movies := func() *goqu.SelectDataset {
+		return m.db.From(MovieCompanyTable).
+			Join(goqu.T(MovieTable), goqu.On(goqu.T(MovieTable).Col("id").Eq(goqu.T(MovieCompanyTable).Col("movieid")))).
+			Where(goqu.T(MovieCompanyTable).Col("companyid").Eq(goqu.T(CompanyTable).Col("id")), live(MovieTable))
+	}
+	ratings := func() *goqu.SelectDataset {
+		return movies().
+			Join(goqu.T(RatingsTable), goqu.On(goqu.T(RatingsTable).Col("movie_id").Eq(goqu.T(MovieTable).Col("id")))).
+			Where(live(RatingsTable))
+	}
+
+	return m.db.From(CompanyTable).Select(
+		goqu.T(CompanyTable).Col("id"),
+		goqu.T(CompanyTable).Col("name"),
+		movies().Select(goqu.COUNT(goqu.Star())).As("film_count"),
+		movies().Select(goqu.COALESCE(goqu.SUM(goqu.T(MovieTable).Col("revenue")), 0)).As("total_revenue"),
+		ratings().Select(goqu.L("COALESCE(ROUND(AVG(?)::numeric, 2), 0)", goqu.T(RatingsTable).Col("rating"))).As("average_rating"),
+		ratings().Select(goqu.COUNT(goqu.Star())).As("rating_count"),
+	)
+}
+
+// GetCompany returns the company having id along with the aggregates of its movies
+func (m *ProductionModel) GetCompany(id int) (CompanyStats, error) {
+	var company CompanyStats
+	found, err := m.companyStats().Where(goqu.T(CompanyTable).Col("id").Eq(id)).ScanStruct(&company)
+	if err != nil {
+		return CompanyStats{}, fmt.Errorf("error fetching company: %w", err)
+	}
+	if !found {
+		return CompanyStats{}, sql.ErrNoRows
+	}
+	return company, nil
+}
+
+// ListCompanies lists the companies whose name contains name, all of them when it is empty,
+// along with the aggregates of their movies, by id
+func (m *ProductionModel) ListCompanies(name string, page PageRequest) (Page[CompanyStats], error) {
+	var companies []CompanyStats
+	if err := page.CheckKey(1); err != nil {
+		return Page[CompanyStats]{}, err
+	}
+
+	ds := m.companyStats()
+	if name = strings.TrimSpace(name); name != "" {
+		ds = ds.Where(goqu.T(CompanyTable).Col("name").ILike("%" + name + "%"))
+	}
+
+	var total *int64
+	if page.Total {
+		count, err := ds.Count()
+		if err != nil {
+			return Page[CompanyStats]{}, fmt.Errorf("error counting companies: %w", err)
+		}
+		total = &count
+	}
+
+	columns := []keysetColumn{{expr: goqu.T(CompanyTable).Col("id")}}
+	if err := keysetPage(ds, columns, page).ScanStructs(&companies); err != nil {
+		return Page[CompanyStats]{}, fmt.Errorf("error fetching companies: %w", err)
+	}
+
+	result := keysetRows(companies, page, func(company CompanyStats) []string {
+		return []string{strconv.Itoa(company.ID)}
+	})
+	result.Total = total
+	return result, nil
+}
+
+// GetCountry returns the country having the ISO 3166-1 code
+func (m *ProductionModel) GetCountry(code string) (Country, error) {
+	var country Country
+	found, err := m.db.From(CountryTable).Select("iso_code", "name").
+		Where(goqu.C("iso_code").Eq(strings.ToUpper(code))).
+		ScanStruct(&country)
+	if err != nil {
+		return Country{}, fmt.Errorf("error fetching country: %w", err)
+	}
+	if !found {
+		return Country{}, sql.ErrNoRows
+	}
+	return country, nil
+}
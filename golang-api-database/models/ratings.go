@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
 )
 
 // RatingsTable represent table name
@@ -18,45 +19,155 @@ type Ratings struct {
 	Rating  float32 `db:"rating" json:"rating" validate:"required"`
 }
 
+// MovieRating are the rating stats of a movie. Rating is the mean rating, Score the mean
+// weighted towards the prior, so movies with few ratings do not outrank well rated popular ones.
 type MovieRating struct {
-	MovieId int     `db:"movie_id"`
-	Title   string  `db:"title"`
-	Rating  float32 `db:"avg_rating"`
+	MovieId   int           `db:"movie_id"`
+	Title     string        `db:"title"`
+	Rating    float32       `db:"avg_rating"`
+	Count     int           `db:"count"`
+	StdDev    float64       `db:"std_dev"`
+	Score     float64       `db:"score"`
+	Histogram []RatingCount `db:"-"`
+}
+
+// RatingPrior weights the score of movies: a movie is scored as if it had Votes more ratings
+// of Mean, the mean of all ratings when Mean is 0
+type RatingPrior struct {
+	Votes float64
+	Mean  float64
 }
 
 type RatingModel struct {
-	db *goqu.Database
+	db    Session
+	prior RatingPrior
 }
 
-func InitRatingsModel(goqu *goqu.Database) (*RatingModel, error) {
+func InitRatingsModel(goqu *goqu.Database, prior RatingPrior) (*RatingModel, error) {
 	return &RatingModel{
-		db: goqu,
+		db:    goqu,
+		prior: prior,
 	}, nil
 }
 
-func (r *RatingModel) ListRatings(page, limit uint) ([]MovieRating, error) {
-	var ratings []MovieRating
-
-	offset := (page - 1) * limit
+// WithTx returns the model running its statements in tx
+func (r *RatingModel) WithTx(tx *goqu.TxDatabase) *RatingModel {
+	return &RatingModel{db: tx, prior: r.prior}
+}
 
-	ds := r.db.From(RatingsTable).
+// statsDataset selects the rating stats of movies grouped by movie, mean is the prior mean
+func (r *RatingModel) statsDataset(mean float64) *goqu.SelectDataset {
+	rating := goqu.T(RatingsTable).Col("rating")
+	return r.db.From(RatingsTable).
 		Select(
 			goqu.T(RatingsTable).Col("movie_id"),
 			goqu.T(MovieTable).Col("title"),
-			goqu.Func("AVG", goqu.T(RatingsTable).Col("rating")).As("avg_rating"),
-		)
+			goqu.AVG(rating).As("avg_rating"),
+			goqu.COUNT(rating).As("count"),
+			goqu.L("ROUND(COALESCE(STDDEV_POP(?), 0)::numeric, 2)", rating).As("std_dev"),
+			r.score(mean).As("score"),
+		).
+		Join(goqu.T(MovieTable), goqu.On(goqu.T(MovieTable).Col("id").Eq(goqu.T(RatingsTable).Col("movie_id")))).
+		Where(live(RatingsTable), live(MovieTable)).
+		GroupBy(goqu.T(RatingsTable).Col("movie_id"), goqu.T(MovieTable).Col("title"))
+}
+
+// score is the Bayesian weighted mean of the ratings of a movie, rounded to two decimals
+func (r *RatingModel) score(mean float64) exp.LiteralExpression {
+	rating := goqu.T(RatingsTable).Col("rating")
+	return goqu.L("ROUND(((COUNT(?) * AVG(?) + ?) / (COUNT(?) + ?))::numeric, 2)",
+		rating, rating, r.prior.Votes*mean, rating, r.prior.Votes)
+}
 
-	ds = ds.Join(goqu.T(MovieTable), goqu.On(goqu.T(MovieTable).Col("id").Eq(goqu.T(RatingsTable).Col("movie_id")))).
-		GroupBy("ratings.movie_id", "movies.title").
-		Limit(limit).
-		Offset(offset)
+// priorMean returns the mean scores are weighted towards
+func (r *RatingModel) priorMean() (float64, error) {
+	if r.prior.Mean > 0 {
+		return r.prior.Mean, nil
+	}
+
+	var mean sql.NullFloat64
+	if _, err := r.db.From(RatingsTable).Select(goqu.AVG("rating")).Where(live(RatingsTable)).ScanVal(&mean); err != nil {
+		return 0, fmt.Errorf("failed to fetch mean rating: %w", err)
+	}
+	return mean.Float64, nil
+}
+
+// withHistograms fills the histograms of ratings, the number of ratings of every rating value
+func (r *RatingModel) withHistograms(ratings []MovieRating) error {
+	if len(ratings) == 0 {
+		return nil
+	}
+
+	movieIDs := make([]int, 0, len(ratings))
+	for _, rating := range ratings {
+		movieIDs = append(movieIDs, rating.MovieId)
+	}
 
-	err := ds.ScanStructs(&ratings)
+	var rows []struct {
+		MovieID int     `db:"movie_id"`
+		Rating  float32 `db:"rating"`
+		Count   int     `db:"count"`
+	}
+	err := r.db.From(RatingsTable).
+		Select(goqu.C("movie_id"), goqu.C("rating"), goqu.COUNT("*").As("count")).
+		Where(goqu.C("movie_id").In(movieIDs), live(RatingsTable)).
+		GroupBy(goqu.C("movie_id"), goqu.C("rating")).
+		Order(goqu.C("rating").Asc()).
+		ScanStructs(&rows)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch ratings: %w", err)
+		return fmt.Errorf("failed to fetch rating histograms: %w", err)
 	}
 
-	return ratings, nil
+	histograms := make(map[int][]RatingCount, len(ratings))
+	for _, row := range rows {
+		histograms[row.MovieID] = append(histograms[row.MovieID], RatingCount{Rating: row.Rating, Count: row.Count})
+	}
+	for i := range ratings {
+		ratings[i].Histogram = histograms[ratings[i].MovieId]
+	}
+	return nil
+}
+
+func (r *RatingModel) ListRatings(page PageRequest) (Page[MovieRating], error) {
+	var ratings []MovieRating
+
+	if err := page.CheckKey(1); err != nil {
+		return Page[MovieRating]{}, err
+	}
+
+	var total *int64
+	if page.Total {
+		var count int64
+		_, err := r.db.From(RatingsTable).
+			Join(goqu.T(MovieTable), goqu.On(goqu.T(MovieTable).Col("id").Eq(goqu.T(RatingsTable).Col("movie_id")))).
+			Where(live(RatingsTable), live(MovieTable)).
+			Select(goqu.COUNT(goqu.DISTINCT(goqu.T(RatingsTable).Col("movie_id")))).
+			ScanVal(&count)
+		if err != nil {
+			return Page[MovieRating]{}, fmt.Errorf("failed to count ratings: %w", err)
+		}
+		total = &count
+	}
+
+	mean, err := r.priorMean()
+	if err != nil {
+		return Page[MovieRating]{}, err
+	}
+
+	columns := []keysetColumn{{expr: goqu.T(RatingsTable).Col("movie_id")}}
+	err = keysetPage(r.statsDataset(mean), columns, page).ScanStructs(&ratings)
+	if err != nil {
+		return Page[MovieRating]{}, fmt.Errorf("failed to fetch ratings: %w", err)
+	}
+	if err := r.withHistograms(ratings); err != nil {
+		return Page[MovieRating]{}, err
+	}
+
+	result := keysetRows(ratings, page, func(rating MovieRating) []string {
+		return []string{strconv.Itoa(rating.MovieId)}
+	})
+	result.Total = total
+	return result, nil
 }
 
 func (r *RatingModel) GetRating(id string) (MovieRating, error) {
@@ -67,15 +178,13 @@ func (r *RatingModel) GetRating(id string) (MovieRating, error) {
 		return MovieRating{}, fmt.Errorf("invalid movie ID: %w", err)
 	}
 
-	found, err := r.db.From(RatingsTable).
-		Select(
-			goqu.T(RatingsTable).Col("movie_id"),
-			goqu.T(MovieTable).Col("title"),
-			goqu.Func("AVG", goqu.T(RatingsTable).Col("rating")).As("avg_rating"),
-		).
-		Join(goqu.T(MovieTable), goqu.On(goqu.T(MovieTable).Col("id").Eq(goqu.T(RatingsTable).Col("movie_id")))).
+	mean, err := r.priorMean()
+	if err != nil {
+		return MovieRating{}, err
+	}
+
+	found, err := r.statsDataset(mean).
 		Where(goqu.T(RatingsTable).Col("movie_id").Eq(movieID)).
-		GroupBy(goqu.T(RatingsTable).Col("movie_id"), goqu.T(MovieTable).Col("title")).
 		ScanStruct(&rating)
 
 	if err != nil {
@@ -86,14 +195,104 @@ func (r *RatingModel) GetRating(id string) (MovieRating, error) {
 		return MovieRating{}, sql.ErrNoRows
 	}
 
+	ratings := []MovieRating{rating}
+	if err := r.withHistograms(ratings); err != nil {
+		return MovieRating{}, err
+	}
+	return ratings[0], nil
+}
+
+// TopRatedMovies ranks the rated movies matching filters by score and returns the first limit.
+// Filters are genre, language and year, the release year.
+func (r *RatingModel) TopRatedMovies(filters map[string]string, limit uint) ([]MovieRating, error) {
+	filter, err := parseTopRatedFilter(filters)
+	if err != nil {
+		return nil, err
+	}
+	mean, err := r.priorMean()
+	if err != nil {
+		return nil, err
+	}
+
+	ds := r.statsDataset(mean)
+
+	// Genres and languages are matched in subqueries, joining them would repeat the ratings
+	if len(filter.genres) > 0 {
+		ds = ds.Where(goqu.T(MovieTable).Col("id").In(
+			r.db.From("movie_genres").
+				Select(goqu.T("movie_genres").Col("movieid")).
+				Join(goqu.T("genres"), goqu.On(goqu.T("movie_genres").Col("genreid").Eq(goqu.T("genres").Col("id")))).
+				Where(goqu.Func("LOWER", goqu.T("genres").Col("name")).In(filter.genres)),
+		))
+	}
+	if filter.language != "" {
+		ds = ds.Where(goqu.T(MovieTable).Col("id").In(
+			r.db.From("movie_languages").
+				Select(goqu.T("movie_languages").Col("movieid")).
+				Where(goqu.T("movie_languages").Col("language_code").Eq(filter.language)),
+		))
+	}
+	for _, bounds := range filter.ranges {
+		if bounds.min != nil {
+			ds = ds.Where(goqu.T(MovieTable).Col(bounds.column).Gte(bounds.min))
+		}
+		if bounds.max != nil {
+			ds = ds.Where(goqu.T(MovieTable).Col(bounds.column).Lte(bounds.max))
+		}
+	}
+
+	ratings := []MovieRating{}
+	err = ds.Order(
+		goqu.I("score").Desc(),
+		goqu.I("count").Desc(),
+		goqu.T(RatingsTable).Col("movie_id").Asc(),
+	).Limit(limit).ScanStructs(&ratings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch top rated movies: %w", err)
+	}
+
+	if err := r.withHistograms(ratings); err != nil {
+		return nil, err
+	}
+	return ratings, nil
+}
+
+// LockRating locks the row of the rating userId gave to movieId until the transaction of the
+// model ends, so the rating read after it stays as it is until the writes of the transaction
+func (r *RatingModel) LockRating(movieId, userId int) error {
+	_, err := r.db.From(RatingsTable).Select("user_id").
+		Where(goqu.Ex{"user_id": userId, "movie_id": movieId}).
+		ForUpdate(exp.Wait).Executor().Exec()
+	if err != nil {
+		return fmt.Errorf("failed to lock rating: %w", err)
+	}
+	return nil
+}
+
+// GetUserRating gets the rating of userId for movieId
+func (r *RatingModel) GetUserRating(movieId, userId int) (Ratings, error) {
+	var rating Ratings
+	found, err := r.db.From(RatingsTable).
+		Select("user_id", "movie_id", "rating").
+		Where(goqu.Ex{"user_id": userId, "movie_id": movieId, "deleted_at": nil}).
+		ScanStruct(&rating)
+	if err != nil {
+		return Ratings{}, fmt.Errorf("failed to fetch rating: %w", err)
+	}
+	if !found {
+		return Ratings{}, sql.ErrNoRows
+	}
 	return rating, nil
 }
 
+// DeleteRatings soft deletes the rating of userId for movieId
 func (r *RatingModel) DeleteRatings(movieId, userId int) error {
-	res, err := r.db.From(RatingsTable).Delete().
+	res, err := r.db.Update(RatingsTable).
+		Set(goqu.Record{"deleted_at": goqu.L("now()")}).
 		Where(goqu.Ex{
-			"user_id":  userId,
-			"movie_id": movieId,
+			"user_id":    userId,
+			"movie_id":   movieId,
+			"deleted_at": nil,
 		}).
 		Executor().
 		Exec()
@@ -114,8 +313,9 @@ func (r *RatingModel) UpdateRatings(userId, movieId int, newRating float32) erro
 		"rating":    newRating,
 		"timestamp": time.Now().Format("2006-01-02 15:04:05"),
 	}).Where(goqu.Ex{
-		"user_id":  userId,
-		"movie_id": movieId,
+		"user_id":    userId,
+		"movie_id":   movieId,
+		"deleted_at": nil,
 	})
 
 	res, err := ds.Executor().Exec()
@@ -135,7 +335,7 @@ func (r *RatingModel) AddorUpdateRatings(rating *Ratings) error {
 	var count int
 	_, err := r.db.From(MovieTable).
 		Select(goqu.COUNT("*")).
-		Where(goqu.Ex{"id": rating.MovieId}).
+		Where(goqu.Ex{"id": rating.MovieId, "deleted_at": nil}).
 		ScanVal(&count)
 
 	if err != nil {
@@ -153,10 +353,12 @@ func (r *RatingModel) AddorUpdateRatings(rating *Ratings) error {
 			"rating":   rating.Rating,
 		},
 	).OnConflict(
+		// Rating again brings back a soft deleted rating
 		goqu.DoUpdate(
 			"user_id, movie_id", goqu.Record{
-				"rating":    rating.Rating,
-				"timestamp": time.Now().Format("2006-01-02 15:04:05"),
+				"rating":     rating.Rating,
+				"timestamp":  time.Now().Format("2006-01-02 15:04:05"),
+				"deleted_at": nil,
 			}),
 	)
 
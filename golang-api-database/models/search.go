@@ -0,0 +1,81 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+// Markers ts_headline puts around matched words, private use characters which the text of movies
+// does not hold. They are swapped for <b> and </b> once the text is HTML escaped.
+const (
+	highlightStart = "\uE000"
+	highlightStop  = "\uE001"
+)
+
+// searchHighlight are the ts_headline options of search snippets
+const searchHighlight = `StartSel="` + highlightStart + `", StopSel="` + highlightStop + `", MaxWords=30, MinWords=10`
+
+var highlightMarkers = strings.NewReplacer(highlightStart, "<b>", highlightStop, "</b>")
+
+type MovieSearchDB struct {
+	MovieDB
+	Score                float64        `db:"score"`
+	TitleSnippet         sql.NullString `db:"title_snippet"`
+	OriginalTitleSnippet sql.NullString `db:"original_title_snippet"`
+	TaglineSnippet       sql.NullString `db:"tagline_snippet"`
+	OverviewSnippet      sql.NullString `db:"overview_snippet"`
+}
+
+type MovieSearchResult struct {
+	Movie    Movie             `json:"movie"`
+	Score    float64           `json:"score"`
+	Snippets map[string]string `json:"snippets,omitempty"`
+}
+
+// SearchMovies ranks movies matching query on title, original title, tagline and overview
+func (m *MovieModel) SearchMovies(query string, page, limit uint) ([]MovieSearchResult, error) {
+	var rows []MovieSearchDB
+	results := []MovieSearchResult{}
+
+	tsquery := goqu.L("websearch_to_tsquery('english', ?)", query)
+	ds := m.db.From(MovieTable).
+		Select(append(selectMovieColumns(),
+			goqu.L("ts_rank_cd(search_vector, ?)", tsquery).As("score"),
+			goqu.L("ts_headline('english', coalesce(title, ''), ?, ?)", tsquery, searchHighlight).As("title_snippet"),
+			goqu.L("ts_headline('english', coalesce(original_title, ''), ?, ?)", tsquery, searchHighlight).As("original_title_snippet"),
+			goqu.L("ts_headline('english', coalesce(tagline, ''), ?, ?)", tsquery, searchHighlight).As("tagline_snippet"),
+			goqu.L("ts_headline('english', coalesce(overview, ''), ?, ?)", tsquery, searchHighlight).As("overview_snippet"))...).
+		Where(goqu.L("search_vector @@ ?", tsquery), live(MovieTable)).
+		Order(goqu.I("score").Desc(), goqu.I("id").Asc()).
+		Offset((page - 1) * limit).Limit(limit)
+
+	if err := ds.ScanStructs(&rows); err != nil {
+		return nil, fmt.Errorf("error searching movies: %w", err)
+	}
+
+	for _, row := range rows {
+		snippets := make(map[string]string)
+		for name, snippet := range map[string]sql.NullString{
+			"title":          row.TitleSnippet,
+			"original_title": row.OriginalTitleSnippet,
+			"tagline":        row.TaglineSnippet,
+			"overview":       row.OverviewSnippet,
+		} {
+			// ts_headline returns the start of the text when nothing in it matched
+			if snippet.Valid && strings.Contains(snippet.String, highlightStart) {
+				snippets[name] = highlightMarkers.Replace(html.EscapeString(snippet.String))
+			}
+		}
+		results = append(results, MovieSearchResult{
+			Movie:    ConvertMovieDBToMovie(row.MovieDB),
+			Score:    row.Score,
+			Snippets: snippets,
+		})
+	}
+
+	return results, nil
+}
@@ -0,0 +1,157 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+var ErrInvalidSort = errors.New("invalid sort")
+
+// favouriteGenresLimit is the number of genres listed as favourites of a user
+const favouriteGenresLimit = 5
+
+// ratingUnixTime is the timestamp of ratings in unix seconds, ratings without one are at 0
+var ratingUnixTime = goqu.L("COALESCE(EXTRACT(EPOCH FROM ?), 0)::bigint", goqu.T(RatingsTable).Col("timestamp"))
+
+type UserRating struct {
+	MovieId   int     `db:"movie_id" json:"movieId"`
+	Title     string  `db:"title" json:"title"`
+	Rating    float32 `db:"rating" json:"rating"`
+	Timestamp int64   `db:"unix_timestamp" json:"timestamp"`
+}
+
+type RatingCount struct {
+	Rating float32 `db:"rating" json:"rating"`
+	Count  int     `db:"count" json:"count"`
+}
+
+type GenreCount struct {
+	Genre   string  `db:"genre" json:"genre"`
+	Count   int     `db:"count" json:"count"`
+	Average float64 `db:"average" json:"average"`
+}
+
+// UserStats summarizes the ratings of a user. Distribution is ordered by rating, favourite
+// genres are the genres rated most, ties going to the better rated genre.
+type UserStats struct {
+	UserId          int           `json:"userId"`
+	Count           int           `json:"count"`
+	Mean            float64       `json:"mean"`
+	Distribution    []RatingCount `json:"distribution"`
+	FavouriteGenres []GenreCount  `json:"favouriteGenres"`
+}
+
+type UserModel struct {
+	db *goqu.Database
+}
+
+func InitUsersModel(goqu *goqu.Database) (*UserModel, error) {
+	return &UserModel{
+		db: goqu,
+	}, nil
+}
+
+// ListUserRatings lists the ratings of userId sorted by timestamp or rating, prefixed with "-"
+// for descending order. The latest ratings come first by default, ties are broken by movie id.
+func (u *UserModel) ListUserRatings(userId int, sort string, page PageRequest) (Page[UserRating], error) {
+	sort = strings.TrimSpace(sort)
+	if sort == "" {
+		sort = "-timestamp"
+	}
+
+	column := keysetColumn{expr: ratingUnixTime, desc: strings.HasPrefix(sort, "-")}
+	key := func(rating UserRating) string { return strconv.FormatInt(rating.Timestamp, 10) }
+	switch strings.TrimPrefix(sort, "-") {
+	case "timestamp":
+	case "rating":
+		column.expr = goqu.T(RatingsTable).Col("rating")
+		key = func(rating UserRating) string { return strconv.FormatFloat(float64(rating.Rating), 'f', -1, 32) }
+	default:
+		return Page[UserRating]{}, fmt.Errorf("%w: cannot sort ratings by %q", ErrInvalidSort, strings.TrimPrefix(sort, "-"))
+	}
+
+	if err := page.CheckKey(2); err != nil {
+		return Page[UserRating]{}, err
+	}
+
+	ds := u.db.From(RatingsTable).Where(goqu.T(RatingsTable).Col("user_id").Eq(userId), live(RatingsTable))
+
+	var count int64
+	if _, err := ds.Select(goqu.COUNT("*")).ScanVal(&count); err != nil {
+		return Page[UserRating]{}, fmt.Errorf("failed to count user ratings: %w", err)
+	}
+	if count == 0 {
+		return Page[UserRating]{}, sql.ErrNoRows
+	}
+
+	ds = ds.Select(
+		goqu.T(RatingsTable).Col("movie_id"),
+		goqu.T(MovieTable).Col("title"),
+		goqu.T(RatingsTable).Col("rating"),
+		ratingUnixTime.As("unix_timestamp"),
+	).Join(goqu.T(MovieTable), goqu.On(goqu.T(MovieTable).Col("id").Eq(goqu.T(RatingsTable).Col("movie_id"))))
+
+	var ratings []UserRating
+	columns := []keysetColumn{column, {expr: goqu.T(RatingsTable).Col("movie_id")}}
+	if err := keysetPage(ds, columns, page).ScanStructs(&ratings); err != nil {
+		return Page[UserRating]{}, fmt.Errorf("failed to fetch user ratings: %w", err)
+	}
+
+	result := keysetRows(ratings, page, func(rating UserRating) []string {
+		return []string{key(rating), strconv.Itoa(rating.MovieId)}
+	})
+	if page.Total {
+		result.Total = &count
+	}
+	return result, nil
+}
+
+// GetUserStats summarizes the ratings of userId, genres are joined through movie_genres
+func (u *UserModel) GetUserStats(userId int) (UserStats, error) {
+	stats := UserStats{UserId: userId}
+
+	err := u.db.From(RatingsTable).
+		Select(goqu.C("rating"), goqu.COUNT("*").As("count")).
+		Where(goqu.C("user_id").Eq(userId), live(RatingsTable)).
+		GroupBy(goqu.C("rating")).
+		Order(goqu.C("rating").Asc()).
+		ScanStructs(&stats.Distribution)
+	if err != nil {
+		return UserStats{}, fmt.Errorf("failed to fetch rating distribution: %w", err)
+	}
+	if len(stats.Distribution) == 0 {
+		return UserStats{}, sql.ErrNoRows
+	}
+
+	var sum float64
+	for _, bucket := range stats.Distribution {
+		stats.Count += bucket.Count
+		sum += float64(bucket.Rating) * float64(bucket.Count)
+	}
+	stats.Mean = math.Round(sum/float64(stats.Count)*100) / 100
+
+	err = u.db.From(RatingsTable).
+		Select(
+			goqu.T("genres").Col("name").As("genre"),
+			goqu.COUNT("*").As("count"),
+			goqu.L("ROUND(AVG(?)::numeric, 2)", goqu.T(RatingsTable).Col("rating")).As("average"),
+		).
+		Join(goqu.T("movie_genres"), goqu.On(goqu.T("movie_genres").Col("movieid").Eq(goqu.T(RatingsTable).Col("movie_id")))).
+		Join(goqu.T("genres"), goqu.On(goqu.T("genres").Col("id").Eq(goqu.T("movie_genres").Col("genreid")))).
+		Where(goqu.T(RatingsTable).Col("user_id").Eq(userId), live(RatingsTable)).
+		GroupBy(goqu.T("genres").Col("name")).
+		Order(goqu.I("count").Desc(), goqu.I("average").Desc(), goqu.I("genre").Asc()).
+		Limit(favouriteGenresLimit).
+		ScanStructs(&stats.FavouriteGenres)
+	if err != nil {
+		return UserStats{}, fmt.Errorf("failed to fetch favourite genres: %w", err)
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,48 @@
+package parquet
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestThriftFieldHeaders checks the compact protocol headers against bytes worked out by hand:
+// field id deltas up to 15 share the byte of the type, longer ones follow it as a zigzag varint,
+// nested structs start their ids over and lists of 15 elements or more carry their size apart.
+func TestThriftFieldHeaders(t *testing.T) {
+	var w thriftWriter
+	w.i32(1, 1)
+	w.i64(2, -1)
+	w.i32(20, 3)
+	w.binary(21, "a")
+	w.structBegin(23)
+	w.i32(1, 0)
+	w.structEnd()
+	w.i32(24, -2)
+	w.listBegin(25, typeI32, 15)
+	for i := 0; i < 15; i++ {
+		w.appendI32(int32(i))
+	}
+	w.listBegin(26, typeBinary, 2)
+	w.appendBinary("x")
+	w.appendBinary("")
+	w.stop()
+
+	want := []byte{
+		0x15, 0x02, // 1: i32 1
+		0x16, 0x01, // 2: i64 -1
+		0x05, 0x28, 0x06, // 20: i32 3, the delta of 18 is too long for the type byte
+		0x18, 0x01, 'a', // 21: binary "a"
+		0x2c,       // 23: struct
+		0x15, 0x00, // 1: i32 0
+		0x00,       // end of struct 23
+		0x15, 0x03, // 24: i32 -2, the delta counts from 23
+		0x19, 0xf5, 0x0f, // 25: list of 15 i32
+		0x00, 0x02, 0x04, 0x06, 0x08, 0x0a, 0x0c, 0x0e, 0x10, 0x12, 0x14, 0x16, 0x18, 0x1a, 0x1c,
+		0x19, 0x28, // 26: list of 2 binary
+		0x01, 'x', 0x00,
+		0x00, // stop
+	}
+	if !bytes.Equal(w.buf, want) {
+		t.Errorf("encoded\n% x\nwant\n% x", w.buf, want)
+	}
+}
@@ -0,0 +1,298 @@
+// Package parquet writes Parquet files of flat rows. Every column is optional and written
+// uncompressed with plain encoding in one page per row group, which every Parquet reader accepts.
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Type is the type of the values of a column
+type Type int32
+
+// Types of columns, their values are given to Writer.Write as bool, int64, float64 and string
+const (
+	Boolean Type = 0
+	Int64   Type = 2
+	Double  Type = 5
+	String  Type = 6
+)
+
+// DefaultRowGroupSize is the number of rows a Writer buffers before writing them
+const DefaultRowGroupSize = 10000
+
+var magic = []byte("PAR1")
+
+// Thrift enums of the file metadata
+const (
+	encodingPlain   = 0
+	encodingRLE     = 3
+	pageTypeData    = 0
+	repetitionOpt   = 1
+	convertedUTF8   = 0
+	codecUncompress = 0
+)
+
+// Column is a column of a file
+type Column struct {
+	Name string
+	Type Type
+}
+
+// Writer writes the rows given to it as a Parquet file. Rows are buffered in memory by row
+// group, so at most RowGroupSize rows are held at a time.
+type Writer struct {
+	// RowGroupSize is the number of rows written together, DefaultRowGroupSize when 0
+	RowGroupSize int
+
+	w         io.Writer
+	offset    int64
+	columns   []Column
+	buffers   []columnBuffer
+	rows      int
+	numRows   int64
+	rowGroups []rowGroup
+}
+
+type columnBuffer struct {
+	levels []byte
+	values bytes.Buffer
+	// bits are the booleans not yet written to values, nbits of them
+	bits  byte
+	nbits int
+}
+
+type rowGroup struct {
+	chunks   []columnChunk
+	byteSize int64
+	numRows  int64
+}
+
+type columnChunk struct {
+	offset int64
+	size   int64
+	values int64
+}
+
+// NewWriter starts a Parquet file of columns written to w
+func NewWriter(w io.Writer, columns []Column) (*Writer, error) {
+	if len(columns) == 0 {
+		return nil, errors.New("parquet: a file needs columns")
+	}
+	pw := &Writer{w: w, columns: columns, buffers: make([]columnBuffer, len(columns))}
+	if err := pw.write(magic); err != nil {
+		return nil, err
+	}
+	return pw, nil
+}
+
+func (pw *Writer) write(data []byte) error {
+	n, err := pw.w.Write(data)
+	pw.offset += int64(n)
+	return err
+}
+
+// Write adds a row holding a value or nil for every column
+func (pw *Writer) Write(row []interface{}) error {
+	if len(row) != len(pw.columns) {
+		return fmt.Errorf("parquet: expected %d values, got %d", len(pw.columns), len(row))
+	}
+
+	for i, value := range row {
+		column, buffer := pw.columns[i], &pw.buffers[i]
+		if value == nil {
+			buffer.levels = append(buffer.levels, 0)
+			continue
+		}
+		buffer.levels = append(buffer.levels, 1)
+
+		switch column.Type {
+		case Boolean:
+			v, ok := value.(bool)
+			if !ok {
+				return typeError(column, value)
+			}
+			if v {
+				buffer.bits |= 1 << buffer.nbits
+			}
+			if buffer.nbits++; buffer.nbits == 8 {
+				buffer.values.WriteByte(buffer.bits)
+				buffer.bits, buffer.nbits = 0, 0
+			}
+		case Int64:
+			v, ok := value.(int64)
+			if !ok {
+				return typeError(column, value)
+			}
+			buffer.values.Write(binary.LittleEndian.AppendUint64(nil, uint64(v)))
+		case Double:
+			v, ok := value.(float64)
+			if !ok {
+				return typeError(column, value)
+			}
+			buffer.values.Write(binary.LittleEndian.AppendUint64(nil, math.Float64bits(v)))
+		case String:
+			v, ok := value.(string)
+			if !ok {
+				return typeError(column, value)
+			}
+			buffer.values.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(v))))
+			buffer.values.WriteString(v)
+		}
+	}
+
+	pw.rows++
+	size := pw.RowGroupSize
+	if size <= 0 {
+		size = DefaultRowGroupSize
+	}
+	if pw.rows >= size {
+		return pw.flush()
+	}
+	return nil
+}
+
+func typeError(column Column, value interface{}) error {
+	return fmt.Errorf("parquet: unexpected %T value of column %s", value, column.Name)
+}
+
+// flush writes the buffered rows as a row group, a page per column
+func (pw *Writer) flush() error {
+	if pw.rows == 0 {
+		return nil
+	}
+
+	group := rowGroup{numRows: int64(pw.rows)}
+	for i := range pw.buffers {
+		buffer := &pw.buffers[i]
+		if buffer.nbits > 0 {
+			buffer.values.WriteByte(buffer.bits)
+			buffer.bits, buffer.nbits = 0, 0
+		}
+
+		levels := encodeLevels(buffer.levels)
+		data := make([]byte, 0, 4+len(levels)+buffer.values.Len())
+		data = binary.LittleEndian.AppendUint32(data, uint32(len(levels)))
+		data = append(data, levels...)
+		data = append(data, buffer.values.Bytes()...)
+
+		header := pageHeader(len(data), pw.rows)
+		chunk := columnChunk{offset: pw.offset, size: int64(len(header) + len(data)), values: int64(pw.rows)}
+		if err := pw.write(header); err != nil {
+			return err
+		}
+		if err := pw.write(data); err != nil {
+			return err
+		}
+
+		group.chunks = append(group.chunks, chunk)
+		group.byteSize += chunk.size
+		buffer.levels = buffer.levels[:0]
+		buffer.values.Reset()
+	}
+
+	pw.rowGroups = append(pw.rowGroups, group)
+	pw.numRows += int64(pw.rows)
+	pw.rows = 0
+	return nil
+}
+
+// Close writes the rows left and the footer of the file, it does not close the underlying writer
+func (pw *Writer) Close() error {
+	if err := pw.flush(); err != nil {
+		return err
+	}
+	footer := pw.fileMetaData()
+	footer = binary.LittleEndian.AppendUint32(footer, uint32(len(footer)))
+	footer = append(footer, magic...)
+	return pw.write(footer)
+}
+
+// encodeLevels encodes definition levels with the RLE/bit-packing hybrid encoding, as RLE runs
+// of bit width 1
+func encodeLevels(levels []byte) []byte {
+	var out []byte
+	for i := 0; i < len(levels); {
+		j := i
+		for j < len(levels) && levels[j] == levels[i] {
+			j++
+		}
+		out = binary.AppendUvarint(out, uint64(j-i)<<1)
+		out = append(out, levels[i])
+		i = j
+	}
+	return out
+}
+
+func pageHeader(size, numValues int) []byte {
+	var t thriftWriter
+	t.i32(1, pageTypeData)
+	t.i32(2, int32(size))
+	t.i32(3, int32(size))
+	t.structBegin(5)
+	t.i32(1, int32(numValues))
+	t.i32(2, encodingPlain)
+	t.i32(3, encodingRLE)
+	t.i32(4, encodingRLE)
+	t.structEnd()
+	t.stop()
+	return t.buf
+}
+
+func (pw *Writer) fileMetaData() []byte {
+	var t thriftWriter
+	t.i32(1, 1)
+
+	t.listBegin(2, typeStruct, len(pw.columns)+1)
+	t.elemBegin()
+	t.binary(4, "schema")
+	t.i32(5, int32(len(pw.columns)))
+	t.structEnd()
+	for _, column := range pw.columns {
+		t.elemBegin()
+		t.i32(1, int32(column.Type))
+		t.i32(3, repetitionOpt)
+		t.binary(4, column.Name)
+		if column.Type == String {
+			t.i32(6, convertedUTF8)
+		}
+		t.structEnd()
+	}
+
+	t.i64(3, pw.numRows)
+
+	t.listBegin(4, typeStruct, len(pw.rowGroups))
+	for _, group := range pw.rowGroups {
+		t.elemBegin()
+		t.listBegin(1, typeStruct, len(group.chunks))
+		for i, chunk := range group.chunks {
+			t.elemBegin()
+			t.i64(2, chunk.offset)
+			t.structBegin(3)
+			t.i32(1, int32(pw.columns[i].Type))
+			t.listBegin(2, typeI32, 2)
+			t.appendI32(encodingPlain)
+			t.appendI32(encodingRLE)
+			t.listBegin(3, typeBinary, 1)
+			t.appendBinary(pw.columns[i].Name)
+			t.i32(4, codecUncompress)
+			t.i64(5, chunk.values)
+			t.i64(6, chunk.size)
+			t.i64(7, chunk.size)
+			t.i64(9, chunk.offset)
+			t.structEnd()
+			t.structEnd()
+		}
+		t.i64(2, group.byteSize)
+		t.i64(3, group.numRows)
+		t.structEnd()
+	}
+
+	t.binary(6, "go-api")
+	t.stop()
+	return t.buf
+}
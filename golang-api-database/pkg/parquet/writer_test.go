@@ -0,0 +1,446 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of testdata")
+
+// writerCases are written and compared with the golden files of testdata, then read back with
+// the reader below, which follows the Parquet format spec apart from the writer
+var writerCases = []struct {
+	name         string
+	columns      []Column
+	rowGroupSize int
+	rows         [][]interface{}
+}{
+	{
+		name:    "empty",
+		columns: []Column{{Name: "id", Type: Int64}, {Name: "title", Type: String}},
+	},
+	{
+		name: "nulls",
+		columns: []Column{
+			{Name: "id", Type: Int64},
+			{Name: "title", Type: String},
+			{Name: "popularity", Type: Double},
+			{Name: "adult", Type: Boolean},
+			{Name: "tagline", Type: String},
+		},
+		rows: [][]interface{}{
+			{int64(862), "Toy Story", 21.946943, false, nil},
+			{nil, nil, nil, nil, nil},
+			{int64(-1), "", math.Inf(1), true, nil},
+			{int64(math.MaxInt64), "Jumanji", nil, nil, nil},
+			{nil, "Grumpier Old Men", -0.5, true, nil},
+		},
+	},
+	{
+		// Values span three bytes, nulls take no bit and the last byte is partly filled
+		name:    "booleans",
+		columns: []Column{{Name: "video", Type: Boolean}},
+		rows: [][]interface{}{
+			{true}, {false}, {true}, {true}, {nil}, {false}, {false}, {true},
+			{true}, {nil}, {true}, {false}, {true}, {false}, {nil}, {false},
+			{true}, {true}, {false}, {true}, {nil},
+		},
+	},
+	{
+		// Booleans start on a new byte in every row group
+		name:         "row_groups",
+		columns:      []Column{{Name: "id", Type: Int64}, {Name: "video", Type: Boolean}, {Name: "title", Type: String}},
+		rowGroupSize: 3,
+		rows: [][]interface{}{
+			{int64(1), true, "a"},
+			{int64(2), nil, "b"},
+			{int64(3), true, nil},
+			{int64(4), false, "d"},
+			{nil, true, "e"},
+			{int64(6), true, "f"},
+			{int64(7), false, "g"},
+			{int64(8), true, nil},
+		},
+	},
+	{
+		// The schema lists more than 15 elements, its size follows the list header
+		name:    "wide",
+		columns: wideColumns(16),
+		rows:    [][]interface{}{wideRow(16, 0), wideRow(16, 1)},
+	},
+}
+
+func wideColumns(n int) []Column {
+	columns := make([]Column, n)
+	for i := range columns {
+		columns[i] = Column{Name: fmt.Sprintf("c%d", i), Type: Int64}
+	}
+	return columns
+}
+
+func wideRow(n, row int) []interface{} {
+	values := make([]interface{}, n)
+	for i := range values {
+		if (i+row)%3 != 0 {
+			values[i] = int64(i * row)
+		}
+	}
+	return values
+}
+
+func TestWriterGolden(t *testing.T) {
+	for _, tc := range writerCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := NewWriter(&buf, tc.columns)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.RowGroupSize = tc.rowGroupSize
+			for _, row := range tc.rows {
+				if err := w.Write(row); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			golden := filepath.Join("testdata", tc.name+".parquet")
+			if *update {
+				if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(buf.Bytes(), want) {
+				t.Errorf("file differs from %s, rerun with -update once the change is checked", golden)
+			}
+
+			file := readFile(t, buf.Bytes())
+			if !reflect.DeepEqual(file.columns, tc.columns) {
+				t.Errorf("columns %v, want %v", file.columns, tc.columns)
+			}
+			if len(file.rows) != len(tc.rows) || (len(tc.rows) > 0 && !reflect.DeepEqual(file.rows, tc.rows)) {
+				t.Errorf("rows %v, want %v", file.rows, tc.rows)
+			}
+
+			size := tc.rowGroupSize
+			if size <= 0 {
+				size = DefaultRowGroupSize
+			}
+			if groups := (len(tc.rows) + size - 1) / size; file.rowGroups != groups {
+				t.Errorf("%d row groups, want %d", file.rowGroups, groups)
+			}
+		})
+	}
+}
+
+// TestWriterEmptyFile checks the file of an empty export against bytes worked out by hand from
+// the spec: the magic, a footer without row groups, its length and the magic again
+func TestWriterEmptyFile(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, []Column{{Name: "id", Type: Int64}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	footer := []byte{
+		0x15, 0x02, // 1 version: i32 1
+		0x19, 0x2c, // 2 schema: list of 2 structs
+		0x48, 0x06, 's', 'c', 'h', 'e', 'm', 'a', // 4 name: "schema"
+		0x15, 0x02, // 5 num_children: 1
+		0x00,
+		0x15, 0x04, // 1 type: INT64
+		0x25, 0x02, // 3 repetition_type: OPTIONAL
+		0x18, 0x02, 'i', 'd', // 4 name: "id"
+		0x00,
+		0x16, 0x00, // 3 num_rows: 0
+		0x19, 0x0c, // 4 row_groups: empty list of structs
+		0x28, 0x06, 'g', 'o', '-', 'a', 'p', 'i', // 6 created_by: "go-api"
+		0x00,
+	}
+	want := append([]byte("PAR1"), footer...)
+	want = binary.LittleEndian.AppendUint32(want, uint32(len(footer)))
+	want = append(want, "PAR1"...)
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("file\n% x\nwant\n% x", buf.Bytes(), want)
+	}
+}
+
+func TestWriterRejectsWrongValues(t *testing.T) {
+	w, err := NewWriter(&bytes.Buffer{}, []Column{{Name: "id", Type: Int64}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write([]interface{}{1}); err == nil {
+		t.Error("int value of an Int64 column was accepted")
+	}
+	if err := w.Write([]interface{}{int64(1), int64(2)}); err == nil {
+		t.Error("row of 2 values was accepted for 1 column")
+	}
+}
+
+// parquetFile is what readFile makes of a file
+type parquetFile struct {
+	columns   []Column
+	rows      [][]interface{}
+	rowGroups int
+}
+
+// readFile reads a file as the Parquet format spec lays it out, failing t on anything a reader
+// would reject
+func readFile(t *testing.T, data []byte) parquetFile {
+	t.Helper()
+	if len(data) < 12 || string(data[:4]) != "PAR1" || string(data[len(data)-4:]) != "PAR1" {
+		t.Fatalf("file is not framed by PAR1")
+	}
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	footerStart := len(data) - 8 - footerLen
+	r := &thriftReader{t: t, buf: data[footerStart : len(data)-8]}
+	meta := r.readStruct()
+	if r.pos != footerLen {
+		t.Fatalf("footer of %d bytes holds %d", footerLen, r.pos)
+	}
+	if meta[1] != int64(1) {
+		t.Errorf("version %v, want 1", meta[1])
+	}
+	if meta[6] != "go-api" {
+		t.Errorf("created_by %v", meta[6])
+	}
+
+	var file parquetFile
+	schema := meta[2].([]interface{})
+	root := schema[0].(map[int16]interface{})
+	if root[4] != "schema" || root[5] != int64(len(schema)-1) {
+		t.Errorf("root schema element %v", root)
+	}
+	for _, element := range schema[1:] {
+		element := element.(map[int16]interface{})
+		column := Column{Name: element[4].(string), Type: Type(element[1].(int64))}
+		if element[3] != int64(repetitionOpt) {
+			t.Errorf("column %s is not optional", column.Name)
+		}
+		if _, utf8 := element[6]; utf8 != (column.Type == String) {
+			t.Errorf("column %s has converted type %v", column.Name, element[6])
+		}
+		file.columns = append(file.columns, column)
+	}
+
+	var numRows int64
+	offset := int64(4)
+	for _, group := range meta[4].([]interface{}) {
+		group := group.(map[int16]interface{})
+		groupRows := group[3].(int64)
+		chunks := group[1].([]interface{})
+		if len(chunks) != len(file.columns) {
+			t.Fatalf("row group has %d column chunks, want %d", len(chunks), len(file.columns))
+		}
+
+		rows := make([][]interface{}, groupRows)
+		for i := range rows {
+			rows[i] = make([]interface{}, len(file.columns))
+		}
+		var groupSize int64
+		for i, chunk := range chunks {
+			chunk := chunk.(map[int16]interface{})
+			md := chunk[3].(map[int16]interface{})
+			column := file.columns[i]
+			pageOffset := md[9].(int64)
+			if pageOffset != offset || chunk[2] != pageOffset {
+				t.Fatalf("column %s starts at %d, file_offset %v, want %d", column.Name, pageOffset, chunk[2], offset)
+			}
+			if md[1] != int64(column.Type) || md[4] != int64(codecUncompress) || md[5] != groupRows {
+				t.Errorf("column %s metadata %v", column.Name, md)
+			}
+			if path := md[3].([]interface{}); len(path) != 1 || path[0] != column.Name {
+				t.Errorf("column %s path %v", column.Name, path)
+			}
+
+			r := &thriftReader{t: t, buf: data[pageOffset:]}
+			header := r.readStruct()
+			pageSize := header[3].(int64)
+			if header[1] != int64(pageTypeData) || header[2] != pageSize {
+				t.Errorf("column %s page header %v", column.Name, header)
+			}
+			chunkSize := int64(r.pos) + pageSize
+			if md[6] != chunkSize || md[7] != chunkSize {
+				t.Errorf("column %s chunk of %d bytes has sizes %v and %v", column.Name, chunkSize, md[6], md[7])
+			}
+			dataPage := header[5].(map[int16]interface{})
+			if dataPage[1] != groupRows || dataPage[2] != int64(encodingPlain) || dataPage[3] != int64(encodingRLE) {
+				t.Errorf("column %s data page header %v", column.Name, dataPage)
+			}
+
+			page := data[pageOffset+int64(r.pos) : pageOffset+chunkSize]
+			levelsLen := int(binary.LittleEndian.Uint32(page))
+			levels := readLevels(t, page[4:4+levelsLen], int(groupRows))
+			values := page[4+levelsLen:]
+			bit := 0
+			for row, level := range levels {
+				if level == 0 {
+					continue
+				}
+				switch column.Type {
+				case Boolean:
+					rows[row][i] = values[bit/8]>>(bit%8)&1 == 1
+					bit++
+				case Int64:
+					rows[row][i] = int64(binary.LittleEndian.Uint64(values))
+					values = values[8:]
+				case Double:
+					rows[row][i] = math.Float64frombits(binary.LittleEndian.Uint64(values))
+					values = values[8:]
+				case String:
+					n := binary.LittleEndian.Uint32(values)
+					rows[row][i] = string(values[4 : 4+n])
+					values = values[4+n:]
+				}
+			}
+			if column.Type == Boolean {
+				values = values[(bit+7)/8:]
+			}
+			if len(values) != 0 {
+				t.Errorf("column %s page has %d bytes left over", column.Name, len(values))
+			}
+
+			offset += chunkSize
+			groupSize += chunkSize
+		}
+		if group[2] != groupSize {
+			t.Errorf("row group of %d bytes has total_byte_size %v", groupSize, group[2])
+		}
+
+		numRows += groupRows
+		file.rows = append(file.rows, rows...)
+		file.rowGroups++
+	}
+	if offset != int64(footerStart) {
+		t.Errorf("column chunks end at %d, the footer starts at %d", offset, footerStart)
+	}
+	if meta[3] != numRows {
+		t.Errorf("num_rows %v, row groups hold %d", meta[3], numRows)
+	}
+	return file
+}
+
+// readLevels decodes n definition levels of bit width 1, held with the RLE/bit-packing hybrid
+// encoding
+func readLevels(t *testing.T, data []byte, n int) []byte {
+	t.Helper()
+	var levels []byte
+	for len(levels) < n {
+		header, size := binary.Uvarint(data)
+		if size <= 0 {
+			t.Fatalf("levels end after %d of %d", len(levels), n)
+		}
+		data = data[size:]
+		if header&1 == 0 {
+			for i := uint64(0); i < header>>1; i++ {
+				levels = append(levels, data[0])
+			}
+			data = data[1:]
+			continue
+		}
+		for _, b := range data[:header>>1] {
+			for i := 0; i < 8; i++ {
+				levels = append(levels, b>>i&1)
+			}
+		}
+		data = data[header>>1:]
+	}
+	if len(levels) != n || len(data) != 0 {
+		t.Fatalf("%d levels and %d bytes decoded, want %d levels", len(levels), len(data), n)
+	}
+	return levels
+}
+
+// thriftReader decodes thrift compact protocol structs into maps of their field ids
+type thriftReader struct {
+	t   *testing.T
+	buf []byte
+	pos int
+}
+
+func (r *thriftReader) byte() byte {
+	if r.pos >= len(r.buf) {
+		r.t.Fatalf("thrift data ends at %d", r.pos)
+	}
+	b := r.buf[r.pos]
+	r.pos++
+	return b
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.buf[r.pos:])
+	if n <= 0 {
+		r.t.Fatalf("bad varint at %d", r.pos)
+	}
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) zigzag() int64 {
+	v := r.uvarint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *thriftReader) readStruct() map[int16]interface{} {
+	fields := map[int16]interface{}{}
+	var id int16
+	for {
+		header := r.byte()
+		if header == 0 {
+			return fields
+		}
+		if delta := int16(header >> 4); delta != 0 {
+			id += delta
+		} else {
+			id = int16(r.zigzag())
+		}
+		fields[id] = r.readValue(header & 0x0f)
+	}
+}
+
+func (r *thriftReader) readValue(fieldType byte) interface{} {
+	switch fieldType {
+	case typeI32, typeI64:
+		return r.zigzag()
+	case typeBinary:
+		n := int(r.uvarint())
+		if r.pos+n > len(r.buf) {
+			r.t.Fatalf("binary of %d bytes at %d overruns the data", n, r.pos)
+		}
+		s := string(r.buf[r.pos : r.pos+n])
+		r.pos += n
+		return s
+	case typeList:
+		header := r.byte()
+		size := int(header >> 4)
+		if size == 15 {
+			size = int(r.uvarint())
+		}
+		list := make([]interface{}, size)
+		for i := range list {
+			list[i] = r.readValue(header & 0x0f)
+		}
+		return list
+	case typeStruct:
+		return r.readStruct()
+	}
+	r.t.Fatalf("unexpected thrift type %d at %d", fieldType, r.pos)
+	return nil
+}
@@ -0,0 +1,28 @@
+package prometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const Namespace = "golang_api"
+
+type PrometheusMetrics struct {
+	RateLimitRejects *prometheus.CounterVec
+}
+
+var metrics *PrometheusMetrics = nil
+
+func InitPrometheusMetrics() *PrometheusMetrics {
+	if metrics == nil {
+		metrics = &PrometheusMetrics{
+			RateLimitRejects: promauto.NewCounterVec(prometheus.CounterOpts{
+				Namespace: Namespace,
+				Name:      "rate_limit_rejections_total",
+				Help:      "Requests rejected by the rate limiter by route group and kind of client",
+			}, []string{"group", "client"}),
+		}
+	}
+
+	return metrics
+}
@@ -10,6 +10,8 @@ import (
 	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/constants"
 	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/controllers"
 	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/middlewares"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/models"
+	pMetrics "git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/pkg/prometheus"
 	"github.com/doug-martin/goqu/v9"
 	"github.com/gofiber/contrib/swagger"
 	"github.com/gofiber/fiber/v2"
@@ -18,37 +20,95 @@ import (
 var mu sync.Mutex
 
 // Setup func
-func Setup(app *fiber.App, goqu *goqu.Database, logger *zap.Logger, config config.AppConfig) error {
+func Setup(app *fiber.App, goqu *goqu.Database, logger *zap.Logger, config config.AppConfig, pMetrics *pMetrics.PrometheusMetrics) error {
 	mu.Lock()
 
 	app.Use(middlewares.LogHandler(logger))
 
+	auth, err := middlewares.NewAuth(config.Auth)
+	if err != nil {
+		logger.Error("Failed to initialize authentication", zap.Error(err))
+		return err
+	}
+
+	// Clients are limited by IP before their credentials are checked and by subject or IP after
+	if config.RateLimit.Enabled {
+		limiter, err := middlewares.NewRateLimiter(config, goqu, logger, pMetrics)
+		if err != nil {
+			logger.Error("Failed to initialize rate limiter", zap.Error(err))
+			return err
+		}
+		app.Use(limiter.Handler(), auth.Authenticate(), limiter.ClientHandler())
+	} else {
+		app.Use(auth.Authenticate())
+	}
+
 	app.Use(swagger.New(swagger.Config{
 		FilePath: "./assets/swagger.json",
 		Title:    "Swagger API Docs",
 	}))
 
-	err := healthCheckController(app, goqu, logger)
+	err = healthCheckController(app, goqu, logger)
+	if err != nil {
+		return err
+	}
+
+	err = setupMoviesController(app, goqu, logger, auth)
+	if err != nil {
+		return err
+	}
+
+	err = setupRatingsController(app, goqu, logger, config, auth)
+	if err != nil {
+		return err
+	}
+
+	err = setupUsersController(app, goqu, logger)
+	if err != nil {
+		return err
+	}
+
+	err = setupCastController(app, goqu, logger, auth)
+	if err != nil {
+		return err
+	}
+
+	err = setupCrewController(app, goqu, logger, auth)
 	if err != nil {
 		return err
 	}
 
-	err = setupMoviesController(app, goqu, logger)
+	err = setupImportController(app, goqu, logger, auth)
 	if err != nil {
 		return err
 	}
 
-	err = setupRatingsController(app, goqu, logger)
+	err = setupAuditController(app, goqu, logger, auth)
 	if err != nil {
 		return err
 	}
 
-	err = setupCastController(app, goqu, logger)
+	err = setupExportController(app, goqu, logger, auth)
 	if err != nil {
 		return err
 	}
 
-	err = setupCrewController(app, goqu, logger)
+	err = setupProductionController(app, goqu, logger)
+	if err != nil {
+		return err
+	}
+
+	err = setupCollectionController(app, goqu, logger, auth)
+	if err != nil {
+		return err
+	}
+
+	err = setupPeopleController(app, goqu, logger, auth)
+	if err != nil {
+		return err
+	}
+
+	err = metricsController(app, logger, pMetrics)
 	if err != nil {
 		return err
 	}
@@ -57,6 +117,16 @@ func Setup(app *fiber.App, goqu *goqu.Database, logger *zap.Logger, config confi
 	return nil
 }
 
+func metricsController(app *fiber.App, logger *zap.Logger, pMetrics *pMetrics.PrometheusMetrics) error {
+	metricsController, err := controllers.InitMetricsController(logger, pMetrics)
+	if err != nil {
+		return err
+	}
+
+	app.Get("/metrics", metricsController.Metrics)
+	return nil
+}
+
 func healthCheckController(app *fiber.App, goqu *goqu.Database, logger *zap.Logger) error {
 	healthController, err := controllers.NewHealthController(goqu, logger)
 	if err != nil {
@@ -69,7 +139,7 @@ func healthCheckController(app *fiber.App, goqu *goqu.Database, logger *zap.Logg
 	return nil
 }
 
-func setupMoviesController(app *fiber.App, goqu *goqu.Database, logger *zap.Logger) error {
+func setupMoviesController(app *fiber.App, goqu *goqu.Database, logger *zap.Logger, auth *middlewares.Auth) error {
 	movieController, err := controllers.NewMovieController(goqu, logger)
 	if err != nil {
 		logger.Error("Failed to initialize MovieController", zap.Error(err))
@@ -79,17 +149,20 @@ func setupMoviesController(app *fiber.App, goqu *goqu.Database, logger *zap.Logg
 	// Register movie routes
 	movieRouter := app.Group("/movies")
 	movieRouter.Get("/", movieController.ListMovies)
+	movieRouter.Get("/search", movieController.SearchMovies)
 	movieRouter.Get(fmt.Sprintf("/:%s", constants.ParamMid), movieController.GetMovieByID)
-	movieRouter.Delete(fmt.Sprintf("/:%s", constants.ParamMid), movieController.DeleteMovieById)
-	movieRouter.Post("/", movieController.AddMovie)
-	movieRouter.Put(fmt.Sprintf("/:%s", constants.ParamMid), movieController.UpdateMovie)
+	movieRouter.Delete(fmt.Sprintf("/:%s", constants.ParamMid), auth.Require(middlewares.RoleAdmin), movieController.DeleteMovieById)
+	movieRouter.Post(fmt.Sprintf("/:%s/restore", constants.ParamMid), auth.Require(middlewares.RoleAdmin), movieController.RestoreMovieById)
+	movieRouter.Post("/", auth.Require(middlewares.RoleEditor), movieController.AddMovie)
+	movieRouter.Put(fmt.Sprintf("/:%s", constants.ParamMid), auth.Require(middlewares.RoleEditor), movieController.UpdateMovie)
 
 	return nil
 
 }
 
-func setupRatingsController(app *fiber.App, goqu *goqu.Database, logger *zap.Logger) error {
-	ratingController, err := controllers.NewRatingsController(goqu, logger)
+func setupRatingsController(app *fiber.App, goqu *goqu.Database, logger *zap.Logger, config config.AppConfig, auth *middlewares.Auth) error {
+	prior := models.RatingPrior{Votes: config.RatingPriorVotes, Mean: config.RatingPriorMean}
+	ratingController, err := controllers.NewRatingsController(goqu, logger, prior)
 	if err != nil {
 		logger.Error("Failed to intialize RatingController", zap.Error(err))
 		return err
@@ -98,15 +171,32 @@ func setupRatingsController(app *fiber.App, goqu *goqu.Database, logger *zap.Log
 	ratingRouter := app.Group("ratings")
 
 	ratingRouter.Get("/movies", ratingController.ListAllMovieRatings)
+	ratingRouter.Get("/top", ratingController.TopRatedMovies)
 	app.Get(fmt.Sprintf("movies/:%s/ratings", constants.ParamMid), ratingController.GetRatingByMovieId)
-	ratingRouter.Post(fmt.Sprintf("/user/:%s/ratings", constants.UserId), ratingController.AddRating)
-	ratingRouter.Put(fmt.Sprintf("/movies/:%s/user/:%s/ratings", constants.ParamMid, constants.UserId), ratingController.UpdateRating)
-	ratingRouter.Delete(fmt.Sprintf("/movies/:%s/user/:%s/ratings", constants.ParamMid, constants.UserId), ratingController.DeleteRating)
+	ratingRouter.Post(fmt.Sprintf("/user/:%s/ratings", constants.UserId), auth.Require(middlewares.RoleReader), ratingController.AddRating)
+	ratingRouter.Get(fmt.Sprintf("/movies/:%s/user/:%s/ratings", constants.ParamMid, constants.UserId), ratingController.GetUserRating)
+	ratingRouter.Put(fmt.Sprintf("/movies/:%s/user/:%s/ratings", constants.ParamMid, constants.UserId), auth.Require(middlewares.RoleReader), ratingController.UpdateRating)
+	ratingRouter.Delete(fmt.Sprintf("/movies/:%s/user/:%s/ratings", constants.ParamMid, constants.UserId), auth.Require(middlewares.RoleReader), ratingController.DeleteRating)
+
+	return nil
+}
+
+func setupUsersController(app *fiber.App, goqu *goqu.Database, logger *zap.Logger) error {
+	userController, err := controllers.NewUsersController(goqu, logger)
+	if err != nil {
+		logger.Error("Failed to intialize UsersController", zap.Error(err))
+		return err
+	}
+
+	userRouter := app.Group("users")
+
+	userRouter.Get(fmt.Sprintf("/:%s/ratings", constants.UserId), userController.ListUserRatings)
+	userRouter.Get(fmt.Sprintf("/:%s/stats", constants.UserId), userController.GetUserStats)
 
 	return nil
 }
 
-func setupCastController(app *fiber.App, goqu *goqu.Database, logger *zap.Logger) error {
+func setupCastController(app *fiber.App, goqu *goqu.Database, logger *zap.Logger, auth *middlewares.Auth) error {
 	castController, err := controllers.NewCastController(goqu, logger)
 	if err != nil {
 		logger.Error("Failed to intialize CastController", zap.Error(err))
@@ -115,12 +205,12 @@ func setupCastController(app *fiber.App, goqu *goqu.Database, logger *zap.Logger
 
 	app.Get(fmt.Sprintf("/movies/:%s/casts", constants.ParamMid), castController.ListCastMembers)
 	app.Get(fmt.Sprintf("/actor/:%s/movies", constants.CastId), castController.ListMoviesByCastId)
-	app.Post(fmt.Sprintf("/movies/:%s/credit/:%s/cast", constants.ParamMid, constants.CreditId), castController.AddMovieCastMember)
+	app.Post(fmt.Sprintf("/movies/:%s/credit/:%s/cast", constants.ParamMid, constants.CreditId), auth.Require(middlewares.RoleEditor), castController.AddMovieCastMember)
 
 	return nil
 }
 
-func setupCrewController(app *fiber.App, goqu *goqu.Database, logger *zap.Logger) error {
+func setupCrewController(app *fiber.App, goqu *goqu.Database, logger *zap.Logger, auth *middlewares.Auth) error {
 	crewController, err := controllers.NewCrewController(goqu, logger)
 	if err != nil {
 		logger.Error("Failed to intialize CrewController", zap.Error(err))
@@ -128,7 +218,94 @@ func setupCrewController(app *fiber.App, goqu *goqu.Database, logger *zap.Logger
 	}
 
 	app.Get(fmt.Sprintf("/movies/:%s/crew", constants.ParamMid), crewController.ListCrewMembers)
-	app.Post(fmt.Sprintf("/movies/:%s/credit/:%s/crew", constants.ParamMid, constants.CreditId), crewController.AddMovieCrewMember)
+	app.Post(fmt.Sprintf("/movies/:%s/credit/:%s/crew", constants.ParamMid, constants.CreditId), auth.Require(middlewares.RoleEditor), crewController.AddMovieCrewMember)
+
+	return nil
+}
+
+func setupImportController(app *fiber.App, goqu *goqu.Database, logger *zap.Logger, auth *middlewares.Auth) error {
+	importController, err := controllers.NewImportController(goqu, logger)
+	if err != nil {
+		logger.Error("Failed to intialize ImportController", zap.Error(err))
+		return err
+	}
+
+	importRouter := app.Group("/import")
+	importRouter.Get(fmt.Sprintf("/jobs/:%s", constants.JobId), auth.Require(middlewares.RoleAdmin), importController.GetImportJob)
+	importRouter.Post(fmt.Sprintf("/:%s", constants.ParamKind), auth.Require(middlewares.RoleAdmin), importController.StartImport)
+
+	return nil
+}
+
+func setupAuditController(app *fiber.App, goqu *goqu.Database, logger *zap.Logger, auth *middlewares.Auth) error {
+	auditController, err := controllers.NewAuditController(goqu, logger)
+	if err != nil {
+		logger.Error("Failed to intialize AuditController", zap.Error(err))
+		return err
+	}
+
+	app.Get("/audit", auth.Require(middlewares.RoleAdmin), auditController.ListAudit)
+
+	return nil
+}
+
+func setupExportController(app *fiber.App, goqu *goqu.Database, logger *zap.Logger, auth *middlewares.Auth) error {
+	exportController, err := controllers.NewExportController(goqu, logger)
+	if err != nil {
+		logger.Error("Failed to intialize ExportController", zap.Error(err))
+		return err
+	}
+
+	app.Get(fmt.Sprintf("/export/:%s", constants.ParamKind), auth.Require(middlewares.RoleEditor), exportController.Export)
+
+	return nil
+}
+
+func setupProductionController(app *fiber.App, goqu *goqu.Database, logger *zap.Logger) error {
+	productionController, err := controllers.NewProductionController(goqu, logger)
+	if err != nil {
+		logger.Error("Failed to intialize ProductionController", zap.Error(err))
+		return err
+	}
+
+	companyRouter := app.Group("/companies")
+	companyRouter.Get("/", productionController.ListCompanies)
+	companyRouter.Get(fmt.Sprintf("/:%s", constants.CompanyId), productionController.GetCompany)
+	companyRouter.Get(fmt.Sprintf("/:%s/movies", constants.CompanyId), productionController.ListCompanyMovies)
+	app.Get(fmt.Sprintf("/countries/:%s/movies", constants.CountryCode), productionController.ListCountryMovies)
+
+	return nil
+}
+
+func setupCollectionController(app *fiber.App, goqu *goqu.Database, logger *zap.Logger, auth *middlewares.Auth) error {
+	collectionController, err := controllers.NewCollectionController(goqu, logger)
+	if err != nil {
+		logger.Error("Failed to intialize CollectionController", zap.Error(err))
+		return err
+	}
+
+	collectionRouter := app.Group("/collections")
+	collectionRouter.Get("/", collectionController.ListCollections)
+	collectionRouter.Get(fmt.Sprintf("/:%s", constants.CollectionId), collectionController.GetCollection)
+	app.Put(fmt.Sprintf("/movies/:%s/collection", constants.ParamMid), auth.Require(middlewares.RoleEditor), collectionController.AttachMovieCollection)
+	app.Delete(fmt.Sprintf("/movies/:%s/collection", constants.ParamMid), auth.Require(middlewares.RoleEditor), collectionController.DetachMovieCollection)
+
+	return nil
+}
+
+func setupPeopleController(app *fiber.App, goqu *goqu.Database, logger *zap.Logger, auth *middlewares.Auth) error {
+	peopleController, err := controllers.NewPeopleController(goqu, logger)
+	if err != nil {
+		logger.Error("Failed to intialize PeopleController", zap.Error(err))
+		return err
+	}
+
+	peopleRouter := app.Group("/people")
+	peopleRouter.Get("/", peopleController.ListPeople)
+	peopleRouter.Get(fmt.Sprintf("/:%s", constants.PersonId), peopleController.GetPerson)
+	peopleRouter.Post("/", auth.Require(middlewares.RoleEditor), peopleController.AddPerson)
+	peopleRouter.Put(fmt.Sprintf("/:%s", constants.PersonId), auth.Require(middlewares.RoleEditor), peopleController.UpdatePerson)
+	peopleRouter.Delete(fmt.Sprintf("/:%s", constants.PersonId), auth.Require(middlewares.RoleAdmin), peopleController.DeletePerson)
 
 	return nil
 }
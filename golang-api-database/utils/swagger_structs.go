@@ -1,6 +1,9 @@
 package utils
 
-import "git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/models"
+import (
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/database/seed"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/models"
+)
 
 ////////////////////
 // --- MOVIES  ---//
@@ -26,11 +29,47 @@ type ResponseGetMovieByID struct {
 // swagger:parameters ListMovies
 type RequestListMovies struct {
 	// in: query
-	Page     int    `json:"page"`
-	Limit    int    `json:"limit"`
-	Name     string `json:"name"`
-	Genre    string `json:"genre"`
-	Language string `json:"language"`
+	// Cursor of the page, taken from the next or prev link of another page
+	Cursor string `json:"cursor"`
+	Limit  int    `json:"limit"`
+	// Count the movies matching the filters
+	Total bool   `json:"total"`
+	Name  string `json:"name"`
+	// Comma separated genres
+	Genre string `json:"genre"`
+	// Match any (or) or all (and) of the genres
+	// enum: or,and
+	GenreMode string `json:"genre_mode"`
+	Language  string `json:"language"`
+	Status    string `json:"status"`
+	// Comma separated columns out of id, title, release_date, runtime, popularity,
+	// vote_average, vote_count, budget and revenue, prefixed with - for descending order
+	// example: -popularity,title
+	Sort string `json:"sort"`
+	// format: date
+	ReleaseDateMin string `json:"release_date_min"`
+	// format: date
+	ReleaseDateMax string  `json:"release_date_max"`
+	RuntimeMin     float64 `json:"runtime_min"`
+	RuntimeMax     float64 `json:"runtime_max"`
+	PopularityMin  float64 `json:"popularity_min"`
+	PopularityMax  float64 `json:"popularity_max"`
+	VoteAverageMin float64 `json:"vote_average_min"`
+	VoteAverageMax float64 `json:"vote_average_max"`
+	VoteCountMin   int     `json:"vote_count_min"`
+	VoteCountMax   int     `json:"vote_count_max"`
+	BudgetMin      float64 `json:"budget_min"`
+	BudgetMax      float64 `json:"budget_max"`
+	RevenueMin     float64 `json:"revenue_min"`
+	RevenueMax     float64 `json:"revenue_max"`
+	Adult          bool    `json:"adult"`
+	Video          bool    `json:"video"`
+	// Id of the collection the movies belong to
+	Collection int `json:"collection"`
+	// Id of a production company
+	Company int `json:"company"`
+	// ISO 3166-1 code of a production country
+	Country string `json:"country"`
 }
 
 // swagger:response ResponseListMovies
@@ -38,8 +77,28 @@ type ResponseListMovies struct {
 	// in: body
 	Body struct {
 		// enum: success
-		Status string         `json:"status"`
-		Data   []models.Movie `json:"data"`
+		Status     string         `json:"status"`
+		Data       []models.Movie `json:"data"`
+		Pagination Pagination     `json:"pagination"`
+	} `json:"body"`
+}
+
+// swagger:parameters SearchMovies
+type RequestSearchMovies struct {
+	// in: query
+	// required: true
+	Q     string `json:"q"`
+	Page  int    `json:"page"`
+	Limit int    `json:"limit"`
+}
+
+// swagger:response ResponseSearchMovies
+type ResponseSearchMovies struct {
+	// in: body
+	Body struct {
+		// enum: success
+		Status string                     `json:"status"`
+		Data   []models.MovieSearchResult `json:"data"`
 	} `json:"body"`
 }
 
@@ -50,6 +109,13 @@ type RequestDeleteMovieByID struct {
 	MovieID int `json:"movieId"`
 }
 
+// swagger:parameters RestoreMovieById
+type RequestRestoreMovieByID struct {
+	// in: path
+	// required: true
+	MovieID int `json:"movieId"`
+}
+
 // swagger:parameters AddMovie
 type RequestAddMovie struct {
 	// in: body
@@ -77,18 +143,48 @@ type RequestUpdateMovie struct {
 // swagger:parameters ListAllMovieRatings
 type RequestListAllMovieRatings struct {
 	// in: query
-	Page  int `json:"page"`
-	Limit int `json:"limit"`
+	// Cursor of the page, taken from the next or prev link of another page
+	Cursor string `json:"cursor"`
+	Limit  int    `json:"limit"`
+	// Count the rated movies
+	Total bool `json:"total"`
 }
 
 // swagger:response ResponseListAllMovieRatings
 type ResponseListAllMovieRatings struct {
+	// in: body
+	Body struct {
+		// enum: success
+		Status     string               `json:"status"`
+		Data       []models.MovieRating `json:"data"`
+		Pagination Pagination           `json:"pagination"`
+	}
+}
+
+// swagger:parameters TopRatedMovies
+type RequestTopRatedMovies struct {
+	// in: query
+	Genre string `json:"genre"`
+	// in: query
+	// ISO 639-1 code of a spoken language
+	Language string `json:"language"`
+	// in: query
+	// Release year
+	Year int `json:"year"`
+	// in: query
+	// maximum: 100
+	// default: 10
+	Limit int `json:"limit"`
+}
+
+// swagger:response ResponseTopRatedMovies
+type ResponseTopRatedMovies struct {
 	// in: body
 	Body struct {
 		// enum: success
 		Status string               `json:"status"`
 		Data   []models.MovieRating `json:"data"`
-	}
+	} `json:"body"`
 }
 
 // swagger:parameters GetRatingsByMovieId
@@ -108,6 +204,73 @@ type ResponseGetRatingsByMovieId struct {
 	} `json:"body"`
 }
 
+// swagger:parameters GetUserRating
+type RequestGetUserRating struct {
+	// in: path
+	// required: true
+	MovieID int `json:"movieId"`
+	// in: path
+	// required: true
+	UserID int `json:"userId"`
+}
+
+// swagger:response ResponseGetUserRating
+type ResponseGetUserRating struct {
+	// in: body
+	Body struct {
+		// enum: success
+		Status string         `json:"status"`
+		Data   models.Ratings `json:"data"`
+	} `json:"body"`
+}
+
+// swagger:parameters ListUserRatings
+type RequestListUserRatings struct {
+	// in: path
+	// required: true
+	UserID int `json:"userId"`
+	// in: query
+	// timestamp or rating, prefixed with - for descending order
+	// default: -timestamp
+	Sort string `json:"sort"`
+	// in: query
+	// Cursor of the page, taken from the next or prev link of another page
+	Cursor string `json:"cursor"`
+	// in: query
+	Limit int `json:"limit"`
+	// in: query
+	// Count the ratings of the user
+	Total bool `json:"total"`
+}
+
+// swagger:response ResponseListUserRatings
+type ResponseListUserRatings struct {
+	// in: body
+	Body struct {
+		// enum: success
+		Status     string              `json:"status"`
+		Data       []models.UserRating `json:"data"`
+		Pagination Pagination          `json:"pagination"`
+	}
+}
+
+// swagger:parameters GetUserStats
+type RequestGetUserStats struct {
+	// in: path
+	// required: true
+	UserID int `json:"userId"`
+}
+
+// swagger:response ResponseGetUserStats
+type ResponseGetUserStats struct {
+	// in: body
+	Body struct {
+		// enum: success
+		Status string           `json:"status"`
+		Data   models.UserStats `json:"data"`
+	} `json:"body"`
+}
+
 // swagger:parameters AddRating
 type RequestAddRating struct {
 	// in: path
@@ -154,6 +317,14 @@ type RequestListCastMembers struct {
 	// in: path
 	// required: true
 	MovieID int `json:"movieId"`
+	// in: query
+	// Cursor of the page, taken from the next or prev link of another page
+	Cursor string `json:"cursor"`
+	// in: query
+	Limit int `json:"limit"`
+	// in: query
+	// Count the rows of the whole list
+	Total bool `json:"total"`
 }
 
 // swagger:response ResponseListCastMembers
@@ -161,8 +332,9 @@ type ResponseListCastMembers struct {
 	// in: body
 	Body struct {
 		// enum: success
-		Status string             `json:"status"`
-		Data   []models.MovieCast `json:"data"`
+		Status     string             `json:"status"`
+		Data       []models.MovieCast `json:"data"`
+		Pagination Pagination         `json:"pagination"`
 	} `json:"body"`
 }
 
@@ -171,6 +343,14 @@ type RequestListMoviesByCastId struct {
 	// in: path
 	// required: true
 	CastID int `json:"castId"`
+	// in: query
+	// Cursor of the page, taken from the next or prev link of another page
+	Cursor string `json:"cursor"`
+	// in: query
+	Limit int `json:"limit"`
+	// in: query
+	// Count the rows of the whole list
+	Total bool `json:"total"`
 }
 
 // swagger:response ResponseListMoviesByCastId
@@ -178,8 +358,9 @@ type ResponseListMoviesByCastId struct {
 	// in: body
 	Body struct {
 		// enum: success
-		Status string                 `json:"status"`
-		Data   models.ActorWithMovies `json:"data"`
+		Status     string                 `json:"status"`
+		Data       models.ActorWithMovies `json:"data"`
+		Pagination Pagination             `json:"pagination"`
 	}
 }
 
@@ -207,6 +388,14 @@ type RequestListCrewMembers struct {
 	// in: path
 	// required: true
 	MovieID int `json:"movieId"`
+	// in: query
+	// Cursor of the page, taken from the next or prev link of another page
+	Cursor string `json:"cursor"`
+	// in: query
+	Limit int `json:"limit"`
+	// in: query
+	// Count the rows of the whole list
+	Total bool `json:"total"`
 }
 
 // swagger:response ResponseListCrewMembers
@@ -214,8 +403,9 @@ type ResponseListCrewMembers struct {
 	// in: body
 	Body struct {
 		// enum: success
-		Status string             `json:"status"`
-		Data   []models.MovieCrew `json:"data"`
+		Status     string             `json:"status"`
+		Data       []models.MovieCrew `json:"data"`
+		Pagination Pagination         `json:"pagination"`
 	} `json:"body"`
 }
 
@@ -234,6 +424,393 @@ type RequestAddMovieCrewMember struct {
 	}
 }
 
+////////////////////
+// --- IMPORT ---//
+////////////////////
+
+// swagger:parameters StartImport
+type RequestStartImport struct {
+	// in: path
+	// required: true
+	// enum: movies,ratings,credits
+	Kind string `json:"kind"`
+	// in: query
+	// Format of the upload, taken from the content type when missing
+	// enum: csv,json,ndjson
+	Format string `json:"format"`
+	// in: query
+	// Validate the upload without importing it
+	DryRun bool `json:"dry_run"`
+	// in: body
+	// required: true
+	// Rows with the columns of the seed files, as CSV with a header row, a JSON array of objects
+	// or JSON objects on separate lines
+	Body string
+}
+
+// swagger:parameters GetImportJob
+type RequestGetImportJob struct {
+	// in: path
+	// required: true
+	JobID string `json:"jobId"`
+}
+
+// swagger:response ResponseImportJob
+type ResponseImportJob struct {
+	// in: body
+	Body struct {
+		// enum: success
+		Status string   `json:"status"`
+		Data   seed.Job `json:"data"`
+	} `json:"body"`
+}
+
+////////////////////
+// --- EXPORT ---//
+////////////////////
+
+// swagger:parameters Export
+type RequestExport struct {
+	// in: path
+	// required: true
+	// enum: movies,ratings,credits
+	Kind string `json:"kind"`
+	// in: query
+	// enum: csv,ndjson,parquet
+	// default: csv
+	Format string `json:"format"`
+	// in: query
+	Name string `json:"name"`
+	// in: query
+	// Comma separated genres
+	Genre string `json:"genre"`
+	// in: query
+	// Match any (or) or all (and) of the genres
+	// enum: or,and
+	GenreMode string `json:"genre_mode"`
+	// in: query
+	Language string `json:"language"`
+	// in: query
+	Status string `json:"status"`
+	// in: query
+	// format: date
+	ReleaseDateMin string `json:"release_date_min"`
+	// in: query
+	// format: date
+	ReleaseDateMax string `json:"release_date_max"`
+	// in: query
+	RuntimeMin float64 `json:"runtime_min"`
+	// in: query
+	RuntimeMax float64 `json:"runtime_max"`
+	// in: query
+	PopularityMin float64 `json:"popularity_min"`
+	// in: query
+	PopularityMax float64 `json:"popularity_max"`
+	// in: query
+	VoteAverageMin float64 `json:"vote_average_min"`
+	// in: query
+	VoteAverageMax float64 `json:"vote_average_max"`
+	// in: query
+	VoteCountMin int `json:"vote_count_min"`
+	// in: query
+	VoteCountMax int `json:"vote_count_max"`
+	// in: query
+	BudgetMin float64 `json:"budget_min"`
+	// in: query
+	BudgetMax float64 `json:"budget_max"`
+	// in: query
+	RevenueMin float64 `json:"revenue_min"`
+	// in: query
+	RevenueMax float64 `json:"revenue_max"`
+	// in: query
+	Adult bool `json:"adult"`
+	// in: query
+	Video bool `json:"video"`
+	// in: query
+	// Id of the collection the movies belong to
+	Collection int `json:"collection"`
+	// in: query
+	// Id of a production company
+	Company int `json:"company"`
+	// in: query
+	// ISO 3166-1 code of a production country
+	Country string `json:"country"`
+}
+
+// swagger:response ResponseExport
+type ResponseExport struct {
+	// in: body
+	// Rows as CSV with a header row, JSON objects on separate lines or a Parquet file
+	Body string
+}
+
+///////////////////////////
+// --- PRODUCTION  ---//
+///////////////////////////
+
+// swagger:parameters ListCompanies
+type RequestListCompanies struct {
+	// in: query
+	// Cursor of the page, taken from the next or prev link of another page
+	Cursor string `json:"cursor"`
+	// in: query
+	Limit int `json:"limit"`
+	// in: query
+	// Count the companies matching the name
+	Total bool `json:"total"`
+	// in: query
+	// Part of the name of the company, case insensitive
+	Name string `json:"name"`
+}
+
+// swagger:response ResponseListCompanies
+type ResponseListCompanies struct {
+	// in: body
+	Body struct {
+		// enum: success
+		Status     string                `json:"status"`
+		Data       []models.CompanyStats `json:"data"`
+		Pagination Pagination            `json:"pagination"`
+	} `json:"body"`
+}
+
+// swagger:parameters GetCompany
+type RequestGetCompany struct {
+	// in: path
+	// required: true
+	CompanyID int `json:"companyId"`
+}
+
+// swagger:response ResponseGetCompany
+type ResponseGetCompany struct {
+	// in: body
+	Body struct {
+		// enum: success
+		Status string              `json:"status"`
+		Data   models.CompanyStats `json:"data"`
+	} `json:"body"`
+}
+
+// swagger:parameters ListCompanyMovies
+type RequestListCompanyMovies struct {
+	// in: path
+	// required: true
+	CompanyID int `json:"companyId"`
+	RequestListMovies
+}
+
+// swagger:parameters ListCountryMovies
+type RequestListCountryMovies struct {
+	// in: path
+	// required: true
+	// ISO 3166-1 code of the country
+	CountryCode string `json:"countryCode"`
+	RequestListMovies
+}
+
+///////////////////////////
+// --- COLLECTIONS  ---//
+///////////////////////////
+
+// swagger:parameters ListCollections
+type RequestListCollections struct {
+	// in: query
+	// Cursor of the page, taken from the next or prev link of another page
+	Cursor string `json:"cursor"`
+	// in: query
+	Limit int `json:"limit"`
+	// in: query
+	// Count the collections matching the name
+	Total bool `json:"total"`
+	// in: query
+	// Part of the name of the collection, case insensitive
+	Name string `json:"name"`
+}
+
+// swagger:response ResponseListCollections
+type ResponseListCollections struct {
+	// in: body
+	Body struct {
+		// enum: success
+		Status     string                   `json:"status"`
+		Data       []models.CollectionStats `json:"data"`
+		Pagination Pagination               `json:"pagination"`
+	} `json:"body"`
+}
+
+// swagger:parameters GetCollection
+type RequestGetCollection struct {
+	// in: path
+	// required: true
+	CollectionID int `json:"collectionId"`
+}
+
+// swagger:response ResponseGetCollection
+type ResponseGetCollection struct {
+	// in: body
+	Body struct {
+		// enum: success
+		Status string                      `json:"status"`
+		Data   models.CollectionWithMovies `json:"data"`
+	} `json:"body"`
+}
+
+// swagger:parameters AttachMovieCollection
+type RequestAttachMovieCollection struct {
+	// in: path
+	// required: true
+	MovieID int `json:"movieId"`
+	// in: body
+	// required: true
+	Body struct {
+		models.CollectionMember
+	}
+}
+
+// swagger:parameters DetachMovieCollection
+type RequestDetachMovieCollection struct {
+	// in: path
+	// required: true
+	MovieID int `json:"movieId"`
+}
+
+//////////////////////
+// --- PEOPLE  ---//
+//////////////////////
+
+// swagger:parameters GetPerson
+type RequestGetPerson struct {
+	// in: path
+	// required: true
+	PersonID int `json:"personId"`
+	// in: query
+	// Cursor of the page, taken from the next or prev link of another page
+	Cursor string `json:"cursor"`
+	// in: query
+	Limit int `json:"limit"`
+	// in: query
+	// Count the credits matching the filters
+	Total bool `json:"total"`
+	// in: query
+	// enum: cast,crew
+	Role string `json:"role"`
+	// in: query
+	// Department of the credits, Acting for the cast
+	Department string `json:"department"`
+	// in: query
+	// Comma separated year, title and rating, prefixed with - for descending order
+	// default: -year
+	Sort string `json:"sort"`
+}
+
+// swagger:response ResponseGetPerson
+type ResponseGetPerson struct {
+	// in: body
+	Body struct {
+		// enum: success
+		Status     string                       `json:"status"`
+		Data       models.PersonWithFilmography `json:"data"`
+		Pagination Pagination                   `json:"pagination"`
+	} `json:"body"`
+}
+
+// swagger:parameters ListPeople
+type RequestListPeople struct {
+	// in: query
+	// Cursor of the page, taken from the next or prev link of another page
+	Cursor string `json:"cursor"`
+	// in: query
+	Limit int `json:"limit"`
+	// in: query
+	// Count the people matching the name
+	Total bool `json:"total"`
+	// in: query
+	// Part of the name of the person, case insensitive
+	Name string `json:"name"`
+}
+
+// swagger:response ResponseListPeople
+type ResponseListPeople struct {
+	// in: body
+	Body struct {
+		// enum: success
+		Status     string          `json:"status"`
+		Data       []models.Person `json:"data"`
+		Pagination Pagination      `json:"pagination"`
+	} `json:"body"`
+}
+
+// swagger:parameters AddPerson
+type RequestAddPerson struct {
+	// in: body
+	Body struct {
+		models.Person
+	}
+}
+
+// swagger:response ResponseAddPerson
+type ResponseAddPerson struct {
+	// in: body
+	Body struct {
+		// enum: success
+		Status string        `json:"status"`
+		Data   models.Person `json:"data"`
+	} `json:"body"`
+}
+
+// swagger:parameters UpdatePerson
+type RequestUpdatePerson struct {
+	// in: path
+	// required: true
+	PersonID int `json:"personId"`
+	// in: body
+	Body struct {
+		models.Person
+	}
+}
+
+// swagger:parameters DeletePerson
+type RequestDeletePerson struct {
+	// in: path
+	// required: true
+	PersonID int `json:"personId"`
+	// in: query
+	// Delete the cast and crew credits of the person along, a credited person is not deleted
+	// otherwise
+	Cascade bool `json:"cascade"`
+}
+
+////////////////////
+// --- AUDIT  ---//
+////////////////////
+
+// swagger:parameters ListAudit
+type RequestListAudit struct {
+	// in: query
+	// enum: movie,rating,cast,crew,person
+	Entity string `json:"entity"`
+	// in: query
+	// Id of the entity, a movie id also matches the ratings and credits of the movie
+	ID string `json:"id"`
+	// in: query
+	// format: date-time
+	Since string `json:"since"`
+	// in: query
+	// maximum: 1000
+	// default: 100
+	Limit int `json:"limit"`
+}
+
+// swagger:response ResponseListAudit
+type ResponseListAudit struct {
+	// in: body
+	Body struct {
+		// enum: success
+		Status string              `json:"status"`
+		Data   []models.AuditEntry `json:"data"`
+	} `json:"body"`
+}
+
 ////////////////////
 // --- GENERIC ---//
 ////////////////////
@@ -259,6 +836,28 @@ type ResFailBadRequest struct {
 	} `json:"body"`
 }
 
+// Fail due to missing or invalid credentials
+// swagger:response GenericResFailUnauthorized
+type ResFailUnauthorized struct {
+	// in: body
+	Body struct {
+		// enum: fail
+		Status string      `json:"status"`
+		Data   interface{} `json:"data"`
+	} `json:"body"`
+}
+
+// Fail due to the caller lacking the permission
+// swagger:response GenericResFailForbidden
+type ResFailForbidden struct {
+	// in: body
+	Body struct {
+		// enum: fail
+		Status string      `json:"status"`
+		Data   interface{} `json:"data"`
+	} `json:"body"`
+}
+
 // Fail due to resource not exists
 // swagger:response GenericResFailNotFound
 type ResFailNotFound struct {
@@ -270,6 +869,28 @@ type ResFailNotFound struct {
 	} `json:"body"`
 }
 
+// Fail due to the resource being in a conflicting state
+// swagger:response GenericResFailConflict
+type ResFailConflict struct {
+	// in: body
+	Body struct {
+		// enum: fail
+		Status string      `json:"status"`
+		Data   interface{} `json:"data"`
+	} `json:"body"`
+}
+
+// Fail due to an If-Match header listing none of the ETags of the resource
+// swagger:response GenericResFailPrecondition
+type ResFailPrecondition struct {
+	// in: body
+	Body struct {
+		// enum: fail
+		Status string      `json:"status"`
+		Data   interface{} `json:"data"`
+	} `json:"body"`
+}
+
 // Unexpected error occurred
 // swagger:response GenericResError
 type ResError struct {
@@ -8,6 +8,7 @@ import (
 	"go.uber.org/zap"
 
 	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/config"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/models"
 	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/routes"
 	"github.com/gofiber/fiber/v2"
 	"github.com/spf13/cobra"
@@ -28,8 +29,16 @@ func GetAPICommandDef(cfg config.AppConfig, logger *zap.Logger) cobra.Command {
 
 			promMetrics := pMetrics.InitPrometheusMetrics()
 
+			repos, err := models.NewRepositories(cfg, logger)
+			if err != nil {
+				logger.Error("Failed to initialize storage", zap.String("storage", cfg.Storage), zap.Error(err))
+				return err
+			}
+
+			repos.WatchDatasets(cfg.CSVReloadInterval, logger, promMetrics)
+
 			// setup routes
-			err := routes.Setup(app, logger, cfg, promMetrics)
+			err = routes.Setup(app, repos, logger, cfg, promMetrics)
 
 			if err != nil {
 				return err
@@ -49,6 +58,10 @@ func GetAPICommandDef(cfg config.AppConfig, logger *zap.Logger) cobra.Command {
 				logger.Panic("error while shutdown server", zap.Error(err))
 			}
 
+			if err := repos.Close(); err != nil {
+				logger.Error("error while closing storage", zap.Error(err))
+			}
+
 			logger.Info("server stopped to receive new requests or connection.")
 			return nil
 		},
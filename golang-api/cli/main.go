@@ -9,8 +9,9 @@ import (
 // Init app initialization
 func Init(cfg config.AppConfig, logger *zap.Logger) error {
 	apiCmd := GetAPICommandDef(cfg, logger)
+	purgeCmd := GetPurgeCommandDef(cfg, logger)
 
 	rootCmd := &cobra.Command{Use: "golang-api"}
-	rootCmd.AddCommand(&apiCmd)
+	rootCmd.AddCommand(&apiCmd, &purgeCmd)
 	return rootCmd.Execute()
 }
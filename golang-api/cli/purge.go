@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/config"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/models"
+	"github.com/spf13/cobra"
+)
+
+// GetPurgeCommandDef removes soft deleted movies, ratings and credits for good. CSV files must not
+// be served by a running api meanwhile, as both would write them.
+func GetPurgeCommandDef(cfg config.AppConfig, logger *zap.Logger) cobra.Command {
+	var olderThan time.Duration
+
+	purgeCommand := cobra.Command{
+		Use:   "purge",
+		Short: "To remove soft deleted data for good",
+		Long:  `To remove the movies, ratings and credits deleted longer than --older-than ago for good`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repos, err := models.NewRepositories(cfg, logger)
+			if err != nil {
+				logger.Error("Failed to initialize storage", zap.String("storage", cfg.Storage), zap.Error(err))
+				return err
+			}
+			defer func() {
+				if err := repos.Close(); err != nil {
+					logger.Error("error while closing storage", zap.Error(err))
+				}
+			}()
+
+			counts, err := repos.Movies.PurgeDeleted(time.Now().Add(-olderThan))
+			if err != nil {
+				logger.Error("Failed to purge deleted data", zap.Error(err))
+				return err
+			}
+
+			logger.Info("Purged deleted data",
+				zap.Duration("olderThan", olderThan),
+				zap.Int("movies", counts.Movies),
+				zap.Int("ratings", counts.Ratings),
+				zap.Int("credits", counts.Credits),
+			)
+			return nil
+		},
+	}
+	purgeCommand.Flags().DurationVar(&olderThan, "older-than", 30*24*time.Hour, "purge data deleted longer ago than this")
+
+	return purgeCommand
+}
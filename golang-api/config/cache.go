@@ -0,0 +1,11 @@
+package config
+
+import "time"
+
+// CacheConfig type of response cache config object. Up to Size responses of read routes are
+// cached until a write changes their data, and for at most TTL so writes made by other replicas
+// show up. A Size of 0 disables the cache.
+type CacheConfig struct {
+	Size int           `envconfig:"CACHE_SIZE" default:"1000"`
+	TTL  time.Duration `envconfig:"CACHE_TTL" default:"1m"`
+}
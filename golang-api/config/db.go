@@ -0,0 +1,12 @@
+package config
+
+// DBConfig type of db config object, only used by the postgres storage backend
+type DBConfig struct {
+	Host        string `envconfig:"DB_HOST"`
+	Port        int    `envconfig:"DB_PORT"`
+	Username    string `envconfig:"DB_USERNAME"`
+	Password    string `envconfig:"DB_PASSWORD"`
+	Db          string `envconfig:"DB_NAME"`
+	QueryString string `envconfig:"DB_QUERYSTRING"`
+	Dialect     string `envconfig:"DB_DIALECT" default:"postgres"`
+}
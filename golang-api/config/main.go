@@ -3,6 +3,7 @@ package config
 import (
 	"log"
 	"os"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/kelseyhightower/envconfig"
@@ -17,9 +18,25 @@ type AppConfig struct {
 	Debug         bool   `envconfig:"DEBUG"`
 	Env           string `envconfig:"APP_ENV"`
 	Port          string `envconfig:"APP_PORT"`
+	Storage       string `envconfig:"STORAGE" default:"csv"`
+	DB            DBConfig
+	Auth          AuthConfig
+	RateLimit     RateLimitConfig
+	Cache         CacheConfig
 	Movies        string `envconfig:"MOVIES"`
 	Credits       string `envconfig:"CREDITS"`
 	Ratings       string `envconfig:"RATINGS"`
+	// AuditLog is the file the csv backend appends the audit log to
+	AuditLog string `envconfig:"AUDIT_LOG" default:"audit.jsonl"`
+
+	CSVCompactInterval  time.Duration `envconfig:"CSV_COMPACT_INTERVAL" default:"5m"`
+	CSVCompactThreshold int           `envconfig:"CSV_COMPACT_THRESHOLD" default:"1000"`
+	CSVReloadInterval   time.Duration `envconfig:"CSV_RELOAD_INTERVAL" default:"30s"`
+
+	// Movie scores are weighted as if every movie had RatingPriorVotes more ratings of
+	// RatingPriorMean, the mean of all ratings when it is 0
+	RatingPriorVotes float64 `envconfig:"RATING_PRIOR_VOTES" default:"10"`
+	RatingPriorMean  float64 `envconfig:"RATING_PRIOR_MEAN" default:"0"`
 }
 
 // GetConfig Collects all configs
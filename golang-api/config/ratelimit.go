@@ -0,0 +1,17 @@
+package config
+
+// RateLimitConfig type of rate limit config object. Limits are token buckets written as
+// count/period, e.g. 100/1m. IP limits every client IP across all route groups before credentials
+// are checked, it bounds the callers sharing an address, e.g. behind a NAT or proxy, all together
+// and so should be above the limits they get apart. Callers are then limited per route group, by
+// the subject of their credentials or else by their IP. Groups and Subjects are comma separated
+// /group=limit and subject=limit entries overriding Limit there, a subject override being capped
+// by IP. Store is memory or postgres, the latter sharing the buckets between replicas.
+type RateLimitConfig struct {
+	Enabled  bool   `envconfig:"RATE_LIMIT_ENABLED" default:"true"`
+	Store    string `envconfig:"RATE_LIMIT_STORE" default:"memory"`
+	IP       string `envconfig:"RATE_LIMIT_IP" default:"1200/1m"`
+	Limit    string `envconfig:"RATE_LIMIT" default:"300/1m"`
+	Groups   string `envconfig:"RATE_LIMIT_GROUPS"`
+	Subjects string `envconfig:"RATE_LIMIT_SUBJECTS"`
+}
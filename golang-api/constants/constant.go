@@ -1,9 +1,15 @@
 package constants
 
 const (
-	LoadMoviesError  = "Failed to load movies"
-	LoadRatingsError = "Failed to load ratings of movies"
-	LoadCreditsError = "Failed to load credits of movies"
+	LoadMoviesError      = "Failed to load movies"
+	LoadRatingsError     = "Failed to load ratings of movies"
+	LoadCreditsError     = "Failed to load credits of movies"
+	SearchMoviesError    = "Failed to search movies"
+	LoadUserRatingsError = "Failed to load ratings of user"
+	LoadUserStatsError   = "Failed to load rating stats of user"
+	LoadTopRatedError    = "Failed to load top rated movies"
+	LoadAuditError       = "Failed to load audit log"
+	LoadPersonError      = "Failed to load person"
 )
 
 const (
@@ -11,17 +17,20 @@ const (
 	AddRatingError    = "Failed to add ratings"
 	DeleteRatingError = "Failed to delete rating"
 	DeleteMovieError  = "Failed to delete movie"
+	RestoreMovieError = "Failed to restore movie"
 	UpdateMovieError  = "Failed to update movie"
 	UpdateRatingError = "Failed to update rating"
 	UpdateCrewError   = "Failed to update crew member details"
 	UpdateCastError   = "Failed to update cast member details"
+	AuditRecordError  = "Failed to record write in audit log"
 )
 
 const (
-	MovieId = "movieId"
-	UserId  = "userId"
-	CastId  = "castId"
-	CrewId  = "crewId"
+	MovieId  = "movieId"
+	UserId   = "userId"
+	CastId   = "castId"
+	CrewId   = "crewId"
+	PersonId = "personId"
 )
 
 const (
@@ -29,6 +38,7 @@ const (
 	AddMovieSuccess     = "Movie added successfully"
 	DeleteRatingSuccess = "Ratings deleted successfully"
 	DeleteMovieSuccess  = "Movie deleted successfully"
+	RestoreMovieSuccess = "Movie restored successfully"
 	UpdateMovieSuccess  = "Movie updated successfully"
 	UpdateRatingSuccess = "Ratings updated successfully"
 	UpdateCrewSuccess   = "Crew Member details updated successfully"
@@ -36,9 +46,28 @@ const (
 )
 
 const (
-	InvalidPageOrLimitError = "Invalid page number or limit number"
-	PaginationError         = "Pagination error"
-	InvalidRequestBody      = "Failed to parse request body"
-	ValidationFailed        = "Request body is not as required"
-	MovieCheckError         = "Movie not found"
+	InvalidPageOrLimitError   = "Invalid page number or limit number"
+	InvalidCursorOrLimitError = "Invalid cursor or limit number"
+	PaginationError           = "Pagination error"
+	InvalidRequestBody        = "Failed to parse request body"
+	ValidationFailed          = "Request body is not as required"
+	MovieCheckError           = "Movie not found"
+	SearchQueryRequired       = "Search query q is required"
+	UserNotFound              = "User has no ratings"
+	InvalidTopRatedLimitError = "Limit must be between 1 and 100"
+	MovieNotDeleted           = "Movie is not deleted"
+	MovieTitleTaken           = "Another movie has the title of the movie"
+	InvalidAuditSince         = "Since must be an RFC 3339 time"
+	InvalidAuditLimit         = "Limit must be between 1 and 1000"
+	PersonNotFound            = "Person not found"
+	RatingNotFound            = "Rating not found"
+)
+
+const (
+	InvalidCredentials     = "Invalid credentials"
+	AuthenticationRequired = "Authentication required"
+	PermissionDenied       = "Permission denied"
+	RatingOwnerError       = "Ratings of other users cannot be changed"
+	RateLimitExceeded      = "Rate limit exceeded, retry later"
+	PreconditionFailed     = "Resource was changed, fetch it again before retrying"
 )
@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"fmt"
+
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/constants"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/middlewares"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/models"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// anonymousActor is the actor of writes made without credentials, which auth disabled allows
+const anonymousActor = "anonymous"
+
+// Auditor records the writes made through the controllers in the audit log
+type Auditor struct {
+	repos  *models.Repositories
+	logger *zap.Logger
+}
+
+// NewAuditor is to initialize Auditor
+func NewAuditor(logger *zap.Logger, repos *models.Repositories) *Auditor {
+	return &Auditor{
+		repos:  repos,
+		logger: logger,
+	}
+}
+
+// auditedWrite is a write to record in the audit log. It changed the entity having ID from Before
+// into After, a nil Before or After standing for an entity which did not exist.
+type auditedWrite struct {
+	Entity    string
+	ID        string
+	Operation string
+	Before    interface{}
+	After     interface{}
+}
+
+// write runs write, which makes its changes through the repositories tx, and records it in the
+// audit log of tx, see models.Repositories.Atomically. The write is kept only along with its
+// entry: when the entry cannot be recorded the write is put back and the error returned, so the
+// request fails. Errors of write are returned as is.
+func (a *Auditor) write(c *fiber.Ctx, write func(tx *models.Repositories) (auditedWrite, error)) error {
+	actor := anonymousActor
+	if principal := middlewares.CurrentPrincipal(c); principal != nil && principal.Subject != "" {
+		actor = principal.Subject
+	}
+
+	return a.repos.Atomically(func(tx *models.Repositories) error {
+		audited, err := write(tx)
+		if err != nil {
+			return err
+		}
+
+		entry, err := models.NewAuditEntry(actor, audited.Entity, audited.ID, audited.Operation, audited.Before, audited.After)
+		if err == nil {
+			err = tx.Audit.Record(&entry)
+		}
+		if err != nil {
+			a.logger.Error(constants.AuditRecordError, zap.String("entity", audited.Entity), zap.String("id", audited.ID), zap.Error(err))
+			return fmt.Errorf("%s: %w", constants.AuditRecordError, err)
+		}
+		return nil
+	})
+}
+
+// auditState returns the entity read by current for the audit log, nil when it does not exist
+func auditState(current func() (interface{}, error)) (interface{}, error) {
+	data, err := current()
+	if isNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
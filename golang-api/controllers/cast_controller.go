@@ -1,8 +1,11 @@
 package controllers
 
 import (
+	"cmp"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
 
 	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/constants"
 	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/models"
@@ -13,18 +16,35 @@ import (
 )
 
 type CastController struct {
-	castModel *models.CastModel
+	castModel models.CastRepository
+	auditor   *Auditor
 	logger    *zap.Logger
 }
 
-func NewCastController(logger *zap.Logger) (*CastController, error) {
-	model := models.NewCastModel()
+func NewCastController(logger *zap.Logger, cast models.CastRepository, auditor *Auditor) (*CastController, error) {
 	return &CastController{
-		castModel: model,
+		castModel: cast,
+		auditor:   auditor,
 		logger:    logger,
 	}, nil
 }
 
+// currentCastMember reads the cast member having castId of movieId through cast for the audit log
+func currentCastMember(cast models.CastRepository, movieId, castId string) func() (interface{}, error) {
+	return func() (interface{}, error) {
+		castMembers, err := cast.ListCastMembers(movieId)
+		if err != nil {
+			return nil, err
+		}
+		for _, member := range castMembers {
+			if strconv.Itoa(member.ID) == castId {
+				return member, nil
+			}
+		}
+		return nil, models.ErrCastNotFound
+	}
+}
+
 // ListCastMembers retrieves all cast members by movieID
 // swagger:route GET /movies/{movieId}/casts Cast ListCastMembers
 //
@@ -41,9 +61,16 @@ func NewCastController(logger *zap.Logger) (*CastController, error) {
 //
 // Responses:
 //
-//	200: ResponseListCrewMembers
+//	200: ResponseListCastMembers
+//	304: description: Not Modified
+//	400: GenericErrorResponse
 //	500: GenericErrorResponse
 func (ctrl *CastController) ListCastMembers(c *fiber.Ctx) error {
+	page, err := CursorQuery(c)
+	if err != nil {
+		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidCursorOrLimitError)
+	}
+
 	movieId := c.Params(constants.MovieId)
 	castMembers, err := ctrl.castModel.ListCastMembers(movieId)
 	if err != nil {
@@ -51,7 +78,21 @@ func (ctrl *CastController) ListCastMembers(c *fiber.Ctx) error {
 		return utils.JSONError(c, http.StatusInternalServerError, constants.LoadCreditsError)
 	}
 
-	return utils.JSONSuccess(c, http.StatusOK, castMembers)
+	// The ETag covers every cast member, whichever page is asked for
+	if err := setETag(c, castMembers); err != nil {
+		ctrl.logger.Error(constants.LoadCreditsError, zap.Error(err))
+		return utils.JSONError(c, http.StatusInternalServerError, constants.LoadCreditsError)
+	}
+
+	// Cast members keep their billing order, cursors point at a credit
+	castPage, err := utils.KeysetPaginate(castMembers, page, func(member models.CastMember) []string {
+		return []string{member.CreditID}
+	}, nil)
+	if err != nil {
+		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidCursorOrLimitError)
+	}
+
+	return utils.JSONPage(c, http.StatusOK, castPage.Items, castPage)
 }
 
 // ListMoviesByCastId retrieves all movies IDs by castID
@@ -71,8 +112,14 @@ func (ctrl *CastController) ListCastMembers(c *fiber.Ctx) error {
 // Responses:
 //
 //	200: ResponseListMoviesByCastId
+//	400: GenericErrorResponse
 //	500: GenericErrorResponse
 func (ctrl *CastController) ListMoviesByCastId(c *fiber.Ctx) error {
+	page, err := CursorQuery(c)
+	if err != nil {
+		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidCursorOrLimitError)
+	}
+
 	castId := c.Params(constants.CastId)
 	movies, err := ctrl.castModel.ListMoviesByCastId(castId)
 	if err != nil {
@@ -80,7 +127,19 @@ func (ctrl *CastController) ListMoviesByCastId(c *fiber.Ctx) error {
 		return utils.JSONError(c, http.StatusInternalServerError, constants.LoadCreditsError)
 	}
 
-	return utils.JSONSuccess(c, http.StatusOK, movies)
+	// Movie ids are sorted, so cursors stay valid when movies are added or removed
+	moviesPage, err := utils.KeysetPaginate(movies, page, func(movieId int) []string {
+		return []string{strconv.Itoa(movieId)}
+	}, func(a, b []string) int {
+		x, _ := strconv.Atoi(a[0])
+		y, _ := strconv.Atoi(b[0])
+		return cmp.Compare(x, y)
+	})
+	if err != nil {
+		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidCursorOrLimitError)
+	}
+
+	return utils.JSONPage(c, http.StatusOK, moviesPage.Items, moviesPage)
 
 }
 
@@ -102,6 +161,9 @@ func (ctrl *CastController) ListMoviesByCastId(c *fiber.Ctx) error {
 //
 //	200: GenericSuccessResponse
 //	400: ValidationErrorResponse
+//	401: GenericErrorResponse
+//	403: GenericErrorResponse
+//	412: GenericErrorResponse
 //	500: GenericErrorResponse
 func (ctrl *CastController) UpdateCastMember(c *fiber.Ctx) error {
 	movieId := c.Params(constants.MovieId)
@@ -122,7 +184,27 @@ func (ctrl *CastController) UpdateCastMember(c *fiber.Ctx) error {
 		return utils.JSONError(c, http.StatusBadRequest, err.Error())
 	}
 
-	if err := ctrl.castModel.UpdateCastMember(movieId, castId, cast); err != nil {
+	err := ctrl.auditor.write(c, func(tx *models.Repositories) (auditedWrite, error) {
+		if err := checkIfMatch(c, tx, func() (interface{}, error) {
+			return tx.Cast.ListCastMembers(movieId)
+		}, models.AuditCast, movieId); err != nil {
+			return auditedWrite{}, err
+		}
+		before, err := auditState(currentCastMember(tx.Cast, movieId, castId))
+		if err != nil {
+			return auditedWrite{}, err
+		}
+		if err := tx.Cast.UpdateCastMember(movieId, castId, cast); err != nil {
+			return auditedWrite{}, err
+		}
+		after, err := auditState(currentCastMember(tx.Cast, movieId, castId))
+		return auditedWrite{Entity: models.AuditCast, ID: models.AuditEntityID(movieId, castId),
+			Operation: models.AuditUpdate, Before: before, After: after}, err
+	})
+	if errors.Is(err, errPreconditionFailed) {
+		return utils.JSONFail(c, http.StatusPreconditionFailed, constants.PreconditionFailed)
+	}
+	if err != nil {
 		ctrl.logger.Error(constants.UpdateCastError, zap.Error(err))
 		return utils.JSONError(c, http.StatusInternalServerError, constants.UpdateCastError)
 	}
@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"errors"
+
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/models"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+var errPreconditionFailed = errors.New("precondition failed")
+
+// setETag tags the response with the ETag of data, the resource it renders. Writes to the resource
+// compare it against their If-Match header.
+func setETag(c *fiber.Ctx, data interface{}) error {
+	etag, err := utils.DataETag(data)
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderETag, etag)
+	return nil
+}
+
+// checkIfMatch evaluates the If-Match header of a write against the ETag of the resource returned
+// by current, as set by setETag when the resource was read. It runs in the auditor.write closure of
+// the write and first locks the resource, entity having key, through tx, so no other write comes
+// between the check and the write. It returns errPreconditionFailed when none of the listed ETags
+// match, a missing resource matching none.
+func checkIfMatch(c *fiber.Ctx, tx *models.Repositories, current func() (interface{}, error), entity string, key ...string) error {
+	header := c.Get(fiber.HeaderIfMatch)
+	if header == "" {
+		return nil
+	}
+
+	if err := tx.Lock(entity, key...); err != nil {
+		return err
+	}
+	data, err := current()
+	if isNotFound(err) {
+		return errPreconditionFailed
+	}
+	if err != nil {
+		return err
+	}
+
+	etag, err := utils.DataETag(data)
+	if err != nil {
+		return err
+	}
+	if !utils.MatchETag(header, etag) {
+		return errPreconditionFailed
+	}
+	return nil
+}
+
+func isNotFound(err error) bool {
+	return errors.Is(err, models.ErrMovieNotFound) || errors.Is(err, models.ErrRatingNotFound) ||
+		errors.Is(err, models.ErrCastNotFound) || errors.Is(err, models.ErrCrewNotFound)
+}
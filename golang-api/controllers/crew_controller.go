@@ -2,7 +2,9 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
 
 	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/constants"
 	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/models"
@@ -13,18 +15,35 @@ import (
 )
 
 type CrewController struct {
-	crewModel *models.CrewModel
+	crewModel models.CrewRepository
+	auditor   *Auditor
 	logger    *zap.Logger
 }
 
-func NewCrewController(logger *zap.Logger) (*CrewController, error) {
-	model := models.NewCrewModel()
+func NewCrewController(logger *zap.Logger, crew models.CrewRepository, auditor *Auditor) (*CrewController, error) {
 	return &CrewController{
-		crewModel: model,
+		crewModel: crew,
+		auditor:   auditor,
 		logger:    logger,
 	}, nil
 }
 
+// currentCrewMember reads the crew member having crewId of movieId through crew for the audit log
+func currentCrewMember(crew models.CrewRepository, movieId, crewId string) func() (interface{}, error) {
+	return func() (interface{}, error) {
+		crewMembers, err := crew.ListCrewMembers(movieId)
+		if err != nil {
+			return nil, err
+		}
+		for _, member := range crewMembers {
+			if strconv.Itoa(member.ID) == crewId {
+				return member, nil
+			}
+		}
+		return nil, models.ErrCrewNotFound
+	}
+}
+
 // ListCrewMembers retrieves all crew members by movieID
 // swagger:route GET /movies/{movieId}/crew Crew ListCrewMembers
 //
@@ -42,8 +61,15 @@ func NewCrewController(logger *zap.Logger) (*CrewController, error) {
 // Responses:
 //
 //	200: ResponseListCrewMembers
+//	304: description: Not Modified
+//	400: GenericErrorResponse
 //	500: GenericErrorResponse
 func (ctrl *CrewController) ListCrewMembers(c *fiber.Ctx) error {
+	page, err := CursorQuery(c)
+	if err != nil {
+		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidCursorOrLimitError)
+	}
+
 	movieId := c.Params(constants.MovieId)
 	crewMembers, err := ctrl.crewModel.ListCrewMembers(movieId)
 	if err != nil {
@@ -51,7 +77,21 @@ func (ctrl *CrewController) ListCrewMembers(c *fiber.Ctx) error {
 		return utils.JSONError(c, http.StatusInternalServerError, constants.LoadCreditsError)
 	}
 
-	return utils.JSONSuccess(c, http.StatusOK, crewMembers)
+	// The ETag covers every crew member, whichever page is asked for
+	if err := setETag(c, crewMembers); err != nil {
+		ctrl.logger.Error(constants.LoadCreditsError, zap.Error(err))
+		return utils.JSONError(c, http.StatusInternalServerError, constants.LoadCreditsError)
+	}
+
+	// Crew members keep their stored order, cursors point at a credit
+	crewPage, err := utils.KeysetPaginate(crewMembers, page, func(member models.CrewMember) []string {
+		return []string{member.CreditID}
+	}, nil)
+	if err != nil {
+		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidCursorOrLimitError)
+	}
+
+	return utils.JSONPage(c, http.StatusOK, crewPage.Items, crewPage)
 
 }
 
@@ -73,6 +113,9 @@ func (ctrl *CrewController) ListCrewMembers(c *fiber.Ctx) error {
 //
 //	200: GenericSuccessResponse
 //	400: ValidationErrorResponse
+//	401: GenericErrorResponse
+//	403: GenericErrorResponse
+//	412: GenericErrorResponse
 //	500: GenericErrorResponse
 func (ctrl *CrewController) UpdateCrewMember(c *fiber.Ctx) error {
 	movieId := c.Params(constants.MovieId)
@@ -93,7 +136,27 @@ func (ctrl *CrewController) UpdateCrewMember(c *fiber.Ctx) error {
 		return utils.JSONError(c, http.StatusBadRequest, err.Error())
 	}
 
-	if err := ctrl.crewModel.UpdateCrewMember(movieId, crewId, crew); err != nil {
+	err := ctrl.auditor.write(c, func(tx *models.Repositories) (auditedWrite, error) {
+		if err := checkIfMatch(c, tx, func() (interface{}, error) {
+			return tx.Crew.ListCrewMembers(movieId)
+		}, models.AuditCrew, movieId); err != nil {
+			return auditedWrite{}, err
+		}
+		before, err := auditState(currentCrewMember(tx.Crew, movieId, crewId))
+		if err != nil {
+			return auditedWrite{}, err
+		}
+		if err := tx.Crew.UpdateCrewMember(movieId, crewId, crew); err != nil {
+			return auditedWrite{}, err
+		}
+		after, err := auditState(currentCrewMember(tx.Crew, movieId, crewId))
+		return auditedWrite{Entity: models.AuditCrew, ID: models.AuditEntityID(movieId, crewId),
+			Operation: models.AuditUpdate, Before: before, After: after}, err
+	})
+	if errors.Is(err, errPreconditionFailed) {
+		return utils.JSONFail(c, http.StatusPreconditionFailed, constants.PreconditionFailed)
+	}
+	if err != nil {
 		ctrl.logger.Error(constants.UpdateCrewError, zap.Error(err))
 		return utils.JSONError(c, http.StatusInternalServerError, constants.UpdateCrewError)
 	}
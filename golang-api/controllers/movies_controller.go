@@ -2,8 +2,11 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	constants "git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/constants"
 	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/models"
@@ -15,19 +18,27 @@ import (
 
 // MovieController for movieModel controllers
 type MovieController struct {
-	movieModel *models.MovieModel
+	movieModel models.MovieRepository
+	auditor    *Auditor
 	logger     *zap.Logger
 }
 
 // NewMovieController is to intialize MovieController
-func NewMovieController(logger *zap.Logger) (*MovieController, error) {
-	model := models.NewMovieModel()
+func NewMovieController(logger *zap.Logger, movies models.MovieRepository, auditor *Auditor) (*MovieController, error) {
 	return &MovieController{
-		movieModel: model,
+		movieModel: movies,
+		auditor:    auditor,
 		logger:     logger,
 	}, nil
 }
 
+// currentMovie reads the movie having movieId through movies for the audit log and If-Match checks
+func currentMovie(movies models.MovieRepository, movieId string) func() (interface{}, error) {
+	return func() (interface{}, error) {
+		return movies.GetMovie(movieId)
+	}
+}
+
 // PaginationQuery is to handle page and limit query
 func PaginationQuery(c *fiber.Ctx) (int, int, error) {
 	pageStr := c.Query("page", "1")    // Default: 1
@@ -46,10 +57,38 @@ func PaginationQuery(c *fiber.Ctx) (int, int, error) {
 	return page, limit, nil
 }
 
+// CursorQuery is to handle cursor, limit and total query of keyset paginated lists
+func CursorQuery(c *fiber.Ctx) (utils.PageRequest, error) {
+	limit, err := strconv.Atoi(c.Query("limit", "10")) // Default: 10
+	if err != nil {
+		return utils.PageRequest{}, err
+	}
+	if limit <= 0 {
+		return utils.PageRequest{}, fmt.Errorf("limit must be positive")
+	}
+
+	page := utils.PageRequest{Limit: limit, Total: c.QueryBool("total")}
+	if token := c.Query("cursor"); token != "" {
+		page.Cursor, err = utils.DecodeCursor(token)
+		if err != nil {
+			return utils.PageRequest{}, err
+		}
+	}
+	return page, nil
+}
+
+// movieFilterKeys are the query parameters of ListMovies passed on to the movie model
+var movieFilterKeys = []string{
+	"name", "genre", "genre_mode", "language", "status", "sort",
+	"release_date_min", "release_date_max", "runtime_min", "runtime_max",
+	"popularity_min", "popularity_max", "vote_average_min", "vote_average_max",
+	"vote_count_min", "vote_count_max",
+}
+
 // ListMovies lists all movies with pagination
 // swagger:route GET /movies Movies ListMovies
 //
-// Retrieves a paginated list of movies.
+// Retrieves a paginated list of movies, filtered and sorted by the query parameters.
 //
 // Consumes:
 // - application/json
@@ -63,26 +102,74 @@ func PaginationQuery(c *fiber.Ctx) (int, int, error) {
 // Responses:
 //
 //	200: ResponseListMovies
+//	400: GenericErrorResponse
 //	500: GenericErrorResponse
 func (ctrl *MovieController) ListMovies(c *fiber.Ctx) error {
 	// Extract query parameters
-	filters := map[string]string{
-		"name":     c.Query("name"),
-		"genre":    c.Query("genre"),
-		"language": c.Query("language"),
+	filters := make(map[string]string, len(movieFilterKeys))
+	for _, key := range movieFilterKeys {
+		filters[key] = c.Query(key)
 	}
 
-	page, limit, err := PaginationQuery(c)
+	page, err := CursorQuery(c)
 	if err != nil {
-		return utils.JSONFail(c, http.StatusInternalServerError, constants.InvalidPageOrLimitError)
+		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidCursorOrLimitError)
 	}
 
-	movies, err := ctrl.movieModel.ListMovies(filters, page, limit)
+	movies, err := ctrl.movieModel.ListMovies(filters, page)
+	if errors.Is(err, models.ErrInvalidFilter) {
+		return utils.JSONFail(c, http.StatusBadRequest, err.Error())
+	}
+	if errors.Is(err, utils.ErrInvalidCursor) {
+		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidCursorOrLimitError)
+	}
 	if err != nil {
 		return utils.JSONError(c, http.StatusInternalServerError, constants.PaginationError)
 	}
 
-	return utils.JSONSuccess(c, http.StatusOK, movies)
+	return utils.JSONPage(c, http.StatusOK, movies.Items, movies)
+}
+
+// SearchMovies runs a ranked full-text search over movies
+// swagger:route GET /movies/search Movies SearchMovies
+//
+// Searches titles, original titles, taglines and overviews of movies, best matches first.
+//
+// Consumes:
+// - application/json
+//
+// Produces:
+// - application/json
+//
+// Parameters:
+// - RequestSearchMovies
+//
+// Responses:
+//
+//	200: ResponseSearchMovies
+//	400: GenericErrorResponse
+//	500: GenericErrorResponse
+func (ctrl *MovieController) SearchMovies(c *fiber.Ctx) error {
+	query := c.Query("q")
+	if strings.TrimSpace(query) == "" {
+		return utils.JSONFail(c, http.StatusBadRequest, constants.SearchQueryRequired)
+	}
+
+	page, limit, err := PaginationQuery(c)
+	if err != nil {
+		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidPageOrLimitError)
+	}
+
+	results, err := ctrl.movieModel.SearchMovies(query, page, limit)
+	if errors.Is(err, models.ErrNoMoviesMatched) {
+		return utils.JSONSuccess(c, http.StatusOK, []models.MovieSearchResult{})
+	}
+	if err != nil {
+		ctrl.logger.Error(constants.SearchMoviesError, zap.String("query", query), zap.Error(err))
+		return utils.JSONError(c, http.StatusInternalServerError, constants.SearchMoviesError)
+	}
+
+	return utils.JSONSuccess(c, http.StatusOK, results)
 }
 
 // GetMovieByID retrieves a movie by ID
@@ -102,6 +189,7 @@ func (ctrl *MovieController) ListMovies(c *fiber.Ctx) error {
 // Responses:
 //
 //	200: ResponseGetMovieByID
+//	304: description: Not Modified
 //	500: GenericErrorResponse
 func (ctrl *MovieController) GetMovieByID(c *fiber.Ctx) error {
 	movieId := c.Params(constants.MovieId)
@@ -111,6 +199,11 @@ func (ctrl *MovieController) GetMovieByID(c *fiber.Ctx) error {
 		return utils.JSONError(c, http.StatusInternalServerError, constants.LoadMoviesError)
 	}
 
+	if err := setETag(c, movie); err != nil {
+		ctrl.logger.Error(constants.LoadMoviesError, zap.Error(err))
+		return utils.JSONError(c, http.StatusInternalServerError, constants.LoadMoviesError)
+	}
+
 	return utils.JSONSuccess(c, http.StatusOK, movie)
 }
 
@@ -133,6 +226,8 @@ func (ctrl *MovieController) GetMovieByID(c *fiber.Ctx) error {
 //
 //	200: GenericSuccessResponse
 //	400: ValidationErrorResponse
+//	401: GenericErrorResponse
+//	403: GenericErrorResponse
 //	500: GenericErrorResponse
 func (ctrl *MovieController) AddMovie(c *fiber.Ctx) error {
 	var validate = validator.New()
@@ -150,7 +245,14 @@ func (ctrl *MovieController) AddMovie(c *fiber.Ctx) error {
 		return utils.JSONError(c, http.StatusBadRequest, err.Error())
 	}
 
-	if err := ctrl.movieModel.AddMovie(&movie); err != nil {
+	err := ctrl.auditor.write(c, func(tx *models.Repositories) (auditedWrite, error) {
+		if err := tx.Movies.AddMovie(&movie); err != nil {
+			return auditedWrite{}, err
+		}
+		after, err := auditState(currentMovie(tx.Movies, movie.ID))
+		return auditedWrite{Entity: models.AuditMovie, ID: movie.ID, Operation: models.AuditCreate, After: after}, err
+	})
+	if err != nil {
 		ctrl.logger.Error(constants.AddMovieError, zap.Error(err))
 		return utils.JSONError(c, http.StatusInternalServerError, constants.AddMovieError)
 	}
@@ -176,6 +278,9 @@ func (ctrl *MovieController) AddMovie(c *fiber.Ctx) error {
 //
 //	200: GenericSuccessResponse
 //	400: GenericErrorResponse
+//	401: GenericErrorResponse
+//	403: GenericErrorResponse
+//	412: GenericErrorResponse
 //	500: GenericErrorResponse
 func (ctrl *MovieController) DeleteMovieById(c *fiber.Ctx) error {
 	movieId := c.Params(constants.MovieId)
@@ -184,7 +289,20 @@ func (ctrl *MovieController) DeleteMovieById(c *fiber.Ctx) error {
 		return utils.JSONError(c, http.StatusBadRequest, constants.InvalidRequestBody)
 	}
 
-	err := ctrl.movieModel.DeleteMovie(movieId)
+	err := ctrl.auditor.write(c, func(tx *models.Repositories) (auditedWrite, error) {
+		if err := checkIfMatch(c, tx, currentMovie(tx.Movies, movieId), models.AuditMovie, movieId); err != nil {
+			return auditedWrite{}, err
+		}
+		before, err := auditState(currentMovie(tx.Movies, movieId))
+		if err != nil {
+			return auditedWrite{}, err
+		}
+		err = tx.Movies.DeleteMovie(movieId)
+		return auditedWrite{Entity: models.AuditMovie, ID: movieId, Operation: models.AuditDelete, Before: before}, err
+	})
+	if errors.Is(err, errPreconditionFailed) {
+		return utils.JSONFail(c, http.StatusPreconditionFailed, constants.PreconditionFailed)
+	}
 	if err != nil {
 		ctrl.logger.Error(constants.DeleteMovieError, zap.Error(err))
 		return utils.JSONError(c, http.StatusInternalServerError, constants.DeleteMovieError)
@@ -193,6 +311,50 @@ func (ctrl *MovieController) DeleteMovieById(c *fiber.Ctx) error {
 	return utils.JSONSuccess(c, http.StatusOK, constants.DeleteMovieSuccess)
 }
 
+// RestoreMovieById restores a deleted movie by ID
+// swagger:route POST /movies/{movieId}/restore Movies RestoreMovieById
+//
+// Restores a deleted movie along with the ratings and credits deleted with it.
+//
+// Produces:
+// - application/json
+//
+// Parameters:
+// - RequestRestoreMovieByID
+//
+// Responses:
+//
+//	200: GenericSuccessResponse
+//	401: GenericErrorResponse
+//	403: GenericErrorResponse
+//	404: GenericErrorResponse
+//	409: GenericErrorResponse
+//	500: GenericErrorResponse
+func (ctrl *MovieController) RestoreMovieById(c *fiber.Ctx) error {
+	movieId := c.Params(constants.MovieId)
+
+	err := ctrl.auditor.write(c, func(tx *models.Repositories) (auditedWrite, error) {
+		if err := tx.Movies.RestoreMovie(movieId); err != nil {
+			return auditedWrite{}, err
+		}
+		after, err := auditState(currentMovie(tx.Movies, movieId))
+		return auditedWrite{Entity: models.AuditMovie, ID: movieId, Operation: models.AuditRestore, After: after}, err
+	})
+	switch {
+	case errors.Is(err, models.ErrMovieNotFound):
+		return utils.JSONFail(c, http.StatusNotFound, constants.MovieCheckError)
+	case errors.Is(err, models.ErrMovieNotDeleted):
+		return utils.JSONFail(c, http.StatusConflict, constants.MovieNotDeleted)
+	case errors.Is(err, models.ErrMovieAlreadyExists):
+		return utils.JSONFail(c, http.StatusConflict, constants.MovieTitleTaken)
+	case err != nil:
+		ctrl.logger.Error(constants.RestoreMovieError, zap.Error(err))
+		return utils.JSONError(c, http.StatusInternalServerError, constants.RestoreMovieError)
+	}
+
+	return utils.JSONSuccess(c, http.StatusOK, constants.RestoreMovieSuccess)
+}
+
 // UpdateMovie updates a movie by ID
 // swagger:route PUT /movies/{movieId} Movies UpdateMovie
 //
@@ -211,6 +373,9 @@ func (ctrl *MovieController) DeleteMovieById(c *fiber.Ctx) error {
 //
 //	200: GenericSuccessResponse
 //	400: ValidationErrorResponse
+//	401: GenericErrorResponse
+//	403: GenericErrorResponse
+//	412: GenericErrorResponse
 //	500: GenericErrorResponse
 func (ctrl *MovieController) UpdateMovie(c *fiber.Ctx) error {
 	movieId := c.Params(constants.MovieId)
@@ -235,7 +400,24 @@ func (ctrl *MovieController) UpdateMovie(c *fiber.Ctx) error {
 		return utils.JSONError(c, http.StatusBadRequest, err.Error())
 	}
 
-	if err := ctrl.movieModel.UpdateMovie(movieId, &movie); err != nil {
+	err := ctrl.auditor.write(c, func(tx *models.Repositories) (auditedWrite, error) {
+		if err := checkIfMatch(c, tx, currentMovie(tx.Movies, movieId), models.AuditMovie, movieId); err != nil {
+			return auditedWrite{}, err
+		}
+		before, err := auditState(currentMovie(tx.Movies, movieId))
+		if err != nil {
+			return auditedWrite{}, err
+		}
+		if err := tx.Movies.UpdateMovie(movieId, &movie); err != nil {
+			return auditedWrite{}, err
+		}
+		after, err := auditState(currentMovie(tx.Movies, movieId))
+		return auditedWrite{Entity: models.AuditMovie, ID: movieId, Operation: models.AuditUpdate, Before: before, After: after}, err
+	})
+	if errors.Is(err, errPreconditionFailed) {
+		return utils.JSONFail(c, http.StatusPreconditionFailed, constants.PreconditionFailed)
+	}
+	if err != nil {
 		ctrl.logger.Error(constants.UpdateMovieError, zap.Error(err))
 		return utils.JSONError(c, http.StatusInternalServerError, constants.UpdateMovieError)
 	}
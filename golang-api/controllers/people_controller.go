@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/constants"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/models"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/utils"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// filmographyFilterKeys are the query parameters passed to PeopleRepository.GetPerson as filters
+var filmographyFilterKeys = []string{"role", "department", "sort"}
+
+// PeopleController serves the people credited on movies
+type PeopleController struct {
+	peopleModel models.PeopleRepository
+	logger      *zap.Logger
+}
+
+// NewPeopleController is to initialize PeopleController
+func NewPeopleController(logger *zap.Logger, people models.PeopleRepository) (*PeopleController, error) {
+	return &PeopleController{
+		peopleModel: people,
+		logger:      logger,
+	}, nil
+}
+
+// GetPerson retrieves a person along with their filmography
+// swagger:route GET /people/{personId} People GetPerson
+//
+// Retrieves a person along with a paginated filmography merging their cast and crew credits.
+//
+// Consumes:
+// - application/json
+//
+// Produces:
+// - application/json
+//
+// Parameters:
+// - RequestGetPerson
+//
+// Responses:
+//
+//	200: ResponseGetPerson
+//	400: GenericErrorResponse
+//	404: GenericErrorResponse
+//	500: GenericErrorResponse
+func (ctrl *PeopleController) GetPerson(c *fiber.Ctx) error {
+	filters := make(map[string]string, len(filmographyFilterKeys))
+	for _, key := range filmographyFilterKeys {
+		filters[key] = c.Query(key)
+	}
+
+	page, err := CursorQuery(c)
+	if err != nil {
+		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidCursorOrLimitError)
+	}
+
+	person, filmography, err := ctrl.peopleModel.GetPerson(c.Params(constants.PersonId), filters, page)
+	if errors.Is(err, models.ErrInvalidFilter) {
+		return utils.JSONFail(c, http.StatusBadRequest, err.Error())
+	}
+	if errors.Is(err, utils.ErrInvalidCursor) {
+		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidCursorOrLimitError)
+	}
+	if errors.Is(err, models.ErrPersonNotFound) {
+		return utils.JSONFail(c, http.StatusNotFound, constants.PersonNotFound)
+	}
+	if err != nil {
+		ctrl.logger.Error(constants.LoadPersonError, zap.Error(err))
+		return utils.JSONError(c, http.StatusInternalServerError, constants.LoadPersonError)
+	}
+
+	if person.Filmography == nil {
+		person.Filmography = []models.FilmographyEntry{}
+	}
+	return utils.JSONPage(c, http.StatusOK, person, filmography)
+}
@@ -2,11 +2,14 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	constants "git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/constants"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/middlewares"
 	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/models"
 	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/utils"
 	"github.com/go-playground/validator/v10"
@@ -16,26 +19,34 @@ import (
 
 // RatingsController for ratingModel and movieModel controllers
 type RatingsController struct {
-	ratingModel *models.RatingModel
-	movieModel  *models.MovieModel
+	ratingModel models.RatingRepository
+	movieModel  models.MovieRepository
+	auditor     *Auditor
 	logger      *zap.Logger
 }
 
 // NewRatingsController is to initialize RatingsController
-func NewRatingsController(logger *zap.Logger) (*RatingsController, error) {
-	model := models.NewRatingsModel()
-	movieModel := models.NewMovieModel()
+func NewRatingsController(logger *zap.Logger, ratings models.RatingRepository, movies models.MovieRepository, auditor *Auditor) (*RatingsController, error) {
 	return &RatingsController{
-		ratingModel: model,
-		movieModel:  movieModel,
+		ratingModel: ratings,
+		movieModel:  movies,
+		auditor:     auditor,
 		logger:      logger,
 	}, nil
 }
 
+// currentRating reads the rating userId gave to movieId through ratings for the audit log and
+// If-Match checks
+func currentRating(ratings models.RatingRepository, movieId, userId string) func() (interface{}, error) {
+	return func() (interface{}, error) {
+		return ratings.GetRating(movieId, userId)
+	}
+}
+
 // ListAllMovieRatings displays average ratings of all movies
 // swagger:route GET /ratings Ratings ListAllMovieRatings
 //
-// Retrieves a paginated ratings of movies.
+// Retrieves a paginated list of the rating stats of movies.
 //
 // Consumes:
 // - application/json
@@ -49,20 +60,70 @@ func NewRatingsController(logger *zap.Logger) (*RatingsController, error) {
 // Responses:
 //
 //	200: ResponseListAllMovieRatings
+//	400: GenericErrorResponse
 //	500: GenericErrorResponse
 func (ctrl *RatingsController) ListAllMovieRatings(c *fiber.Ctx) error {
-	page, limit, err := PaginationQuery(c)
+	page, err := CursorQuery(c)
 	if err != nil {
-		return utils.JSONFail(c, http.StatusInternalServerError, constants.InvalidPageOrLimitError)
+		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidCursorOrLimitError)
 	}
 
-	ratings, err := ctrl.ratingModel.ListRatings(page, limit)
+	ratings, err := ctrl.ratingModel.ListRatings(page)
+	if errors.Is(err, utils.ErrInvalidCursor) {
+		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidCursorOrLimitError)
+	}
 	if err != nil {
 		ctrl.logger.Error(constants.LoadRatingsError, zap.Error(err))
 		return utils.JSONError(c, http.StatusInternalServerError, constants.LoadRatingsError)
 	}
 
-	return utils.JSONSuccess(c, http.StatusOK, ratings)
+	return utils.JSONPage(c, http.StatusOK, ratings.Items, ratings)
+}
+
+// topRatedLimit caps the number of movies of the top rated leaderboard
+const topRatedLimit = 100
+
+// TopRatedMovies lists the movies with the best weighted rating scores
+// swagger:route GET /ratings/top Ratings TopRatedMovies
+//
+// Retrieves the top rated movies, filtered by genre, language and release year.
+//
+// Consumes:
+// - application/json
+//
+// Produces:
+// - application/json
+//
+// Parameters:
+// - RequestTopRatedMovies
+//
+// Responses:
+//
+//	200: ResponseTopRatedMovies
+//	400: GenericErrorResponse
+//	500: GenericErrorResponse
+func (ctrl *RatingsController) TopRatedMovies(c *fiber.Ctx) error {
+	limit, err := strconv.Atoi(c.Query("limit", "10")) // Default: 10
+	if err != nil || limit <= 0 || limit > topRatedLimit {
+		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidTopRatedLimitError)
+	}
+
+	filters := map[string]string{
+		"genre":    c.Query("genre"),
+		"language": c.Query("language"),
+		"year":     c.Query("year"),
+	}
+
+	movies, err := ctrl.movieModel.TopRatedMovies(filters, limit)
+	if errors.Is(err, models.ErrInvalidFilter) {
+		return utils.JSONFail(c, http.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		ctrl.logger.Error(constants.LoadTopRatedError, zap.Error(err))
+		return utils.JSONError(c, http.StatusInternalServerError, constants.LoadTopRatedError)
+	}
+
+	return utils.JSONSuccess(c, http.StatusOK, movies)
 }
 
 // GetRatingsByMovieId retrieves ratings of a movie by its ID
@@ -82,6 +143,7 @@ func (ctrl *RatingsController) ListAllMovieRatings(c *fiber.Ctx) error {
 // Responses:
 //
 //	200: ResponseGetRatingsByMovieId
+//	304: description: Not Modified
 //	500: GenericErrorResponse
 func (ctrl *RatingsController) GetRatingsByMovieId(c *fiber.Ctx) error {
 	movieId := c.Params(constants.MovieId)
@@ -92,9 +154,53 @@ func (ctrl *RatingsController) GetRatingsByMovieId(c *fiber.Ctx) error {
 		return utils.JSONError(c, http.StatusInternalServerError, constants.LoadRatingsError)
 	}
 
+	if err := setETag(c, ratings); err != nil {
+		ctrl.logger.Error(constants.LoadRatingsError, zap.Error(err))
+		return utils.JSONError(c, http.StatusInternalServerError, constants.LoadRatingsError)
+	}
+
 	return utils.JSONSuccess(c, http.StatusOK, ratings)
 }
 
+// GetRating retrieves the rating a user gave to a movie
+// swagger:route GET /ratings/movies/{movieId}/user/{userId}/ratings Ratings GetRating
+//
+// Retrieves the rating a user gave to a movie. Its ETag is the one PUT and DELETE of the rating
+// compare their If-Match header against.
+//
+// Produces:
+// - application/json
+//
+// Parameters:
+// - RequestGetRating
+//
+// Responses:
+//
+//	200: ResponseGetRating
+//	304: description: Not Modified
+//	404: GenericErrorResponse
+//	500: GenericErrorResponse
+func (ctrl *RatingsController) GetRating(c *fiber.Ctx) error {
+	movieId := c.Params(constants.MovieId)
+	userId := c.Params(constants.UserId)
+
+	rating, err := ctrl.ratingModel.GetRating(movieId, userId)
+	if errors.Is(err, models.ErrRatingNotFound) {
+		return utils.JSONFail(c, http.StatusNotFound, constants.RatingNotFound)
+	}
+	if err != nil {
+		ctrl.logger.Error(constants.LoadRatingsError, zap.Error(err))
+		return utils.JSONError(c, http.StatusInternalServerError, constants.LoadRatingsError)
+	}
+
+	if err := setETag(c, rating); err != nil {
+		ctrl.logger.Error(constants.LoadRatingsError, zap.Error(err))
+		return utils.JSONError(c, http.StatusInternalServerError, constants.LoadRatingsError)
+	}
+
+	return utils.JSONSuccess(c, http.StatusOK, rating)
+}
+
 // AddRating adds ratings of a movie
 // swagger:route POST /ratings Ratings AddRating
 //
@@ -114,6 +220,8 @@ func (ctrl *RatingsController) GetRatingsByMovieId(c *fiber.Ctx) error {
 //
 //	200: GenericSuccessResponse
 //	400: ValidationErrorResponse
+//	401: GenericErrorResponse
+//	403: GenericErrorResponse
 //	500: GenericErrorResponse
 func (ctrl *RatingsController) AddRating(c *fiber.Ctx) error {
 	var rating models.Ratings
@@ -125,6 +233,12 @@ func (ctrl *RatingsController) AddRating(c *fiber.Ctx) error {
 		return utils.JSONError(c, http.StatusBadRequest, constants.InvalidRequestBody)
 	}
 
+	userId, ok := ratingOwner(c, rating.UserId)
+	if !ok {
+		return utils.JSONFail(c, http.StatusForbidden, constants.RatingOwnerError)
+	}
+	rating.UserId = userId
+
 	// Validate rating fields
 	if err := validate.Struct(rating); err != nil {
 		ctrl.logger.Error(constants.ValidationFailed, zap.Error(err))
@@ -143,7 +257,25 @@ func (ctrl *RatingsController) AddRating(c *fiber.Ctx) error {
 	}
 
 	// Save rating if movie exists
-	if err := ctrl.ratingModel.AddRatings(&rating); err != nil {
+	err = ctrl.auditor.write(c, func(tx *models.Repositories) (auditedWrite, error) {
+		before, err := auditState(currentRating(tx.Ratings, rating.MovieId, rating.UserId))
+		if err != nil {
+			return auditedWrite{}, err
+		}
+		if err := tx.Ratings.AddRatings(&rating); err != nil {
+			return auditedWrite{}, err
+		}
+		after, err := auditState(currentRating(tx.Ratings, rating.MovieId, rating.UserId))
+
+		// Rating a movie again replaces the earlier rating
+		operation := models.AuditCreate
+		if before != nil {
+			operation = models.AuditUpdate
+		}
+		return auditedWrite{Entity: models.AuditRating, ID: models.AuditEntityID(rating.MovieId, rating.UserId),
+			Operation: operation, Before: before, After: after}, err
+	})
+	if err != nil {
 		ctrl.logger.Error(constants.AddRatingError, zap.Error(err))
 		return utils.JSONError(c, http.StatusInternalServerError, constants.AddRatingError)
 	}
@@ -169,16 +301,37 @@ func (ctrl *RatingsController) AddRating(c *fiber.Ctx) error {
 //
 //	200: GenericSuccessResponse
 //	400: GenericErrorResponse
+//	401: GenericErrorResponse
+//	403: GenericErrorResponse
+//	412: GenericErrorResponse
 //	500: GenericErrorResponse
 func (ctrl *RatingsController) DeleteRating(c *fiber.Ctx) error {
 	userId := c.Params(constants.UserId)
 	movieId := c.Params(constants.MovieId)
 
+	if _, ok := ratingOwner(c, userId); !ok {
+		return utils.JSONFail(c, http.StatusForbidden, constants.RatingOwnerError)
+	}
+
 	if movieId == "" && userId == "" {
 		return utils.JSONError(c, http.StatusBadRequest, constants.ValidationFailed)
 	}
 
-	err := ctrl.ratingModel.DeleteRatings(movieId, &userId)
+	err := ctrl.auditor.write(c, func(tx *models.Repositories) (auditedWrite, error) {
+		if err := checkIfMatch(c, tx, currentRating(tx.Ratings, movieId, userId), models.AuditRating, movieId, userId); err != nil {
+			return auditedWrite{}, err
+		}
+		before, err := auditState(currentRating(tx.Ratings, movieId, userId))
+		if err != nil {
+			return auditedWrite{}, err
+		}
+		err = tx.Ratings.DeleteRatings(movieId, &userId)
+		return auditedWrite{Entity: models.AuditRating, ID: models.AuditEntityID(movieId, userId),
+			Operation: models.AuditDelete, Before: before}, err
+	})
+	if errors.Is(err, errPreconditionFailed) {
+		return utils.JSONFail(c, http.StatusPreconditionFailed, constants.PreconditionFailed)
+	}
 	if err != nil {
 		ctrl.logger.Error(constants.DeleteRatingError, zap.Error(err))
 		return utils.JSONError(c, http.StatusInternalServerError, constants.DeleteRatingError)
@@ -205,11 +358,18 @@ func (ctrl *RatingsController) DeleteRating(c *fiber.Ctx) error {
 //
 //	200: GenericSuccessResponse
 //	400: ValidationErrorResponse
+//	401: GenericErrorResponse
+//	403: GenericErrorResponse
+//	412: GenericErrorResponse
 //	500: GenericErrorResponse
 func (ctrl *RatingsController) UpdateRating(c *fiber.Ctx) error {
 	userId := c.Params(constants.UserId)
 	movieId := c.Params(constants.MovieId)
 
+	if _, ok := ratingOwner(c, userId); !ok {
+		return utils.JSONFail(c, http.StatusForbidden, constants.RatingOwnerError)
+	}
+
 	if movieId == "" && userId == "" {
 		return utils.JSONError(c, http.StatusBadRequest, constants.InvalidRequestBody)
 	}
@@ -224,7 +384,24 @@ func (ctrl *RatingsController) UpdateRating(c *fiber.Ctx) error {
 
 	newTimestamp := fmt.Sprintf("%d", time.Now().Unix())
 
-	err := ctrl.ratingModel.UpdateRatings(userId, movieId, updateData.Rating, newTimestamp)
+	err := ctrl.auditor.write(c, func(tx *models.Repositories) (auditedWrite, error) {
+		if err := checkIfMatch(c, tx, currentRating(tx.Ratings, movieId, userId), models.AuditRating, movieId, userId); err != nil {
+			return auditedWrite{}, err
+		}
+		before, err := auditState(currentRating(tx.Ratings, movieId, userId))
+		if err != nil {
+			return auditedWrite{}, err
+		}
+		if err := tx.Ratings.UpdateRatings(userId, movieId, updateData.Rating, newTimestamp); err != nil {
+			return auditedWrite{}, err
+		}
+		after, err := auditState(currentRating(tx.Ratings, movieId, userId))
+		return auditedWrite{Entity: models.AuditRating, ID: models.AuditEntityID(movieId, userId),
+			Operation: models.AuditUpdate, Before: before, After: after}, err
+	})
+	if errors.Is(err, errPreconditionFailed) {
+		return utils.JSONFail(c, http.StatusPreconditionFailed, constants.PreconditionFailed)
+	}
 	if err != nil {
 		ctrl.logger.Error(constants.UpdateRatingError, zap.Error(err))
 		return utils.JSONError(c, http.StatusInternalServerError, constants.UpdateRatingError)
@@ -233,3 +410,16 @@ func (ctrl *RatingsController) UpdateRating(c *fiber.Ctx) error {
 	return utils.JSONSuccess(c, http.StatusOK, constants.UpdateRatingSuccess)
 
 }
+
+// ratingOwner resolves the user a rating request acts for, an empty userId meaning the caller.
+// Only admins may change the ratings of other users.
+func ratingOwner(c *fiber.Ctx, userId string) (string, bool) {
+	principal := middlewares.CurrentPrincipal(c)
+	if principal == nil {
+		return "", false
+	}
+	if userId == "" {
+		return principal.Subject, true
+	}
+	return userId, userId == principal.Subject || principal.Has(middlewares.RoleAdmin)
+}
@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	constants "git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/constants"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/models"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/utils"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// UsersController for userModel controllers
+type UsersController struct {
+	userModel models.UserRepository
+	logger    *zap.Logger
+}
+
+// NewUsersController is to initialize UsersController
+func NewUsersController(logger *zap.Logger, users models.UserRepository) (*UsersController, error) {
+	return &UsersController{
+		userModel: users,
+		logger:    logger,
+	}, nil
+}
+
+// ListUserRatings lists the ratings given by a user
+// swagger:route GET /users/{userId}/ratings Users ListUserRatings
+//
+// Retrieves a paginated list of the ratings given by a user.
+//
+// Consumes:
+// - application/json
+//
+// Produces:
+// - application/json
+//
+// Parameters:
+// - RequestListUserRatings
+//
+// Responses:
+//
+//	200: ResponseListUserRatings
+//	400: GenericErrorResponse
+//	404: GenericErrorResponse
+//	500: GenericErrorResponse
+func (ctrl *UsersController) ListUserRatings(c *fiber.Ctx) error {
+	userId := c.Params(constants.UserId)
+
+	page, err := CursorQuery(c)
+	if err != nil {
+		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidCursorOrLimitError)
+	}
+
+	ratings, err := ctrl.userModel.ListUserRatings(userId, c.Query("sort"), page)
+	if errors.Is(err, utils.ErrInvalidCursor) {
+		return utils.JSONFail(c, http.StatusBadRequest, constants.InvalidCursorOrLimitError)
+	}
+	if errors.Is(err, models.ErrInvalidSort) {
+		return utils.JSONFail(c, http.StatusBadRequest, err.Error())
+	}
+	if errors.Is(err, models.ErrUserNotFound) {
+		return utils.JSONFail(c, http.StatusNotFound, constants.UserNotFound)
+	}
+	if err != nil {
+		ctrl.logger.Error(constants.LoadUserRatingsError, zap.Error(err))
+		return utils.JSONError(c, http.StatusInternalServerError, constants.LoadUserRatingsError)
+	}
+
+	return utils.JSONPage(c, http.StatusOK, ratings.Items, ratings)
+}
+
+// GetUserStats summarizes the ratings given by a user
+// swagger:route GET /users/{userId}/stats Users GetUserStats
+//
+// Retrieves the rating count, mean, distribution and favourite genres of a user.
+//
+// Consumes:
+// - application/json
+//
+// Produces:
+// - application/json
+//
+// Parameters:
+// - RequestGetUserStats
+//
+// Responses:
+//
+//	200: ResponseGetUserStats
+//	404: GenericErrorResponse
+//	500: GenericErrorResponse
+func (ctrl *UsersController) GetUserStats(c *fiber.Ctx) error {
+	userId := c.Params(constants.UserId)
+
+	stats, err := ctrl.userModel.GetUserStats(userId)
+	if errors.Is(err, models.ErrUserNotFound) {
+		return utils.JSONFail(c, http.StatusNotFound, constants.UserNotFound)
+	}
+	if err != nil {
+		ctrl.logger.Error(constants.LoadUserStatsError, zap.Error(err))
+		return utils.JSONError(c, http.StatusInternalServerError, constants.LoadUserStatsError)
+	}
+
+	return utils.JSONSuccess(c, http.StatusOK, stats)
+}
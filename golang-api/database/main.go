@@ -0,0 +1,40 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/config"
+	"github.com/doug-martin/goqu/v9"
+	_ "github.com/doug-martin/goqu/v9/dialect/postgres"
+	_ "github.com/lib/pq" // for postgres driver
+)
+
+var db *sql.DB
+var dbURL string
+var err error
+
+const POSTGRES = "postgres"
+
+// Connect with database
+func Connect(cfg config.DBConfig) (*goqu.Database, error) {
+	switch cfg.Dialect {
+	case POSTGRES:
+		return postgresDBConnection(cfg)
+	default:
+		return nil, errors.New("no suitable dialect found")
+	}
+}
+
+func postgresDBConnection(cfg config.DBConfig) (*goqu.Database, error) {
+	dbURL = "postgres://" + cfg.Username + ":" + cfg.Password + "@" + cfg.Host + ":" + strconv.Itoa(cfg.Port) + "/" + cfg.Db + "?" + cfg.QueryString
+	if db == nil {
+		db, err = sql.Open(POSTGRES, dbURL)
+		if err != nil {
+			return nil, err
+		}
+		return goqu.New(POSTGRES, db), err
+	}
+	return goqu.New(POSTGRES, db), err
+}
@@ -6,6 +6,7 @@ toolchain go1.21.6
 
 require (
 	clevergo.tech/jsend v1.1.3
+	github.com/doug-martin/goqu/v9 v9.19.0
 	github.com/getsentry/sentry-go v0.25.0
 	github.com/go-playground/validator/v10 v10.25.0
 	github.com/gofiber/adaptor/v2 v2.2.1
@@ -13,6 +14,7 @@ require (
 	github.com/gofiber/fiber/v2 v2.52.5
 	github.com/joho/godotenv v1.5.1
 	github.com/kelseyhightower/envconfig v1.4.0
+	github.com/lib/pq v1.10.9
 	github.com/prometheus/client_golang v1.18.0
 	github.com/samber/lo v1.38.1
 	github.com/sirupsen/logrus v1.9.3
@@ -0,0 +1,122 @@
+package middlewares
+
+import (
+	"net/http"
+	"time"
+
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/config"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/models"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// HeaderCache tells whether a response was served from the cache
+const HeaderCache = "X-Cache"
+
+// cachedResponse is a successful response of a read route along with the revision of the
+// datasets it was built from
+type cachedResponse struct {
+	body        []byte
+	contentType string
+	etag        string
+	revision    models.Revision
+	stored      time.Time
+}
+
+// ResponseCache serves read routes from a bounded LRU cache and answers their conditional
+// requests. Entries are dropped once a write bumps the revision of the datasets of their route.
+type ResponseCache struct {
+	entries   *utils.LRU[string, cachedResponse]
+	revisions *models.Revisions
+	ttl       time.Duration
+	// lastModified tells whether revisions see every write, so their times can be sent
+	lastModified bool
+}
+
+// NewResponseCache initializes an empty ResponseCache, revisions are bumped by the writes to the
+// repositories of storage. Revisions do not see the writes of other replicas sharing a postgres
+// database, Last-Modified is only sent for the CSV and memory backends.
+func NewResponseCache(cfg config.CacheConfig, revisions *models.Revisions, storage string) *ResponseCache {
+	return &ResponseCache{
+		entries:      utils.NewLRU[string, cachedResponse](cfg.Size),
+		revisions:    revisions,
+		ttl:          cfg.TTL,
+		lastModified: storage != models.StoragePostgres,
+	}
+}
+
+// Handler caches the successful GET responses of a route built from datasets. Responses carry a
+// strong ETag, the one set by the handler or else the hash of the body, and unless the backend is
+// postgres the time datasets were last modified. Requests whose If-None-Match or If-Modified-Since
+// header shows the client has the response get 304 Not Modified.
+func (rc *ResponseCache) Handler(datasets ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Method() != fiber.MethodGet {
+			return c.Next()
+		}
+
+		// The revision is taken before the handler runs, so a response racing a write is not
+		// cached as newer than it is
+		key := c.OriginalURL()
+		revision := rc.revisions.Current(datasets...)
+		if entry, ok := rc.entries.Get(key); ok {
+			if entry.revision.Count == revision.Count && time.Since(entry.stored) < rc.ttl {
+				c.Set(HeaderCache, "HIT")
+				return rc.send(c, entry)
+			}
+			rc.entries.Remove(key)
+		}
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+		if c.Response().StatusCode() != http.StatusOK {
+			return nil
+		}
+
+		entry := cachedResponse{
+			body:        append([]byte(nil), c.Response().Body()...),
+			contentType: string(c.Response().Header.ContentType()),
+			etag:        string(c.Response().Header.Peek(fiber.HeaderETag)),
+			revision:    revision,
+			stored:      time.Now(),
+		}
+		if entry.etag == "" {
+			entry.etag = utils.ETag(entry.body)
+		}
+		rc.entries.Add(key, entry)
+
+		c.Set(HeaderCache, "MISS")
+		return rc.send(c, entry)
+	}
+}
+
+// send writes the entry, or 304 Not Modified when the client has it
+func (rc *ResponseCache) send(c *fiber.Ctx, entry cachedResponse) error {
+	c.Set(fiber.HeaderETag, entry.etag)
+	if rc.lastModified {
+		c.Set(fiber.HeaderLastModified, entry.revision.Modified.UTC().Format(http.TimeFormat))
+	}
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+
+	if rc.notModified(c, entry) {
+		c.Response().ResetBody()
+		return c.SendStatus(http.StatusNotModified)
+	}
+
+	c.Set(fiber.HeaderContentType, entry.contentType)
+	return c.Status(http.StatusOK).Send(entry.body)
+}
+
+// notModified evaluates the If-None-Match header of the request, or its If-Modified-Since header
+// when it has none and Last-Modified is sent
+func (rc *ResponseCache) notModified(c *fiber.Ctx, entry cachedResponse) bool {
+	if header := c.Get(fiber.HeaderIfNoneMatch); header != "" {
+		return utils.MatchETag(header, entry.etag)
+	}
+	if header := c.Get(fiber.HeaderIfModifiedSince); header != "" && rc.lastModified {
+		since, err := http.ParseTime(header)
+		return err == nil && !entry.revision.Modified.After(since)
+	}
+	return false
+}
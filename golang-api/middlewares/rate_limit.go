@@ -0,0 +1,220 @@
+package middlewares
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/config"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/constants"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/database"
+	pMetrics "git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/pkg/prometheus"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/utils"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// Stores of rate limit buckets
+const (
+	RateLimitStoreMemory   = "memory"
+	RateLimitStorePostgres = "postgres"
+)
+
+// defaultGroup names the route groups without a limit of their own, allGroups the IP buckets
+// shared by every route group
+const (
+	defaultGroup = "default"
+	allGroups    = "all"
+)
+
+// Kinds of clients reported to prometheus
+const (
+	clientSubject = "subject"
+	clientIP      = "ip"
+)
+
+// RateLimit is a token bucket holding Count tokens, refilled at Count tokens per Period
+type RateLimit struct {
+	Count  int
+	Period time.Duration
+}
+
+// ParseRateLimit parses count/period, e.g. 100/1m
+func ParseRateLimit(value string) (RateLimit, error) {
+	count, period, ok := strings.Cut(strings.TrimSpace(value), "/")
+	if !ok {
+		return RateLimit{}, fmt.Errorf("rate limit %q must be count/period", value)
+	}
+
+	limit := RateLimit{}
+	var err error
+	if limit.Count, err = strconv.Atoi(count); err != nil || limit.Count < 1 {
+		return RateLimit{}, fmt.Errorf("rate limit %q must allow at least one request", value)
+	}
+	if limit.Period, err = time.ParseDuration(period); err != nil || limit.Period <= 0 {
+		return RateLimit{}, fmt.Errorf("rate limit %q has an invalid period", value)
+	}
+	return limit, nil
+}
+
+// rate is the number of tokens added to the bucket per second
+func (l RateLimit) rate() float64 {
+	return float64(l.Count) / l.Period.Seconds()
+}
+
+// takeToken refills a bucket holding tokens for elapsed seconds and takes a token from it. It
+// returns the tokens left and false when there was no token to take.
+func takeToken(tokens, elapsed float64, limit RateLimit) (float64, bool) {
+	tokens = math.Min(float64(limit.Count), tokens+math.Max(elapsed, 0)*limit.rate())
+	if tokens < 1 {
+		return tokens, false
+	}
+	return tokens - 1, true
+}
+
+// parseRateLimits parses comma separated name=limit entries
+func parseRateLimits(entries string) (map[string]RateLimit, error) {
+	limits := make(map[string]RateLimit)
+	for _, entry := range strings.Split(entries, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("rate limit entries must be name=count/period")
+		}
+		limit, err := ParseRateLimit(value)
+		if err != nil {
+			return nil, err
+		}
+		limits[strings.TrimSpace(name)] = limit
+	}
+	return limits, nil
+}
+
+// RateLimiter limits the requests of every client IP, then the requests of each caller to each
+// route group, a group being the first segment of the path. Groups without a limit of their own
+// share the default limit. Handler limits client IPs before credentials are checked and
+// ClientHandler then limits callers by the subject of their credentials or else by IP, so Handler
+// has to run before Auth.Authenticate and ClientHandler after it.
+type RateLimiter struct {
+	ip       RateLimit
+	limit    RateLimit
+	groups   map[string]RateLimit
+	subjects map[string]RateLimit
+	store    RateLimitStore
+	logger   *zap.Logger
+	metrics  *pMetrics.PrometheusMetrics
+}
+
+// NewRateLimiter builds the limits and the store configured by cfg
+func NewRateLimiter(cfg config.AppConfig, logger *zap.Logger, metrics *pMetrics.PrometheusMetrics) (*RateLimiter, error) {
+	limiter := &RateLimiter{logger: logger, metrics: metrics}
+
+	var err error
+	if limiter.ip, err = ParseRateLimit(cfg.RateLimit.IP); err != nil {
+		return nil, err
+	}
+	if limiter.limit, err = ParseRateLimit(cfg.RateLimit.Limit); err != nil {
+		return nil, err
+	}
+	if limiter.groups, err = parseRateLimits(cfg.RateLimit.Groups); err != nil {
+		return nil, err
+	}
+	if limiter.subjects, err = parseRateLimits(cfg.RateLimit.Subjects); err != nil {
+		return nil, err
+	}
+
+	// A bucket left alone for the longest period is full again, as good as a new one
+	idle := max(limiter.ip.Period, limiter.limit.Period)
+	for _, limits := range []map[string]RateLimit{limiter.groups, limiter.subjects} {
+		for _, limit := range limits {
+			idle = max(idle, limit.Period)
+		}
+	}
+
+	switch cfg.RateLimit.Store {
+	case RateLimitStoreMemory:
+		limiter.store = NewMemoryRateLimitStore(idle)
+	case RateLimitStorePostgres:
+		db, err := database.Connect(cfg.DB)
+		if err != nil {
+			return nil, err
+		}
+		limiter.store = NewPostgresRateLimitStore(db, idle)
+	default:
+		return nil, fmt.Errorf("unknown rate limit store %q, use %s or %s", cfg.RateLimit.Store, RateLimitStoreMemory, RateLimitStorePostgres)
+	}
+
+	return limiter, nil
+}
+
+// Handler takes a token from the bucket of the client IP of every request, shared by all route
+// groups, before its credentials are checked, so floods and guessed credentials are turned away
+// cheaply. It rejects the request with 429 when the bucket is empty. Responses carry
+// RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers, rejections a Retry-After header.
+func (l *RateLimiter) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return l.take(c, allGroups, "ip:"+c.IP(), clientIP, l.ip)
+	}
+}
+
+// ClientHandler takes a token from the bucket of the caller in the route group of every request,
+// the caller being the subject of the credentials or else the client IP. The limit of a subject
+// replaces the one of the group when it has one. The headers it sets replace the ones of Handler.
+func (l *RateLimiter) ClientHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		group, limit := l.groupLimit(c.Path())
+
+		principal := CurrentPrincipal(c)
+		if principal == nil || principal.Subject == "" {
+			return l.take(c, group, "ip:"+c.IP(), clientIP, limit)
+		}
+		if subjectLimit, ok := l.subjects[principal.Subject]; ok {
+			limit = subjectLimit
+		}
+		return l.take(c, group, "subject:"+principal.Subject, clientSubject, limit)
+	}
+}
+
+// groupLimit returns the route group of path and its limit. Groups without a limit of their own
+// share the default bucket, so made up paths do not get fresh buckets nor metric labels.
+func (l *RateLimiter) groupLimit(path string) (string, RateLimit) {
+	group := routeGroup(path)
+	if limit, ok := l.groups[group]; ok {
+		return group, limit
+	}
+	return defaultGroup, l.limit
+}
+
+// take takes a token from the bucket of client in group and goes on with the request, or rejects
+// it when the bucket is empty
+func (l *RateLimiter) take(c *fiber.Ctx, group, client, kind string, limit RateLimit) error {
+	tokens, allowed, err := l.store.Take(group+"|"+client, limit)
+	if err != nil {
+		// Failing open keeps the API up while the store is down
+		l.logger.Error("Failed to take rate limit token", zap.String("group", group), zap.Error(err))
+		return c.Next()
+	}
+
+	c.Set("RateLimit-Limit", strconv.Itoa(limit.Count))
+	c.Set("RateLimit-Remaining", strconv.Itoa(int(tokens)))
+	c.Set("RateLimit-Reset", strconv.Itoa(int(math.Ceil((float64(limit.Count)-tokens)/limit.rate()))))
+
+	if !allowed {
+		l.metrics.RateLimitRejects.WithLabelValues(group, kind).Inc()
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil((1-tokens)/limit.rate()))))
+		return utils.JSONFail(c, http.StatusTooManyRequests, constants.RateLimitExceeded)
+	}
+	return c.Next()
+}
+
+// routeGroup returns the first segment of path, e.g. /movies for /movies/1/casts
+func routeGroup(path string) string {
+	segment, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	return "/" + segment
+}
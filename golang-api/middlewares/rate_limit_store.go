@@ -0,0 +1,162 @@
+package middlewares
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+)
+
+// rateLimitTable holds the buckets of the postgres store, created by the golang-api-database
+// migrations
+const rateLimitTable = "rate_limit_buckets"
+
+// RateLimitStore keeps the token buckets of a RateLimiter
+type RateLimitStore interface {
+	// Take takes a token from the bucket of key, refilled as configured by limit since it was
+	// last used, and returns the tokens left. A new bucket is full. It returns false when the
+	// bucket had no token to take.
+	Take(key string, limit RateLimit) (float64, bool, error)
+}
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// MemoryRateLimitStore keeps buckets in process memory, every replica limiting on its own.
+// Buckets idle for longer than idle are dropped.
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	idle      time.Duration
+	lastSweep time.Time
+}
+
+// NewMemoryRateLimitStore initializes an empty MemoryRateLimitStore
+func NewMemoryRateLimitStore(idle time.Duration) *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		buckets:   make(map[string]*tokenBucket),
+		idle:      idle,
+		lastSweep: time.Now(),
+	}
+}
+
+// Take takes a token from the bucket of key
+func (s *MemoryRateLimitStore) Take(key string, limit RateLimit) (float64, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastSweep) >= s.idle {
+		s.sweep(now)
+	}
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: float64(limit.Count), updated: now}
+		s.buckets[key] = bucket
+	}
+
+	tokens, allowed := takeToken(bucket.tokens, now.Sub(bucket.updated).Seconds(), limit)
+	bucket.tokens, bucket.updated = tokens, now
+	return tokens, allowed, nil
+}
+
+// sweep drops the buckets idle for longer than s.idle, they would be full again anyway
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	for key, bucket := range s.buckets {
+		if now.Sub(bucket.updated) >= s.idle {
+			delete(s.buckets, key)
+		}
+	}
+	s.lastSweep = now
+}
+
+// PostgresRateLimitStore keeps buckets in the rate_limit_buckets table, so every replica shares
+// them. Buckets are refilled using the clock of the database and idle ones are deleted now and
+// then.
+type PostgresRateLimitStore struct {
+	db        *goqu.Database
+	idle      time.Duration
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+// NewPostgresRateLimitStore initializes a PostgresRateLimitStore on db
+func NewPostgresRateLimitStore(db *goqu.Database, idle time.Duration) *PostgresRateLimitStore {
+	return &PostgresRateLimitStore{
+		db:        db,
+		idle:      idle,
+		lastSweep: time.Now(),
+	}
+}
+
+// Take takes a token from the bucket of key, the row of the bucket is locked meanwhile
+func (s *PostgresRateLimitStore) Take(key string, limit RateLimit) (float64, bool, error) {
+	if err := s.sweep(); err != nil {
+		return 0, false, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, false, err
+	}
+
+	var tokens float64
+	var allowed bool
+	err = tx.Wrap(func() error {
+		_, err := tx.Insert(rateLimitTable).
+			Rows(goqu.Record{"key": key, "tokens": limit.Count, "updated_at": goqu.L("now()")}).
+			OnConflict(goqu.DoNothing()).
+			Executor().Exec()
+		if err != nil {
+			return fmt.Errorf("failed to create rate limit bucket: %w", err)
+		}
+
+		var bucket struct {
+			Tokens  float64 `db:"tokens"`
+			Elapsed float64 `db:"elapsed"`
+		}
+		_, err = tx.From(rateLimitTable).
+			Select(goqu.C("tokens"), goqu.L("EXTRACT(EPOCH FROM now() - ?)", goqu.C("updated_at")).As("elapsed")).
+			Where(goqu.C("key").Eq(key)).
+			ForUpdate(exp.Wait).
+			ScanStruct(&bucket)
+		if err != nil {
+			return fmt.Errorf("failed to fetch rate limit bucket: %w", err)
+		}
+
+		tokens, allowed = takeToken(bucket.Tokens, bucket.Elapsed, limit)
+		_, err = tx.Update(rateLimitTable).
+			Set(goqu.Record{"tokens": tokens, "updated_at": goqu.L("now()")}).
+			Where(goqu.C("key").Eq(key)).
+			Executor().Exec()
+		if err != nil {
+			return fmt.Errorf("failed to update rate limit bucket: %w", err)
+		}
+		return nil
+	})
+	return tokens, allowed, err
+}
+
+// sweep deletes the buckets idle for longer than s.idle, at most once every s.idle
+func (s *PostgresRateLimitStore) sweep() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if time.Since(s.lastSweep) < s.idle {
+		return nil
+	}
+
+	_, err := s.db.Delete(rateLimitTable).
+		Where(goqu.C("updated_at").Lt(goqu.L("now() - ? * interval '1 second'", s.idle.Seconds()))).
+		Executor().Exec()
+	if err != nil {
+		return fmt.Errorf("failed to delete idle rate limit buckets: %w", err)
+	}
+	s.lastSweep = time.Now()
+	return nil
+}
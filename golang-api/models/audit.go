@@ -0,0 +1,293 @@
+package models
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Entities whose writes are recorded in the audit log
+const (
+	AuditMovie  = "movie"
+	AuditRating = "rating"
+	AuditCast   = "cast"
+	AuditCrew   = "crew"
+)
+
+// Operations recorded in the audit log
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+)
+
+// AuditEntry records one write to an entity. Changes holds the fields of the entity which the
+// write changed, all of them when it was created or deleted.
+type AuditEntry struct {
+	ID        int64                  `json:"id"`
+	Time      time.Time              `json:"timestamp"`
+	Actor     string                 `json:"actor"`
+	Entity    string                 `json:"entity"`
+	EntityID  string                 `json:"entity_id"`
+	Operation string                 `json:"operation"`
+	Changes   map[string]AuditChange `json:"changes"`
+}
+
+// AuditChange is the value of a field before and after a write, a missing value meaning the
+// field did not exist
+type AuditChange struct {
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+// AuditFilter selects audit entries. EntityID matches the entity id and, for a movie id, the ids
+// of the ratings and credits of the movie. Zero fields match every entry.
+type AuditFilter struct {
+	Entity   string
+	EntityID string
+	Since    time.Time
+	Limit    int
+}
+
+func (f AuditFilter) matches(entry AuditEntry) bool {
+	if f.Entity != "" && entry.Entity != f.Entity {
+		return false
+	}
+	if f.EntityID != "" && entry.EntityID != f.EntityID && !strings.HasPrefix(entry.EntityID, f.EntityID+"/") {
+		return false
+	}
+	return !entry.Time.Before(f.Since)
+}
+
+// AuditRepository is implemented by every storage backend, it keeps the audit log of the writes
+type AuditRepository interface {
+	// Record appends entry to the audit log, setting its id and time
+	Record(entry *AuditEntry) error
+	// ListAudit returns the entries matching filter, oldest first
+	ListAudit(filter AuditFilter) ([]AuditEntry, error)
+}
+
+// AuditEntityID is the id of an entity in the audit log. Ratings and credits are identified by
+// their movie followed by the user or the credit, so they are found along with the movie.
+func AuditEntityID(movieId string, ids ...string) string {
+	return strings.Join(append([]string{movieId}, ids...), "/")
+}
+
+// NewAuditEntry builds the entry of the write of actor which changed the entity having entityID
+// from before into after, nil standing for an entity which did not exist
+func NewAuditEntry(actor, entity, entityID, operation string, before, after interface{}) (AuditEntry, error) {
+	from, err := auditFields(before)
+	if err != nil {
+		return AuditEntry{}, err
+	}
+	to, err := auditFields(after)
+	if err != nil {
+		return AuditEntry{}, err
+	}
+
+	changes := make(map[string]AuditChange)
+	for field, value := range from {
+		if !reflect.DeepEqual(value, to[field]) {
+			changes[field] = AuditChange{Before: value, After: to[field]}
+		}
+	}
+	for field, value := range to {
+		if _, ok := from[field]; !ok {
+			changes[field] = AuditChange{After: value}
+		}
+	}
+
+	return AuditEntry{
+		Actor:     actor,
+		Entity:    entity,
+		EntityID:  entityID,
+		Operation: operation,
+		Changes:   changes,
+	}, nil
+}
+
+// auditFields are the fields of the JSON encoding of an entity
+func auditFields(entity interface{}) (map[string]interface{}, error) {
+	if entity == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(entity)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding audited entity: %v", err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("error decoding audited entity: %v", err)
+	}
+	return fields, nil
+}
+
+// MemoryAuditLog is the in-memory AuditRepository
+type MemoryAuditLog struct {
+	mu      sync.RWMutex
+	entries []AuditEntry
+}
+
+// NewMemoryAuditLog initializes an empty MemoryAuditLog
+func NewMemoryAuditLog() *MemoryAuditLog {
+	return &MemoryAuditLog{}
+}
+
+// Record appends entry to the log
+func (l *MemoryAuditLog) Record(entry *AuditEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry.ID = int64(len(l.entries)) + 1
+	entry.Time = time.Now().UTC()
+	l.entries = append(l.entries, *entry)
+	return nil
+}
+
+// ListAudit returns the entries matching filter, oldest first
+func (l *MemoryAuditLog) ListAudit(filter AuditFilter) ([]AuditEntry, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return filterAudit(l.entries, filter), nil
+}
+
+func filterAudit(entries []AuditEntry, filter AuditFilter) []AuditEntry {
+	matched := []AuditEntry{}
+	for _, entry := range entries {
+		if filter.Limit > 0 && len(matched) == filter.Limit {
+			break
+		}
+		if filter.matches(entry) {
+			matched = append(matched, entry)
+		}
+	}
+	return matched
+}
+
+// FileAuditLog is the AuditRepository of the CSV backend, an append-only file holding an entry
+// as JSON on every line. Entries are synced to disk before Record returns, an entry which failed
+// to is cut off again.
+type FileAuditLog struct {
+	mu     sync.RWMutex
+	path   string
+	file   *os.File
+	lastID int64
+}
+
+// OpenFileAuditLog opens the audit log fileName, creating it when missing. A torn last line left
+// by a crash is cut off, it is an entry which was never recorded.
+func OpenFileAuditLog(fileName string, logger *zap.Logger) (*FileAuditLog, error) {
+	l := &FileAuditLog{path: fileName}
+
+	data, err := os.ReadFile(fileName)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if end := bytes.LastIndexByte(data, '\n') + 1; end < len(data) {
+		logger.Warn("Cutting off torn last entry of the audit log", zap.String("file", fileName))
+		if err := os.Truncate(fileName, int64(end)); err != nil {
+			return nil, err
+		}
+		data = data[:end]
+	}
+	if last := bytes.LastIndexByte(bytes.TrimSuffix(data, []byte("\n")), '\n') + 1; last < len(data) {
+		var entry AuditEntry
+		if err := json.Unmarshal(data[last:], &entry); err != nil {
+			return nil, fmt.Errorf("corrupt last entry of audit log %s: %v", fileName, err)
+		}
+		l.lastID = entry.ID
+	}
+
+	l.file, err = os.OpenFile(fileName, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Record appends entry to the file
+func (l *FileAuditLog) Record(entry *AuditEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry.ID = l.lastID + 1
+	entry.Time = time.Now().UTC()
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	info, err := l.file.Stat()
+	if err != nil {
+		return err
+	}
+
+	// A failed entry is cut off again, the write it records is put back by the caller
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		return l.cutOff(info.Size(), fmt.Errorf("error writing audit log: %v", err))
+	}
+	if err := l.file.Sync(); err != nil {
+		return l.cutOff(info.Size(), fmt.Errorf("error syncing audit log: %v", err))
+	}
+	l.lastID = entry.ID
+	return nil
+}
+
+// cutOff truncates the file to size after err, so a partly written entry is not left behind
+func (l *FileAuditLog) cutOff(size int64, err error) error {
+	if truncErr := l.file.Truncate(size); truncErr != nil {
+		return fmt.Errorf("%v, and cutting it off failed: %v", err, truncErr)
+	}
+	return err
+}
+
+// ListAudit scans the file for the entries matching filter, oldest first
+func (l *FileAuditLog) ListAudit(filter AuditFilter) ([]AuditEntry, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	file, err := os.Open(l.path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	matched := []AuditEntry{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if filter.Limit > 0 && len(matched) == filter.Limit {
+			break
+		}
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("corrupt audit log entry: %v", err)
+		}
+		if filter.matches(entry) {
+			matched = append(matched, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading audit log: %v", err)
+	}
+	return matched, nil
+}
+
+// Close closes the file
+func (l *FileAuditLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.file.Close()
+}
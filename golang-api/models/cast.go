@@ -2,210 +2,233 @@ package models
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
-	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/config"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/pkg/csvstore"
 	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/utils"
 )
 
 type CastMember struct {
-	CreditID  string `json:"credit_id"`
-	ID        int    `json:"id"`
-	Character string `json:"character"`
-	Name      string `json:"name"`
+	CreditID    string `json:"credit_id"`
+	ID          int    `json:"id"`
+	Character   string `json:"character"`
+	Name        string `json:"name"`
+	Gender      int    `json:"gender"`
+	ProfilePath string `json:"profile_path,omitempty"`
 }
 
+// CastModel is the CSV backed CastRepository, it shares the credits table and snapshot with CrewModel
 type CastModel struct {
-	CastData map[int][]CastMember
-	loaded   bool
+	*CreditStore
 }
 
-func NewCastModel() *CastModel {
-	return &CastModel{
-		CastData: make(map[int][]CastMember),
-	}
+// CreditStore holds the credits table and its snapshot, it is shared by CastModel and CrewModel
+// because both write rows of the credits table
+type CreditStore struct {
+	// mu serializes writers, so the log and the snapshot apply changes in the same order
+	mu       sync.Mutex
+	snapshot *MemoryCreditModel
+	table    *csvstore.Table
 }
 
-func ParseCastsData() (map[int][]CastMember, error) {
-	records, err := utils.ReadCSVFile(config.AllConfig.Credits)
-	if err != nil {
-		return nil, err
+func NewCastModel(writer *CreditStore) *CastModel {
+	return &CastModel{writer}
+}
+
+// NewCreditStore builds the cast and crew snapshot of the credits table, the returned writer is
+// shared by NewCastModel and NewCrewModel
+func NewCreditStore(table *csvstore.Table) *CreditStore {
+	credits := &CreditStore{
+		snapshot: NewMemoryCreditModel(),
+		table:    table,
 	}
+	credits.load()
+	return credits
+}
 
-	movieCasts := make(map[int][]CastMember)
+// load rebuilds the snapshot from the rows of the credits table, soft deleted credits are left out
+func (w *CreditStore) load() {
+	castData := make(map[int][]CastMember)
+	crewData := make(map[int][]CrewMember)
 
-	// Iterate over rows, skipping the header
-	for _, row := range records[1:] {
-		if len(row) < 3 {
+	for _, row := range w.table.Rows() {
+		if w.table.Value(row, deletedAtColumn) != "" {
 			continue
 		}
 
-		var cast []CastMember
-		movieID, err := strconv.Atoi(strings.TrimSpace(row[2])) // Convert movieID from string to int
-		if err != nil {
-			fmt.Printf("Warning: Invalid movie ID '%s': %v\n", row[2], err)
+		movieID, cast, crew, ok := w.parseRow(row)
+		if !ok {
 			continue
 		}
-
-		row[0] = strings.ReplaceAll(row[0], `'`, `"`)
-
-		err = json.Unmarshal([]byte(row[0]), &cast)
-		if err != nil {
-			fmt.Printf("Warning: Error parsing cast JSON for movie %d: %v\n", movieID, err)
-			continue
+		if cast != nil {
+			castData[movieID] = cast
+		}
+		if crew != nil {
+			crewData[movieID] = crew
 		}
-
-		movieCasts[movieID] = cast
 	}
 
-	return movieCasts, nil
+	w.snapshot.setCredits(castData, crewData)
 }
 
-func (c *CastModel) LoadCast() error {
-	movieCasts, err := ParseCastsData()
+// parseRow decodes the cast and crew of a row, a column failing to decode is returned nil
+func (w *CreditStore) parseRow(row []string) (int, []CastMember, []CrewMember, bool) {
+	table := w.table
+	movieID, err := strconv.Atoi(strings.TrimSpace(table.Value(row, "id")))
 	if err != nil {
-		return err
+		fmt.Printf("Warning: Invalid movie ID '%s': %v\n", table.Value(row, "id"), err)
+		return 0, nil, nil, false
 	}
 
-	c.CastData = movieCasts
-	c.loaded = true
-
-	return nil
-}
-
-func (c *CastModel) ListCastMembers(movieID string) ([]CastMember, error) {
-	if !c.loaded {
-		err := c.LoadCast()
-		if err != nil {
-			return nil, err
-		}
+	cast, err := parseCredits[CastMember](table.Value(row, "cast"))
+	if err != nil {
+		fmt.Printf("Warning: Error parsing cast JSON for movie %d: %v\n", movieID, err)
+		cast = nil
+	} else if cast == nil {
+		cast = []CastMember{}
 	}
 
-	id, err := strconv.Atoi(movieID) // Convert string to int
+	crew, err := parseCredits[CrewMember](table.Value(row, "crew"))
 	if err != nil {
-		return nil, errors.New("invalid movie ID format")
+		fmt.Printf("Warning: Error parsing crew JSON for movie %d: %v\n", movieID, err)
+		crew = nil
+	} else if crew == nil {
+		crew = []CrewMember{}
 	}
 
-	movieCast, exists := c.CastData[id]
-	if !exists {
-		return nil, errors.New("movie not found")
-	}
-	return movieCast, nil
+	return movieID, cast, crew, true
 }
 
-func (c *CastModel) ListMoviesByCastId(castId string) ([]int, error) {
-	if !c.loaded {
-		err := c.LoadCast()
-		if err != nil {
-			return nil, err
-		}
+// liveRow returns the row of the credits of movieId unless they are soft deleted
+func (w *CreditStore) liveRow(movieId string) ([]string, bool) {
+	row, ok := w.table.Get(movieId)
+	if !ok || w.table.Value(row, deletedAtColumn) != "" {
+		return nil, false
 	}
+	return row, true
+}
 
-	id, err := strconv.Atoi(castId) // Convert string to int
-	if err != nil {
-		return nil, errors.New("invalid cast ID format")
+// deleteOps marks the credits of movieId as deleted at at, callers hold the lock
+func (w *CreditStore) deleteOps(movieId, at string) []csvstore.Op {
+	row, ok := w.liveRow(movieId)
+	if !ok {
+		return nil
 	}
+	w.table.Set(row, deletedAtColumn, at)
+	return []csvstore.Op{{Key: movieId, Row: row}}
+}
 
-	var movieIDs []int
+// restoreOps clears the deleted_at column of the credits of movieId deleted at at and returns
+// the row as it is restored. Callers hold the lock.
+func (w *CreditStore) restoreOps(movieId, at string) ([]csvstore.Op, []string) {
+	row, ok := w.table.Get(movieId)
+	if !ok || w.table.Value(row, deletedAtColumn) != at {
+		return nil, nil
+	}
+	w.table.Set(row, deletedAtColumn, "")
+	return []csvstore.Op{{Key: movieId, Row: row}}, row
+}
 
-	// Iterate over all movies to find occurrences of the given cast member
-	for movieID, castMembers := range c.CastData {
-		for _, member := range castMembers {
-			if member.ID == id {
-				movieIDs = append(movieIDs, movieID)
-				break // Avoid duplicate entries for the same movie
-			}
+// purgeOps deletes the credits soft deleted before before, callers hold the lock
+func (w *CreditStore) purgeOps(before time.Time) []csvstore.Op {
+	var ops []csvstore.Op
+	for _, row := range w.table.Rows() {
+		if deletedBefore(w.table, row, before) {
+			ops = append(ops, csvstore.Op{Delete: true, Key: w.table.Value(row, "id")})
 		}
 	}
+	return ops
+}
 
-	if len(movieIDs) == 0 {
-		return nil, errors.New("no movies found for the given cast ID")
+// Reload swaps in the credits file when it was replaced on disk
+func (w *CreditStore) Reload() (bool, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	changed, err := w.table.Reload()
+	if changed {
+		w.load()
 	}
+	return changed, err
+}
 
-	return movieIDs, nil
+// parseCredits decodes a cast or crew column, which is stored as a Python literal
+func parseCredits[T any](column string) ([]T, error) {
+	var members []T
+	err := utils.DecodePythonLiteral(column, &members)
+	return members, err
 }
 
-// UpdateCastMember is to update cast member details having castId of a movie having movieId
-func (c *CastModel) UpdateCastMember(movieId, castId string, updatedCast CastMember) error {
-	rows, err := utils.ReadCSVFile(config.AllConfig.Credits)
+// updateCredit applies update to the member having memberId in a cast or crew column and returns
+// the new column. Members are decoded as maps so fields unknown to the models are kept.
+func updateCredit(column, memberId string, update func(member map[string]any)) (string, bool, error) {
+	members, err := parseCredits[map[string]any](column)
 	if err != nil {
-		return err
+		return "", false, err
 	}
 
-	var updatedRows [][]string
-	header := rows[0]
-	updatedRows = append(updatedRows, header)
 	updated := false
-	castDataMap := make(map[int][]CastMember)
-
-	for _, row := range rows[1:] {
-		if len(row) < 3 {
-			fmt.Println("Skipping row due to insufficient columns:", row)
-			continue
+	for _, member := range members {
+		id, ok := member["id"].(float64) // JSON unmarshals numbers as float64
+		if ok && strconv.Itoa(int(id)) == memberId {
+			update(member)
+			updated = true
+			break
 		}
+	}
 
-		movieIDInt, err := strconv.Atoi(row[2])
-		if err != nil {
-			fmt.Printf("Skipping row due to invalid movie ID: %s\n", row[2])
-			continue
-		}
+	if !updated {
+		return column, false, nil
+	}
 
-		var cast []map[string]any                     // Use map to preserve extra fields
-		row[0] = strings.ReplaceAll(row[0], `'`, `"`) // Ensure valid JSON format
+	data, err := json.Marshal(members)
+	if err != nil {
+		return "", false, err
+	}
+	return string(data), true, nil
+}
 
-		err = json.Unmarshal([]byte(row[0]), &cast)
-		if err != nil {
-			fmt.Printf("Warning: Error parsing crew JSON for movie %s: %v\n", movieId, err)
-			continue
-		}
+func (c *CastModel) ListCastMembers(movieID string) ([]CastMember, error) {
+	return c.snapshot.ListCastMembers(movieID)
+}
 
-		for i, member := range cast {
-			if strconv.Itoa(int(member["id"].(float64))) == castId {
-				if updatedCast.Name != "" {
-					member["name"] = updatedCast.Name
-				}
-				if updatedCast.Character != "" {
-					member["character"] = updatedCast.Character
-				}
-
-				cast[i] = member
-				updated = true
-				break
-			}
-		}
+func (c *CastModel) ListMoviesByCastId(castId string) ([]int, error) {
+	return c.snapshot.ListMoviesByCastId(castId)
+}
 
-		castJSON, err := json.Marshal(cast)
-		if err != nil {
-			return fmt.Errorf("error marshaling updated crew data: %v", err)
-		}
+// UpdateCastMember is to update cast member details having castId of a movie having movieId
+func (c *CastModel) UpdateCastMember(movieId, castId string, updatedCast CastMember) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-		row[1] = string(castJSON)
-		updatedRows = append(updatedRows, row)
+	row, ok := c.liveRow(movieId)
+	if !ok {
+		return ErrCastNotFound
+	}
 
-		var castMembers []CastMember
-		for _, member := range cast {
-			memberJSON, _ := json.Marshal(member) // Convert map to JSON
-			var castMember CastMember
-			json.Unmarshal(memberJSON, &castMember) // Convert JSON to struct
-			castMembers = append(castMembers, castMember)
+	cast, updated, err := updateCredit(c.table.Value(row, "cast"), castId, func(member map[string]any) {
+		if updatedCast.Name != "" {
+			member["name"] = updatedCast.Name
 		}
-
-		castDataMap[movieIDInt] = castMembers
+		if updatedCast.Character != "" {
+			member["character"] = updatedCast.Character
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("error updating cast data of movie %s: %v", movieId, err)
 	}
-
 	if !updated {
-		return errors.New("cast member not found for the given movie ID")
+		return ErrCastNotFound
 	}
 
-	c.CastData = castDataMap
-
-	err = utils.SaveToCSV(config.AllConfig.Credits, updatedRows)
-	if err != nil {
-		return fmt.Errorf("error updating credits.csv: %v", err)
+	c.table.Set(row, "cast", cast)
+	if err := c.table.Put(row); err != nil {
+		return fmt.Errorf("error updating credits: %v", err)
 	}
 
-	return nil
+	return c.snapshot.UpdateCastMember(movieId, castId, updatedCast)
 }
@@ -0,0 +1,301 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Fields of movies accepted by the range filters and by sort, range filters are given as
+// <field>_min and <field>_max
+var (
+	movieRangeFields = []string{"release_date", "runtime", "popularity", "vote_average", "vote_count"}
+	movieSortFields  = map[string]bool{
+		"id": true, "title": true, "release_date": true, "runtime": true,
+		"popularity": true, "vote_average": true, "vote_count": true,
+	}
+)
+
+// movieRange bounds a field of movies, a nil bound is open
+type movieRange struct {
+	field    string
+	min, max *float64
+}
+
+// movieSort orders movies by a field
+type movieSort struct {
+	field string
+	desc  bool
+}
+
+// movieFilter is the parsed form of the filters given to ListMovies:
+//
+//	name, language, status     exact filters, name matches a part of the title
+//	genre                      comma separated genres
+//	genre_mode                 "or" (default) matches any of the genres, "and" all of them
+//	<field>_min, <field>_max   inclusive ranges, release_date is given as YYYY-MM-DD
+//	sort                       comma separated fields, prefixed with "-" for descending order,
+//	                           movies are always sorted by id last
+type movieFilter struct {
+	name      string
+	language  string
+	status    string
+	genres    []string
+	allGenres bool
+	ranges    []movieRange
+	sort      []movieSort
+}
+
+// parseMovieFilter validates filters, errors wrap ErrInvalidFilter
+func parseMovieFilter(filters map[string]string) (movieFilter, error) {
+	filter := movieFilter{
+		name:     strings.TrimSpace(filters["name"]),
+		language: strings.TrimSpace(filters["language"]),
+		status:   strings.TrimSpace(filters["status"]),
+	}
+
+	for _, genre := range strings.Split(filters["genre"], ",") {
+		if genre = strings.TrimSpace(genre); genre != "" {
+			filter.genres = append(filter.genres, genre)
+		}
+	}
+
+	switch mode := strings.ToLower(filters["genre_mode"]); mode {
+	case "", "or":
+	case "and":
+		filter.allGenres = true
+	default:
+		return movieFilter{}, fmt.Errorf("%w: genre_mode must be and or or, got %q", ErrInvalidFilter, mode)
+	}
+
+	for _, field := range movieRangeFields {
+		bounds := movieRange{field: field}
+		for _, bound := range []struct {
+			suffix string
+			value  **float64
+		}{{"_min", &bounds.min}, {"_max", &bounds.max}} {
+			raw := strings.TrimSpace(filters[field+bound.suffix])
+			if raw == "" {
+				continue
+			}
+			value, err := parseMovieValue(field, raw)
+			if err != nil {
+				return movieFilter{}, fmt.Errorf("%w: %s%s: %v", ErrInvalidFilter, field, bound.suffix, err)
+			}
+			*bound.value = &value
+		}
+		if bounds.min != nil || bounds.max != nil {
+			filter.ranges = append(filter.ranges, bounds)
+		}
+	}
+
+	for _, key := range strings.Split(filters["sort"], ",") {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		order := movieSort{field: strings.TrimPrefix(key, "-"), desc: strings.HasPrefix(key, "-")}
+		if !movieSortFields[order.field] {
+			return movieFilter{}, fmt.Errorf("%w: cannot sort by %q", ErrInvalidFilter, order.field)
+		}
+		filter.sort = append(filter.sort, order)
+		if order.field == "id" {
+			// ids are unique, later fields never decide the order
+			return filter, nil
+		}
+	}
+
+	// Ties are broken by id, so movies have a total order to paginate over
+	filter.sort = append(filter.sort, movieSort{field: "id"})
+
+	return filter, nil
+}
+
+// parseTopRatedFilter parses the filters of the top rated movies: genre and language as for
+// ListMovies and year, the release year
+func parseTopRatedFilter(filters map[string]string) (movieFilter, error) {
+	movieFilters := map[string]string{"genre": filters["genre"], "language": filters["language"]}
+	if year := strings.TrimSpace(filters["year"]); year != "" {
+		if _, err := time.Parse("2006", year); err != nil {
+			return movieFilter{}, fmt.Errorf("%w: year must be a four digit year, got %q", ErrInvalidFilter, year)
+		}
+		movieFilters["release_date_min"] = year + "-01-01"
+		movieFilters["release_date_max"] = year + "-12-31"
+	}
+	return parseMovieFilter(movieFilters)
+}
+
+// parseMovieValue converts a value of a range field to a number, dates become unix seconds
+func parseMovieValue(field, value string) (float64, error) {
+	if field == "release_date" {
+		date, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return 0, err
+		}
+		return float64(date.Unix()), nil
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+// movieField returns the stored value of field of movie
+func movieField(movie Movies, field string) string {
+	switch field {
+	case "id":
+		return movie.ID
+	case "title":
+		return movie.Title
+	case "release_date":
+		return movie.ReleaseDate
+	case "runtime":
+		return movie.Runtime
+	case "popularity":
+		return movie.Popularity
+	case "vote_average":
+		return movie.VoteAverage
+	case "vote_count":
+		return movie.VoteCount
+	}
+	return ""
+}
+
+// matches reports whether movie passes every filter
+func (f movieFilter) matches(movie Movies) bool {
+	if f.name != "" && !strings.Contains(strings.ToLower(movie.Title), strings.ToLower(f.name)) {
+		return false
+	}
+
+	if f.status != "" && !strings.EqualFold(movie.Status, f.status) {
+		return false
+	}
+
+	if f.language != "" && !containsFold(movie.SpokenLanguages, f.language) {
+		return false
+	}
+
+	if len(f.genres) > 0 {
+		matched := 0
+		for _, genre := range f.genres {
+			if containsFold(movie.Genres, genre) {
+				matched++
+			}
+		}
+		if matched == 0 || (f.allGenres && matched < len(f.genres)) {
+			return false
+		}
+	}
+
+	for _, bounds := range f.ranges {
+		value, err := parseMovieValue(bounds.field, movieField(movie, bounds.field))
+		if err != nil {
+			return false
+		}
+		if (bounds.min != nil && value < *bounds.min) || (bounds.max != nil && value > *bounds.max) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// key returns the values of movie the sort of f is made of
+func (f movieFilter) key(movie Movies) []string {
+	key := make([]string, len(f.sort))
+	for i, order := range f.sort {
+		key[i] = movieField(movie, order.field)
+	}
+	return key
+}
+
+// compareKeys compares the keys of two movies in the sort of f, missing values come last in
+// both directions
+func (f movieFilter) compareKeys(a, b []string) int {
+	for i, order := range f.sort {
+		cmp := compareMovieValues(order.field, a[i], b[i])
+		if cmp == 0 {
+			continue
+		}
+		if order.desc && hasMovieValue(order.field, a[i]) && hasMovieValue(order.field, b[i]) {
+			cmp = -cmp
+		}
+		return cmp
+	}
+	return 0
+}
+
+// sortMovies orders movies by the sort of f
+func (f movieFilter) sortMovies(movies []Movies) {
+	keyed := make([]struct {
+		movie Movies
+		key   []string
+	}, len(movies))
+	for i, movie := range movies {
+		keyed[i].movie, keyed[i].key = movie, f.key(movie)
+	}
+
+	sort.Slice(keyed, func(a, b int) bool {
+		return f.compareKeys(keyed[a].key, keyed[b].key) < 0
+	})
+
+	for i := range keyed {
+		movies[i] = keyed[i].movie
+	}
+}
+
+// hasMovieValue reports whether value is a usable value of field
+func hasMovieValue(field, value string) bool {
+	if value == "" {
+		return false
+	}
+	if field == "id" || field == "title" {
+		return true
+	}
+	_, err := parseMovieValue(field, value)
+	return err == nil
+}
+
+// compareMovieValues compares two values of field, a missing value is greater than any other
+func compareMovieValues(field, x, y string) int {
+	hasX, hasY := hasMovieValue(field, x), hasMovieValue(field, y)
+	switch {
+	case !hasX && !hasY:
+		return 0
+	case !hasX:
+		return 1
+	case !hasY:
+		return -1
+	}
+
+	switch field {
+	case "title":
+		return strings.Compare(strings.ToLower(x), strings.ToLower(y))
+	case "id":
+		if x == y {
+			return 0
+		}
+		if lessID(x, y) {
+			return -1
+		}
+		return 1
+	}
+
+	xv, _ := parseMovieValue(field, x)
+	yv, _ := parseMovieValue(field, y)
+	switch {
+	case xv < yv:
+		return -1
+	case xv > yv:
+		return 1
+	}
+	return 0
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/utils"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+)
+
+// keysetColumn is a column of the order of a keyset paginated query. NULLs sort last in both
+// directions and are carried in cursors as empty strings.
+type keysetColumn struct {
+	expr interface {
+		exp.Comparable
+		exp.Isable
+		exp.Orderable
+	}
+	desc bool
+}
+
+// keysetPage narrows ds to the page requested by page over the order of columns. Pages before
+// a cursor are read in reverse order, keysetRows turns them back.
+func keysetPage(ds *goqu.SelectDataset, columns []keysetColumn, page utils.PageRequest) *goqu.SelectDataset {
+	before := page.Cursor != nil && page.Cursor.Before
+
+	for _, column := range columns {
+		order := column.expr.Asc()
+		if column.desc != before {
+			order = column.expr.Desc()
+		}
+		if before {
+			ds = ds.OrderAppend(order.NullsFirst())
+		} else {
+			ds = ds.OrderAppend(order.NullsLast())
+		}
+	}
+
+	if page.Cursor != nil {
+		ds = ds.Where(keysetCondition(columns, page.Cursor.Key, before))
+	}
+
+	// One more row than asked tells whether a page follows
+	return ds.Limit(uint(page.Limit + 1))
+}
+
+// keysetCondition matches the rows ordered after key, or before it
+func keysetCondition(columns []keysetColumn, key []string, before bool) exp.Expression {
+	var matches []exp.Expression
+	for i, column := range columns {
+		var conditions []exp.Expression
+		for j, prev := range columns[:i] {
+			if key[j] == "" {
+				conditions = append(conditions, prev.expr.IsNull())
+			} else {
+				conditions = append(conditions, prev.expr.Eq(key[j]))
+			}
+		}
+		if beyond := keysetBeyond(column, key[i], before); beyond != nil {
+			matches = append(matches, goqu.And(append(conditions, beyond)...))
+		}
+	}
+	if len(matches) == 0 {
+		return goqu.L("FALSE")
+	}
+	return goqu.Or(matches...)
+}
+
+// keysetBeyond matches the values of column ordered after value, or before it, nil when none are
+func keysetBeyond(column keysetColumn, value string, before bool) exp.Expression {
+	switch {
+	case value == "" && before:
+		return column.expr.IsNotNull()
+	case value == "":
+		return nil
+	case before && column.desc:
+		return column.expr.Gt(value)
+	case before:
+		return column.expr.Lt(value)
+	case column.desc:
+		return goqu.Or(column.expr.Lt(value), column.expr.IsNull())
+	}
+	return goqu.Or(column.expr.Gt(value), column.expr.IsNull())
+}
+
+// keysetRows trims the rows read by a keysetPage query to the page and returns the page with
+// the cursors of its neighbours, key gives the sort key of a row
+func keysetRows[T any](rows []T, page utils.PageRequest, key func(T) []string) utils.Page[T] {
+	before := page.Cursor != nil && page.Cursor.Before
+	more := len(rows) > page.Limit
+	if more {
+		rows = rows[:page.Limit]
+	}
+	if before {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+
+	result := utils.Page[T]{Items: rows, Limit: page.Limit}
+	if len(rows) == 0 {
+		return result
+	}
+	// Rows before a cursor are followed by the cursor row, rows after it are preceded by it
+	if more || before {
+		result.Next = &utils.Cursor{Key: key(rows[len(rows)-1])}
+	}
+	if more && before || page.Cursor != nil && !before {
+		result.Prev = &utils.Cursor{Key: key(rows[0]), Before: true}
+	}
+	return result
+}
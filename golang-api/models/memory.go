@@ -0,0 +1,909 @@
+package models
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/pkg/search"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/utils"
+)
+
+// MemoryMovieModel is the in-memory MovieRepository, data lives only as long as the process.
+// It also serves as the indexed snapshot of the CSV backend, which keeps soft deleted movies in
+// its tables instead of in deleted. Readers get copies of the movies, so a write never changes
+// a result that was already returned.
+type MemoryMovieModel struct {
+	mu      sync.RWMutex
+	Movies  []Movies
+	index   map[string]int
+	search  *search.Index
+	deleted map[string]deletedMovie
+	ratings *MemoryRatingModel
+	credits *MemoryCreditModel
+}
+
+// deletedMovie is a soft deleted movie along with the time it was deleted at
+type deletedMovie struct {
+	movie Movies
+	at    time.Time
+}
+
+// NewMemoryMovieModel initializes an empty MemoryMovieModel, deletes cascade to ratings and credits
+func NewMemoryMovieModel(ratings *MemoryRatingModel, credits *MemoryCreditModel) *MemoryMovieModel {
+	return &MemoryMovieModel{
+		index:   make(map[string]int),
+		search:  newMovieIndex(),
+		deleted: make(map[string]deletedMovie),
+		ratings: ratings,
+		credits: credits,
+	}
+}
+
+// setMovies replaces all movies and rebuilds the index by id
+func (m *MemoryMovieModel) setMovies(movies []Movies) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.Movies = movies
+	m.reindex(0)
+
+	m.search = newMovieIndex()
+	for _, movie := range movies {
+		indexMovie(m.search, movie)
+	}
+}
+
+// reindex refreshes index positions of movies from position from onwards
+func (m *MemoryMovieModel) reindex(from int) {
+	if from == 0 {
+		m.index = make(map[string]int, len(m.Movies))
+	}
+	for i := from; i < len(m.Movies); i++ {
+		m.index[m.Movies[i].ID] = i
+	}
+}
+
+// ListMovies fetches a page of movies
+func (m *MemoryMovieModel) ListMovies(filters map[string]string, page utils.PageRequest) (utils.Page[Movies], error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	// filterMovies copies the matched movies
+	return filterMovies(m.Movies, filters, page)
+}
+
+// GetMovie returns the movie having movieID
+func (m *MemoryMovieModel) GetMovie(movieID string) (Movies, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	i, ok := m.index[movieID]
+	if !ok {
+		return Movies{}, ErrMovieNotFound
+	}
+	return m.Movies[i], nil
+}
+
+// AddMovie stores a new movie
+func (m *MemoryMovieModel) AddMovie(movie *Movies) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.checkNewMovie(movie); err != nil {
+		return err
+	}
+
+	m.insert(*movie)
+	return nil
+}
+
+// insert appends movie and indexes it, callers hold the lock
+func (m *MemoryMovieModel) insert(movie Movies) {
+	m.index[movie.ID] = len(m.Movies)
+	m.Movies = append(m.Movies, movie)
+	indexMovie(m.search, movie)
+}
+
+// validateNewMovie rejects a movie whose id or title is already taken
+func (m *MemoryMovieModel) validateNewMovie(movie *Movies) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.checkNewMovie(movie)
+}
+
+// checkNewMovie is validateNewMovie for callers holding the lock, the id of a soft deleted movie
+// stays taken until it is purged
+func (m *MemoryMovieModel) checkNewMovie(movie *Movies) error {
+	if _, exists := m.index[movie.ID]; exists {
+		return ErrMovieAlreadyExists
+	}
+	if _, deleted := m.deleted[movie.ID]; deleted {
+		return ErrMovieAlreadyExists
+	}
+	for _, existingMovie := range m.Movies {
+		if existingMovie.Title == movie.Title {
+			return ErrMovieAlreadyExists
+		}
+	}
+	return nil
+}
+
+// UpdateMovie replaces the movie having movieId
+func (m *MemoryMovieModel) UpdateMovie(movieId string, updatedMovie *Movies) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i, ok := m.index[movieId]
+	if !ok {
+		return ErrMovieNotFound
+	}
+
+	movie := *updatedMovie
+	movie.ID = movieId
+
+	// Fields left out of a request keep their stored value
+	if movie.OriginalTitle == "" {
+		movie.OriginalTitle = m.Movies[i].OriginalTitle
+	}
+	if movie.Overview == "" {
+		movie.Overview = m.Movies[i].Overview
+	}
+	if movie.Tagline == "" {
+		movie.Tagline = m.Movies[i].Tagline
+	}
+	if movie.VoteAverage == "" {
+		movie.VoteAverage = m.Movies[i].VoteAverage
+	}
+	if movie.VoteCount == "" {
+		movie.VoteCount = m.Movies[i].VoteCount
+	}
+	if movie.BelongsToCollection == nil {
+		movie.BelongsToCollection = m.Movies[i].BelongsToCollection
+	}
+
+	m.Movies[i] = movie
+	indexMovie(m.search, movie)
+	return nil
+}
+
+// DeleteMovie soft deletes the movie having movieId along with its ratings and credits, all of
+// them deleted at the same time so RestoreMovie brings back the ones deleted along with it
+func (m *MemoryMovieModel) DeleteMovie(movieId string) error {
+	at := time.Now()
+
+	m.mu.Lock()
+	movie, err := m.remove(movieId)
+	if err != nil {
+		m.mu.Unlock()
+		return err
+	}
+	m.deleted[movieId] = deletedMovie{movie: movie, at: at}
+	m.mu.Unlock()
+
+	// Cascade outside of the lock, the ratings and credits stores have their own
+	m.ratings.trash(movieId, nil, at)
+	m.credits.trash(movieId, at)
+	return nil
+}
+
+// remove drops the movie having movieId and returns it, callers hold the lock
+func (m *MemoryMovieModel) remove(movieId string) (Movies, error) {
+	i, ok := m.index[movieId]
+	if !ok {
+		return Movies{}, ErrMovieNotFound
+	}
+
+	movie := m.Movies[i]
+	m.Movies = append(m.Movies[:i], m.Movies[i+1:]...)
+	delete(m.index, movieId)
+	m.reindex(i)
+	m.search.Remove(movieId)
+	return movie, nil
+}
+
+// drop removes the movie having movieId from the snapshot only
+func (m *MemoryMovieModel) drop(movieId string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, err := m.remove(movieId)
+	return err
+}
+
+// RestoreMovie brings back the soft deleted movie having movieId along with the ratings and
+// credits deleted with it
+func (m *MemoryMovieModel) RestoreMovie(movieId string) error {
+	m.mu.Lock()
+	deleted, ok := m.deleted[movieId]
+	if !ok {
+		_, live := m.index[movieId]
+		m.mu.Unlock()
+		if live {
+			return ErrMovieNotDeleted
+		}
+		return ErrMovieNotFound
+	}
+
+	delete(m.deleted, movieId)
+	if err := m.checkNewMovie(&deleted.movie); err != nil {
+		m.deleted[movieId] = deleted
+		m.mu.Unlock()
+		return err
+	}
+	m.insert(deleted.movie)
+	m.mu.Unlock()
+
+	m.ratings.restore(movieId, deleted.at)
+	m.credits.restore(movieId, deleted.at)
+	return nil
+}
+
+// PurgeDeleted removes the movies, ratings and credits soft deleted before before for good
+func (m *MemoryMovieModel) PurgeDeleted(before time.Time) (PurgeCounts, error) {
+	var counts PurgeCounts
+
+	m.mu.Lock()
+	for movieId, deleted := range m.deleted {
+		if deleted.at.Before(before) {
+			delete(m.deleted, movieId)
+			counts.Movies++
+		}
+	}
+	m.mu.Unlock()
+
+	counts.Ratings = m.ratings.purge(before)
+	counts.Credits = m.credits.purge(before)
+	return counts, nil
+}
+
+// MovieExists checks whether a movie having movieId is stored
+func (m *MemoryMovieModel) MovieExists(movieId string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, ok := m.index[movieId]
+	return ok, nil
+}
+
+// SearchMovies runs a ranked full-text search over titles, taglines and overviews
+func (m *MemoryMovieModel) SearchMovies(query string, page, limit int) ([]MovieSearchResult, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return searchMovies(m.search, query, page, limit, func(id string) (Movies, bool) {
+		i, ok := m.index[id]
+		if !ok {
+			return Movies{}, false
+		}
+		return m.Movies[i], true
+	})
+}
+
+// TopRatedMovies ranks the rated movies matching filters by score and returns the first limit
+func (m *MemoryMovieModel) TopRatedMovies(filters map[string]string, limit int) ([]TopRatedMovie, error) {
+	filter, err := parseTopRatedFilter(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	top := []TopRatedMovie{}
+	for _, movie := range m.Movies {
+		if !filter.matches(movie) {
+			continue
+		}
+		stats, err := m.ratings.GetRatingsByMovieId(movie.ID)
+		if errors.Is(err, ErrMovieNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if stats.Count > 0 {
+			top = append(top, TopRatedMovie{Title: movie.Title, MovieRatings: stats})
+		}
+	}
+
+	sortTopRated(top)
+	if len(top) > limit {
+		top = top[:limit]
+	}
+	return top, nil
+}
+
+// MemoryRatingModel is the in-memory RatingRepository, ratings are indexed by movie and user.
+// byUser holds the movie ids rated by every user, the ratings themselves live in byMovie.
+type MemoryRatingModel struct {
+	mu      sync.RWMutex
+	byMovie map[string]map[string]Ratings
+	byUser  map[string]map[string]struct{}
+	// deleted holds the soft deleted ratings by movie and user
+	deleted map[string]map[string]deletedRating
+	prior   RatingPrior
+	// sum and count of all ratings, kept up to date for the mean of the prior
+	sum   float64
+	count int
+}
+
+// NewMemoryRatingModel initializes an empty MemoryRatingModel, prior weights the scores of movies
+func NewMemoryRatingModel(prior RatingPrior) *MemoryRatingModel {
+	return &MemoryRatingModel{
+		byMovie: make(map[string]map[string]Ratings),
+		byUser:  make(map[string]map[string]struct{}),
+		deleted: make(map[string]map[string]deletedRating),
+		prior:   prior,
+	}
+}
+
+// deletedRating is a soft deleted rating along with the time it was deleted at
+type deletedRating struct {
+	rating Ratings
+	at     time.Time
+}
+
+// setRatings replaces all ratings
+func (r *MemoryRatingModel) setRatings(ratings []Ratings) {
+	byMovie := make(map[string]map[string]Ratings)
+	byUser := make(map[string]map[string]struct{})
+	var sum float64
+	var count int
+	for _, rating := range ratings {
+		if value, err := strconv.ParseFloat(rating.Rating, 64); err == nil {
+			sum += value
+			count++
+		}
+		if byMovie[rating.MovieId] == nil {
+			byMovie[rating.MovieId] = make(map[string]Ratings)
+		}
+		byMovie[rating.MovieId][rating.UserId] = rating
+		indexUserRating(byUser, rating.UserId, rating.MovieId)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.byMovie = byMovie
+	r.byUser = byUser
+	r.sum, r.count = sum, count
+}
+
+// tally adds rating to the totals of all ratings, or removes it when remove is set
+func (r *MemoryRatingModel) tally(rating string, remove bool) {
+	value, err := strconv.ParseFloat(rating, 64)
+	if err != nil {
+		return
+	}
+	if remove {
+		r.sum -= value
+		r.count--
+		return
+	}
+	r.sum += value
+	r.count++
+}
+
+// priorMean is the mean scores are weighted towards, callers hold the lock
+func (r *MemoryRatingModel) priorMean() float64 {
+	if r.prior.Mean > 0 || r.count == 0 {
+		return r.prior.Mean
+	}
+	return r.sum / float64(r.count)
+}
+
+// indexUserRating records in byUser that userId rated movieId
+func indexUserRating(byUser map[string]map[string]struct{}, userId, movieId string) {
+	if byUser[userId] == nil {
+		byUser[userId] = make(map[string]struct{})
+	}
+	byUser[userId][movieId] = struct{}{}
+}
+
+// unindexUserRating removes from byUser that userId rated movieId
+func unindexUserRating(byUser map[string]map[string]struct{}, userId, movieId string) {
+	delete(byUser[userId], movieId)
+	if len(byUser[userId]) == 0 {
+		delete(byUser, userId)
+	}
+}
+
+// CalculateAverageRatings aggregates the rating stats of every rated movie, ordered by movie id
+func (r *MemoryRatingModel) CalculateAverageRatings() []MovieRatings {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	movieIDs := make([]string, 0, len(r.byMovie))
+	for movieID := range r.byMovie {
+		movieIDs = append(movieIDs, movieID)
+	}
+	sort.Slice(movieIDs, func(i, j int) bool {
+		return lessID(movieIDs[i], movieIDs[j])
+	})
+
+	mean := r.priorMean()
+	movieRatings := make([]MovieRatings, 0, len(movieIDs))
+	for _, movieID := range movieIDs {
+		movieRatings = append(movieRatings, ratingStats(movieID, r.byMovie[movieID], r.prior, mean))
+	}
+	return movieRatings
+}
+
+// lessID orders numeric ids by value and falls back to string order for anything else
+func lessID(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return a < b
+}
+
+// ListRatings lists the rating stats of all movies
+func (r *MemoryRatingModel) ListRatings(page utils.PageRequest) (utils.Page[MovieRatings], error) {
+	return utils.KeysetPaginate(r.CalculateAverageRatings(), page, movieRatingsKey, compareIDKeys)
+}
+
+func movieRatingsKey(ratings MovieRatings) []string {
+	return []string{ratings.MovieId}
+}
+
+// compareIDKeys compares keys made of one id in the order of lessID
+func compareIDKeys(a, b []string) int {
+	switch {
+	case a[0] == b[0]:
+		return 0
+	case lessID(a[0], b[0]):
+		return -1
+	}
+	return 1
+}
+
+// GetRatingsByMovieId returns the rating stats of movie having movieId
+func (r *MemoryRatingModel) GetRatingsByMovieId(movieId string) (MovieRatings, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ratings, ok := r.byMovie[movieId]
+	if !ok {
+		return MovieRatings{}, ErrMovieNotFound
+	}
+	return ratingStats(movieId, ratings, r.prior, r.priorMean()), nil
+}
+
+// GetRating returns the rating userId gave to movieId
+func (r *MemoryRatingModel) GetRating(movieId, userId string) (Ratings, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rating, ok := r.byMovie[movieId][userId]
+	if !ok {
+		return Ratings{}, ErrRatingNotFound
+	}
+	return rating, nil
+}
+
+// AddRatings stores a rating, replacing an earlier rating of the same user for the same movie
+// along with one they deleted
+func (r *MemoryRatingModel) AddRatings(rating *Ratings) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if rating.Timestamp == "" {
+		rating.Timestamp = strconv.FormatInt(time.Now().Unix(), 10)
+	}
+	delete(r.deleted[rating.MovieId], rating.UserId)
+	if len(r.deleted[rating.MovieId]) == 0 {
+		delete(r.deleted, rating.MovieId)
+	}
+	r.add(*rating)
+	return nil
+}
+
+// add stores rating, callers hold the lock
+func (r *MemoryRatingModel) add(rating Ratings) {
+	ratings, ok := r.byMovie[rating.MovieId]
+	if !ok {
+		ratings = make(map[string]Ratings)
+		r.byMovie[rating.MovieId] = ratings
+	}
+
+	if earlier, ok := ratings[rating.UserId]; ok {
+		r.tally(earlier.Rating, true)
+	}
+	r.tally(rating.Rating, false)
+	ratings[rating.UserId] = rating
+	indexUserRating(r.byUser, rating.UserId, rating.MovieId)
+}
+
+// UpdateRatings changes the rating given by userId to movieId
+func (r *MemoryRatingModel) UpdateRatings(userId, movieId, newRating, newTimestamp string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rating, ok := r.byMovie[movieId][userId]
+	if !ok {
+		return ErrRatingNotFound
+	}
+
+	r.tally(rating.Rating, true)
+	r.tally(newRating, false)
+	rating.Rating = newRating
+	rating.Timestamp = newTimestamp
+	r.byMovie[movieId][userId] = rating
+	return nil
+}
+
+// DeleteRatings soft deletes ratings of movieId, only the one given by userId when it is set
+func (r *MemoryRatingModel) DeleteRatings(movieId string, userId *string) error {
+	return r.trash(movieId, userId, time.Now())
+}
+
+// trash soft deletes ratings of movieId as deleted at at, only the one given by userId when it is set
+func (r *MemoryRatingModel) trash(movieId string, userId *string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	removed, err := r.remove(movieId, userId)
+	if err != nil {
+		return err
+	}
+
+	if r.deleted[movieId] == nil {
+		r.deleted[movieId] = make(map[string]deletedRating)
+	}
+	for _, rating := range removed {
+		r.deleted[movieId][rating.UserId] = deletedRating{rating: rating, at: at}
+	}
+	return nil
+}
+
+// drop removes ratings of movieId from the snapshot only, only the one given by userId when it is set
+func (r *MemoryRatingModel) drop(movieId string, userId *string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, err := r.remove(movieId, userId)
+	return err
+}
+
+// remove removes ratings of movieId and returns them, callers hold the lock
+func (r *MemoryRatingModel) remove(movieId string, userId *string) ([]Ratings, error) {
+	ratings, ok := r.byMovie[movieId]
+	if !ok {
+		return nil, ErrRatingNotFound
+	}
+
+	if userId == nil {
+		removed := make([]Ratings, 0, len(ratings))
+		for user, rating := range ratings {
+			unindexUserRating(r.byUser, user, movieId)
+			r.tally(rating.Rating, true)
+			removed = append(removed, rating)
+		}
+		delete(r.byMovie, movieId)
+		return removed, nil
+	}
+
+	rating, ok := ratings[*userId]
+	if !ok {
+		return nil, ErrRatingNotFound
+	}
+
+	r.tally(rating.Rating, true)
+	delete(ratings, *userId)
+	unindexUserRating(r.byUser, *userId, movieId)
+	if len(ratings) == 0 {
+		delete(r.byMovie, movieId)
+	}
+	return []Ratings{rating}, nil
+}
+
+// restore brings back the ratings of movieId soft deleted at at
+func (r *MemoryRatingModel) restore(movieId string, at time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for userId, deleted := range r.deleted[movieId] {
+		if deleted.at.Equal(at) {
+			r.add(deleted.rating)
+			delete(r.deleted[movieId], userId)
+		}
+	}
+	if len(r.deleted[movieId]) == 0 {
+		delete(r.deleted, movieId)
+	}
+}
+
+// purge removes the ratings soft deleted before before for good and returns how many there were
+func (r *MemoryRatingModel) purge(before time.Time) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	purged := 0
+	for movieId, ratings := range r.deleted {
+		for userId, deleted := range ratings {
+			if deleted.at.Before(before) {
+				delete(ratings, userId)
+				purged++
+			}
+		}
+		if len(ratings) == 0 {
+			delete(r.deleted, movieId)
+		}
+	}
+	return purged
+}
+
+// usersOfMovie lists the ids of users who rated movieId
+func (r *MemoryRatingModel) usersOfMovie(movieId string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	userIDs := make([]string, 0, len(r.byMovie[movieId]))
+	for userID := range r.byMovie[movieId] {
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs
+}
+
+// userRatings lists the ratings given by userId, ratings that are not numbers are left out
+func (r *MemoryRatingModel) userRatings(userId string) []UserRating {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ratings := make([]UserRating, 0, len(r.byUser[userId]))
+	for movieId := range r.byUser[userId] {
+		rating := r.byMovie[movieId][userId]
+		value, err := strconv.ParseFloat(rating.Rating, 64)
+		if err != nil {
+			continue
+		}
+		timestamp, _ := strconv.ParseInt(rating.Timestamp, 10, 64)
+		ratings = append(ratings, UserRating{MovieId: movieId, Rating: value, Timestamp: timestamp})
+	}
+	return ratings
+}
+
+// MemoryCreditModel is the in-memory CastRepository and CrewRepository. Updates replace the
+// member slice of a movie instead of changing it, as listed slices are shared with readers.
+type MemoryCreditModel struct {
+	mu       sync.RWMutex
+	CastData map[int][]CastMember
+	CrewData map[int][]CrewMember
+	deleted  map[int]deletedCredits
+}
+
+// deletedCredits are the soft deleted cast and crew of a movie along with the time they were
+// deleted at
+type deletedCredits struct {
+	cast []CastMember
+	crew []CrewMember
+	at   time.Time
+}
+
+// NewMemoryCreditModel initializes an empty MemoryCreditModel
+func NewMemoryCreditModel() *MemoryCreditModel {
+	return &MemoryCreditModel{
+		CastData: make(map[int][]CastMember),
+		CrewData: make(map[int][]CrewMember),
+		deleted:  make(map[int]deletedCredits),
+	}
+}
+
+// setCredits replaces the cast and crew of all movies
+func (c *MemoryCreditModel) setCredits(cast map[int][]CastMember, crew map[int][]CrewMember) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.CastData = cast
+	c.CrewData = crew
+}
+
+// ListCastMembers is to list cast members of movie having id movieID
+func (c *MemoryCreditModel) ListCastMembers(movieID string) ([]CastMember, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	id, err := strconv.Atoi(movieID)
+	if err != nil {
+		return nil, errors.New("invalid movie ID format")
+	}
+
+	movieCast, exists := c.CastData[id]
+	if !exists {
+		return nil, ErrMovieNotFound
+	}
+	return movieCast, nil
+}
+
+// ListMoviesByCastId is to list ids of movies in which cast member having castId played
+func (c *MemoryCreditModel) ListMoviesByCastId(castId string) ([]int, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	id, err := strconv.Atoi(castId)
+	if err != nil {
+		return nil, errors.New("invalid cast ID format")
+	}
+
+	var movieIDs []int
+	for movieID, castMembers := range c.CastData {
+		for _, member := range castMembers {
+			if member.ID == id {
+				movieIDs = append(movieIDs, movieID)
+				break
+			}
+		}
+	}
+
+	if len(movieIDs) == 0 {
+		return nil, errors.New("no movies found for the given cast ID")
+	}
+	sort.Ints(movieIDs)
+	return movieIDs, nil
+}
+
+// UpdateCastMember is to update cast member details having castId of a movie having movieId
+func (c *MemoryCreditModel) UpdateCastMember(movieId, castId string, updatedCast CastMember) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	movieID, err := strconv.Atoi(movieId)
+	if err != nil {
+		return errors.New("invalid movie ID format")
+	}
+
+	for i, member := range c.CastData[movieID] {
+		if strconv.Itoa(member.ID) != castId {
+			continue
+		}
+		if updatedCast.Name != "" {
+			member.Name = updatedCast.Name
+		}
+		if updatedCast.Character != "" {
+			member.Character = updatedCast.Character
+		}
+		cast := append([]CastMember(nil), c.CastData[movieID]...)
+		cast[i] = member
+		c.CastData[movieID] = cast
+		return nil
+	}
+	return ErrCastNotFound
+}
+
+// ListCrewMembers is to list crew members of movie having id movieID
+func (c *MemoryCreditModel) ListCrewMembers(movieID string) ([]CrewMember, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	id, err := strconv.Atoi(movieID)
+	if err != nil {
+		return nil, errors.New("invalid movie ID format")
+	}
+
+	movieCrew, exists := c.CrewData[id]
+	if !exists {
+		return nil, ErrMovieNotFound
+	}
+	return movieCrew, nil
+}
+
+// UpdateCrewMember is to update details of crew member having crewId in a movie having movieId
+func (c *MemoryCreditModel) UpdateCrewMember(movieId, crewId string, updatedCrew CrewMember) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	movieID, err := strconv.Atoi(movieId)
+	if err != nil {
+		return errors.New("invalid movie ID format")
+	}
+
+	for i, member := range c.CrewData[movieID] {
+		if strconv.Itoa(member.ID) != crewId {
+			continue
+		}
+		if updatedCrew.Name != "" {
+			member.Name = updatedCrew.Name
+		}
+		if updatedCrew.Department != "" {
+			member.Department = updatedCrew.Department
+		}
+		if updatedCrew.Job != "" {
+			member.Job = updatedCrew.Job
+		}
+		crew := append([]CrewMember(nil), c.CrewData[movieID]...)
+		crew[i] = member
+		c.CrewData[movieID] = crew
+		return nil
+	}
+	return ErrCrewNotFound
+}
+
+// DeleteCreditsForMovie is to soft delete cast and crew of a movie when that movie is deleted
+func (c *MemoryCreditModel) DeleteCreditsForMovie(movieId string) error {
+	return c.trash(movieId, time.Now())
+}
+
+// trash soft deletes cast and crew of movieId as deleted at at
+func (c *MemoryCreditModel) trash(movieId string, at time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id, err := strconv.Atoi(movieId)
+	if err != nil {
+		return errors.New("invalid movie ID format")
+	}
+
+	cast, hasCast := c.CastData[id]
+	crew, hasCrew := c.CrewData[id]
+	if !hasCast && !hasCrew {
+		return nil
+	}
+
+	c.deleted[id] = deletedCredits{cast: cast, crew: crew, at: at}
+	delete(c.CastData, id)
+	delete(c.CrewData, id)
+	return nil
+}
+
+// drop removes cast and crew of movieId from the snapshot only
+func (c *MemoryCreditModel) drop(movieId string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if id, err := strconv.Atoi(movieId); err == nil {
+		delete(c.CastData, id)
+		delete(c.CrewData, id)
+	}
+}
+
+// put replaces cast and crew of movieID, nil ones are left out
+func (c *MemoryCreditModel) put(movieID int, cast []CastMember, crew []CrewMember) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cast != nil {
+		c.CastData[movieID] = cast
+	}
+	if crew != nil {
+		c.CrewData[movieID] = crew
+	}
+}
+
+// restore brings back cast and crew of movieId soft deleted at at
+func (c *MemoryCreditModel) restore(movieId string, at time.Time) {
+	id, err := strconv.Atoi(movieId)
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	deleted, ok := c.deleted[id]
+	if !ok || !deleted.at.Equal(at) {
+		c.mu.Unlock()
+		return
+	}
+	delete(c.deleted, id)
+	c.mu.Unlock()
+
+	c.put(id, deleted.cast, deleted.crew)
+}
+
+// purge removes cast and crew soft deleted before before for good and returns of how many
+// movies there were
+func (c *MemoryCreditModel) purge(before time.Time) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	purged := 0
+	for id, deleted := range c.deleted {
+		if deleted.at.Before(before) {
+			delete(c.deleted, id)
+			purged++
+		}
+	}
+	return purged
+}
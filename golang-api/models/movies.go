@@ -1,16 +1,13 @@
 package models
 
 import (
-	"encoding/csv"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
-	"os"
-	"path/filepath"
-	"strings"
 	"sync"
+	"time"
 
-	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/config"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/pkg/csvstore"
 	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/utils"
 )
 
@@ -24,155 +21,171 @@ type Movies struct {
 	Runtime          string   `json:"runtime" validate:"gte=0,lte=100"`
 	SpokenLanguages  []string `json:"spoken_languages" validate:"required,dive,min=5,max=50"`
 	Status           string   `json:"status" validate:"required,oneof=Released Upcoming Cancelled"`
+	OriginalTitle    string   `json:"original_title,omitempty"`
+	Overview         string   `json:"overview,omitempty"`
+	Tagline          string   `json:"tagline,omitempty"`
+	VoteAverage      string   `json:"vote_average,omitempty"`
+	VoteCount        string   `json:"vote_count,omitempty"`
+	// BelongsToCollection is the collection the movie is part of, nil when it is none
+	BelongsToCollection *Collection `json:"belongs_to_collection,omitempty"`
 }
 
-type MovieModel struct {
-	Movies []Movies
-	loaded bool
-	mu     sync.Mutex
+// Collection is a series of movies, such as the sequels of a movie, as in the
+// belongs_to_collection column of movies_metadata.csv
+type Collection struct {
+	ID           int    `json:"id" validate:"required,gt=0"`
+	Name         string `json:"name" validate:"required,max=255"`
+	PosterPath   string `json:"poster_path,omitempty" validate:"omitempty,max=255"`
+	BackdropPath string `json:"backdrop_path,omitempty" validate:"omitempty,max=255"`
 }
 
-func NewMovieModel() *MovieModel {
-	return &MovieModel{}
+// parseCollection decodes the belongs_to_collection column, nil when it is empty or of another
+// shape
+func parseCollection(raw string) *Collection {
+	var collection Collection
+	if raw == "" || utils.DecodePythonLiteral(raw, &collection) != nil || collection.ID == 0 {
+		return nil
+	}
+	return &collection
 }
 
-// Function to load movies using utils.ParseData()
-func (m *MovieModel) LoadMovies() error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+// MovieModel is the CSV backed MovieRepository. Reads are served from an in-memory snapshot
+// indexed by id, writes go to the write-ahead log of the movies table before the snapshot.
+// Soft deleted rows stay in the tables with their deleted_at column set and are left out of the
+// snapshots.
+type MovieModel struct {
+	// mu serializes writers, so the log and the snapshot apply changes in the same order
+	mu       sync.Mutex
+	snapshot *MemoryMovieModel
+	table    *csvstore.Table
+	ratings  *RatingModel
+	credits  *CreditStore
+	// journal makes deletes cascading to the ratings and credits tables all-or-nothing
+	journal *csvstore.Journal
+}
 
-	config := config.AllConfig
-	data, err := utils.ParseData(config.Movies)
-	if err != nil {
-		return err
+// NewMovieModel loads the movies of table, deletes cascade to ratings and credits through journal
+func NewMovieModel(table *csvstore.Table, ratings *RatingModel, credits *CreditStore, journal *csvstore.Journal) *MovieModel {
+	m := &MovieModel{
+		snapshot: NewMemoryMovieModel(ratings.snapshot, credits.snapshot),
+		table:    table,
+		ratings:  ratings,
+		credits:  credits,
+		journal:  journal,
 	}
-
-	var movies []Movies
-	for _, row := range data {
-		movies = append(movies, Movies{
-			ID:               row["id"],
-			OriginalLanguage: row["original_language"],
-			Title:            row["title"],
-			Popularity:       row["popularity"],
-			Genres:           utils.ParseJSONField(row["genres"], "name"),
-			ReleaseDate:      row["release_date"],
-			Runtime:          row["runtime"],
-			SpokenLanguages:  utils.ParseJSONField(row["spoken_languages"], "name"),
-			Status:           row["status"],
-		})
-	}
-	m.Movies = movies
-	return nil
+	m.LoadMovies()
+	return m
 }
 
-// ListMovies fetches paginated movies
-func (m *MovieModel) ListMovies(filters map[string]string, page, limit int) ([]Movies, error) {
-	if !m.loaded {
-		if err := m.LoadMovies(); err != nil {
-			return nil, err
+// LoadMovies rebuilds the snapshot from the rows of the movies table, soft deleted movies are
+// left out
+func (m *MovieModel) LoadMovies() {
+	var movies []Movies
+	for _, row := range m.table.Rows() {
+		if m.table.Value(row, deletedAtColumn) == "" {
+			movies = append(movies, m.movieFromRow(row))
 		}
-		m.loaded = true
 	}
+	m.snapshot.setMovies(movies)
+}
 
-	if len(m.Movies) == 0 {
-		return nil, errors.New("no movies loaded, call LoadMovies first")
+func (m *MovieModel) movieFromRow(row []string) Movies {
+	return Movies{
+		ID:               m.table.Value(row, "id"),
+		OriginalLanguage: m.table.Value(row, "original_language"),
+		Title:            m.table.Value(row, "title"),
+		Popularity:       m.table.Value(row, "popularity"),
+		Genres:           utils.ParseJSONField(m.table.Value(row, "genres"), "name"),
+		ReleaseDate:      m.table.Value(row, "release_date"),
+		Runtime:          m.table.Value(row, "runtime"),
+		SpokenLanguages:  utils.ParseJSONField(m.table.Value(row, "spoken_languages"), "name"),
+		Status:           m.table.Value(row, "status"),
+		OriginalTitle:    m.table.Value(row, "original_title"),
+		Overview:         m.table.Value(row, "overview"),
+		Tagline:          m.table.Value(row, "tagline"),
+		VoteAverage:      m.table.Value(row, "vote_average"),
+		VoteCount:        m.table.Value(row, "vote_count"),
+
+		BelongsToCollection: parseCollection(m.table.Value(row, "belongs_to_collection")),
 	}
+}
 
-	movieName := strings.ToLower(filters["name"])
-	movieGenre := strings.ToLower(filters["genre"])
-	movieLanguage := strings.ToLower(filters["language"])
-
-	var matchedMovies []Movies
+// Reload swaps in the movies file when it was replaced on disk
+func (m *MovieModel) Reload() (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-	for _, movie := range m.Movies {
-		if movieName != "" && !strings.Contains(strings.ToLower(movie.Title), strings.ToLower(movieName)) {
-			continue
-		}
+	changed, err := m.table.Reload()
+	if changed {
+		m.LoadMovies()
+	}
+	return changed, err
+}
 
-		if movieGenre != "" {
-			found := false
-			for _, genre := range movie.Genres {
-				if strings.ToLower(genre) == strings.ToLower(movieGenre) {
-					found = true
-					break
-				}
-			}
-			if !found {
-				continue
-			}
-		}
+// ListMovies fetches a page of movies
+func (m *MovieModel) ListMovies(filters map[string]string, page utils.PageRequest) (utils.Page[Movies], error) {
+	return m.snapshot.ListMovies(filters, page)
+}
 
-		if movieLanguage != "" {
-			found := false
-			for _, language := range movie.SpokenLanguages {
-				if strings.ToLower(language) == strings.ToLower(movieLanguage) {
-					found = true
-					break
-				}
-			}
-			if !found {
-				continue
-			}
-		}
+// filterMovies applies filters, sorts the matched movies and returns the requested page
+func filterMovies(movies []Movies, filters map[string]string, page utils.PageRequest) (utils.Page[Movies], error) {
+	filter, err := parseMovieFilter(filters)
+	if err != nil {
+		return utils.Page[Movies]{}, err
+	}
+	if err := page.CheckKey(len(filter.sort)); err != nil {
+		return utils.Page[Movies]{}, err
+	}
 
-		// If all conditions pass, add movie to matched list
-		matchedMovies = append(matchedMovies, movie)
+	var matchedMovies []Movies
 
+	for _, movie := range movies {
+		if filter.matches(movie) {
+			matchedMovies = append(matchedMovies, movie)
+		}
 	}
 
 	// If no movies match the filters, return an error
 	if len(matchedMovies) == 0 {
-		return nil, errors.New("no movies found matching the given criteria")
+		return utils.Page[Movies]{}, ErrNoMoviesMatched
 	}
 
-	return utils.Paginate(matchedMovies, page, limit)
+	filter.sortMovies(matchedMovies)
+
+	return utils.KeysetPaginate(matchedMovies, page, filter.key, filter.compareKeys)
+}
+
+// TopRatedMovies ranks the rated movies matching filters by score and returns the first limit
+func (m *MovieModel) TopRatedMovies(filters map[string]string, limit int) ([]TopRatedMovie, error) {
+	return m.snapshot.TopRatedMovies(filters, limit)
 }
 
 // function GetMovie to get movie by its specified movieID
 func (m *MovieModel) GetMovie(movieID string) (Movies, error) {
-	if !m.loaded {
-		if err := m.LoadMovies(); err != nil {
-			return Movies{}, err
-		}
-		m.loaded = true
-	}
-	for i := range m.Movies {
-		if m.Movies[i].ID == movieID {
-			return m.Movies[i], nil
-		}
-	}
-	return Movies{}, errors.New("movie not found")
+	return m.snapshot.GetMovie(movieID)
 }
 
 // Function is to add movie
 func (m *MovieModel) AddMovie(movie *Movies) error {
-	if !m.loaded {
-		if err := m.LoadMovies(); err != nil {
-			return err
-		}
-		m.loaded = true
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.snapshot.validateNewMovie(movie); err != nil {
+		return err
 	}
-	for _, existingMovie := range m.Movies {
-		if existingMovie.ID == movie.ID || existingMovie.Title == movie.Title {
-			return errors.New("movie with this ID or title already exists")
-		}
+	// The id of a soft deleted movie stays taken until it is purged
+	if _, ok := m.table.Get(movie.ID); ok {
+		return ErrMovieAlreadyExists
 	}
 
-	// Add new movie to the list
-	m.Movies = append(m.Movies, *movie)
-	workingDirPath, err := os.Getwd()
-	if err != nil {
-		return fmt.Errorf("error getting working directory: %v", err)
+	row := m.table.NewRow()
+	m.setMovieFields(row, movie.ID, movie)
+	if err := m.table.Put(row); err != nil {
+		return fmt.Errorf("error writing movie: %v", err)
 	}
 
-	// Fetch file path from config
-	filePath := filepath.Join(workingDirPath, config.AllConfig.Movies)
-
-	err = appendMovieToCSV(filePath, *movie)
-	if err != nil {
-		return err
-	}
-	return nil
+	// Add new movie to the snapshot once it is persisted
+	return m.snapshot.AddMovie(movie)
 }
 
 func formatData(datas []string) string {
@@ -194,174 +207,193 @@ func joinStrings(elements []string, sep string) string {
 	return result
 }
 
-// appendMovieToCSV is to append movies at the end of csv file
-func appendMovieToCSV(filePath string, movie Movies) error {
-	// Open the file in append mode or create it if it doesn't exist
-	file, err := os.OpenFile(filePath, os.O_APPEND|os.O_WRONLY, 0644)
-	if err != nil {
-		return fmt.Errorf("Failed to open file: %v", err)
-	}
-	defer file.Close()
-
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
-
-	// Convert slice fields (Genres & SpokenLanguages) to string
-	genreJSON := formatData(movie.Genres)
-	spokenLanguagesStr := formatData(movie.SpokenLanguages)
-
-	// Convert struct to a slice of strings
-	record := []string{
-		"",
-		"",
-		"",
-		genreJSON,
-		"",
-		movie.ID,
-		"",
-		movie.OriginalLanguage,
-		"",
-		"",
-		movie.Popularity,
-		"",
-		"",
-		"",
-		movie.ReleaseDate,
-		"",
-		movie.Runtime,
-		spokenLanguagesStr,
-		movie.Status,
-		"",
-		movie.Title,
-		"",
-		"",
-		"",
-	}
-
-	// Write the record to CSV
-	if err := writer.Write(record); err != nil {
-		return fmt.Errorf("Failed to write record: %v", err)
-	}
-
-	log.Println("Movie appended successfully!")
-	return nil
+// setMovieFields copies the fields of movie into its CSV row, other columns are left as they are
+func (m *MovieModel) setMovieFields(row []string, movieId string, movie *Movies) {
+	m.table.Set(row, "id", movieId)
+	m.table.Set(row, "original_language", movie.OriginalLanguage)
+	m.table.Set(row, "popularity", movie.Popularity)
+	m.table.Set(row, "release_date", movie.ReleaseDate)
+	m.table.Set(row, "runtime", movie.Runtime)
+	m.table.Set(row, "status", movie.Status)
+	m.table.Set(row, "title", movie.Title)
+	m.table.Set(row, "genres", formatData(movie.Genres))
+	m.table.Set(row, "spoken_languages", formatData(movie.SpokenLanguages))
+
+	// Fields left out of a request keep their stored value
+	if movie.OriginalTitle != "" {
+		m.table.Set(row, "original_title", movie.OriginalTitle)
+	}
+	if movie.Overview != "" {
+		m.table.Set(row, "overview", movie.Overview)
+	}
+	if movie.Tagline != "" {
+		m.table.Set(row, "tagline", movie.Tagline)
+	}
+	if movie.VoteAverage != "" {
+		m.table.Set(row, "vote_average", movie.VoteAverage)
+	}
+	if movie.VoteCount != "" {
+		m.table.Set(row, "vote_count", movie.VoteCount)
+	}
+	if movie.BelongsToCollection != nil {
+		collection, _ := json.Marshal(movie.BelongsToCollection)
+		m.table.Set(row, "belongs_to_collection", string(collection))
+	}
 }
 
 func (m *MovieModel) MovieExists(movieId string) (bool, error) {
-	if !m.loaded {
-		if err := m.LoadMovies(); err != nil {
-			return false, err
-		}
-		m.loaded = true
-	}
-	for _, movie := range m.Movies {
-		if movie.ID == movieId {
-			return true, nil
-		}
-	}
-	return false, nil
+	return m.snapshot.MovieExists(movieId)
 }
 
-func parseCSVArray(data string) []string {
-	data = strings.Trim(data, "[]") // Remove square brackets
-	if data == "" {
-		return []string{}
-	}
-	parts := strings.Split(data, ",") // Split by comma
-	for i := range parts {
-		parts[i] = strings.Trim(parts[i], " '\"") // Trim spaces and quotes
-	}
-	return parts
+// SearchMovies runs a ranked full-text search over titles, taglines and overviews
+func (m *MovieModel) SearchMovies(query string, page, limit int) ([]MovieSearchResult, error) {
+	return m.snapshot.SearchMovies(query, page, limit)
 }
 
-// ModifyMovie will modify movies according to input in struct as well as in csv file
+// ModifyMovie will modify movies according to input in struct as well as in the movies table
 func (m *MovieModel) ModifyMovie(movieId string, updatedMovie *Movies, operation string) error {
-	if !m.loaded {
-		if err := m.LoadMovies(); err != nil {
-			return err
-		}
-		m.loaded = true
-	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-	rows, err := utils.ReadCSVFile(config.AllConfig.Movies)
-	if err != nil {
-		return err
+	row, ok := m.table.Get(movieId)
+	if !ok || m.table.Value(row, deletedAtColumn) != "" {
+		return ErrMovieNotFound
 	}
 
-	var updatedRows [][]string
-	var updatedMovies []Movies
-
-	header := rows[0]
-	updatedRows = append(updatedRows, header)
-	modified := false
+	switch operation {
+	case "delete":
+		return m.deleteMovie(movieId, row)
 
-	for _, row := range rows[1:] {
-		if len(row) < 23 {
-			fmt.Println("Skipping invalid row:", row)
-			continue
+	case "update":
+		if updatedMovie == nil {
+			return ErrMovieNotFound
 		}
-
-		genres := parseCSVArray(row[3])
-		spokenlanguages := parseCSVArray(row[17])
-
-		if row[5] == movieId {
-			if operation == "delete" {
-				modified = true
-				continue
-			} else if operation == "update" && updatedMovie != nil {
-				row[5] = movieId
-				row[7] = updatedMovie.OriginalLanguage
-				row[10] = updatedMovie.Popularity
-				row[14] = updatedMovie.ReleaseDate
-				row[16] = updatedMovie.Runtime
-				row[18] = updatedMovie.Status
-				row[20] = updatedMovie.Title
-				row[3] = formatData(updatedMovie.Genres)
-				row[17] = formatData(updatedMovie.SpokenLanguages)
-				modified = true
-			}
+		m.setMovieFields(row, movieId, updatedMovie)
+		if err := m.table.Put(row); err != nil {
+			return fmt.Errorf("error updating movie: %v", err)
 		}
+		return m.snapshot.UpdateMovie(movieId, updatedMovie)
+	}
+
+	return fmt.Errorf("unknown operation %q", operation)
+}
+
+// deleteMovie soft deletes the movie having movieId along with its ratings and credits, the
+// rows of the three tables change together or not at all. Callers hold the lock.
+func (m *MovieModel) deleteMovie(movieId string, row []string) error {
+	m.ratings.mu.Lock()
+	defer m.ratings.mu.Unlock()
+	m.credits.mu.Lock()
+	defer m.credits.mu.Unlock()
+
+	at := deletedAt(time.Now())
+	m.table.Set(row, deletedAtColumn, at)
+
+	err := m.journal.Apply(
+		csvstore.Change{Table: m.table, Ops: []csvstore.Op{{Key: movieId, Row: row}}},
+		csvstore.Change{Table: m.ratings.table, Ops: m.ratings.deleteOps(movieId, nil, at)},
+		csvstore.Change{Table: m.credits.table, Ops: m.credits.deleteOps(movieId, at)},
+	)
+	if err != nil {
+		return fmt.Errorf("error deleting movie: %v", err)
+	}
 
-		updatedRows = append(updatedRows, row)
+	if err := m.snapshot.drop(movieId); err != nil {
+		return err
+	}
+	if err := m.ratings.snapshot.drop(movieId, nil); err != nil && !errors.Is(err, ErrRatingNotFound) {
+		return err
+	}
+	m.credits.snapshot.drop(movieId)
+	return nil
+}
 
-		updatedMovies = append(updatedMovies, Movies{
-			ID:               row[5],
-			OriginalLanguage: row[7],
-			Title:            row[20],
-			Popularity:       row[10],
-			Genres:           genres,
-			ReleaseDate:      row[14],
-			Runtime:          row[16],
-			SpokenLanguages:  spokenlanguages,
-			Status:           row[18],
-		})
+// RestoreMovie brings back the soft deleted movie having movieId along with the ratings and
+// credits deleted with it
+func (m *MovieModel) RestoreMovie(movieId string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	row, ok := m.table.Get(movieId)
+	if !ok {
+		return ErrMovieNotFound
+	}
+	at := m.table.Value(row, deletedAtColumn)
+	if at == "" {
+		return ErrMovieNotDeleted
 	}
 
-	if !modified {
-		return errors.New("movie not found")
+	// Another movie may have taken the title meanwhile
+	movie := m.movieFromRow(row)
+	if err := m.snapshot.validateNewMovie(&movie); err != nil {
+		return err
 	}
 
-	m.Movies = updatedMovies
+	m.ratings.mu.Lock()
+	defer m.ratings.mu.Unlock()
+	m.credits.mu.Lock()
+	defer m.credits.mu.Unlock()
+
+	m.table.Set(row, deletedAtColumn, "")
+	ratingOps, ratings := m.ratings.restoreOps(movieId, at)
+	creditOps, creditRow := m.credits.restoreOps(movieId, at)
 
-	err = utils.SaveToCSV(config.AllConfig.Movies, updatedRows)
+	err := m.journal.Apply(
+		csvstore.Change{Table: m.table, Ops: []csvstore.Op{{Key: movieId, Row: row}}},
+		csvstore.Change{Table: m.ratings.table, Ops: ratingOps},
+		csvstore.Change{Table: m.credits.table, Ops: creditOps},
+	)
 	if err != nil {
-		return fmt.Errorf("error updating movies_metadata.csv: %v", err)
+		return fmt.Errorf("error restoring movie: %v", err)
 	}
 
-	if operation == "delete" {
-		ratingModel := &RatingModel{}
-		if err := ratingModel.DeleteRatings(movieId, nil); err != nil {
-			return fmt.Errorf("error deleting ratings for movie: %v", err)
+	if err := m.snapshot.AddMovie(&movie); err != nil {
+		return err
+	}
+	for i := range ratings {
+		if err := m.ratings.snapshot.AddRatings(&ratings[i]); err != nil {
+			return err
 		}
-		err = DeleteCreditsForMovie(movieId)
-		if err != nil {
-			return fmt.Errorf("error deleting credits: %v", err)
+	}
+	if creditRow != nil {
+		if movieID, cast, crew, ok := m.credits.parseRow(creditRow); ok {
+			m.credits.snapshot.put(movieID, cast, crew)
 		}
 	}
-
 	return nil
 }
 
+// PurgeDeleted removes the movies, ratings and credits soft deleted before before from the
+// tables, all together or not at all
+func (m *MovieModel) PurgeDeleted(before time.Time) (PurgeCounts, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ratings.mu.Lock()
+	defer m.ratings.mu.Unlock()
+	m.credits.mu.Lock()
+	defer m.credits.mu.Unlock()
+
+	var movieOps []csvstore.Op
+	for _, row := range m.table.Rows() {
+		if deletedBefore(m.table, row, before) {
+			movieOps = append(movieOps, csvstore.Op{Delete: true, Key: m.table.Value(row, "id")})
+		}
+	}
+	ratingOps := m.ratings.purgeOps(before)
+	creditOps := m.credits.purgeOps(before)
+
+	err := m.journal.Apply(
+		csvstore.Change{Table: m.table, Ops: movieOps},
+		csvstore.Change{Table: m.ratings.table, Ops: ratingOps},
+		csvstore.Change{Table: m.credits.table, Ops: creditOps},
+	)
+	if err != nil {
+		return PurgeCounts{}, fmt.Errorf("error purging deleted movies: %v", err)
+	}
+
+	return PurgeCounts{Movies: len(movieOps), Ratings: len(ratingOps), Credits: len(creditOps)}, nil
+}
+
 // DeleteMovie is to delete movie having movieId
 func (m *MovieModel) DeleteMovie(movieId string) error {
 	return m.ModifyMovie(movieId, nil, "delete")
@@ -0,0 +1,292 @@
+package models
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/utils"
+)
+
+// Roles of a person in the filmography
+const (
+	RoleCast = "cast"
+	RoleCrew = "crew"
+)
+
+// actingDepartment is the department of cast credits, as crew departments are named
+const actingDepartment = "Acting"
+
+// Person is a member of the cast or crew of movies
+type Person struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Gender      int    `json:"gender"`
+	ProfilePath string `json:"profile_path,omitempty"`
+}
+
+// FilmographyEntry is a credit of a person on a live movie: the character played in the cast or
+// the job held in the crew. Rating is the mean of the ratings of the movie, 0 when it was not
+// rated.
+type FilmographyEntry struct {
+	CreditID    string  `json:"credit_id"`
+	MovieID     int     `json:"movie_id"`
+	Title       string  `json:"title"`
+	Role        string  `json:"role"`
+	Character   string  `json:"character,omitempty"`
+	Job         string  `json:"job,omitempty"`
+	Department  string  `json:"department"`
+	ReleaseYear *int    `json:"release_year"`
+	Rating      float64 `json:"rating"`
+	RatingCount int     `json:"rating_count"`
+}
+
+// PersonWithFilmography is a person along with a page of their filmography
+type PersonWithFilmography struct {
+	Person
+	Filmography []FilmographyEntry `json:"filmography"`
+}
+
+// filmographySortFields are the fields of the filmography accepted by sort
+var filmographySortFields = map[string]bool{"year": true, "title": true, "rating": true}
+
+// filmographySort orders the filmography by a field
+type filmographySort struct {
+	field string
+	desc  bool
+}
+
+// filmographyFilter is the parsed form of the filters given to GetPerson:
+//
+//	role         cast or crew
+//	department   the department of the credits, Acting being the one of cast credits
+//	sort         comma separated year, title and rating, prefixed with "-" for descending
+//	             order, -year by default. Credits are always sorted by credit id last.
+type filmographyFilter struct {
+	role       string
+	department string
+	sort       []filmographySort
+}
+
+// parseFilmographyFilter validates filters, errors wrap ErrInvalidFilter
+func parseFilmographyFilter(filters map[string]string) (filmographyFilter, error) {
+	filter := filmographyFilter{
+		role:       strings.ToLower(strings.TrimSpace(filters["role"])),
+		department: strings.TrimSpace(filters["department"]),
+	}
+	if filter.role != "" && filter.role != RoleCast && filter.role != RoleCrew {
+		return filmographyFilter{}, fmt.Errorf("%w: role must be cast or crew, got %q", ErrInvalidFilter, filter.role)
+	}
+
+	sortBy := filters["sort"]
+	if strings.TrimSpace(sortBy) == "" {
+		sortBy = "-year"
+	}
+	for _, key := range strings.Split(sortBy, ",") {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		field := strings.TrimPrefix(key, "-")
+		if !filmographySortFields[field] {
+			return filmographyFilter{}, fmt.Errorf("%w: cannot sort by %q", ErrInvalidFilter, field)
+		}
+		filter.sort = append(filter.sort, filmographySort{field: field, desc: strings.HasPrefix(key, "-")})
+	}
+	// Ties are broken by credit id, so credits have a total order to paginate over
+	filter.sort = append(filter.sort, filmographySort{field: "credit_id"})
+
+	return filter, nil
+}
+
+// matches reports whether entry passes the role and department filters of f
+func (f filmographyFilter) matches(entry FilmographyEntry) bool {
+	if f.role != "" && entry.Role != f.role {
+		return false
+	}
+	return f.department == "" || strings.EqualFold(entry.Department, f.department)
+}
+
+// key returns the values of entry the sort of f is made of, a missing release year is an empty
+// string
+func (f filmographyFilter) key(entry FilmographyEntry) []string {
+	key := make([]string, len(f.sort))
+	for i, order := range f.sort {
+		switch order.field {
+		case "year":
+			if entry.ReleaseYear != nil {
+				key[i] = strconv.Itoa(*entry.ReleaseYear)
+			}
+		case "title":
+			key[i] = entry.Title
+		case "rating":
+			key[i] = strconv.FormatFloat(entry.Rating, 'f', -1, 64)
+		case "credit_id":
+			key[i] = entry.CreditID
+		}
+	}
+	return key
+}
+
+// compareKeys compares the keys of two entries in the sort of f, missing release years come
+// last in both directions
+func (f filmographyFilter) compareKeys(a, b []string) int {
+	for i, order := range f.sort {
+		var c int
+		switch order.field {
+		case "year", "rating":
+			switch {
+			case a[i] == "" && b[i] == "":
+				continue
+			case a[i] == "":
+				return 1
+			case b[i] == "":
+				return -1
+			}
+			x, _ := strconv.ParseFloat(a[i], 64)
+			y, _ := strconv.ParseFloat(b[i], 64)
+			c = cmp.Compare(x, y)
+		case "title":
+			c = strings.Compare(strings.ToLower(a[i]), strings.ToLower(b[i]))
+		default:
+			c = strings.Compare(a[i], b[i])
+		}
+		if c == 0 {
+			continue
+		}
+		if order.desc {
+			return -c
+		}
+		return c
+	}
+	return 0
+}
+
+// releaseYear is the year of a release date given as YYYY-MM-DD, nil when it is not one
+func releaseYear(date string) *int {
+	released, err := time.Parse("2006-01-02", strings.TrimSpace(date))
+	if err != nil {
+		return nil
+	}
+	year := released.Year()
+	return &year
+}
+
+// credits returns the person having id, as named by the first of their credits naming them, along
+// with their cast and crew credits, the details of the movies left out. ok is false when the
+// person is credited on no movie.
+func (c *MemoryCreditModel) credits(id int) (Person, []FilmographyEntry, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	person := Person{ID: id}
+	var entries []FilmographyEntry
+	for movieID, cast := range c.CastData {
+		for _, member := range cast {
+			if member.ID != id {
+				continue
+			}
+			if person.Name == "" {
+				person = Person{ID: id, Name: member.Name, Gender: member.Gender, ProfilePath: member.ProfilePath}
+			}
+			entries = append(entries, FilmographyEntry{
+				CreditID:   member.CreditID,
+				MovieID:    movieID,
+				Role:       RoleCast,
+				Character:  member.Character,
+				Department: actingDepartment,
+			})
+		}
+	}
+	for movieID, crew := range c.CrewData {
+		for _, member := range crew {
+			if member.ID != id {
+				continue
+			}
+			if person.Name == "" {
+				person = Person{ID: id, Name: member.Name, Gender: member.Gender, ProfilePath: member.ProfilePath}
+			}
+			entries = append(entries, FilmographyEntry{
+				CreditID:   member.CreditID,
+				MovieID:    movieID,
+				Role:       RoleCrew,
+				Job:        member.Job,
+				Department: member.Department,
+			})
+		}
+	}
+	return person, entries, len(entries) > 0
+}
+
+// MemoryPeopleModel is the PeopleRepository of the in-memory and CSV backends, it reads the
+// credit and rating snapshots and looks up titles and release dates in movies
+type MemoryPeopleModel struct {
+	credits *MemoryCreditModel
+	movies  MovieRepository
+	ratings *MemoryRatingModel
+}
+
+// NewMemoryPeopleModel initializes a MemoryPeopleModel over credits, movies and ratings
+func NewMemoryPeopleModel(credits *MemoryCreditModel, movies MovieRepository, ratings *MemoryRatingModel) *MemoryPeopleModel {
+	return &MemoryPeopleModel{
+		credits: credits,
+		movies:  movies,
+		ratings: ratings,
+	}
+}
+
+// GetPerson returns the person having personId along with a page of their filmography, cast and
+// crew credits merged, matching filters
+func (p *MemoryPeopleModel) GetPerson(personId string, filters map[string]string, page utils.PageRequest) (PersonWithFilmography, utils.Page[FilmographyEntry], error) {
+	filter, err := parseFilmographyFilter(filters)
+	if err != nil {
+		return PersonWithFilmography{}, utils.Page[FilmographyEntry]{}, err
+	}
+	if err := page.CheckKey(len(filter.sort)); err != nil {
+		return PersonWithFilmography{}, utils.Page[FilmographyEntry]{}, err
+	}
+
+	id, err := strconv.Atoi(strings.TrimSpace(personId))
+	if err != nil {
+		return PersonWithFilmography{}, utils.Page[FilmographyEntry]{}, ErrPersonNotFound
+	}
+	person, credits, ok := p.credits.credits(id)
+	if !ok {
+		return PersonWithFilmography{}, utils.Page[FilmographyEntry]{}, ErrPersonNotFound
+	}
+
+	entries := make([]FilmographyEntry, 0, len(credits))
+	for _, entry := range credits {
+		if !filter.matches(entry) {
+			continue
+		}
+		movieId := strconv.Itoa(entry.MovieID)
+		movie, err := p.movies.GetMovie(movieId)
+		if errors.Is(err, ErrMovieNotFound) {
+			continue
+		}
+		if err != nil {
+			return PersonWithFilmography{}, utils.Page[FilmographyEntry]{}, err
+		}
+		entry.Title = movie.Title
+		entry.ReleaseYear = releaseYear(movie.ReleaseDate)
+		if ratings, err := p.ratings.GetRatingsByMovieId(movieId); err == nil {
+			entry.Rating, entry.RatingCount = ratings.Ratings, ratings.Count
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return filter.compareKeys(filter.key(entries[i]), filter.key(entries[j])) < 0
+	})
+
+	result, err := utils.KeysetPaginate(entries, page, filter.key, filter.compareKeys)
+	if err != nil {
+		return PersonWithFilmography{}, utils.Page[FilmographyEntry]{}, err
+	}
+	return PersonWithFilmography{Person: person, Filmography: result.Items}, result, nil
+}
@@ -0,0 +1,1634 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/pkg/search"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/utils"
+	"github.com/doug-martin/goqu/v9"
+	"github.com/doug-martin/goqu/v9/exp"
+)
+
+// Tables of the schema created by golang-api-database migrations
+const (
+	moviesTable         = "movies"
+	genresTable         = "genres"
+	languagesTable      = "languages"
+	collectionsTable    = "collections"
+	movieGenresTable    = "movie_genres"
+	movieLanguagesTable = "movie_languages"
+	ratingsTable        = "ratings"
+	creditsTable        = "credits"
+	movieCastsTable     = "movie_casts"
+	movieCrewTable      = "movie_crew"
+	auditLogTable       = "audit_log"
+)
+
+// live keeps the rows of table which are not soft deleted
+func live(table string) exp.BooleanExpression {
+	return goqu.T(table).Col("deleted_at").IsNull()
+}
+
+// lockRows locks the rows of entity, one of the audit entities, having key through db until its
+// transaction ends: the movie having key movieId, the rating having key movieId and userId, or the
+// cast or crew of movieId along with the credited people. Keys which are not numbers lock nothing.
+func lockRows(db Session, entity string, key ...string) error {
+	ids := make([]int, 0, len(key))
+	for _, k := range key {
+		id, err := strconv.Atoi(k)
+		if err != nil {
+			return nil
+		}
+		ids = append(ids, id)
+	}
+
+	var ds *goqu.SelectDataset
+	switch {
+	case entity == AuditMovie && len(ids) == 1:
+		ds = db.From(moviesTable).Select("id").Where(goqu.C("id").Eq(ids[0]))
+	case entity == AuditRating && len(ids) == 2:
+		ds = db.From(ratingsTable).Select("user_id").Where(goqu.Ex{"movie_id": ids[0], "user_id": ids[1]})
+	case (entity == AuditCast || entity == AuditCrew) && len(ids) == 1:
+		table := movieCastsTable
+		if entity == AuditCrew {
+			table = movieCrewTable
+		}
+		ds = db.From(table).Select(goqu.T(table).Col("person_id")).
+			Join(goqu.T(creditsTable), goqu.On(goqu.T(table).Col("person_id").Eq(goqu.T(creditsTable).Col("id")))).
+			Where(goqu.T(table).Col("movie_id").Eq(ids[0]))
+	default:
+		return fmt.Errorf("cannot lock %s %v", entity, key)
+	}
+
+	if _, err := ds.ForUpdate(exp.Wait).Executor().Exec(); err != nil {
+		return fmt.Errorf("failed to lock %s: %w", entity, err)
+	}
+	return nil
+}
+
+type moviePgRow struct {
+	ID               int             `db:"id"`
+	OriginalLanguage sql.NullString  `db:"original_language"`
+	Title            sql.NullString  `db:"title"`
+	Popularity       sql.NullFloat64 `db:"popularity"`
+	ReleaseDate      sql.NullTime    `db:"release_date"`
+	Runtime          sql.NullFloat64 `db:"runtime"`
+	Status           sql.NullString  `db:"status"`
+	OriginalTitle    sql.NullString  `db:"original_title"`
+	Overview         sql.NullString  `db:"overview"`
+	Tagline          sql.NullString  `db:"tagline"`
+	VoteAverage      sql.NullFloat64 `db:"vote_average"`
+	VoteCount        sql.NullInt64   `db:"vote_count"`
+	// BelongsToCollection is JSONB, scanned as it is
+	BelongsToCollection []byte `db:"belongs_to_collection"`
+}
+
+func (row moviePgRow) toMovie() Movies {
+	movie := Movies{
+		ID:               strconv.Itoa(row.ID),
+		OriginalLanguage: row.OriginalLanguage.String,
+		Title:            row.Title.String,
+		Status:           row.Status.String,
+		OriginalTitle:    row.OriginalTitle.String,
+		Overview:         row.Overview.String,
+		Tagline:          row.Tagline.String,
+	}
+	if row.Popularity.Valid {
+		movie.Popularity = strconv.FormatFloat(row.Popularity.Float64, 'f', -1, 64)
+	}
+	if row.ReleaseDate.Valid {
+		movie.ReleaseDate = row.ReleaseDate.Time.Format("2006-01-02")
+	}
+	if row.Runtime.Valid {
+		movie.Runtime = strconv.FormatFloat(row.Runtime.Float64, 'f', -1, 64)
+	}
+	if row.VoteAverage.Valid {
+		movie.VoteAverage = strconv.FormatFloat(row.VoteAverage.Float64, 'f', -1, 64)
+	}
+	if row.VoteCount.Valid {
+		movie.VoteCount = strconv.FormatInt(row.VoteCount.Int64, 10)
+	}
+	movie.BelongsToCollection = parseCollection(string(row.BelongsToCollection))
+	return movie
+}
+
+// PostgresMovieModel is the goqu backed MovieRepository
+type PostgresMovieModel struct {
+	db    Session
+	prior RatingPrior
+}
+
+// NewPostgresMovieModel initializes a PostgresMovieModel, prior weights the scores of top rated movies
+func NewPostgresMovieModel(db Session, prior RatingPrior) *PostgresMovieModel {
+	return &PostgresMovieModel{db: db, prior: prior}
+}
+
+func (m *PostgresMovieModel) moviesDataset() *goqu.SelectDataset {
+	return m.db.From(moviesTable).
+		Select("id", "original_language", "title", "popularity", "release_date", "runtime", "status", "original_title", "overview", "tagline", "vote_average", "vote_count", "belongs_to_collection").
+		Where(live(moviesTable))
+}
+
+// ListMovies fetches a page of movies
+func (m *PostgresMovieModel) ListMovies(filters map[string]string, page utils.PageRequest) (utils.Page[Movies], error) {
+	filter, err := parseMovieFilter(filters)
+	if err != nil {
+		return utils.Page[Movies]{}, err
+	}
+	if err := page.CheckKey(len(filter.sort)); err != nil {
+		return utils.Page[Movies]{}, err
+	}
+
+	ds := m.filterDataset(m.moviesDataset(), filter)
+
+	var total *int
+	if page.Total {
+		count, err := ds.Count()
+		if err != nil {
+			return utils.Page[Movies]{}, fmt.Errorf("error counting movies: %w", err)
+		}
+		n := int(count)
+		total = &n
+	}
+
+	columns := make([]keysetColumn, 0, len(filter.sort))
+	for _, order := range filter.sort {
+		columns = append(columns, keysetColumn{expr: goqu.C(order.field), desc: order.desc})
+	}
+
+	var rows []moviePgRow
+	err = keysetPage(ds, columns, page).ScanStructs(&rows)
+	if err != nil {
+		return utils.Page[Movies]{}, fmt.Errorf("error fetching movies: %w", err)
+	}
+
+	if len(rows) == 0 && page.Cursor == nil {
+		return utils.Page[Movies]{}, ErrNoMoviesMatched
+	}
+
+	rowsPage := keysetRows(rows, page, func(row moviePgRow) []string {
+		return filter.key(row.toMovie())
+	})
+	movies, err := m.withGenresAndLanguages(rowsPage.Items)
+	if err != nil {
+		return utils.Page[Movies]{}, err
+	}
+	return utils.Page[Movies]{Items: movies, Limit: rowsPage.Limit, Next: rowsPage.Next, Prev: rowsPage.Prev, Total: total}, nil
+}
+
+// filterDataset narrows ds to the movies passing every filter of filter but sort
+func (m *PostgresMovieModel) filterDataset(ds *goqu.SelectDataset, filter movieFilter) *goqu.SelectDataset {
+	if filter.name != "" {
+		ds = ds.Where(goqu.C("title").ILike("%" + filter.name + "%"))
+	}
+
+	if filter.status != "" {
+		ds = ds.Where(goqu.L("LOWER(?) = LOWER(?)", goqu.C("status"), filter.status))
+	}
+
+	if len(filter.genres) > 0 {
+		lowered := make([]string, 0, len(filter.genres))
+		for _, genre := range filter.genres {
+			lowered = append(lowered, strings.ToLower(genre))
+		}
+		genreMovies := m.db.From(movieGenresTable).
+			Select(goqu.T(movieGenresTable).Col("movieid")).
+			Join(goqu.T(genresTable), goqu.On(goqu.T(genresTable).Col("id").Eq(goqu.T(movieGenresTable).Col("genreid")))).
+			Where(goqu.Func("LOWER", goqu.T(genresTable).Col("name")).In(lowered))
+		if filter.allGenres {
+			genreMovies = genreMovies.
+				GroupBy(goqu.T(movieGenresTable).Col("movieid")).
+				Having(goqu.L("COUNT(DISTINCT LOWER(?))", goqu.T(genresTable).Col("name")).Eq(len(lowered)))
+		}
+		ds = ds.Where(goqu.C("id").In(genreMovies))
+	}
+
+	for _, bounds := range filter.ranges {
+		col := goqu.C(bounds.field)
+		if bounds.min != nil {
+			ds = ds.Where(col.Gte(rangeValue(bounds.field, *bounds.min)))
+		}
+		if bounds.max != nil {
+			ds = ds.Where(col.Lte(rangeValue(bounds.field, *bounds.max)))
+		}
+	}
+
+	if language := filter.language; language != "" {
+		ds = ds.Where(goqu.C("id").In(
+			m.db.From(movieLanguagesTable).
+				Select(goqu.T(movieLanguagesTable).Col("movieid")).
+				Join(goqu.T(languagesTable), goqu.On(goqu.T(languagesTable).Col("iso_code").Eq(goqu.T(movieLanguagesTable).Col("language_code")))).
+				Where(goqu.Or(
+					goqu.L("LOWER(?) = LOWER(?)", goqu.T(languagesTable).Col("name"), language),
+					goqu.L("LOWER(?) = LOWER(?)", goqu.T(languagesTable).Col("iso_code"), language),
+				)),
+		))
+	}
+
+	return ds
+}
+
+// rangeValue converts a parsed range bound back to the type of its column
+func rangeValue(field string, value float64) any {
+	if field == "release_date" {
+		return time.Unix(int64(value), 0).UTC().Format("2006-01-02")
+	}
+	return value
+}
+
+// withGenresAndLanguages converts rows to movies and fills their genres and spoken languages
+func (m *PostgresMovieModel) withGenresAndLanguages(rows []moviePgRow) ([]Movies, error) {
+	ids := make([]int, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.ID)
+	}
+
+	var genres []struct {
+		MovieID int    `db:"movieid"`
+		Name    string `db:"name"`
+	}
+	err := m.db.From(movieGenresTable).
+		Select(goqu.T(movieGenresTable).Col("movieid"), goqu.T(genresTable).Col("name")).
+		Join(goqu.T(genresTable), goqu.On(goqu.T(genresTable).Col("id").Eq(goqu.T(movieGenresTable).Col("genreid")))).
+		Where(goqu.T(movieGenresTable).Col("movieid").In(ids)).
+		ScanStructs(&genres)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching genres: %w", err)
+	}
+
+	var languages []struct {
+		MovieID int            `db:"movieid"`
+		Code    string         `db:"language_code"`
+		Name    sql.NullString `db:"name"`
+	}
+	err = m.db.From(movieLanguagesTable).
+		Select(goqu.T(movieLanguagesTable).Col("movieid"), goqu.T(movieLanguagesTable).Col("language_code"), goqu.T(languagesTable).Col("name")).
+		LeftJoin(goqu.T(languagesTable), goqu.On(goqu.T(languagesTable).Col("iso_code").Eq(goqu.T(movieLanguagesTable).Col("language_code")))).
+		Where(goqu.T(movieLanguagesTable).Col("movieid").In(ids)).
+		ScanStructs(&languages)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching languages: %w", err)
+	}
+
+	movieGenres := make(map[int][]string)
+	for _, genre := range genres {
+		movieGenres[genre.MovieID] = append(movieGenres[genre.MovieID], genre.Name)
+	}
+
+	movieLanguages := make(map[int][]string)
+	for _, language := range languages {
+		name := language.Name.String
+		if name == "" {
+			name = language.Code
+		}
+		movieLanguages[language.MovieID] = append(movieLanguages[language.MovieID], name)
+	}
+
+	movies := make([]Movies, 0, len(rows))
+	for _, row := range rows {
+		movie := row.toMovie()
+		movie.Genres = movieGenres[row.ID]
+		movie.SpokenLanguages = movieLanguages[row.ID]
+		movies = append(movies, movie)
+	}
+	return movies, nil
+}
+
+// GetMovie returns the movie having movieID
+func (m *PostgresMovieModel) GetMovie(movieID string) (Movies, error) {
+	id, err := strconv.Atoi(movieID)
+	if err != nil {
+		return Movies{}, ErrMovieNotFound
+	}
+
+	var row moviePgRow
+	found, err := m.moviesDataset().Where(goqu.C("id").Eq(id)).ScanStruct(&row)
+	if err != nil {
+		return Movies{}, fmt.Errorf("error fetching movie: %w", err)
+	}
+	if !found {
+		return Movies{}, ErrMovieNotFound
+	}
+
+	movies, err := m.withGenresAndLanguages([]moviePgRow{row})
+	if err != nil {
+		return Movies{}, err
+	}
+	return movies[0], nil
+}
+
+type movieSearchPgRow struct {
+	moviePgRow
+	Score                float64 `db:"score"`
+	TitleSnippet         string  `db:"title_snippet"`
+	OriginalTitleSnippet string  `db:"original_title_snippet"`
+	TaglineSnippet       string  `db:"tagline_snippet"`
+	OverviewSnippet      string  `db:"overview_snippet"`
+}
+
+// headline highlights the words of column matching tsquery, like the snippets of the CSV backend
+func headline(column string, tsquery exp.LiteralExpression) exp.AliasedExpression {
+	return goqu.L("ts_headline('english', COALESCE(?, ''), ?, ?)",
+		goqu.C(column), tsquery, search.HeadlineOptions).As(column + "_snippet")
+}
+
+// SearchMovies runs a ranked full-text search over the search_vector column of movies
+func (m *PostgresMovieModel) SearchMovies(query string, page, limit int) ([]MovieSearchResult, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, ErrEmptySearchQuery
+	}
+	if page <= 0 {
+		page = 1
+	}
+	if limit == 0 {
+		limit = 10
+	}
+	if limit < 0 {
+		return nil, fmt.Errorf("limit cannot be negative")
+	}
+
+	tsquery := goqu.L("websearch_to_tsquery('english', ?)", query)
+
+	var rows []movieSearchPgRow
+	err := m.moviesDataset().
+		SelectAppend(
+			goqu.L("ts_rank_cd(search_vector, ?)", tsquery).As("score"),
+			headline("title", tsquery),
+			headline("original_title", tsquery),
+			headline("tagline", tsquery),
+			headline("overview", tsquery),
+		).
+		Where(goqu.L("search_vector @@ ?", tsquery)).
+		Order(goqu.I("score").Desc(), goqu.C("id").Asc()).
+		Offset(uint((page - 1) * limit)).Limit(uint(limit)).
+		ScanStructs(&rows)
+	if err != nil {
+		return nil, fmt.Errorf("error searching movies: %w", err)
+	}
+
+	if len(rows) == 0 {
+		return nil, ErrNoMoviesMatched
+	}
+
+	movieRows := make([]moviePgRow, 0, len(rows))
+	for _, row := range rows {
+		movieRows = append(movieRows, row.moviePgRow)
+	}
+	movies, err := m.withGenresAndLanguages(movieRows)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]MovieSearchResult, 0, len(rows))
+	for i, row := range rows {
+		snippets := make(map[string]string)
+		for field, snippet := range map[string]string{
+			"title":          row.TitleSnippet,
+			"original_title": row.OriginalTitleSnippet,
+			"tagline":        row.TaglineSnippet,
+			"overview":       row.OverviewSnippet,
+		} {
+			if snippet, ok := search.Headline(snippet); ok {
+				snippets[field] = snippet
+			}
+		}
+		results = append(results, MovieSearchResult{
+			Movie:    movies[i],
+			Score:    row.Score,
+			Snippets: snippets,
+		})
+	}
+	return results, nil
+}
+
+// movieRecord converts a movie to the columns stored in the movies table
+func movieRecord(movie *Movies) goqu.Record {
+	record := goqu.Record{
+		"original_language": movie.OriginalLanguage,
+		"original_title":    movie.Title,
+		"title":             movie.Title,
+		"status":            movie.Status,
+		"release_date":      movie.ReleaseDate,
+	}
+	// Fields left out of a request keep their stored value
+	if movie.OriginalTitle != "" {
+		record["original_title"] = movie.OriginalTitle
+	}
+	if movie.Overview != "" {
+		record["overview"] = movie.Overview
+	}
+	if movie.Tagline != "" {
+		record["tagline"] = movie.Tagline
+	}
+	if popularity, err := strconv.ParseFloat(movie.Popularity, 64); err == nil {
+		record["popularity"] = popularity
+	}
+	if runtime, err := strconv.ParseFloat(movie.Runtime, 64); err == nil {
+		record["runtime"] = runtime
+	}
+	if voteAverage, err := strconv.ParseFloat(movie.VoteAverage, 64); err == nil {
+		record["vote_average"] = voteAverage
+	}
+	if voteCount, err := strconv.ParseInt(movie.VoteCount, 10, 64); err == nil {
+		record["vote_count"] = voteCount
+	}
+	if movie.BelongsToCollection != nil {
+		collection, _ := json.Marshal(movie.BelongsToCollection)
+		record["belongs_to_collection"] = string(collection)
+		record["collection_id"] = movie.BelongsToCollection.ID
+	}
+	return record
+}
+
+// AddMovie stores a new movie along with its genres and spoken languages
+func (m *PostgresMovieModel) AddMovie(movie *Movies) error {
+	id, err := strconv.Atoi(movie.ID)
+	if err != nil {
+		return fmt.Errorf("invalid movie ID: %w", err)
+	}
+
+	// The id of a soft deleted movie stays taken until it is purged, its title does not
+	var count int
+	_, err = m.db.From(moviesTable).Select(goqu.COUNT("*")).
+		Where(goqu.Or(goqu.C("id").Eq(id), goqu.And(goqu.C("title").Eq(movie.Title), live(moviesTable)))).
+		ScanVal(&count)
+	if err != nil {
+		return fmt.Errorf("failed to check movie existence: %w", err)
+	}
+	if count > 0 {
+		return ErrMovieAlreadyExists
+	}
+
+	return inTx(m.db, func(tx *goqu.TxDatabase) error {
+		if err := ensureLanguage(tx, movie.OriginalLanguage); err != nil {
+			return err
+		}
+		if err := ensureCollection(tx, movie.BelongsToCollection); err != nil {
+			return err
+		}
+
+		record := movieRecord(movie)
+		record["id"] = id
+		if _, err := tx.Insert(moviesTable).Rows(record).Executor().Exec(); err != nil {
+			return fmt.Errorf("failed to insert movie: %w", err)
+		}
+
+		return linkGenresAndLanguages(tx, id, movie)
+	})
+}
+
+// UpdateMovie replaces the movie having movieId along with its genres and spoken languages
+func (m *PostgresMovieModel) UpdateMovie(movieId string, updatedMovie *Movies) error {
+	id, err := strconv.Atoi(movieId)
+	if err != nil {
+		return ErrMovieNotFound
+	}
+
+	return inTx(m.db, func(tx *goqu.TxDatabase) error {
+		if err := ensureLanguage(tx, updatedMovie.OriginalLanguage); err != nil {
+			return err
+		}
+		if err := ensureCollection(tx, updatedMovie.BelongsToCollection); err != nil {
+			return err
+		}
+
+		res, err := tx.Update(moviesTable).Set(movieRecord(updatedMovie)).
+			Where(goqu.C("id").Eq(id), live(moviesTable)).
+			Executor().Exec()
+		if err != nil {
+			return fmt.Errorf("failed to update movie: %w", err)
+		}
+		if rowsAffected, _ := res.RowsAffected(); rowsAffected == 0 {
+			return ErrMovieNotFound
+		}
+
+		if _, err := tx.Delete(movieGenresTable).Where(goqu.C("movieid").Eq(id)).Executor().Exec(); err != nil {
+			return fmt.Errorf("failed to delete old genres: %w", err)
+		}
+		if _, err := tx.Delete(movieLanguagesTable).Where(goqu.C("movieid").Eq(id)).Executor().Exec(); err != nil {
+			return fmt.Errorf("failed to delete old languages: %w", err)
+		}
+
+		return linkGenresAndLanguages(tx, id, updatedMovie)
+	})
+}
+
+// ensureLanguage inserts a language with isoCode if it is not stored yet
+func ensureLanguage(tx *goqu.TxDatabase, isoCode string) error {
+	_, err := tx.Insert(languagesTable).Rows(goqu.Record{"iso_code": isoCode}).
+		OnConflict(goqu.DoNothing()).
+		Executor().Exec()
+	if err != nil {
+		return fmt.Errorf("failed to insert language: %w", err)
+	}
+	return nil
+}
+
+// ensureCollection inserts collection if it is not stored yet, stored collections keep their names
+func ensureCollection(tx *goqu.TxDatabase, collection *Collection) error {
+	if collection == nil {
+		return nil
+	}
+	row := goqu.Record{"id": collection.ID, "name": collection.Name}
+	if collection.PosterPath != "" {
+		row["poster_path"] = collection.PosterPath
+	}
+	if collection.BackdropPath != "" {
+		row["backdrop_path"] = collection.BackdropPath
+	}
+	_, err := tx.Insert(collectionsTable).Rows(row).
+		OnConflict(goqu.DoNothing()).
+		Executor().Exec()
+	if err != nil {
+		return fmt.Errorf("failed to insert collection: %w", err)
+	}
+	return nil
+}
+
+// linkGenresAndLanguages links the movie to its genres and spoken languages, creating missing genres
+func linkGenresAndLanguages(tx *goqu.TxDatabase, movieID int, movie *Movies) error {
+	for _, genre := range movie.Genres {
+		var genreID int
+		found, err := tx.From(genresTable).
+			Where(goqu.L("LOWER(name) = LOWER(?)", genre)).
+			Select("id").ScanVal(&genreID)
+		if err != nil {
+			return fmt.Errorf("error querying genre: %w", err)
+		}
+
+		if !found {
+			_, err = tx.From(genresTable).Select(goqu.L("COALESCE(MAX(id), 0) + 1")).ScanVal(&genreID)
+			if err != nil {
+				return fmt.Errorf("failed to get next genre ID: %w", err)
+			}
+			_, err = tx.Insert(genresTable).Rows(goqu.Record{"id": genreID, "name": genre}).Executor().Exec()
+			if err != nil {
+				return fmt.Errorf("failed to insert genre: %w", err)
+			}
+		}
+
+		_, err = tx.Insert(movieGenresTable).Rows(goqu.Record{"movieid": movieID, "genreid": genreID}).
+			OnConflict(goqu.DoNothing()).
+			Executor().Exec()
+		if err != nil {
+			return fmt.Errorf("failed to link movie and genre: %w", err)
+		}
+	}
+
+	for _, language := range movie.SpokenLanguages {
+		var isoCode string
+		found, err := tx.From(languagesTable).
+			Where(goqu.Or(
+				goqu.L("LOWER(name) = LOWER(?)", language),
+				goqu.L("LOWER(iso_code) = LOWER(?)", language),
+			)).
+			Select("iso_code").ScanVal(&isoCode)
+		if err != nil {
+			return fmt.Errorf("error checking language: %w", err)
+		}
+
+		if !found {
+			if len(language) != 2 {
+				return fmt.Errorf("unknown spoken language %q", language)
+			}
+			isoCode = strings.ToLower(language)
+			if err := ensureLanguage(tx, isoCode); err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.Insert(movieLanguagesTable).Rows(goqu.Record{"movieid": movieID, "language_code": isoCode}).
+			OnConflict(goqu.DoNothing()).
+			Executor().Exec()
+		if err != nil {
+			return fmt.Errorf("failed to link movie and language: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// DeleteMovie soft deletes the movie having movieId along with its ratings and credits. now()
+// is the start of the transaction, so they all get the same deleted_at.
+func (m *PostgresMovieModel) DeleteMovie(movieId string) error {
+	id, err := strconv.Atoi(movieId)
+	if err != nil {
+		return ErrMovieNotFound
+	}
+
+	return inTx(m.db, func(tx *goqu.TxDatabase) error {
+		res, err := tx.Update(moviesTable).Set(goqu.Record{"deleted_at": goqu.L("now()")}).
+			Where(goqu.C("id").Eq(id), live(moviesTable)).
+			Executor().Exec()
+		if err != nil {
+			return fmt.Errorf("failed to delete movie: %w", err)
+		}
+		if rowsAffected, _ := res.RowsAffected(); rowsAffected == 0 {
+			return ErrMovieNotFound
+		}
+
+		for _, table := range []string{ratingsTable, movieCastsTable, movieCrewTable} {
+			_, err := tx.Update(table).Set(goqu.Record{"deleted_at": goqu.L("now()")}).
+				Where(goqu.C("movie_id").Eq(id), live(table)).
+				Executor().Exec()
+			if err != nil {
+				return fmt.Errorf("failed to delete %s of movie: %w", table, err)
+			}
+		}
+		return nil
+	})
+}
+
+// RestoreMovie brings back the soft deleted movie having movieId along with the ratings and
+// credits deleted with it
+func (m *PostgresMovieModel) RestoreMovie(movieId string) error {
+	id, err := strconv.Atoi(movieId)
+	if err != nil {
+		return ErrMovieNotFound
+	}
+
+	return inTx(m.db, func(tx *goqu.TxDatabase) error {
+		var movie struct {
+			Title     sql.NullString `db:"title"`
+			DeletedAt sql.NullTime   `db:"deleted_at"`
+		}
+		found, err := tx.From(moviesTable).Select("title", "deleted_at").
+			Where(goqu.C("id").Eq(id)).
+			ForUpdate(exp.Wait).
+			ScanStruct(&movie)
+		if err != nil {
+			return fmt.Errorf("failed to fetch movie: %w", err)
+		}
+		if !found {
+			return ErrMovieNotFound
+		}
+		if !movie.DeletedAt.Valid {
+			return ErrMovieNotDeleted
+		}
+
+		// Another movie may have taken the title meanwhile
+		var count int
+		_, err = tx.From(moviesTable).Select(goqu.COUNT("*")).
+			Where(goqu.C("title").Eq(movie.Title), live(moviesTable)).
+			ScanVal(&count)
+		if err != nil {
+			return fmt.Errorf("failed to check movie existence: %w", err)
+		}
+		if count > 0 {
+			return ErrMovieAlreadyExists
+		}
+
+		// Rows deleted before the movie stay deleted
+		deletedAt := tx.From(moviesTable).Select("deleted_at").Where(goqu.C("id").Eq(id))
+		for _, table := range []string{ratingsTable, movieCastsTable, movieCrewTable} {
+			_, err := tx.Update(table).Set(goqu.Record{"deleted_at": nil}).
+				Where(goqu.C("movie_id").Eq(id), goqu.C("deleted_at").Eq(deletedAt)).
+				Executor().Exec()
+			if err != nil {
+				return fmt.Errorf("failed to restore %s of movie: %w", table, err)
+			}
+		}
+
+		_, err = tx.Update(moviesTable).Set(goqu.Record{"deleted_at": nil}).
+			Where(goqu.C("id").Eq(id)).
+			Executor().Exec()
+		if err != nil {
+			return fmt.Errorf("failed to restore movie: %w", err)
+		}
+		return nil
+	})
+}
+
+// PurgeDeleted removes the movies, ratings and credits soft deleted before before for good
+func (m *PostgresMovieModel) PurgeDeleted(before time.Time) (PurgeCounts, error) {
+	var counts PurgeCounts
+	err := inTx(m.db, func(tx *goqu.TxDatabase) error {
+		// Ratings and credits go first, so the ones deleted with their movie are counted too
+		purged := make(map[string]int64)
+		for _, table := range []string{ratingsTable, movieCastsTable, movieCrewTable, moviesTable} {
+			res, err := tx.Delete(table).Where(goqu.C("deleted_at").Lt(before)).Executor().Exec()
+			if err != nil {
+				return fmt.Errorf("failed to purge %s: %w", table, err)
+			}
+			purged[table], _ = res.RowsAffected()
+		}
+
+		counts = PurgeCounts{
+			Movies:  int(purged[moviesTable]),
+			Ratings: int(purged[ratingsTable]),
+			Credits: int(purged[movieCastsTable] + purged[movieCrewTable]),
+		}
+		return nil
+	})
+	return counts, err
+}
+
+// MovieExists checks whether a movie having movieId is stored
+func (m *PostgresMovieModel) MovieExists(movieId string) (bool, error) {
+	id, err := strconv.Atoi(movieId)
+	if err != nil {
+		return false, nil
+	}
+
+	var count int
+	_, err = m.db.From(moviesTable).Select(goqu.COUNT("*")).Where(goqu.C("id").Eq(id), live(moviesTable)).ScanVal(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to check movie existence: %w", err)
+	}
+	return count > 0, nil
+}
+
+// TopRatedMovies ranks the rated movies matching filters by score and returns the first limit
+func (m *PostgresMovieModel) TopRatedMovies(filters map[string]string, limit int) ([]TopRatedMovie, error) {
+	filter, err := parseTopRatedFilter(filters)
+	if err != nil {
+		return nil, err
+	}
+	mean, err := priorMean(m.db, m.prior)
+	if err != nil {
+		return nil, err
+	}
+
+	rating := goqu.T(ratingsTable).Col("rating")
+	score := goqu.L("(COUNT(?) * AVG(?) + ?) / (COUNT(?) + ?)", rating, rating, m.prior.Votes*mean, rating, m.prior.Votes)
+
+	ds := m.db.From(moviesTable).
+		Join(goqu.T(ratingsTable), goqu.On(goqu.T(ratingsTable).Col("movie_id").Eq(goqu.T(moviesTable).Col("id")))).
+		Where(live(moviesTable), live(ratingsTable))
+	ds = m.filterDataset(ds, filter).
+		Select(append([]interface{}{
+			goqu.T(moviesTable).Col("id").As("movie_id"),
+			goqu.T(moviesTable).Col("title"),
+		}, ratingAggregates()...)...).
+		GroupBy(goqu.T(moviesTable).Col("id"), goqu.T(moviesTable).Col("title")).
+		Order(score.Desc(), goqu.COUNT(rating).Desc(), goqu.T(moviesTable).Col("id").Asc()).
+		Limit(uint(limit))
+
+	var rows []struct {
+		movieRatingPgRow
+		Title sql.NullString `db:"title"`
+	}
+	if err := ds.ScanStructs(&rows); err != nil {
+		return nil, fmt.Errorf("failed to fetch top rated movies: %w", err)
+	}
+
+	movieIDs := make([]int, 0, len(rows))
+	for _, row := range rows {
+		movieIDs = append(movieIDs, row.MovieID)
+	}
+	histograms, err := ratingHistograms(m.db, movieIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	top := make([]TopRatedMovie, 0, len(rows))
+	for _, row := range rows {
+		top = append(top, TopRatedMovie{
+			Title:        row.Title.String,
+			MovieRatings: row.toMovieRatings(m.prior, mean, histograms[row.MovieID]),
+		})
+	}
+	return top, nil
+}
+
+// PostgresRatingModel is the goqu backed RatingRepository
+type PostgresRatingModel struct {
+	db    Session
+	prior RatingPrior
+}
+
+// NewPostgresRatingModel initializes a PostgresRatingModel, prior weights the scores of movies
+func NewPostgresRatingModel(db Session, prior RatingPrior) *PostgresRatingModel {
+	return &PostgresRatingModel{db: db, prior: prior}
+}
+
+type movieRatingPgRow struct {
+	MovieID int     `db:"movie_id"`
+	Average float64 `db:"avg_rating"`
+	Count   int     `db:"count"`
+	StdDev  float64 `db:"std_dev"`
+}
+
+// ratingAggregates are the columns of movieRatingPgRow aggregated over the ratings of a movie
+func ratingAggregates() []interface{} {
+	rating := goqu.T(ratingsTable).Col("rating")
+	return []interface{}{
+		goqu.AVG(rating).As("avg_rating"),
+		goqu.COUNT(rating).As("count"),
+		goqu.COALESCE(goqu.Func("STDDEV_POP", rating), 0).As("std_dev"),
+	}
+}
+
+// toMovieRatings rounds the stats of row and scores them, mean is the prior mean
+func (row movieRatingPgRow) toMovieRatings(prior RatingPrior, mean float64, histogram []RatingCount) MovieRatings {
+	return MovieRatings{
+		MovieId:   strconv.Itoa(row.MovieID),
+		Ratings:   RoundToTwoDecimals(row.Average),
+		Count:     row.Count,
+		StdDev:    RoundToTwoDecimals(row.StdDev),
+		Score:     RoundToTwoDecimals(prior.score(row.Average, row.Count, mean)),
+		Histogram: histogram,
+	}
+}
+
+// priorMean returns the mean scores are weighted towards
+func priorMean(db Session, prior RatingPrior) (float64, error) {
+	if prior.Mean > 0 {
+		return prior.Mean, nil
+	}
+
+	var mean sql.NullFloat64
+	if _, err := db.From(ratingsTable).Select(goqu.AVG("rating")).Where(live(ratingsTable)).ScanVal(&mean); err != nil {
+		return 0, fmt.Errorf("failed to fetch mean rating: %w", err)
+	}
+	return mean.Float64, nil
+}
+
+// ratingHistograms counts the ratings of every rating value of the movies having movieIDs
+func ratingHistograms(db Session, movieIDs []int) (map[int][]RatingCount, error) {
+	histograms := make(map[int][]RatingCount, len(movieIDs))
+	if len(movieIDs) == 0 {
+		return histograms, nil
+	}
+
+	var rows []struct {
+		MovieID int     `db:"movie_id"`
+		Rating  float64 `db:"rating"`
+		Count   int     `db:"count"`
+	}
+	err := db.From(ratingsTable).
+		Select(goqu.C("movie_id"), goqu.C("rating"), goqu.COUNT("*").As("count")).
+		Where(goqu.C("movie_id").In(movieIDs), live(ratingsTable)).
+		GroupBy(goqu.C("movie_id"), goqu.C("rating")).
+		Order(goqu.C("rating").Asc()).
+		ScanStructs(&rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch rating histograms: %w", err)
+	}
+
+	for _, row := range rows {
+		histograms[row.MovieID] = append(histograms[row.MovieID], RatingCount{
+			Rating: RoundToTwoDecimals(row.Rating),
+			Count:  row.Count,
+		})
+	}
+	return histograms, nil
+}
+
+func (r *PostgresRatingModel) averagesDataset() *goqu.SelectDataset {
+	return r.db.From(ratingsTable).
+		Select(append([]interface{}{goqu.C("movie_id")}, ratingAggregates()...)...).
+		Where(live(ratingsTable)).
+		GroupBy("movie_id")
+}
+
+// ListRatings lists the rating stats of all movies
+func (r *PostgresRatingModel) ListRatings(page utils.PageRequest) (utils.Page[MovieRatings], error) {
+	if err := page.CheckKey(1); err != nil {
+		return utils.Page[MovieRatings]{}, err
+	}
+
+	var total *int
+	if page.Total {
+		var count int
+		_, err := r.db.From(ratingsTable).Select(goqu.COUNT(goqu.DISTINCT("movie_id"))).Where(live(ratingsTable)).ScanVal(&count)
+		if err != nil {
+			return utils.Page[MovieRatings]{}, fmt.Errorf("failed to count ratings: %w", err)
+		}
+		total = &count
+	}
+
+	var rows []movieRatingPgRow
+	columns := []keysetColumn{{expr: goqu.C("movie_id")}}
+	err := keysetPage(r.averagesDataset(), columns, page).ScanStructs(&rows)
+	if err != nil {
+		return utils.Page[MovieRatings]{}, fmt.Errorf("failed to fetch ratings: %w", err)
+	}
+
+	mean, err := priorMean(r.db, r.prior)
+	if err != nil {
+		return utils.Page[MovieRatings]{}, err
+	}
+	movieIDs := make([]int, 0, len(rows))
+	for _, row := range rows {
+		movieIDs = append(movieIDs, row.MovieID)
+	}
+	histograms, err := ratingHistograms(r.db, movieIDs)
+	if err != nil {
+		return utils.Page[MovieRatings]{}, err
+	}
+
+	ratings := make([]MovieRatings, 0, len(rows))
+	for _, row := range rows {
+		ratings = append(ratings, row.toMovieRatings(r.prior, mean, histograms[row.MovieID]))
+	}
+
+	result := keysetRows(ratings, page, movieRatingsKey)
+	result.Total = total
+	return result, nil
+}
+
+// GetRatingsByMovieId returns the rating stats of movie having movieId
+func (r *PostgresRatingModel) GetRatingsByMovieId(movieId string) (MovieRatings, error) {
+	id, err := strconv.Atoi(movieId)
+	if err != nil {
+		return MovieRatings{}, ErrMovieNotFound
+	}
+
+	var row movieRatingPgRow
+	found, err := r.averagesDataset().Where(goqu.C("movie_id").Eq(id)).ScanStruct(&row)
+	if err != nil {
+		return MovieRatings{}, fmt.Errorf("failed to fetch rating: %w", err)
+	}
+	if !found {
+		return MovieRatings{}, ErrMovieNotFound
+	}
+
+	mean, err := priorMean(r.db, r.prior)
+	if err != nil {
+		return MovieRatings{}, err
+	}
+	histograms, err := ratingHistograms(r.db, []int{id})
+	if err != nil {
+		return MovieRatings{}, err
+	}
+
+	return row.toMovieRatings(r.prior, mean, histograms[id]), nil
+}
+
+// AddRatings stores a new rating, an existing rating of the same user and movie is replaced
+// even when it was deleted
+func (r *PostgresRatingModel) AddRatings(rating *Ratings) error {
+	_, err := r.db.Insert(ratingsTable).Rows(goqu.Record{
+		"user_id":   rating.UserId,
+		"movie_id":  rating.MovieId,
+		"rating":    rating.Rating,
+		"timestamp": time.Now(),
+	}).OnConflict(goqu.DoUpdate("user_id, movie_id", goqu.Record{
+		"rating":     rating.Rating,
+		"timestamp":  time.Now(),
+		"deleted_at": nil,
+	})).Executor().Exec()
+	if err != nil {
+		return fmt.Errorf("failed to upsert rating: %w", err)
+	}
+	return nil
+}
+
+// GetRating returns the rating userId gave to movieId
+func (r *PostgresRatingModel) GetRating(movieId, userId string) (Ratings, error) {
+	movieID, err := strconv.Atoi(movieId)
+	if err != nil {
+		return Ratings{}, ErrRatingNotFound
+	}
+	userID, err := strconv.Atoi(userId)
+	if err != nil {
+		return Ratings{}, ErrRatingNotFound
+	}
+
+	var row userRatingPgRow
+	found, err := r.db.From(ratingsTable).
+		Select(goqu.C("movie_id"), goqu.C("rating"), ratingUnixTime.As("unix_timestamp")).
+		Where(goqu.Ex{"movie_id": movieID, "user_id": userID, "deleted_at": nil}).
+		ScanStruct(&row)
+	if err != nil {
+		return Ratings{}, fmt.Errorf("failed to fetch rating: %w", err)
+	}
+	if !found {
+		return Ratings{}, ErrRatingNotFound
+	}
+
+	return Ratings{
+		UserId:    userId,
+		MovieId:   movieId,
+		Rating:    strconv.FormatFloat(row.Rating, 'f', -1, 64),
+		Timestamp: strconv.FormatInt(row.Timestamp, 10),
+	}, nil
+}
+
+// UpdateRatings changes the rating given by userId to movieId
+func (r *PostgresRatingModel) UpdateRatings(userId, movieId, newRating, newTimestamp string) error {
+	timestamp := time.Now()
+	if unix, err := strconv.ParseInt(newTimestamp, 10, 64); err == nil {
+		timestamp = time.Unix(unix, 0)
+	}
+
+	res, err := r.db.Update(ratingsTable).Set(goqu.Record{
+		"rating":    newRating,
+		"timestamp": timestamp,
+	}).Where(goqu.Ex{
+		"user_id":    userId,
+		"movie_id":   movieId,
+		"deleted_at": nil,
+	}).Executor().Exec()
+	if err != nil {
+		return fmt.Errorf("failed to update rating: %w", err)
+	}
+	if rowsAffected, _ := res.RowsAffected(); rowsAffected == 0 {
+		return ErrRatingNotFound
+	}
+	return nil
+}
+
+// DeleteRatings soft deletes ratings of movieId, only the one given by userId when it is set
+func (r *PostgresRatingModel) DeleteRatings(movieId string, userId *string) error {
+	where := goqu.Ex{"movie_id": movieId, "deleted_at": nil}
+	if userId != nil {
+		where["user_id"] = *userId
+	}
+
+	res, err := r.db.Update(ratingsTable).Set(goqu.Record{"deleted_at": goqu.L("now()")}).Where(where).Executor().Exec()
+	if err != nil {
+		return fmt.Errorf("failed to delete rating: %w", err)
+	}
+	if rowsAffected, _ := res.RowsAffected(); rowsAffected == 0 {
+		return ErrRatingNotFound
+	}
+	return nil
+}
+
+// PostgresUserModel is the goqu backed UserRepository
+type PostgresUserModel struct {
+	db Session
+}
+
+// NewPostgresUserModel initializes a PostgresUserModel
+func NewPostgresUserModel(db Session) *PostgresUserModel {
+	return &PostgresUserModel{db: db}
+}
+
+// ratingUnixTime is the timestamp of ratings in unix seconds, ratings without one are at 0
+var ratingUnixTime = goqu.L("COALESCE(EXTRACT(EPOCH FROM ?), 0)::bigint", goqu.C("timestamp"))
+
+type userRatingPgRow struct {
+	MovieID   int     `db:"movie_id"`
+	Rating    float64 `db:"rating"`
+	Timestamp int64   `db:"unix_timestamp"`
+}
+
+// ListUserRatings fetches a page of the ratings of userId ordered by sortBy
+func (u *PostgresUserModel) ListUserRatings(userId, sortBy string, page utils.PageRequest) (utils.Page[UserRating], error) {
+	order, err := parseUserRatingSort(sortBy)
+	if err != nil {
+		return utils.Page[UserRating]{}, err
+	}
+	if err := page.CheckKey(2); err != nil {
+		return utils.Page[UserRating]{}, err
+	}
+	id, err := strconv.Atoi(userId)
+	if err != nil {
+		return utils.Page[UserRating]{}, ErrUserNotFound
+	}
+
+	ds := u.db.From(ratingsTable).Where(goqu.C("user_id").Eq(id), live(ratingsTable))
+
+	var count int
+	if _, err := ds.Select(goqu.COUNT("*")).ScanVal(&count); err != nil {
+		return utils.Page[UserRating]{}, fmt.Errorf("failed to count user ratings: %w", err)
+	}
+	if count == 0 {
+		return utils.Page[UserRating]{}, ErrUserNotFound
+	}
+
+	column := keysetColumn{expr: ratingUnixTime, desc: order.desc}
+	if order.field == "rating" {
+		column.expr = goqu.C("rating")
+	}
+	columns := []keysetColumn{column, {expr: goqu.C("movie_id")}}
+
+	var rows []userRatingPgRow
+	ds = ds.Select(goqu.C("movie_id"), goqu.C("rating"), ratingUnixTime.As("unix_timestamp"))
+	if err := keysetPage(ds, columns, page).ScanStructs(&rows); err != nil {
+		return utils.Page[UserRating]{}, fmt.Errorf("failed to fetch user ratings: %w", err)
+	}
+
+	ratings := make([]UserRating, 0, len(rows))
+	for _, row := range rows {
+		ratings = append(ratings, UserRating{
+			MovieId:   strconv.Itoa(row.MovieID),
+			Rating:    RoundToTwoDecimals(row.Rating),
+			Timestamp: row.Timestamp,
+		})
+	}
+
+	result := keysetRows(ratings, page, order.key)
+	if page.Total {
+		result.Total = &count
+	}
+	return result, nil
+}
+
+// GetUserStats summarizes the ratings of userId, genres are joined through movie_genres
+func (u *PostgresUserModel) GetUserStats(userId string) (UserStats, error) {
+	id, err := strconv.Atoi(userId)
+	if err != nil {
+		return UserStats{}, ErrUserNotFound
+	}
+
+	var distribution []struct {
+		Rating float64 `db:"rating"`
+		Count  int     `db:"count"`
+	}
+	err = u.db.From(ratingsTable).
+		Select(goqu.C("rating"), goqu.COUNT("*").As("count")).
+		Where(goqu.C("user_id").Eq(id), live(ratingsTable)).
+		GroupBy(goqu.C("rating")).
+		Order(goqu.C("rating").Asc()).
+		ScanStructs(&distribution)
+	if err != nil {
+		return UserStats{}, fmt.Errorf("failed to fetch rating distribution: %w", err)
+	}
+	if len(distribution) == 0 {
+		return UserStats{}, ErrUserNotFound
+	}
+
+	stats := UserStats{UserId: userId, Distribution: make([]RatingCount, 0, len(distribution))}
+	var sum float64
+	for _, bucket := range distribution {
+		stats.Count += bucket.Count
+		sum += bucket.Rating * float64(bucket.Count)
+		stats.Distribution = append(stats.Distribution, RatingCount{
+			Rating: RoundToTwoDecimals(bucket.Rating),
+			Count:  bucket.Count,
+		})
+	}
+	stats.Mean = RoundToTwoDecimals(sum / float64(stats.Count))
+
+	var genres []struct {
+		Genre   string  `db:"genre"`
+		Count   int     `db:"count"`
+		Average float64 `db:"average"`
+	}
+	err = u.db.From(ratingsTable).
+		Join(goqu.T(movieGenresTable), goqu.On(goqu.T(movieGenresTable).Col("movieid").Eq(goqu.T(ratingsTable).Col("movie_id")))).
+		Join(goqu.T(genresTable), goqu.On(goqu.T(genresTable).Col("id").Eq(goqu.T(movieGenresTable).Col("genreid")))).
+		Select(
+			goqu.T(genresTable).Col("name").As("genre"),
+			goqu.COUNT("*").As("count"),
+			goqu.AVG(goqu.T(ratingsTable).Col("rating")).As("average"),
+		).
+		Where(goqu.T(ratingsTable).Col("user_id").Eq(id), live(ratingsTable)).
+		GroupBy(goqu.T(genresTable).Col("name")).
+		ScanStructs(&genres)
+	if err != nil {
+		return UserStats{}, fmt.Errorf("failed to fetch favourite genres: %w", err)
+	}
+
+	favourites := make([]GenreCount, 0, len(genres))
+	for _, genre := range genres {
+		favourites = append(favourites, GenreCount{
+			Genre:   genre.Genre,
+			Count:   genre.Count,
+			Average: RoundToTwoDecimals(genre.Average),
+		})
+	}
+	stats.FavouriteGenres = sortGenres(favourites)
+
+	return stats, nil
+}
+
+// PostgresCreditModel is the goqu backed CastRepository and CrewRepository
+type PostgresCreditModel struct {
+	db Session
+}
+
+// NewPostgresCreditModel initializes a PostgresCreditModel
+func NewPostgresCreditModel(db Session) *PostgresCreditModel {
+	return &PostgresCreditModel{db: db}
+}
+
+// ListCastMembers is to list cast members of movie having id movieID
+func (c *PostgresCreditModel) ListCastMembers(movieID string) ([]CastMember, error) {
+	id, err := strconv.Atoi(movieID)
+	if err != nil {
+		return nil, errors.New("invalid movie ID format")
+	}
+
+	var rows []struct {
+		CreditID    string         `db:"credit_id"`
+		PersonID    int            `db:"person_id"`
+		Character   sql.NullString `db:"character"`
+		Name        sql.NullString `db:"name"`
+		Gender      sql.NullInt64  `db:"gender"`
+		ProfilePath sql.NullString `db:"profile_path"`
+	}
+	err = c.db.From(movieCastsTable).
+		Select("credit_id", "person_id", "character", "name", "gender", "profile_path").
+		Join(goqu.T(creditsTable), goqu.On(goqu.T(movieCastsTable).Col("person_id").Eq(goqu.T(creditsTable).Col("id")))).
+		Where(goqu.T(movieCastsTable).Col("movie_id").Eq(id), live(movieCastsTable)).
+		Order(goqu.C("cast_order").Asc()).
+		ScanStructs(&rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch cast: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, ErrMovieNotFound
+	}
+
+	cast := make([]CastMember, 0, len(rows))
+	for _, row := range rows {
+		cast = append(cast, CastMember{
+			CreditID:    row.CreditID,
+			ID:          row.PersonID,
+			Character:   row.Character.String,
+			Name:        row.Name.String,
+			Gender:      int(row.Gender.Int64),
+			ProfilePath: row.ProfilePath.String,
+		})
+	}
+	return cast, nil
+}
+
+// ListMoviesByCastId is to list ids of movies in which cast member having castId played
+func (c *PostgresCreditModel) ListMoviesByCastId(castId string) ([]int, error) {
+	id, err := strconv.Atoi(castId)
+	if err != nil {
+		return nil, errors.New("invalid cast ID format")
+	}
+
+	var movieIDs []int
+	err = c.db.From(movieCastsTable).
+		Select(goqu.DISTINCT("movie_id")).
+		Where(goqu.C("person_id").Eq(id), live(movieCastsTable)).
+		Order(goqu.C("movie_id").Asc()).
+		ScanVals(&movieIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch movies for cast: %w", err)
+	}
+	if len(movieIDs) == 0 {
+		return nil, errors.New("no movies found for the given cast ID")
+	}
+	return movieIDs, nil
+}
+
+// UpdateCastMember is to update cast member details having castId of a movie having movieId
+func (c *PostgresCreditModel) UpdateCastMember(movieId, castId string, updatedCast CastMember) error {
+	return inTx(c.db, func(tx *goqu.TxDatabase) error {
+		set := goqu.Record{}
+		if updatedCast.Character != "" {
+			set["character"] = updatedCast.Character
+		}
+		if len(set) == 0 {
+			set["character"] = goqu.C("character")
+		}
+
+		res, err := tx.Update(movieCastsTable).Set(set).
+			Where(goqu.Ex{"movie_id": movieId, "person_id": castId, "deleted_at": nil}).
+			Executor().Exec()
+		if err != nil {
+			return fmt.Errorf("failed to update cast member: %w", err)
+		}
+		if rowsAffected, _ := res.RowsAffected(); rowsAffected == 0 {
+			return ErrCastNotFound
+		}
+
+		return updateCreditName(tx, castId, updatedCast.Name)
+	})
+}
+
+// ListCrewMembers is to list crew members of movie having id movieID
+func (c *PostgresCreditModel) ListCrewMembers(movieID string) ([]CrewMember, error) {
+	id, err := strconv.Atoi(movieID)
+	if err != nil {
+		return nil, errors.New("invalid movie ID format")
+	}
+
+	var rows []struct {
+		CreditID    string         `db:"credit_id"`
+		PersonID    int            `db:"person_id"`
+		Name        sql.NullString `db:"name"`
+		Gender      sql.NullInt64  `db:"gender"`
+		ProfilePath sql.NullString `db:"profile_path"`
+		Department  sql.NullString `db:"department"`
+		Job         sql.NullString `db:"job"`
+	}
+	err = c.db.From(movieCrewTable).
+		Select("credit_id", "person_id", "name", "gender", "profile_path", "department", "job").
+		Join(goqu.T(creditsTable), goqu.On(goqu.T(movieCrewTable).Col("person_id").Eq(goqu.T(creditsTable).Col("id")))).
+		Where(goqu.T(movieCrewTable).Col("movie_id").Eq(id), live(movieCrewTable)).
+		ScanStructs(&rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch crew: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, ErrMovieNotFound
+	}
+
+	crew := make([]CrewMember, 0, len(rows))
+	for _, row := range rows {
+		crew = append(crew, CrewMember{
+			CreditID:    row.CreditID,
+			ID:          row.PersonID,
+			Name:        row.Name.String,
+			Gender:      int(row.Gender.Int64),
+			ProfilePath: row.ProfilePath.String,
+			Department:  row.Department.String,
+			Job:         row.Job.String,
+		})
+	}
+	return crew, nil
+}
+
+// UpdateCrewMember is to update details of crew member having crewId in a movie having movieId
+func (c *PostgresCreditModel) UpdateCrewMember(movieId, crewId string, updatedCrew CrewMember) error {
+	return inTx(c.db, func(tx *goqu.TxDatabase) error {
+		set := goqu.Record{}
+		if updatedCrew.Department != "" {
+			set["department"] = updatedCrew.Department
+		}
+		if updatedCrew.Job != "" {
+			set["job"] = updatedCrew.Job
+		}
+		if len(set) == 0 {
+			set["job"] = goqu.C("job")
+		}
+
+		res, err := tx.Update(movieCrewTable).Set(set).
+			Where(goqu.Ex{"movie_id": movieId, "person_id": crewId, "deleted_at": nil}).
+			Executor().Exec()
+		if err != nil {
+			return fmt.Errorf("failed to update crew member: %w", err)
+		}
+		if rowsAffected, _ := res.RowsAffected(); rowsAffected == 0 {
+			return ErrCrewNotFound
+		}
+
+		return updateCreditName(tx, crewId, updatedCrew.Name)
+	})
+}
+
+// updateCreditName renames the person having personId when name is set
+func updateCreditName(tx *goqu.TxDatabase, personId, name string) error {
+	if name == "" {
+		return nil
+	}
+
+	_, err := tx.Update(creditsTable).Set(goqu.Record{"name": name}).
+		Where(goqu.C("id").Eq(personId)).
+		Executor().Exec()
+	if err != nil {
+		return fmt.Errorf("failed to update credit name: %w", err)
+	}
+	return nil
+}
+
+// DeleteCreditsForMovie is to soft delete cast and crew of a movie when that movie is deleted
+func (c *PostgresCreditModel) DeleteCreditsForMovie(movieId string) error {
+	return inTx(c.db, func(tx *goqu.TxDatabase) error {
+		deleted := goqu.Record{"deleted_at": goqu.L("now()")}
+		where := goqu.Ex{"movie_id": movieId, "deleted_at": nil}
+		if _, err := tx.Update(movieCastsTable).Set(deleted).Where(where).Executor().Exec(); err != nil {
+			return fmt.Errorf("failed to delete movie cast: %w", err)
+		}
+		if _, err := tx.Update(movieCrewTable).Set(deleted).Where(where).Executor().Exec(); err != nil {
+			return fmt.Errorf("failed to delete movie crew: %w", err)
+		}
+		return nil
+	})
+}
+
+// filmographyTable is the alias of the cast and crew credits of a person
+const filmographyTable = "filmography"
+
+// filmographyColumns are the columns of the filmography query behind the fields of sort
+var filmographyColumns = map[string]string{
+	"year":      "release_year",
+	"title":     "title",
+	"rating":    "rating",
+	"credit_id": "credit_id",
+}
+
+// PostgresPeopleModel is the goqu backed PeopleRepository
+type PostgresPeopleModel struct {
+	db Session
+}
+
+// NewPostgresPeopleModel initializes a PostgresPeopleModel
+func NewPostgresPeopleModel(db Session) *PostgresPeopleModel {
+	return &PostgresPeopleModel{db: db}
+}
+
+// credits selects the live credits of table held by personID on live movies, role and details
+// being the columns telling cast and crew credits apart
+func (p *PostgresPeopleModel) credits(table string, personID int, role string, details ...interface{}) *goqu.SelectDataset {
+	ratings := func() *goqu.SelectDataset {
+		return p.db.From(ratingsTable).
+			Where(goqu.T(ratingsTable).Col("movie_id").Eq(goqu.T(moviesTable).Col("id")), live(ratingsTable))
+	}
+
+	columns := []interface{}{
+		goqu.T(table).Col("credit_id"),
+		goqu.T(table).Col("movie_id"),
+		goqu.COALESCE(goqu.T(moviesTable).Col("title"), "").As("title"),
+		goqu.V(role).As("role"),
+	}
+	columns = append(columns, details...)
+	columns = append(columns,
+		goqu.L("EXTRACT(YEAR FROM ?)::integer", goqu.T(moviesTable).Col("release_date")).As("release_year"),
+		ratings().Select(goqu.L("COALESCE(ROUND(AVG(?)::numeric, 2), 0)", goqu.T(ratingsTable).Col("rating"))).As("rating"),
+		ratings().Select(goqu.COUNT(goqu.Star())).As("rating_count"),
+	)
+
+	return p.db.From(table).Select(columns...).
+		Join(goqu.T(moviesTable), goqu.On(goqu.T(table).Col("movie_id").Eq(goqu.T(moviesTable).Col("id")))).
+		Where(goqu.T(table).Col("person_id").Eq(personID), live(table), live(moviesTable))
+}
+
+// GetPerson returns the person having personId along with a page of their filmography, cast and
+// crew credits merged, matching filters
+func (p *PostgresPeopleModel) GetPerson(personId string, filters map[string]string, page utils.PageRequest) (PersonWithFilmography, utils.Page[FilmographyEntry], error) {
+	filter, err := parseFilmographyFilter(filters)
+	if err != nil {
+		return PersonWithFilmography{}, utils.Page[FilmographyEntry]{}, err
+	}
+	if err := page.CheckKey(len(filter.sort)); err != nil {
+		return PersonWithFilmography{}, utils.Page[FilmographyEntry]{}, err
+	}
+
+	id, err := strconv.Atoi(strings.TrimSpace(personId))
+	if err != nil {
+		return PersonWithFilmography{}, utils.Page[FilmographyEntry]{}, ErrPersonNotFound
+	}
+
+	var person struct {
+		ID          int            `db:"id"`
+		Name        sql.NullString `db:"name"`
+		Gender      sql.NullInt64  `db:"gender"`
+		ProfilePath sql.NullString `db:"profile_path"`
+	}
+	found, err := p.db.From(creditsTable).
+		Select("id", "name", "gender", "profile_path").
+		Where(goqu.C("id").Eq(id)).
+		ScanStruct(&person)
+	if err != nil {
+		return PersonWithFilmography{}, utils.Page[FilmographyEntry]{}, fmt.Errorf("failed to fetch person: %w", err)
+	}
+	if !found {
+		return PersonWithFilmography{}, utils.Page[FilmographyEntry]{}, ErrPersonNotFound
+	}
+
+	cast := p.credits(movieCastsTable, id, RoleCast,
+		goqu.COALESCE(goqu.T(movieCastsTable).Col("character"), "").As("character"),
+		goqu.V("").As("job"),
+		goqu.V(actingDepartment).As("department"),
+	)
+	crew := p.credits(movieCrewTable, id, RoleCrew,
+		goqu.V("").As("character"),
+		goqu.COALESCE(goqu.T(movieCrewTable).Col("job"), "").As("job"),
+		goqu.COALESCE(goqu.T(movieCrewTable).Col("department"), "").As("department"),
+	)
+
+	ds := p.db.From(cast.UnionAll(crew).As(filmographyTable))
+	if filter.role != "" {
+		ds = ds.Where(goqu.T(filmographyTable).Col("role").Eq(filter.role))
+	}
+	if filter.department != "" {
+		ds = ds.Where(goqu.L("LOWER(?) = LOWER(?)", goqu.T(filmographyTable).Col("department"), filter.department))
+	}
+
+	var total *int
+	if page.Total {
+		count, err := ds.Count()
+		if err != nil {
+			return PersonWithFilmography{}, utils.Page[FilmographyEntry]{}, fmt.Errorf("failed to count filmography: %w", err)
+		}
+		n := int(count)
+		total = &n
+	}
+
+	columns := make([]keysetColumn, 0, len(filter.sort))
+	for _, order := range filter.sort {
+		columns = append(columns, keysetColumn{expr: goqu.T(filmographyTable).Col(filmographyColumns[order.field]), desc: order.desc})
+	}
+	var rows []struct {
+		CreditID    string  `db:"credit_id"`
+		MovieID     int     `db:"movie_id"`
+		Title       string  `db:"title"`
+		Role        string  `db:"role"`
+		Character   string  `db:"character"`
+		Job         string  `db:"job"`
+		Department  string  `db:"department"`
+		ReleaseYear *int    `db:"release_year"`
+		Rating      float64 `db:"rating"`
+		RatingCount int     `db:"rating_count"`
+	}
+	if err := keysetPage(ds, columns, page).ScanStructs(&rows); err != nil {
+		return PersonWithFilmography{}, utils.Page[FilmographyEntry]{}, fmt.Errorf("failed to fetch filmography: %w", err)
+	}
+
+	entries := make([]FilmographyEntry, 0, len(rows))
+	for _, row := range rows {
+		entries = append(entries, FilmographyEntry(row))
+	}
+	result := keysetRows(entries, page, filter.key)
+	result.Total = total
+
+	return PersonWithFilmography{
+		Person: Person{
+			ID:          person.ID,
+			Name:        person.Name.String,
+			Gender:      int(person.Gender.Int64),
+			ProfilePath: person.ProfilePath.String,
+		},
+		Filmography: result.Items,
+	}, result, nil
+}
+
+// escapeLike escapes the wildcards of s for LIKE patterns
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// PostgresAuditLog is the goqu backed AuditRepository
+type PostgresAuditLog struct {
+	db Session
+}
+
+// NewPostgresAuditLog initializes a PostgresAuditLog
+func NewPostgresAuditLog(db Session) *PostgresAuditLog {
+	return &PostgresAuditLog{db: db}
+}
+
+type auditPgRow struct {
+	ID        int64     `db:"id"`
+	CreatedAt time.Time `db:"created_at"`
+	Actor     string    `db:"actor"`
+	Entity    string    `db:"entity"`
+	EntityID  string    `db:"entity_id"`
+	Operation string    `db:"operation"`
+	Changes   []byte    `db:"changes"`
+}
+
+// Record inserts entry into the audit_log table
+func (l *PostgresAuditLog) Record(entry *AuditEntry) error {
+	changes, err := json.Marshal(entry.Changes)
+	if err != nil {
+		return err
+	}
+
+	var row auditPgRow
+	_, err = l.db.Insert(auditLogTable).Rows(goqu.Record{
+		"actor":     entry.Actor,
+		"entity":    entry.Entity,
+		"entity_id": entry.EntityID,
+		"operation": entry.Operation,
+		"changes":   string(changes),
+	}).Returning("id", "created_at").Executor().ScanStruct(&row)
+	if err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+
+	entry.ID = row.ID
+	entry.Time = row.CreatedAt
+	return nil
+}
+
+// ListAudit fetches the entries matching filter, oldest first
+func (l *PostgresAuditLog) ListAudit(filter AuditFilter) ([]AuditEntry, error) {
+	ds := l.db.From(auditLogTable).
+		Select("id", "created_at", "actor", "entity", "entity_id", "operation", "changes").
+		Order(goqu.C("id").Asc())
+	if filter.Entity != "" {
+		ds = ds.Where(goqu.C("entity").Eq(filter.Entity))
+	}
+	if filter.EntityID != "" {
+		ds = ds.Where(goqu.Or(
+			goqu.C("entity_id").Eq(filter.EntityID),
+			goqu.C("entity_id").Like(escapeLike(filter.EntityID)+"/%"),
+		))
+	}
+	if !filter.Since.IsZero() {
+		ds = ds.Where(goqu.C("created_at").Gte(filter.Since))
+	}
+	if filter.Limit > 0 {
+		ds = ds.Limit(uint(filter.Limit))
+	}
+
+	var rows []auditPgRow
+	if err := ds.ScanStructs(&rows); err != nil {
+		return nil, fmt.Errorf("failed to fetch audit entries: %w", err)
+	}
+
+	entries := make([]AuditEntry, 0, len(rows))
+	for _, row := range rows {
+		entry := AuditEntry{
+			ID:        row.ID,
+			Time:      row.CreatedAt,
+			Actor:     row.Actor,
+			Entity:    row.Entity,
+			EntityID:  row.EntityID,
+			Operation: row.Operation,
+		}
+		if err := json.Unmarshal(row.Changes, &entry.Changes); err != nil {
+			return nil, fmt.Errorf("failed to decode audit entry %d: %w", row.ID, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
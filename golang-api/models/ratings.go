@@ -1,18 +1,14 @@
 package models
 
 import (
-	"encoding/csv"
-	"errors"
 	"fmt"
-	"log"
 	"math"
-	"os"
-	"path/filepath"
+	"sort"
 	"strconv"
 	"sync"
 	"time"
 
-	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/config"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/pkg/csvstore"
 	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/utils"
 )
 
@@ -20,235 +16,270 @@ type Ratings struct {
 	UserId  string `json:"userId" validate:"required,gte=1"`
 	MovieId string `json:"movieId" validate:"required"`
 	Rating  string `json:"rating" validate:"required,gte=0,lte=5"`
+	// Timestamp is the unix time the rating was given at, set when the rating is stored
+	Timestamp string `json:"timestamp,omitempty"`
 }
 
+// MovieRatings are the rating stats of a movie. Ratings is the mean rating, Score the mean
+// weighted towards the prior, so movies with few ratings do not outrank well rated popular ones.
 type MovieRatings struct {
-	MovieId string
-	Ratings float64
+	MovieId   string
+	Ratings   float64
+	Count     int
+	StdDev    float64
+	Score     float64
+	Histogram []RatingCount
 }
 
-type RatingModel struct {
-	Ratings []Ratings
-	loaded  bool
-	mu      sync.Mutex
+// TopRatedMovie is a movie of the top rated leaderboard
+type TopRatedMovie struct {
+	Title string
+	MovieRatings
 }
 
-func NewRatingsModel() *RatingModel {
-	return &RatingModel{}
+// sortTopRated orders movies from the best score, ties going to the movie rated most
+func sortTopRated(movies []TopRatedMovie) {
+	sort.Slice(movies, func(i, j int) bool {
+		if movies[i].Score != movies[j].Score {
+			return movies[i].Score > movies[j].Score
+		}
+		if movies[i].Count != movies[j].Count {
+			return movies[i].Count > movies[j].Count
+		}
+		return lessID(movies[i].MovieId, movies[j].MovieId)
+	})
 }
 
-// Function to load movies using utils.ParseData()
-func (r *RatingModel) LoadRatings() error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
+// RatingPrior weights the score of movies: a movie is scored as if it had Votes more ratings
+// of Mean, the mean of all ratings when Mean is 0
+type RatingPrior struct {
+	Votes float64
+	Mean  float64
+}
 
-	config := config.AllConfig
-	data, err := utils.ParseData(config.Ratings)
-	if err != nil {
-		return err
+// score is the Bayesian weighted mean of count ratings averaging average, mean is the prior mean
+func (p RatingPrior) score(average float64, count int, mean float64) float64 {
+	votes := float64(count)
+	if votes+p.Votes == 0 {
+		return 0
 	}
+	return (votes*average + p.Votes*mean) / (votes + p.Votes)
+}
 
-	var ratings []Ratings
-	for _, row := range data {
-		ratings = append(ratings, Ratings{
-			UserId:  row["userId"],
-			MovieId: row["movieId"],
-			Rating:  row["rating"],
-		})
+// ratingStats computes the rating stats of movieID from its ratings, mean is the prior mean
+func ratingStats(movieID string, ratings map[string]Ratings, prior RatingPrior, mean float64) MovieRatings {
+	var sum, squares float64
+	counts := make(map[float64]int)
+	for _, rating := range ratings {
+		value, err := strconv.ParseFloat(rating.Rating, 64)
+		if err != nil {
+			continue
+		}
+		sum += value
+		squares += value * value
+		counts[value]++
 	}
-	r.Ratings = ratings
-	return nil
 
+	stats := MovieRatings{MovieId: movieID, Histogram: histogram(counts)}
+	for _, bucket := range stats.Histogram {
+		stats.Count += bucket.Count
+	}
+	if stats.Count == 0 {
+		return stats
+	}
+
+	average := sum / float64(stats.Count)
+	stats.Ratings = RoundToTwoDecimals(average)
+	stats.StdDev = RoundToTwoDecimals(math.Sqrt(math.Max(squares/float64(stats.Count)-average*average, 0)))
+	stats.Score = RoundToTwoDecimals(prior.score(average, stats.Count, mean))
+	return stats
 }
 
-func RoundToTwoDecimals(value float64) float64 {
-	return math.Round(value*100) / 100
+// histogram lists the number of ratings of every rating value, ordered by rating
+func histogram(counts map[float64]int) []RatingCount {
+	buckets := make([]RatingCount, 0, len(counts))
+	for value, count := range counts {
+		buckets = append(buckets, RatingCount{Rating: value, Count: count})
+	}
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].Rating < buckets[j].Rating
+	})
+	return buckets
 }
 
-// Function to calculate ratings of a movie
-func (r *RatingModel) CalculateAverageRatings() []MovieRatings {
-	ratingSum := make(map[string]float64)
-	ratingCount := make(map[string]int)
+// RatingModel is the CSV backed RatingRepository. Ratings are served from an in-memory snapshot
+// indexed by movie and user, writes go to the write-ahead log of the ratings table first.
+type RatingModel struct {
+	// mu serializes writers, so the log and the snapshot apply changes in the same order
+	mu       sync.Mutex
+	snapshot *MemoryRatingModel
+	table    *csvstore.Table
+}
 
-	// Aggregate ratings
-	for _, rating := range r.Ratings {
-		ratingValue, _ := strconv.ParseFloat(rating.Rating, 64)
-		ratingSum[rating.MovieId] += ratingValue
-		ratingCount[rating.MovieId]++
+// NewRatingsModel loads the ratings of table, prior weights the scores of movies
+func NewRatingsModel(table *csvstore.Table, prior RatingPrior) *RatingModel {
+	r := &RatingModel{
+		snapshot: NewMemoryRatingModel(prior),
+		table:    table,
 	}
+	r.LoadRatings()
+	return r
+}
 
-	var movieRatings []MovieRatings
-	for movieID, sum := range ratingSum {
-		avgRating := RoundToTwoDecimals(sum / float64(ratingCount[movieID]))
-		movieRatings = append(movieRatings, MovieRatings{
-			MovieId: movieID,
-			Ratings: avgRating,
-		})
+// LoadRatings rebuilds the snapshot from the rows of the ratings table, soft deleted ratings
+// are left out
+func (r *RatingModel) LoadRatings() {
+	var ratings []Ratings
+	for _, row := range r.table.Rows() {
+		if r.table.Value(row, deletedAtColumn) == "" {
+			ratings = append(ratings, r.ratingFromRow(row))
+		}
 	}
-	return movieRatings
+	r.snapshot.setRatings(ratings)
 }
 
-// Function to list all movies ratings
-func (r *RatingModel) ListRatings(page, limit int) ([]MovieRatings, error) {
-	if !r.loaded {
-		if err := r.LoadRatings(); err != nil {
-			return nil, err
-		}
-		r.loaded = true
+func (r *RatingModel) ratingFromRow(row []string) Ratings {
+	return Ratings{
+		UserId:    r.table.Value(row, "userId"),
+		MovieId:   r.table.Value(row, "movieId"),
+		Rating:    r.table.Value(row, "rating"),
+		Timestamp: r.table.Value(row, "timestamp"),
 	}
+}
 
-	if len(r.Ratings) == 0 {
-		return nil, errors.New("no ratings loaded, call LoadRatings first")
+// Reload swaps in the ratings file when it was replaced on disk
+func (r *RatingModel) Reload() (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	changed, err := r.table.Reload()
+	if changed {
+		r.LoadRatings()
 	}
+	return changed, err
+}
 
-	movieRatings := r.CalculateAverageRatings()
+func RoundToTwoDecimals(value float64) float64 {
+	return math.Round(value*100) / 100
+}
 
-	return utils.Paginate(movieRatings, page, limit)
+// Function to list all movies ratings
+func (r *RatingModel) ListRatings(page utils.PageRequest) (utils.Page[MovieRatings], error) {
+	return r.snapshot.ListRatings(page)
 }
 
 // Function to get ratings of a movie having a id movieId
 func (r *RatingModel) GetRatingsByMovieId(movieId string) (MovieRatings, error) {
-	if !r.loaded {
-		if err := r.LoadRatings(); err != nil {
-			return MovieRatings{}, err
-		}
-		r.loaded = true
-	}
-
-	if len(r.Ratings) == 0 {
-		return MovieRatings{}, errors.New("no ratings loaded, call LoadRatings first")
-	}
+	return r.snapshot.GetRatingsByMovieId(movieId)
+}
 
-	movieRatings := r.CalculateAverageRatings()
-	for _, rating := range movieRatings {
-		if rating.MovieId == movieId {
-			return rating, nil
-		}
-	}
-	return MovieRatings{}, errors.New("movie not found")
+// Function to get the rating given by userId to movieId
+func (r *RatingModel) GetRating(movieId, userId string) (Ratings, error) {
+	return r.snapshot.GetRating(movieId, userId)
 }
 
-// Function to add ratings
+// Function to add ratings, a user rating the same movie again replaces the earlier rating
 func (r *RatingModel) AddRatings(rating *Ratings) error {
-	if !r.loaded {
-		if err := r.LoadRatings(); err != nil {
-			return err
-		}
-		r.loaded = true
-	}
-
-	r.Ratings = append(r.Ratings, *rating)
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	workingDirPath, err := os.Getwd()
-	if err != nil {
-		return fmt.Errorf("error getting working directory: %v", err)
-	}
+	rating.Timestamp = strconv.FormatInt(time.Now().Unix(), 10)
 
-	// Fetch file path from config
-	filePath := filepath.Join(workingDirPath, config.AllConfig.Ratings)
+	row := r.table.NewRow()
+	r.table.Set(row, "userId", rating.UserId)
+	r.table.Set(row, "movieId", rating.MovieId)
+	r.table.Set(row, "rating", rating.Rating)
+	r.table.Set(row, "timestamp", rating.Timestamp)
 
-	err = appendRatingsToCSV(filePath, *rating)
-	if err != nil {
+	if err := r.table.Put(row); err != nil {
 		return fmt.Errorf("error in writing record: %v", err)
 	}
-	return nil
-}
 
-// Function to add ratings at the end of csv file
-func appendRatingsToCSV(filepath string, rating Ratings) error {
-	// Open the file in append mode
-	file, err := os.OpenFile(filepath, os.O_APPEND|os.O_WRONLY, 0644)
-	if err != nil {
-		return errors.New("Failed to open file")
-	}
-	defer file.Close()
+	return r.snapshot.AddRatings(rating)
+}
 
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
+// Function to soft delete ratings of a movie, only the one given by userId when it is set
+func (r *RatingModel) DeleteRatings(movieId string, userId *string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	timestamp := time.Now().Unix()
-	// Convert struct to a slice of strings
-	record := []string{
-		rating.UserId,
-		rating.MovieId,
-		rating.Rating,
-		fmt.Sprintf("%d", timestamp),
+	ops := r.deleteOps(movieId, userId, deletedAt(time.Now()))
+	if len(ops) == 0 {
+		return ErrRatingNotFound
 	}
 
-	// Write the record to CSV
-	if err := writer.Write(record); err != nil {
-		return errors.New("Failed to write record")
+	// All ratings of the movie go into a single log entry
+	if err := r.table.Apply(ops...); err != nil {
+		return fmt.Errorf("error deleting ratings: %v", err)
 	}
 
-	log.Println("Rating appended successfully!")
-	return nil
+	return r.snapshot.drop(movieId, userId)
 }
 
-// Function to delete ratings of a movie
-func (r *RatingModel) DeleteRatings(movieId string, userId *string) error {
-	return r.ModifyRatings(userId, &movieId, nil, nil, "delete")
-}
-
-// UpdateRatings is to update the ratings of a movie
-func (r *RatingModel) UpdateRatings(userId, movieId, newRating, newTimestamp string) error {
-	return r.ModifyRatings(&userId, &movieId, &newRating, &newTimestamp, "update")
-}
-
-func (r *RatingModel) ModifyRatings(userId, movieId, newRating, newTimestamp *string, operation string) error {
-	if !r.loaded {
-		if err := r.LoadRatings(); err != nil {
-			return err
-		}
-		r.loaded = true
+// deleteOps marks the ratings of movieId as deleted at at, only the one given by userId when it
+// is set. Callers hold the lock.
+func (r *RatingModel) deleteOps(movieId string, userId *string, at string) []csvstore.Op {
+	users := r.snapshot.usersOfMovie(movieId)
+	if userId != nil {
+		users = []string{*userId}
 	}
 
-	rows, err := utils.ReadCSVFile(config.AllConfig.Ratings)
-	if err != nil {
-		return err
+	var ops []csvstore.Op
+	for _, user := range users {
+		key := csvstore.Key(user, movieId)
+		row, ok := r.table.Get(key)
+		if !ok || r.table.Value(row, deletedAtColumn) != "" {
+			continue
+		}
+		r.table.Set(row, deletedAtColumn, at)
+		ops = append(ops, csvstore.Op{Key: key, Row: row})
 	}
+	return ops
+}
 
-	var updatedRows [][]string
-	var updatedRatings []Ratings
-
-	header := rows[0]
-	updatedRows = append(updatedRows, header)
-	modified := false
-
-	for _, row := range rows[1:] {
-		if len(row) < 4 {
-			fmt.Println("Skipping invalid row:", row)
+// restoreOps clears the deleted_at column of the ratings of movieId deleted at at and returns
+// them. Callers hold the lock.
+func (r *RatingModel) restoreOps(movieId, at string) ([]csvstore.Op, []Ratings) {
+	var ops []csvstore.Op
+	var ratings []Ratings
+	for _, row := range r.table.Rows() {
+		if r.table.Value(row, "movieId") != movieId || r.table.Value(row, deletedAtColumn) != at {
 			continue
 		}
+		r.table.Set(row, deletedAtColumn, "")
+		ops = append(ops, csvstore.Op{Key: csvstore.Key(r.table.Value(row, "userId"), movieId), Row: row})
+		ratings = append(ratings, r.ratingFromRow(row))
+	}
+	return ops, ratings
+}
 
-		if row[1] == *movieId && (userId == nil || row[0] == *userId) {
-			if operation == "delete" {
-				modified = true
-				continue
-			} else if operation == "update" && newRating != nil && newTimestamp != nil {
-				row[2] = *newRating
-				row[3] = *newTimestamp
-				modified = true
-			}
+// purgeOps deletes the ratings soft deleted before before, callers hold the lock
+func (r *RatingModel) purgeOps(before time.Time) []csvstore.Op {
+	var ops []csvstore.Op
+	for _, row := range r.table.Rows() {
+		if deletedBefore(r.table, row, before) {
+			key := csvstore.Key(r.table.Value(row, "userId"), r.table.Value(row, "movieId"))
+			ops = append(ops, csvstore.Op{Delete: true, Key: key})
 		}
-
-		updatedRows = append(updatedRows, row)
-		updatedRatings = append(updatedRatings, Ratings{
-			UserId:  row[0],
-			MovieId: row[1],
-			Rating:  row[2],
-		})
 	}
+	return ops
+}
 
-	if !modified {
-		return errors.New("rating not found ")
-	}
+// UpdateRatings is to update the ratings of a movie
+func (r *RatingModel) UpdateRatings(userId, movieId, newRating, newTimestamp string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	r.Ratings = updatedRatings
+	row, ok := r.table.Get(csvstore.Key(userId, movieId))
+	if !ok || r.table.Value(row, deletedAtColumn) != "" {
+		return ErrRatingNotFound
+	}
 
-	err = utils.SaveToCSV(config.AllConfig.Ratings, updatedRows)
-	if err != nil {
-		return fmt.Errorf("error updating Ratings.csv: %v", err)
+	r.table.Set(row, "rating", newRating)
+	r.table.Set(row, "timestamp", newTimestamp)
+	if err := r.table.Put(row); err != nil {
+		return fmt.Errorf("error updating rating: %v", err)
 	}
 
-	return nil
+	return r.snapshot.UpdateRatings(userId, movieId, newRating, newTimestamp)
 }
@@ -0,0 +1,357 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/config"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/database"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/pkg/csvstore"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/utils"
+	"github.com/doug-martin/goqu/v9"
+	"go.uber.org/zap"
+)
+
+// Storage backends selectable through the STORAGE config
+const (
+	StorageCSV      = "csv"
+	StoragePostgres = "postgres"
+	StorageMemory   = "memory"
+)
+
+var (
+	ErrMovieNotFound      = errors.New("movie not found")
+	ErrRatingNotFound     = errors.New("rating not found")
+	ErrCastNotFound       = errors.New("cast member not found for the given movie ID")
+	ErrCrewNotFound       = errors.New("crew member not found for the given movie ID")
+	ErrMovieAlreadyExists = errors.New("movie with this ID or title already exists")
+	ErrNoMoviesMatched    = errors.New("no movies found matching the given criteria")
+	ErrEmptySearchQuery   = errors.New("search query is empty")
+	ErrInvalidFilter      = errors.New("invalid movie filter")
+	ErrUserNotFound       = errors.New("user has no ratings")
+	ErrInvalidSort        = errors.New("invalid sort")
+	ErrMovieNotDeleted    = errors.New("movie is not deleted")
+	ErrPersonNotFound     = errors.New("person not found")
+)
+
+// deletedAtColumn marks soft deleted rows of the CSV tables with the time they were deleted at
+const deletedAtColumn = "deleted_at"
+
+// deletedAt is the value of the deleted_at column of rows soft deleted at t
+func deletedAt(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// deletedBefore reports whether row of table was soft deleted before before
+func deletedBefore(table *csvstore.Table, row []string, before time.Time) bool {
+	at, err := time.Parse(time.RFC3339Nano, table.Value(row, deletedAtColumn))
+	return err == nil && at.Before(before)
+}
+
+// PurgeCounts are the numbers of soft deleted movies, ratings and credits removed for good
+type PurgeCounts struct {
+	Movies  int
+	Ratings int
+	Credits int
+}
+
+// MovieRepository is implemented by every storage backend holding movies
+type MovieRepository interface {
+	ListMovies(filters map[string]string, page utils.PageRequest) (utils.Page[Movies], error)
+	GetMovie(movieID string) (Movies, error)
+	AddMovie(movie *Movies) error
+	UpdateMovie(movieId string, updatedMovie *Movies) error
+	// DeleteMovie soft deletes a movie along with its ratings and credits
+	DeleteMovie(movieId string) error
+	// RestoreMovie brings back a soft deleted movie along with the ratings and credits deleted with it
+	RestoreMovie(movieId string) error
+	// PurgeDeleted removes the movies, ratings and credits soft deleted before before for good
+	PurgeDeleted(before time.Time) (PurgeCounts, error)
+	MovieExists(movieId string) (bool, error)
+	SearchMovies(query string, page, limit int) ([]MovieSearchResult, error)
+	TopRatedMovies(filters map[string]string, limit int) ([]TopRatedMovie, error)
+}
+
+// RatingRepository is implemented by every storage backend holding ratings
+type RatingRepository interface {
+	ListRatings(page utils.PageRequest) (utils.Page[MovieRatings], error)
+	GetRatingsByMovieId(movieId string) (MovieRatings, error)
+	GetRating(movieId, userId string) (Ratings, error)
+	AddRatings(rating *Ratings) error
+	UpdateRatings(userId, movieId, newRating, newTimestamp string) error
+	DeleteRatings(movieId string, userId *string) error
+}
+
+// UserRepository is implemented by every storage backend, it serves the ratings of a user
+type UserRepository interface {
+	ListUserRatings(userId, sortBy string, page utils.PageRequest) (utils.Page[UserRating], error)
+	GetUserStats(userId string) (UserStats, error)
+}
+
+// CastRepository is implemented by every storage backend holding cast credits
+type CastRepository interface {
+	ListCastMembers(movieID string) ([]CastMember, error)
+	ListMoviesByCastId(castId string) ([]int, error)
+	UpdateCastMember(movieId, castId string, updatedCast CastMember) error
+}
+
+// CrewRepository is implemented by every storage backend holding crew credits
+type CrewRepository interface {
+	ListCrewMembers(movieID string) ([]CrewMember, error)
+	UpdateCrewMember(movieId, crewId string, updatedCrew CrewMember) error
+	DeleteCreditsForMovie(movieId string) error
+}
+
+// PeopleRepository is implemented by every storage backend, it serves the people credited on
+// movies
+type PeopleRepository interface {
+	GetPerson(personId string, filters map[string]string, page utils.PageRequest) (PersonWithFilmography, utils.Page[FilmographyEntry], error)
+}
+
+// Repositories groups the repositories of one storage backend
+type Repositories struct {
+	Movies  MovieRepository
+	Ratings RatingRepository
+	Users   UserRepository
+	Cast    CastRepository
+	Crew    CrewRepository
+	People  PeopleRepository
+	Audit   AuditRepository
+
+	// Revisions tracks the writes made through the repositories and the dataset reloads
+	Revisions *Revisions
+
+	// datasets are the reloadable datasets of the backend by name
+	datasets map[string]dataset
+	// transact runs fn with repositories of the backend writing all together, see Atomically
+	transact func(fn func(tx *Repositories) error) error
+	// lock holds off the writes to an entity until the transaction of the repositories ends, see Lock
+	lock     func(entity string, key ...string) error
+	closers  []func() error
+	stop     chan struct{}
+	watching sync.WaitGroup
+}
+
+// dataset is a store whose content can be swapped in again from its file
+type dataset interface {
+	Reload() (bool, error)
+}
+
+// Close releases the resources of the backend, CSV tables fold their pending log into the files
+func (r *Repositories) Close() error {
+	if r.stop != nil {
+		close(r.stop)
+		r.watching.Wait()
+	}
+
+	var errs []error
+	for _, closer := range r.closers {
+		if err := closer(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Atomically runs fn with repositories whose writes, the audit entries included, are kept all
+// together or not at all when fn fails. The postgres backend runs fn in a transaction. The CSV
+// backend puts back the rows changed by fn when it fails, calls are serialized for it so writes
+// made meanwhile are not put back along, and a crash while fn runs leaves the rows changed so far.
+// The memory backend runs fn as is, one call at a time, its audit log cannot fail to record an entry.
+func (r *Repositories) Atomically(fn func(tx *Repositories) error) error {
+	bumps := &deferredBumps{}
+	defer func() {
+		if r.Revisions != nil {
+			r.Revisions.Bump(bumps.datasets...)
+		}
+	}()
+
+	return r.transact(func(tx *Repositories) error {
+		tx.reviseWith(bumps)
+		return fn(tx)
+	})
+}
+
+// Lock holds off the writes to entity, one of the audit entities, having key until the Atomically
+// call the repositories were given to returns, so a write checking the entity first, against an
+// If-Match header, sees no other write in between. The postgres backend locks the rows of the
+// entity. The CSV and memory backends serialize the Atomically calls, there is nothing to lock.
+func (r *Repositories) Lock(entity string, key ...string) error {
+	if r.lock == nil {
+		return nil
+	}
+	return r.lock(entity, key...)
+}
+
+// loadSnapshots rebuilds the snapshots the CSV models serve from their tables, once rows written
+// by a failed Atomically call were put back
+func loadSnapshots(movies *MovieModel, ratings *RatingModel, credits *CreditStore) {
+	movies.mu.Lock()
+	movies.LoadMovies()
+	movies.mu.Unlock()
+
+	ratings.mu.Lock()
+	ratings.LoadRatings()
+	ratings.mu.Unlock()
+
+	credits.mu.Lock()
+	credits.load()
+	credits.mu.Unlock()
+}
+
+// NewRepositories builds the repositories of the backend selected by cfg.Storage
+func NewRepositories(cfg config.AppConfig, logger *zap.Logger) (*Repositories, error) {
+	repos, err := openRepositories(cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+	repos.revise()
+	return repos, nil
+}
+
+func openRepositories(cfg config.AppConfig, logger *zap.Logger) (*Repositories, error) {
+	prior := RatingPrior{Votes: cfg.RatingPriorVotes, Mean: cfg.RatingPriorMean}
+
+	switch cfg.Storage {
+	case "", StorageCSV:
+		// Repair files left behind by a crash before anything reads them
+		for _, fileName := range []string{cfg.Movies, cfg.Ratings, cfg.Credits} {
+			if err := utils.RecoverCSV(fileName, logger); err != nil {
+				return nil, err
+			}
+		}
+
+		opts := csvstore.Options{
+			CompactInterval:  cfg.CSVCompactInterval,
+			CompactThreshold: cfg.CSVCompactThreshold,
+			Columns:          []string{deletedAtColumn},
+			IntegerKeys:      true,
+			Logger:           logger,
+		}
+
+		movieTable, err := csvstore.Open(cfg.Movies, []string{"id"}, opts)
+		if err != nil {
+			return nil, err
+		}
+		ratingTable, err := csvstore.Open(cfg.Ratings, []string{"userId", "movieId"}, opts)
+		if err != nil {
+			movieTable.Close()
+			return nil, err
+		}
+		creditTable, err := csvstore.Open(cfg.Credits, []string{"id"}, opts)
+		if err != nil {
+			movieTable.Close()
+			ratingTable.Close()
+			return nil, err
+		}
+
+		// Deleting a movie changes the three tables, the journal makes it all-or-nothing
+		journal, err := csvstore.OpenJournal(cfg.Movies+".journal", logger, movieTable, ratingTable, creditTable)
+		if err != nil {
+			movieTable.Close()
+			ratingTable.Close()
+			creditTable.Close()
+			return nil, err
+		}
+		audit, err := OpenFileAuditLog(cfg.AuditLog, logger)
+		if err != nil {
+			journal.Close()
+			movieTable.Close()
+			ratingTable.Close()
+			creditTable.Close()
+			return nil, err
+		}
+
+		ratings := NewRatingsModel(ratingTable, prior)
+		credits := NewCreditStore(creditTable)
+		crew := NewCrewModel(credits)
+		movies := NewMovieModel(movieTable, ratings, credits, journal)
+		build := func() *Repositories {
+			return &Repositories{
+				Movies:  movies,
+				Ratings: ratings,
+				Users:   NewMemoryUserModel(ratings.snapshot, movies),
+				Cast:    NewCastModel(credits),
+				Crew:    crew,
+				People:  NewMemoryPeopleModel(credits.snapshot, movies, ratings.snapshot),
+				Audit:   audit,
+			}
+		}
+
+		repos := build()
+		repos.datasets = map[string]dataset{
+			DatasetMovies:  movies,
+			DatasetRatings: ratings,
+			DatasetCredits: credits,
+		}
+		repos.transact = func(fn func(tx *Repositories) error) error {
+			return journal.Atomically(func() error {
+				return fn(build())
+			}, func() {
+				loadSnapshots(movies, ratings, credits)
+			})
+		}
+		repos.closers = []func() error{journal.Close, movieTable.Close, ratingTable.Close, creditTable.Close, audit.Close}
+		return repos, nil
+
+	case StoragePostgres:
+		db, err := database.Connect(cfg.DB)
+		if err != nil {
+			return nil, err
+		}
+		build := func(db Session) *Repositories {
+			credits := NewPostgresCreditModel(db)
+			return &Repositories{
+				Movies:  NewPostgresMovieModel(db, prior),
+				Ratings: NewPostgresRatingModel(db, prior),
+				Users:   NewPostgresUserModel(db),
+				Cast:    credits,
+				Crew:    credits,
+				People:  NewPostgresPeopleModel(db),
+				Audit:   NewPostgresAuditLog(db),
+			}
+		}
+
+		repos := build(db)
+		repos.transact = func(fn func(tx *Repositories) error) error {
+			return db.WithTx(func(session *goqu.TxDatabase) error {
+				tx := build(session)
+				tx.lock = func(entity string, key ...string) error {
+					return lockRows(session, entity, key...)
+				}
+				return fn(tx)
+			})
+		}
+		return repos, nil
+
+	case StorageMemory:
+		ratings := NewMemoryRatingModel(prior)
+		credits := NewMemoryCreditModel()
+		movies := NewMemoryMovieModel(ratings, credits)
+		audit := NewMemoryAuditLog()
+		build := func() *Repositories {
+			return &Repositories{
+				Movies:  movies,
+				Ratings: ratings,
+				Users:   NewMemoryUserModel(ratings, movies),
+				Cast:    credits,
+				Crew:    credits,
+				People:  NewMemoryPeopleModel(credits, movies, ratings),
+				Audit:   audit,
+			}
+		}
+
+		var serial sync.Mutex
+		repos := build()
+		repos.transact = func(fn func(tx *Repositories) error) error {
+			serial.Lock()
+			defer serial.Unlock()
+			return fn(build())
+		}
+		return repos, nil
+	}
+
+	return nil, fmt.Errorf("unsupported storage backend %q", cfg.Storage)
+}
@@ -0,0 +1,321 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/config"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/utils"
+	"go.uber.org/zap"
+)
+
+const (
+	testMovies  = 20
+	testUsers   = 5
+	testWriters = 4
+	testRounds  = 50
+)
+
+const movieHeader = "adult,belongs_to_collection,budget,genres,homepage,id,imdb_id,original_language," +
+	"original_title,overview,popularity,poster_path,production_companies,production_countries," +
+	"release_date,revenue,runtime,spoken_languages,status,tagline,title,video,vote_average,vote_count"
+
+// testMovie is the movie having id i, its overview and tagline are both set to revision so
+// readers can tell a half-applied update apart
+func testMovie(i int, revision string) Movies {
+	return Movies{
+		ID:          strconv.Itoa(i),
+		Title:       fmt.Sprintf("Movie %d", i),
+		ReleaseDate: "1995-10-30",
+		Status:      "Released",
+		Genres:      []string{"Drama"},
+		Overview:    revision,
+		Tagline:     revision,
+	}
+}
+
+// writeFile writes lines to the file name of dir
+func writeFile(t *testing.T, dir, name string, lines ...string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// openTestRepositories opens the repositories of storage over testMovies movies, each cast with
+// one actor and rated by testUsers users
+func openTestRepositories(t *testing.T, storage string) *Repositories {
+	t.Helper()
+	dir := t.TempDir()
+
+	cfg := config.AppConfig{
+		Storage:             storage,
+		AuditLog:            filepath.Join(dir, "audit.jsonl"),
+		CSVCompactInterval:  time.Hour,
+		CSVCompactThreshold: 1 << 20,
+	}
+	if storage == StorageCSV {
+		// The CSV files are named relative to the working directory
+		wd, err := os.Getwd()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chdir(dir); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { os.Chdir(wd) })
+
+		writeFile(t, dir, "movies.csv", movieHeader)
+		writeFile(t, dir, "credits.csv", "cast,crew,id")
+		writeFile(t, dir, "ratings.csv", "userId,movieId,rating,timestamp")
+		cfg.Movies, cfg.Credits, cfg.Ratings = "movies.csv", "credits.csv", "ratings.csv"
+	}
+
+	// The stores are seeded through the backend models before they are wrapped by revise
+	repos, err := openRepositories(cfg, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { repos.Close() })
+
+	cast := make(map[int][]CastMember, testMovies)
+	for i := 1; i <= testMovies; i++ {
+		movie := testMovie(i, "rev 0")
+		if err := repos.Movies.AddMovie(&movie); err != nil {
+			t.Fatal(err)
+		}
+		for u := 1; u <= testUsers; u++ {
+			rating := Ratings{UserId: strconv.Itoa(u), MovieId: movie.ID, Rating: "3"}
+			if err := repos.Ratings.AddRatings(&rating); err != nil {
+				t.Fatal(err)
+			}
+		}
+		cast[i] = []CastMember{{CreditID: "c" + movie.ID, ID: 100 + i, Name: "rev 0", Character: "rev 0"}}
+	}
+
+	// Credits are only added by loading them, the snapshot every backend serves is set directly
+	switch cast := cast; storage {
+	case StorageCSV:
+		repos.Cast.(*CastModel).snapshot.setCredits(cast, map[int][]CrewMember{})
+		for movieID, members := range cast {
+			store := repos.Cast.(*CastModel).CreditStore
+			row := store.table.NewRow()
+			store.table.Set(row, "id", strconv.Itoa(movieID))
+			store.table.Set(row, "cast", fmt.Sprintf(`[{"credit_id": %q, "id": %d, "name": "rev 0", "character": "rev 0"}]`, members[0].CreditID, members[0].ID))
+			store.table.Set(row, "crew", "[]")
+			if err := store.table.Put(row); err != nil {
+				t.Fatal(err)
+			}
+		}
+	case StorageMemory:
+		repos.Cast.(*MemoryCreditModel).setCredits(cast, map[int][]CrewMember{})
+	}
+	repos.revise()
+	return repos
+}
+
+// TestRepositoriesConcurrentAccess runs writers and readers together on the stores shared by the
+// repositories of a backend, readers must never see a write half applied. Run with -race.
+func TestRepositoriesConcurrentAccess(t *testing.T) {
+	for _, storage := range []string{StorageCSV, StorageMemory} {
+		t.Run(storage, func(t *testing.T) {
+			repos := openTestRepositories(t, storage)
+
+			var writers, readers sync.WaitGroup
+			done := make(chan struct{})
+			errs := make(chan error, 64)
+			report := func(err error) {
+				select {
+				case errs <- err:
+				default:
+				}
+			}
+
+			for w := 0; w < testWriters; w++ {
+				writers.Add(1)
+				go func(w int) {
+					defer writers.Done()
+					for round := 1; round <= testRounds; round++ {
+						revision := fmt.Sprintf("rev %d-%d", w, round)
+						i := (w*testRounds+round)%testMovies + 1
+						movieId := strconv.Itoa(i)
+
+						movie := testMovie(i, revision)
+						if err := repos.Movies.UpdateMovie(movieId, &movie); err != nil {
+							report(fmt.Errorf("UpdateMovie: %w", err))
+						}
+
+						added := testMovie(1000+w*testRounds+round, revision)
+						if err := repos.Movies.AddMovie(&added); err != nil {
+							report(fmt.Errorf("AddMovie: %w", err))
+						}
+
+						if err := repos.Cast.UpdateCastMember(movieId, strconv.Itoa(100+i), CastMember{Name: revision, Character: revision}); err != nil {
+							report(fmt.Errorf("UpdateCastMember: %w", err))
+						}
+
+						userId := strconv.Itoa(w%testUsers + 1)
+						rating := strconv.Itoa(round%5 + 1)
+						if err := repos.Ratings.UpdateRatings(userId, movieId, rating, strconv.Itoa(round)); err != nil {
+							report(fmt.Errorf("UpdateRatings: %w", err))
+						}
+					}
+				}(w)
+			}
+
+			for r := 0; r < testWriters; r++ {
+				readers.Add(1)
+				go func() {
+					defer readers.Done()
+					for {
+						select {
+						case <-done:
+							return
+						default:
+						}
+
+						page, err := repos.Movies.ListMovies(map[string]string{}, utils.PageRequest{Limit: 10000})
+						if err != nil {
+							report(fmt.Errorf("ListMovies: %w", err))
+						}
+						for _, movie := range page.Items {
+							if movie.Overview != movie.Tagline {
+								report(fmt.Errorf("movie %s listed half updated: %q, %q", movie.ID, movie.Overview, movie.Tagline))
+							}
+						}
+
+						for i := 1; i <= testMovies; i++ {
+							movieId := strconv.Itoa(i)
+							movie, err := repos.Movies.GetMovie(movieId)
+							if err != nil {
+								report(fmt.Errorf("GetMovie: %w", err))
+							} else if movie.Overview != movie.Tagline {
+								report(fmt.Errorf("movie %s read half updated: %q, %q", movieId, movie.Overview, movie.Tagline))
+							}
+
+							cast, err := repos.Cast.ListCastMembers(movieId)
+							if err != nil {
+								report(fmt.Errorf("ListCastMembers: %w", err))
+							}
+							for _, member := range cast {
+								if member.Name != member.Character {
+									report(fmt.Errorf("cast of movie %s read half updated: %q, %q", movieId, member.Name, member.Character))
+								}
+							}
+
+							ratings, err := repos.Ratings.GetRatingsByMovieId(movieId)
+							if err != nil {
+								report(fmt.Errorf("GetRatingsByMovieId: %w", err))
+							} else if ratings.Count != testUsers {
+								report(fmt.Errorf("movie %s has %d ratings during updates, want %d", movieId, ratings.Count, testUsers))
+							}
+						}
+
+						stats, err := repos.Users.GetUserStats("1")
+						if err != nil {
+							report(fmt.Errorf("GetUserStats: %w", err))
+						} else if stats.Count != testMovies {
+							report(fmt.Errorf("user 1 has %d ratings during updates, want %d", stats.Count, testMovies))
+						}
+					}
+				}()
+			}
+
+			writers.Wait()
+			close(done)
+			readers.Wait()
+			close(errs)
+			for err := range errs {
+				t.Error(err)
+			}
+
+			// Every repository reads the same stores, so the writes are seen through all of them
+			page, err := repos.Movies.ListMovies(map[string]string{}, utils.PageRequest{Limit: 10000})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := testMovies + testWriters*testRounds; len(page.Items) != want {
+				t.Errorf("listed %d movies, want %d", len(page.Items), want)
+			}
+		})
+	}
+}
+
+// TestRepositoriesShareStores checks that a delete through the movie repository is seen by the
+// repositories of the ratings, credits and users of the same backend
+func TestRepositoriesShareStores(t *testing.T) {
+	for _, storage := range []string{StorageCSV, StorageMemory} {
+		t.Run(storage, func(t *testing.T) {
+			repos := openTestRepositories(t, storage)
+
+			if err := repos.Movies.DeleteMovie("1"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := repos.Ratings.GetRatingsByMovieId("1"); err == nil {
+				t.Error("ratings of the deleted movie are still served")
+			}
+			if _, err := repos.Cast.ListCastMembers("1"); err == nil {
+				t.Error("cast of the deleted movie is still served")
+			}
+			stats, err := repos.Users.GetUserStats("1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stats.Count != testMovies-1 {
+				t.Errorf("user 1 has %d ratings, want %d", stats.Count, testMovies-1)
+			}
+		})
+	}
+}
+
+// TestAtomicallyPutsBackFailedWrites checks that the CSV backend puts back the rows written by a
+// function failing afterwards, as when the audit entry of a write cannot be recorded
+func TestAtomicallyPutsBackFailedWrites(t *testing.T) {
+	repos := openTestRepositories(t, StorageCSV)
+	failed := errors.New("audit log unavailable")
+
+	err := repos.Atomically(func(tx *Repositories) error {
+		movie := testMovie(1, "rolled back")
+		if err := tx.Movies.UpdateMovie("1", &movie); err != nil {
+			return err
+		}
+		if err := tx.Movies.DeleteMovie("2"); err != nil {
+			return err
+		}
+		if err := tx.Ratings.UpdateRatings("1", "3", "5", "1"); err != nil {
+			return err
+		}
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("Atomically returned %v, want %v", err, failed)
+	}
+
+	movie, err := repos.Movies.GetMovie("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if movie.Overview != "rev 0" {
+		t.Errorf("update was kept, overview %q", movie.Overview)
+	}
+	if _, err := repos.Movies.GetMovie("2"); err != nil {
+		t.Errorf("delete was kept: %v", err)
+	}
+	if ratings, err := repos.Ratings.GetRatingsByMovieId("2"); err != nil || ratings.Count != testUsers {
+		t.Errorf("ratings deleted with the movie were not put back: %+v, %v", ratings, err)
+	}
+	rating, err := repos.Ratings.GetRating("3", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rating.Rating != "3" {
+		t.Errorf("rating update was kept, rating %q", rating.Rating)
+	}
+}
@@ -0,0 +1,174 @@
+package models
+
+import (
+	"sync"
+	"time"
+)
+
+// Datasets whose revisions are tracked, named as the reloadable datasets
+const (
+	DatasetMovies  = "movies"
+	DatasetRatings = "ratings"
+	DatasetCredits = "credits"
+)
+
+// Revision of one or more datasets. Count grows with every write, Modified is the time of the
+// latest write or of the start when nothing was written since.
+type Revision struct {
+	Count    uint64
+	Modified time.Time
+}
+
+// Revisions tracks the writes to every dataset of a backend, so responses built from a dataset
+// can be cached until it changes. Writes made by other processes sharing a database are not seen.
+type Revisions struct {
+	mu        sync.RWMutex
+	revisions map[string]Revision
+}
+
+// NewRevisions starts tracking the datasets as modified now
+func NewRevisions() *Revisions {
+	now := time.Now().Truncate(time.Second)
+	return &Revisions{
+		revisions: map[string]Revision{
+			DatasetMovies:  {Modified: now},
+			DatasetRatings: {Modified: now},
+			DatasetCredits: {Modified: now},
+		},
+	}
+}
+
+// Bump records a write to each of datasets
+func (r *Revisions) Bump(datasets ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Last-Modified has a resolution of seconds
+	now := time.Now().Truncate(time.Second)
+	for _, dataset := range datasets {
+		revision := r.revisions[dataset]
+		revision.Count++
+		revision.Modified = now
+		r.revisions[dataset] = revision
+	}
+}
+
+// Current returns the combined revision of datasets, its count changes whenever one of them is
+// written to
+func (r *Revisions) Current(datasets ...string) Revision {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var current Revision
+	for _, dataset := range datasets {
+		revision := r.revisions[dataset]
+		current.Count += revision.Count
+		if revision.Modified.After(current.Modified) {
+			current.Modified = revision.Modified
+		}
+	}
+	return current
+}
+
+// revisedMovies bumps the revisions written to by the movie writes of MovieRepository, deleting
+// and restoring a movie cascade to its ratings and credits. Purging leaves what is served as is.
+type revisedMovies struct {
+	MovieRepository
+	revisions bumper
+}
+
+func (m revisedMovies) AddMovie(movie *Movies) error {
+	defer m.revisions.Bump(DatasetMovies)
+	return m.MovieRepository.AddMovie(movie)
+}
+
+func (m revisedMovies) UpdateMovie(movieId string, updatedMovie *Movies) error {
+	defer m.revisions.Bump(DatasetMovies)
+	return m.MovieRepository.UpdateMovie(movieId, updatedMovie)
+}
+
+func (m revisedMovies) DeleteMovie(movieId string) error {
+	defer m.revisions.Bump(DatasetMovies, DatasetRatings, DatasetCredits)
+	return m.MovieRepository.DeleteMovie(movieId)
+}
+
+func (m revisedMovies) RestoreMovie(movieId string) error {
+	defer m.revisions.Bump(DatasetMovies, DatasetRatings, DatasetCredits)
+	return m.MovieRepository.RestoreMovie(movieId)
+}
+
+// revisedRatings bumps the ratings revision on the writes of RatingRepository
+type revisedRatings struct {
+	RatingRepository
+	revisions bumper
+}
+
+func (r revisedRatings) AddRatings(rating *Ratings) error {
+	defer r.revisions.Bump(DatasetRatings)
+	return r.RatingRepository.AddRatings(rating)
+}
+
+func (r revisedRatings) UpdateRatings(userId, movieId, newRating, newTimestamp string) error {
+	defer r.revisions.Bump(DatasetRatings)
+	return r.RatingRepository.UpdateRatings(userId, movieId, newRating, newTimestamp)
+}
+
+func (r revisedRatings) DeleteRatings(movieId string, userId *string) error {
+	defer r.revisions.Bump(DatasetRatings)
+	return r.RatingRepository.DeleteRatings(movieId, userId)
+}
+
+// revisedCast bumps the credits revision on the writes of CastRepository
+type revisedCast struct {
+	CastRepository
+	revisions bumper
+}
+
+func (c revisedCast) UpdateCastMember(movieId, castId string, updatedCast CastMember) error {
+	defer c.revisions.Bump(DatasetCredits)
+	return c.CastRepository.UpdateCastMember(movieId, castId, updatedCast)
+}
+
+// revisedCrew bumps the credits revision on the writes of CrewRepository
+type revisedCrew struct {
+	CrewRepository
+	revisions bumper
+}
+
+func (c revisedCrew) UpdateCrewMember(movieId, crewId string, updatedCrew CrewMember) error {
+	defer c.revisions.Bump(DatasetCredits)
+	return c.CrewRepository.UpdateCrewMember(movieId, crewId, updatedCrew)
+}
+
+func (c revisedCrew) DeleteCreditsForMovie(movieId string) error {
+	defer c.revisions.Bump(DatasetCredits)
+	return c.CrewRepository.DeleteCreditsForMovie(movieId)
+}
+
+// bumper records writes to datasets
+type bumper interface {
+	Bump(datasets ...string)
+}
+
+// deferredBumps collects the writes of a transaction, they are bumped once it ended so a response
+// cached before the transaction committed or rolled back is not served afterwards
+type deferredBumps struct {
+	datasets []string
+}
+
+func (d *deferredBumps) Bump(datasets ...string) {
+	d.datasets = append(d.datasets, datasets...)
+}
+
+// revise makes the writes to the repositories of r bump r.Revisions
+func (r *Repositories) revise() {
+	r.Revisions = NewRevisions()
+	r.reviseWith(r.Revisions)
+}
+
+func (r *Repositories) reviseWith(revisions bumper) {
+	r.Movies = revisedMovies{r.Movies, revisions}
+	r.Ratings = revisedRatings{r.Ratings, revisions}
+	r.Cast = revisedCast{r.Cast, revisions}
+	r.Crew = revisedCrew{r.Crew, revisions}
+}
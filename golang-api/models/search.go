@@ -0,0 +1,58 @@
+package models
+
+import (
+	"strings"
+
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/pkg/search"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/utils"
+)
+
+// MovieSearchResult is a movie matching a full-text search
+type MovieSearchResult struct {
+	Movie Movies  `json:"movie"`
+	Score float64 `json:"score"`
+	// Snippets holds the matched fields HTML escaped, with matched words wrapped in <b></b>
+	Snippets map[string]string `json:"snippets"`
+}
+
+// movieSearchFields are the fields searched by SearchMovies, the title weighs the most
+var movieSearchFields = []search.Field{
+	{Name: "title", Weight: 1},
+	{Name: "original_title", Weight: 0.8},
+	{Name: "tagline", Weight: 0.5},
+	{Name: "overview", Weight: 0.3},
+}
+
+func newMovieIndex() *search.Index {
+	return search.NewIndex(movieSearchFields...)
+}
+
+func indexMovie(index *search.Index, movie Movies) {
+	index.Put(movie.ID, movie.Title, movie.OriginalTitle, movie.Tagline, movie.Overview)
+}
+
+// searchMovies runs query against index and resolves hits through get
+func searchMovies(index *search.Index, query string, page, limit int, get func(id string) (Movies, bool)) ([]MovieSearchResult, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, ErrEmptySearchQuery
+	}
+
+	var results []MovieSearchResult
+	for _, hit := range index.Search(query) {
+		movie, ok := get(hit.ID)
+		if !ok {
+			continue
+		}
+		results = append(results, MovieSearchResult{
+			Movie:    movie,
+			Score:    hit.Score,
+			Snippets: hit.Snippets,
+		})
+	}
+
+	if len(results) == 0 {
+		return nil, ErrNoMoviesMatched
+	}
+
+	return utils.Paginate(results, page, limit)
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/doug-martin/goqu/v9"
+)
+
+// Session runs the statements of a postgres model, the database itself or a transaction of it.
+// The models Repositories.Atomically builds on a transaction write along with the audit entry.
+type Session interface {
+	From(cols ...interface{}) *goqu.SelectDataset
+	Select(cols ...interface{}) *goqu.SelectDataset
+	Insert(table interface{}) *goqu.InsertDataset
+	Update(table interface{}) *goqu.UpdateDataset
+	Delete(table interface{}) *goqu.DeleteDataset
+}
+
+// inTx runs fn in a transaction of db, or in db itself when it is a transaction already, so
+// writes spanning several statements stay all-or-nothing either way
+func inTx(db Session, fn func(tx *goqu.TxDatabase) error) error {
+	switch db := db.(type) {
+	case *goqu.TxDatabase:
+		return fn(db)
+	case *goqu.Database:
+		return db.WithTx(fn)
+	}
+	return fmt.Errorf("unsupported session %T", db)
+}
@@ -0,0 +1,189 @@
+package models
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/utils"
+)
+
+// favouriteGenresLimit is the number of genres listed as favourites of a user
+const favouriteGenresLimit = 5
+
+// UserRating is a rating given by a user, Timestamp is in unix seconds
+type UserRating struct {
+	MovieId   string  `json:"movieId"`
+	Rating    float64 `json:"rating"`
+	Timestamp int64   `json:"timestamp"`
+}
+
+// RatingCount is the number of ratings having the rating value Rating
+type RatingCount struct {
+	Rating float64 `json:"rating"`
+	Count  int     `json:"count"`
+}
+
+// GenreCount is the number of rated movies of a genre and the average rating given to them
+type GenreCount struct {
+	Genre   string  `json:"genre"`
+	Count   int     `json:"count"`
+	Average float64 `json:"average"`
+}
+
+// UserStats summarizes the ratings of a user. Distribution is ordered by rating, favourite
+// genres are the genres rated most, ties going to the better rated genre.
+type UserStats struct {
+	UserId          string        `json:"userId"`
+	Count           int           `json:"count"`
+	Mean            float64       `json:"mean"`
+	Distribution    []RatingCount `json:"distribution"`
+	FavouriteGenres []GenreCount  `json:"favouriteGenres"`
+}
+
+// userRatingSort orders the ratings of a user by timestamp or rating, ties are broken by movie id
+type userRatingSort struct {
+	field string
+	desc  bool
+}
+
+// parseUserRatingSort parses timestamp or rating, prefixed with "-" for descending order. The
+// latest ratings come first by default.
+func parseUserRatingSort(value string) (userRatingSort, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return userRatingSort{field: "timestamp", desc: true}, nil
+	}
+
+	order := userRatingSort{field: strings.TrimPrefix(value, "-"), desc: strings.HasPrefix(value, "-")}
+	if order.field != "timestamp" && order.field != "rating" {
+		return userRatingSort{}, fmt.Errorf("%w: cannot sort ratings by %q", ErrInvalidSort, order.field)
+	}
+	return order, nil
+}
+
+// key returns the sorted value of rating followed by its movie id
+func (s userRatingSort) key(rating UserRating) []string {
+	value := strconv.FormatInt(rating.Timestamp, 10)
+	if s.field == "rating" {
+		value = strconv.FormatFloat(rating.Rating, 'f', -1, 64)
+	}
+	return []string{value, rating.MovieId}
+}
+
+// compareKeys compares two keys made by key
+func (s userRatingSort) compareKeys(a, b []string) int {
+	x, _ := strconv.ParseFloat(a[0], 64)
+	y, _ := strconv.ParseFloat(b[0], 64)
+	if c := cmp.Compare(x, y); c != 0 {
+		if s.desc {
+			return -c
+		}
+		return c
+	}
+	return compareIDKeys(a[1:], b[1:])
+}
+
+// sortGenres orders genres from the favourite one and keeps the first favouriteGenresLimit
+func sortGenres(genres []GenreCount) []GenreCount {
+	sort.Slice(genres, func(i, j int) bool {
+		if genres[i].Count != genres[j].Count {
+			return genres[i].Count > genres[j].Count
+		}
+		if genres[i].Average != genres[j].Average {
+			return genres[i].Average > genres[j].Average
+		}
+		return genres[i].Genre < genres[j].Genre
+	})
+
+	if len(genres) > favouriteGenresLimit {
+		genres = genres[:favouriteGenresLimit]
+	}
+	return genres
+}
+
+// MemoryUserModel is the UserRepository of the in-memory and CSV backends, it reads the rating
+// snapshot and looks up genres in movies
+type MemoryUserModel struct {
+	ratings *MemoryRatingModel
+	movies  MovieRepository
+}
+
+// NewMemoryUserModel initializes a MemoryUserModel over ratings and movies
+func NewMemoryUserModel(ratings *MemoryRatingModel, movies MovieRepository) *MemoryUserModel {
+	return &MemoryUserModel{
+		ratings: ratings,
+		movies:  movies,
+	}
+}
+
+// ListUserRatings fetches a page of the ratings of userId ordered by sortBy
+func (u *MemoryUserModel) ListUserRatings(userId, sortBy string, page utils.PageRequest) (utils.Page[UserRating], error) {
+	order, err := parseUserRatingSort(sortBy)
+	if err != nil {
+		return utils.Page[UserRating]{}, err
+	}
+	if err := page.CheckKey(2); err != nil {
+		return utils.Page[UserRating]{}, err
+	}
+
+	ratings := u.ratings.userRatings(userId)
+	if len(ratings) == 0 {
+		return utils.Page[UserRating]{}, ErrUserNotFound
+	}
+
+	sort.Slice(ratings, func(i, j int) bool {
+		return order.compareKeys(order.key(ratings[i]), order.key(ratings[j])) < 0
+	})
+
+	return utils.KeysetPaginate(ratings, page, order.key, order.compareKeys)
+}
+
+// GetUserStats summarizes the ratings of userId
+func (u *MemoryUserModel) GetUserStats(userId string) (UserStats, error) {
+	ratings := u.ratings.userRatings(userId)
+	if len(ratings) == 0 {
+		return UserStats{}, ErrUserNotFound
+	}
+
+	stats := UserStats{UserId: userId, Count: len(ratings)}
+
+	var sum float64
+	distribution := make(map[float64]int)
+	genreCounts := make(map[string]int)
+	genreSums := make(map[string]float64)
+	for _, rating := range ratings {
+		sum += rating.Rating
+		distribution[rating.Rating]++
+
+		movie, err := u.movies.GetMovie(rating.MovieId)
+		if errors.Is(err, ErrMovieNotFound) {
+			continue
+		}
+		if err != nil {
+			return UserStats{}, err
+		}
+		for _, genre := range movie.Genres {
+			genreCounts[genre]++
+			genreSums[genre] += rating.Rating
+		}
+	}
+	stats.Mean = RoundToTwoDecimals(sum / float64(len(ratings)))
+
+	stats.Distribution = histogram(distribution)
+
+	genres := make([]GenreCount, 0, len(genreCounts))
+	for genre, count := range genreCounts {
+		genres = append(genres, GenreCount{
+			Genre:   genre,
+			Count:   count,
+			Average: RoundToTwoDecimals(genreSums[genre] / float64(count)),
+		})
+	}
+	stats.FavouriteGenres = sortGenres(genres)
+
+	return stats, nil
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	pMetrics "git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/pkg/prometheus"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/routinewrapper"
+	"go.uber.org/zap"
+)
+
+// Results of a dataset reload reported to prometheus
+const (
+	reloadSuccess = "success"
+	reloadFailure = "failure"
+)
+
+// WatchDatasets checks the dataset files every interval and swaps in the ones replaced on disk,
+// until the repositories are closed. Backends without files have nothing to watch.
+func (r *Repositories) WatchDatasets(interval time.Duration, logger *zap.Logger, metrics *pMetrics.PrometheusMetrics) {
+	if len(r.datasets) == 0 || interval <= 0 {
+		return
+	}
+
+	r.stop = make(chan struct{})
+	stop := r.stop
+
+	r.watching.Add(1)
+	go routinewrapper.RoutineGenerator(func() {
+		defer r.watching.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+
+			for name, dataset := range r.datasets {
+				if reloadDataset(name, dataset, logger, metrics) {
+					r.Revisions.Bump(name)
+				}
+			}
+		}
+	})
+}
+
+// reloadDataset reloads dataset and reports whether it was swapped
+func reloadDataset(name string, dataset dataset, logger *zap.Logger, metrics *pMetrics.PrometheusMetrics) bool {
+	changed, err := dataset.Reload()
+	if err != nil {
+		logger.Error("Failed to reload dataset, keeping the current data", zap.String("dataset", name), zap.Error(err))
+		metrics.DatasetReloads.WithLabelValues(name, reloadFailure).Inc()
+		return false
+	}
+
+	if changed {
+		logger.Info("Reloaded dataset", zap.String("dataset", name))
+		metrics.DatasetReloads.WithLabelValues(name, reloadSuccess).Inc()
+		metrics.DatasetLastReload.WithLabelValues(name).SetToCurrentTime()
+	}
+	return changed
+}
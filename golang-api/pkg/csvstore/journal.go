@@ -0,0 +1,283 @@
+package csvstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// Change is a set of ops of one table
+type Change struct {
+	Table *Table
+	Ops   []Op
+}
+
+// journalRecord is one line of the journal, it holds the ops of every table by file path. An
+// aborted record cancels the change of the record before it, which was rolled back.
+type journalRecord struct {
+	Tables  map[string][]Op `json:"tables,omitempty"`
+	Aborted bool            `json:"aborted,omitempty"`
+}
+
+// Journal makes changes spanning several tables all-or-nothing. A change is logged to the
+// journal before it goes to the write-ahead logs of its tables and the journal is cleared once
+// every table has it. A change left in the journal by a crash is applied again when the journal
+// is opened, which is harmless because applying ops twice leaves the same rows.
+//
+// A change the journal could not be cleared of, or which could not be rolled back, would be
+// applied again over later writes on the next start. The journal and its tables then fail closed,
+// refusing writes with ErrWritesRefused until the service is restarted and completes the change.
+type Journal struct {
+	mu sync.Mutex
+	// atomic serializes the calls of Atomically
+	atomic sync.Mutex
+
+	path   string
+	file   *os.File
+	tables map[string]*Table
+	logger *zap.Logger
+	failed error
+}
+
+// OpenJournal opens the journal fileName of tables and completes the change it holds
+func OpenJournal(fileName string, logger *zap.Logger, tables ...*Table) (*Journal, error) {
+	workingDirPath, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("error getting working directory: %v", err)
+	}
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	j := &Journal{
+		path:   filepath.Join(workingDirPath, fileName),
+		tables: make(map[string]*Table, len(tables)),
+		logger: logger,
+	}
+	for _, table := range tables {
+		j.tables[table.filePath] = table
+	}
+
+	if err := j.recover(); err != nil {
+		return nil, fmt.Errorf("error recovering journal %s: %v", fileName, err)
+	}
+
+	j.file, err = os.OpenFile(j.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// recover applies the complete records of the journal to their tables and clears it, a torn
+// last line is a change that was never committed
+func (j *Journal) recover() error {
+	log, err := os.ReadFile(j.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var records []journalRecord
+	aborted, offset := 0, 0
+	for offset < len(log) {
+		end := bytes.IndexByte(log[offset:], '\n')
+		if end < 0 {
+			break
+		}
+
+		var record journalRecord
+		if err := json.Unmarshal(log[offset:offset+end], &record); err != nil {
+			return fmt.Errorf("corrupt journal entry at offset %d: %v", offset, err)
+		}
+		if record.Aborted {
+			if len(records) > 0 {
+				records = records[:len(records)-1]
+				aborted++
+			}
+		} else {
+			for path := range record.Tables {
+				if _, ok := j.tables[path]; !ok {
+					return fmt.Errorf("journal entry at offset %d changes unknown table %s", offset, path)
+				}
+			}
+			records = append(records, record)
+		}
+
+		offset += end + 1
+	}
+
+	for _, record := range records {
+		for path, ops := range record.Tables {
+			if err := j.tables[path].Apply(ops...); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(records) > 0 || aborted > 0 {
+		j.logger.Info("Completed changes left in the journal", zap.String("file", j.path),
+			zap.Int("entries", len(records)), zap.Int("aborted", aborted))
+	}
+	return os.Truncate(j.path, 0)
+}
+
+// Apply applies changes to their tables all together. When a table fails to take its ops the
+// tables already changed are put back and the change is marked aborted in the journal. Should
+// that fail as well the journal fails closed and the change is completed from the journal when
+// it is opened again.
+func (j *Journal) Apply(changes ...Change) error {
+	record := journalRecord{Tables: make(map[string][]Op, len(changes))}
+	for _, change := range changes {
+		if len(change.Ops) > 0 {
+			record.Tables[change.Table.filePath] = append(record.Tables[change.Table.filePath], change.Ops...)
+		}
+	}
+	if len(record.Tables) == 0 {
+		return nil
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.failed != nil {
+		return fmt.Errorf("%w: %v", ErrWritesRefused, j.failed)
+	}
+
+	if _, err := j.file.Write(line); err != nil {
+		return fmt.Errorf("error writing journal: %v", err)
+	}
+	if err := j.file.Sync(); err != nil {
+		return fmt.Errorf("error syncing journal: %v", err)
+	}
+
+	undo := make([][]Op, len(changes))
+	for i, change := range changes {
+		undo[i] = change.Table.undo(change.Ops)
+		if err := change.Table.Apply(change.Ops...); err != nil {
+			for k := i - 1; k >= 0; k-- {
+				if undoErr := changes[k].Table.Apply(undo[k]...); undoErr != nil {
+					j.fail(fmt.Errorf("error rolling back %s: %v", changes[k].Table.filePath, undoErr))
+					return err
+				}
+			}
+			if clearErr := j.clear(); clearErr != nil {
+				if abortErr := j.abort(); abortErr != nil {
+					j.fail(fmt.Errorf("error clearing rolled back change: %v, %v", clearErr, abortErr))
+				}
+			}
+			return err
+		}
+	}
+
+	if err := j.clear(); err != nil {
+		j.fail(err)
+		return err
+	}
+	return nil
+}
+
+// transaction collects the ops putting back the writes made to the tables of a journal while
+// Atomically runs, latest last
+type transaction struct {
+	mu   sync.Mutex
+	undo []Change
+}
+
+func (tx *transaction) add(change Change) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	tx.undo = append(tx.undo, change)
+}
+
+// Atomically runs fn and, when it fails, puts back the rows of the tables changed by fn all
+// together through the journal and then calls putBack, so views of the tables can be rebuilt.
+// Calls are serialized, but a write to the tables made meanwhile by a caller of Apply or
+// Table.Apply would be put back along. A crash while fn runs leaves the rows it changed so far.
+func (j *Journal) Atomically(fn func() error, putBack func()) error {
+	j.atomic.Lock()
+	defer j.atomic.Unlock()
+
+	tx := &transaction{}
+	for _, table := range j.tables {
+		table.setTx(tx)
+	}
+	err := fn()
+	for _, table := range j.tables {
+		table.setTx(nil)
+	}
+	if err == nil || len(tx.undo) == 0 {
+		return err
+	}
+
+	undo := make([]Change, 0, len(tx.undo))
+	for i := len(tx.undo) - 1; i >= 0; i-- {
+		undo = append(undo, tx.undo[i])
+	}
+	if undoErr := j.Apply(undo...); undoErr != nil {
+		j.logger.Error("Failed to put back the rows of a failed change", zap.String("file", j.path), zap.Error(undoErr))
+		return fmt.Errorf("%w, and putting back its rows failed: %v", err, undoErr)
+	}
+	putBack()
+	return err
+}
+
+// abort marks the last change of the journal as rolled back, so it is not applied on the next start
+func (j *Journal) abort() error {
+	line, err := json.Marshal(journalRecord{Aborted: true})
+	if err != nil {
+		return err
+	}
+	if _, err := j.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("error writing journal: %v", err)
+	}
+	if err := j.file.Sync(); err != nil {
+		return fmt.Errorf("error syncing journal: %v", err)
+	}
+	return nil
+}
+
+// fail makes the journal and its tables refuse writes, the change left in the journal would
+// otherwise be applied on the next start over the writes made meanwhile
+func (j *Journal) fail(err error) {
+	j.logger.Error("Refusing writes until restart, the change left in the journal is completed on the next start",
+		zap.String("file", j.path), zap.Error(err))
+	j.failed = err
+	for _, table := range j.tables {
+		table.fail(err)
+	}
+}
+
+// clear empties the journal once its change reached every table
+func (j *Journal) clear() error {
+	if err := j.file.Truncate(0); err != nil {
+		return fmt.Errorf("error truncating journal: %v", err)
+	}
+	if err := j.file.Sync(); err != nil {
+		return fmt.Errorf("error syncing journal: %v", err)
+	}
+	return nil
+}
+
+// Close closes the journal
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.file.Close()
+}
@@ -0,0 +1,736 @@
+package csvstore
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/utils"
+	"go.uber.org/zap"
+)
+
+// keySeparator joins the values of multi column keys
+const keySeparator = ":"
+
+// ErrWritesRefused is returned by writes after a change could not be completed or rolled back
+var ErrWritesRefused = errors.New("writes are refused until restart")
+
+// Options tune the compaction of a Table
+type Options struct {
+	// CompactInterval is how often pending log entries are folded back into the CSV file
+	CompactInterval time.Duration
+	// CompactThreshold triggers a compaction once that many log entries are pending
+	CompactThreshold int
+	// Columns are appended to the header of a file lacking them, rows of such a file have them empty
+	Columns []string
+	// IntegerKeys rejects a file replaced on disk having a key column which is not an integer
+	IntegerKeys bool
+	Logger      *zap.Logger
+}
+
+// Op is a single change of a Table
+type Op struct {
+	Delete bool     `json:"delete,omitempty"`
+	Key    string   `json:"key"`
+	Row    []string `json:"row,omitempty"`
+}
+
+// walRecord is one line of the write-ahead log, its ops are applied all together
+type walRecord struct {
+	Ops []Op `json:"ops"`
+}
+
+type entry struct {
+	row []string
+	pos int
+}
+
+// tableData is the indexed content of a table, it is replaced as a whole on reload
+type tableData struct {
+	rows  map[string]*entry
+	order []string
+	// unindexed keeps rows with a wrong column count or a duplicate key, they are written back as is
+	unindexed [][]string
+}
+
+// Table is a CSV file loaded into memory and indexed by key. Changes are appended to a
+// write-ahead log next to the file and applied to the index, so they cost O(1) disk work.
+// The log is compacted back into the CSV file periodically, which stays the source of truth.
+type Table struct {
+	mu sync.RWMutex
+
+	filePath string
+	walPath  string
+	header   []string
+	columns  map[string]int
+	keyCols  []int
+
+	data *tableData
+
+	// fileInfo describes the CSV file as last loaded or compacted, pending and rejected track
+	// files replaced on disk which are waiting to be reloaded or failed validation
+	fileInfo os.FileInfo
+	pending  os.FileInfo
+	rejected os.FileInfo
+
+	wal        *os.File
+	walEntries int
+	// failed is set once the journal of the table fails closed, writes are refused from then on
+	failed error
+	// tx collects the ops putting back the writes made while Journal.Atomically runs
+	tx *transaction
+
+	opts    Options
+	compact chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// Open loads the CSV file fileName, indexes its rows by keyColumns and replays its write-ahead log
+func Open(fileName string, keyColumns []string, opts Options) (*Table, error) {
+	workingDirPath, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("error getting working directory: %v", err)
+	}
+
+	if opts.Logger == nil {
+		opts.Logger = zap.NewNop()
+	}
+
+	t := &Table{
+		filePath: filepath.Join(workingDirPath, fileName),
+		walPath:  filepath.Join(workingDirPath, fileName) + ".wal",
+		opts:     opts,
+		compact:  make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	if err := t.load(keyColumns); err != nil {
+		return nil, fmt.Errorf("error loading %s: %v", fileName, err)
+	}
+
+	entries, err := t.replay(t.data)
+	if err != nil {
+		return nil, fmt.Errorf("error replaying log of %s: %v", fileName, err)
+	}
+	t.walEntries = entries
+	if entries > 0 {
+		t.opts.Logger.Info("Replayed write-ahead log", zap.String("file", t.walPath), zap.Int("entries", entries))
+	}
+
+	t.wal, err = os.OpenFile(t.walPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	go t.run()
+
+	return t, nil
+}
+
+func (t *Table) load(keyColumns []string) error {
+	header, err := readHeader(t.filePath)
+	if err != nil {
+		return err
+	}
+
+	t.header = header
+	for _, name := range t.opts.Columns {
+		if !slices.Contains(t.header, name) {
+			t.header = append(t.header, name)
+		}
+	}
+	t.columns = make(map[string]int, len(t.header))
+	for i, name := range t.header {
+		t.columns[name] = i
+	}
+
+	for _, name := range keyColumns {
+		col, ok := t.columns[name]
+		if !ok {
+			return fmt.Errorf("key column %q not found", name)
+		}
+		t.keyCols = append(t.keyCols, col)
+	}
+
+	t.data, t.fileInfo, err = t.read()
+	return err
+}
+
+func readHeader(filePath string) ([]string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	header, err := csv.NewReader(bufio.NewReader(file)).Read()
+	if err != nil {
+		return nil, fmt.Errorf("error reading header: %v", err)
+	}
+	return header, nil
+}
+
+// read parses and indexes the CSV file, the returned info describes the file that was read
+func (t *Table) read() (*tableData, os.FileInfo, error) {
+	file, err := os.Open(t.filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reader := csv.NewReader(bufio.NewReader(file))
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading header: %v", err)
+	}
+	if !t.matchesHeader(header) {
+		return nil, nil, fmt.Errorf("header %v does not match the columns %v", header, t.header)
+	}
+	// Rows of a file lacking the added columns get them empty
+	missing := len(t.header) - len(header)
+
+	data := &tableData{rows: make(map[string]*entry)}
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if missing > 0 && len(row) == len(header) {
+			row = append(row, make([]string, missing)...)
+		}
+
+		key := t.key(row)
+		if _, exists := data.rows[key]; exists || len(row) != len(t.header) {
+			data.unindexed = append(data.unindexed, row)
+			continue
+		}
+		data.put(key, row)
+	}
+
+	if len(data.unindexed) > 0 {
+		t.opts.Logger.Warn("CSV rows kept out of the index",
+			zap.String("file", t.filePath),
+			zap.Int("rows", len(data.unindexed)),
+		)
+	}
+
+	return data, info, nil
+}
+
+// matchesHeader reports whether header is the header of the table, or its start with only
+// added columns missing
+func (t *Table) matchesHeader(header []string) bool {
+	if len(header) > len(t.header) || strings.Join(header, ",") != strings.Join(t.header[:len(header)], ",") {
+		return false
+	}
+	for _, name := range t.header[len(header):] {
+		if !slices.Contains(t.opts.Columns, name) {
+			return false
+		}
+	}
+	return true
+}
+
+// replay applies the write-ahead log onto data and returns the number of entries applied, a
+// torn last line is cut off
+func (t *Table) replay(data *tableData) (int, error) {
+	log, err := os.ReadFile(t.walPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	entries, offset := 0, 0
+	for offset < len(log) {
+		end := bytes.IndexByte(log[offset:], '\n')
+		if end < 0 {
+			break
+		}
+
+		var record walRecord
+		if err := json.Unmarshal(log[offset:offset+end], &record); err != nil {
+			return 0, fmt.Errorf("corrupt log entry at offset %d: %v", offset, err)
+		}
+
+		t.apply(data, record.Ops)
+		entries++
+		offset += end + 1
+	}
+
+	if offset < len(log) {
+		t.opts.Logger.Warn("Cutting off torn write-ahead log entry", zap.String("file", t.walPath), zap.Int("offset", offset))
+		if err := os.Truncate(t.walPath, int64(offset)); err != nil {
+			return 0, err
+		}
+	}
+
+	return entries, nil
+}
+
+// Key builds the key of a row from values of its key columns
+func Key(values ...string) string {
+	return strings.Join(values, keySeparator)
+}
+
+func (t *Table) key(row []string) string {
+	values := make([]string, len(t.keyCols))
+	for i, col := range t.keyCols {
+		if col < len(row) {
+			values[i] = row[col]
+		}
+	}
+	return Key(values...)
+}
+
+// Header returns the column names of the table
+func (t *Table) Header() []string {
+	return append([]string(nil), t.header...)
+}
+
+// Column returns the position of column name, or -1 when there is no such column
+func (t *Table) Column(name string) int {
+	col, ok := t.columns[name]
+	if !ok {
+		return -1
+	}
+	return col
+}
+
+// Get returns a copy of the row stored under key
+func (t *Table) Get(key string) ([]string, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	e, ok := t.data.rows[key]
+	if !ok {
+		return nil, false
+	}
+	return append([]string(nil), e.row...), true
+}
+
+// Rows returns a copy of every indexed row in file order
+func (t *Table) Rows() [][]string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	rows := make([][]string, 0, len(t.data.rows))
+	for pos, key := range t.data.order {
+		if e, ok := t.data.rows[key]; ok && e.pos == pos {
+			rows = append(rows, append([]string(nil), e.row...))
+		}
+	}
+	return rows
+}
+
+// Value returns the field of row in column name, or "" when there is no such column
+func (t *Table) Value(row []string, name string) string {
+	col := t.Column(name)
+	if col < 0 || col >= len(row) {
+		return ""
+	}
+	return row[col]
+}
+
+// Set stores value in the field of row in column name, unknown columns are ignored
+func (t *Table) Set(row []string, name, value string) {
+	if col := t.Column(name); col >= 0 && col < len(row) {
+		row[col] = value
+	}
+}
+
+// NewRow returns an empty row having a field for every column
+func (t *Table) NewRow() []string {
+	return make([]string, len(t.header))
+}
+
+// undo returns the ops putting the rows changed by ops back as they are now, in the order they
+// have to be applied
+func (t *Table) undo(ops []Op) []Op {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.undoLocked(ops)
+}
+
+func (t *Table) undoLocked(ops []Op) []Op {
+	undo := make([]Op, 0, len(ops))
+	for i := len(ops) - 1; i >= 0; i-- {
+		e, ok := t.data.rows[ops[i].Key]
+		if !ok {
+			undo = append(undo, Op{Delete: true, Key: ops[i].Key})
+			continue
+		}
+		undo = append(undo, Op{Key: ops[i].Key, Row: append([]string(nil), e.row...)})
+	}
+	return undo
+}
+
+// Put inserts or replaces rows, the key of each row is read from its key columns
+func (t *Table) Put(rows ...[]string) error {
+	ops := make([]Op, 0, len(rows))
+	for _, row := range rows {
+		if len(row) != len(t.header) {
+			return fmt.Errorf("row has %d fields, expected %d", len(row), len(t.header))
+		}
+		ops = append(ops, Op{Key: t.key(row), Row: row})
+	}
+	return t.Apply(ops...)
+}
+
+// Delete removes the rows stored under keys
+func (t *Table) Delete(keys ...string) error {
+	ops := make([]Op, 0, len(keys))
+	for _, key := range keys {
+		ops = append(ops, Op{Delete: true, Key: key})
+	}
+	return t.Apply(ops...)
+}
+
+// Apply durably logs ops as a single entry and then applies them to the index
+func (t *Table) Apply(ops ...Op) error {
+	if len(ops) == 0 {
+		return nil
+	}
+
+	line, err := json.Marshal(walRecord{Ops: ops})
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.failed != nil {
+		return fmt.Errorf("%w: %v", ErrWritesRefused, t.failed)
+	}
+
+	if _, err := t.wal.Write(line); err != nil {
+		return fmt.Errorf("error writing log: %v", err)
+	}
+	if err := t.wal.Sync(); err != nil {
+		return fmt.Errorf("error syncing log: %v", err)
+	}
+
+	if t.tx != nil {
+		t.tx.add(Change{Table: t, Ops: t.undoLocked(ops)})
+	}
+	t.apply(t.data, ops)
+	t.walEntries++
+
+	if t.opts.CompactThreshold > 0 && t.walEntries >= t.opts.CompactThreshold {
+		select {
+		case t.compact <- struct{}{}:
+		default:
+		}
+	}
+
+	return nil
+}
+
+// fail makes the table refuse writes
+func (t *Table) fail(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.failed = err
+}
+
+// setTx makes the writes to the table add the ops putting them back to tx, nil stops it
+func (t *Table) setTx(tx *transaction) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.tx = tx
+}
+
+func (t *Table) apply(data *tableData, ops []Op) {
+	for _, op := range ops {
+		if op.Delete {
+			delete(data.rows, op.Key)
+			t.dropUnindexed(data, op.Key)
+			continue
+		}
+		if e, ok := data.rows[op.Key]; ok {
+			e.row = op.Row
+			continue
+		}
+		data.put(op.Key, op.Row)
+	}
+}
+
+// dropUnindexed removes duplicates of a deleted key, so they do not take its place on the next load
+func (t *Table) dropUnindexed(data *tableData, key string) {
+	kept := data.unindexed[:0]
+	for _, row := range data.unindexed {
+		if len(row) != len(t.header) || t.key(row) != key {
+			kept = append(kept, row)
+		}
+	}
+	data.unindexed = kept
+}
+
+func (d *tableData) put(key string, row []string) {
+	d.rows[key] = &entry{row: row, pos: len(d.order)}
+	d.order = append(d.order, key)
+}
+
+// Compact rewrites the CSV file from the index and truncates the write-ahead log. A crash
+// between both steps is harmless because replaying the log onto the new file is idempotent.
+func (t *Table) Compact() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.compactLocked()
+}
+
+func (t *Table) compactLocked() error {
+	if t.walEntries == 0 {
+		return nil
+	}
+
+	if replaced, err := t.replacedLocked(); err != nil {
+		return err
+	} else if replaced {
+		// Rewriting now would overwrite the new file, Reload sets the log aside when it swaps it in
+		t.opts.Logger.Warn("Postponing compaction of a CSV file replaced on disk", zap.String("file", t.filePath))
+		return nil
+	}
+
+	start := time.Now()
+
+	rows, order := t.fileRows(t.data)
+	if err := utils.UpdateCSV(t.filePath, rows); err != nil {
+		return fmt.Errorf("error rewriting %s: %v", t.filePath, err)
+	}
+	for pos, key := range order {
+		t.data.rows[key].pos = pos
+	}
+	t.data.order = order
+
+	info, err := os.Stat(t.filePath)
+	if err != nil {
+		return err
+	}
+	t.fileInfo = info
+
+	if err := t.truncateLog(); err != nil {
+		return err
+	}
+
+	t.opts.Logger.Info("Compacted write-ahead log",
+		zap.String("file", t.filePath),
+		zap.Int("entries", t.walEntries),
+		zap.Duration("duration", time.Since(start)),
+	)
+	t.walEntries = 0
+
+	return nil
+}
+
+// fileRows returns the content of the CSV file holding data, header first, along with the keys of
+// its indexed rows in file order
+func (t *Table) fileRows(data *tableData) ([][]string, []string) {
+	rows := make([][]string, 0, len(data.rows)+len(data.unindexed)+1)
+	rows = append(rows, t.header)
+
+	order := make([]string, 0, len(data.rows))
+	for pos, key := range data.order {
+		if e, ok := data.rows[key]; ok && e.pos == pos {
+			order = append(order, key)
+			rows = append(rows, e.row)
+		}
+	}
+	return append(rows, data.unindexed...), order
+}
+
+func (t *Table) truncateLog() error {
+	if err := t.wal.Truncate(0); err != nil {
+		return fmt.Errorf("error truncating log: %v", err)
+	}
+	if err := t.wal.Sync(); err != nil {
+		return fmt.Errorf("error syncing log: %v", err)
+	}
+	return nil
+}
+
+// replacedLocked reports whether the CSV file on disk is no longer the one last loaded or compacted
+func (t *Table) replacedLocked() (bool, error) {
+	info, err := os.Stat(t.filePath)
+	if err != nil {
+		return false, err
+	}
+	return !sameFile(t.fileInfo, info), nil
+}
+
+// sameFile reports whether a and b describe the same unchanged file
+func sameFile(a, b os.FileInfo) bool {
+	return a != nil && b != nil && os.SameFile(a, b) && a.Size() == b.Size() && a.ModTime().Equal(b.ModTime())
+}
+
+// Reload re-reads the CSV file when it was replaced or changed on disk and swaps it in. A file is
+// only read once it looks the same on two calls in a row, so one still being written is not picked
+// up. A file having no rows, rows with a wrong column count, duplicate keys or, with IntegerKeys,
+// keys which are not integers fails validation. It is reported once and keeps the current data in
+// place until it changes again.
+//
+// Log entries still pending were made against the replaced content, replaying them could bring
+// back rows the new file dropped. The replaced content along with them is written to a
+// .superseded copy next to the file and the log is emptied before the new file is swapped in.
+func (t *Table) Reload() (bool, error) {
+	info, err := os.Stat(t.filePath)
+	if err != nil {
+		return false, err
+	}
+
+	t.mu.Lock()
+	if sameFile(t.fileInfo, info) || sameFile(t.rejected, info) {
+		t.pending = nil
+		t.mu.Unlock()
+		return false, nil
+	}
+	if !sameFile(t.pending, info) {
+		t.pending = info
+		t.mu.Unlock()
+		return false, nil
+	}
+	t.mu.Unlock()
+
+	// Parse without holding the lock, readers and writers keep using the current data meanwhile
+	data, readInfo, err := t.read()
+	if err == nil {
+		err = t.validate(data)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.pending = nil
+	if err != nil {
+		t.rejected = info
+		return false, fmt.Errorf("rejected new content of %s: %v", t.filePath, err)
+	}
+
+	// The file changed again while it was parsed, try again on the next call
+	if current, err := os.Stat(t.filePath); err != nil || !sameFile(readInfo, current) {
+		return false, err
+	}
+
+	if t.walEntries > 0 {
+		superseded := fmt.Sprintf("%s.superseded-%d", t.filePath, time.Now().Unix())
+		rows, _ := t.fileRows(t.data)
+		if err := utils.UpdateCSV(superseded, rows); err != nil {
+			return false, fmt.Errorf("error saving replaced content of %s: %v", t.filePath, err)
+		}
+		if err := t.truncateLog(); err != nil {
+			return false, err
+		}
+		t.opts.Logger.Warn("Set aside changes made to a CSV file replaced on disk",
+			zap.String("file", t.filePath),
+			zap.String("superseded", superseded),
+			zap.Int("entries", t.walEntries),
+		)
+		t.walEntries = 0
+	}
+
+	t.data = data
+	t.fileInfo = readInfo
+	t.rejected = nil
+
+	t.opts.Logger.Info("Reloaded CSV file",
+		zap.String("file", t.filePath),
+		zap.Int("rows", len(data.rows)),
+	)
+
+	return true, nil
+}
+
+// validate checks the content of a file replaced on disk before it is swapped in, unlike the file
+// loaded by Open no row is kept out of the index
+func (t *Table) validate(data *tableData) error {
+	if len(data.rows) == 0 {
+		return errors.New("file has no rows")
+	}
+	for _, row := range data.unindexed {
+		if len(row) != len(t.header) {
+			return fmt.Errorf("row %v has %d fields, expected %d", row, len(row), len(t.header))
+		}
+		return fmt.Errorf("duplicate key %q", t.key(row))
+	}
+	if !t.opts.IntegerKeys {
+		return nil
+	}
+	for _, key := range data.order {
+		for _, col := range t.keyCols {
+			value := data.rows[key].row[col]
+			if _, err := strconv.Atoi(value); err != nil {
+				return fmt.Errorf("row %q has %s %q, expected an integer", key, t.header[col], value)
+			}
+		}
+	}
+	return nil
+}
+
+// run compacts the table every CompactInterval and whenever CompactThreshold is reached
+func (t *Table) run() {
+	defer close(t.done)
+
+	var tick <-chan time.Time
+	if t.opts.CompactInterval > 0 {
+		ticker := time.NewTicker(t.opts.CompactInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-t.stop:
+			return
+		case <-tick:
+		case <-t.compact:
+		}
+
+		if err := t.Compact(); err != nil {
+			t.opts.Logger.Error("Failed to compact write-ahead log", zap.String("file", t.filePath), zap.Error(err))
+		}
+	}
+}
+
+// Close stops background compaction, folds pending log entries into the CSV file and closes the log
+func (t *Table) Close() error {
+	close(t.stop)
+	<-t.done
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.compactLocked(); err != nil {
+		return err
+	}
+	return t.wal.Close()
+}
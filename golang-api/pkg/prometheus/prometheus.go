@@ -8,8 +8,11 @@ import (
 const Namespace = "golang_api"
 
 type PrometheusMetrics struct {
-	MoviesMetrics   prometheus.Gauge
-	RequestsMetrics *prometheus.CounterVec
+	MoviesMetrics     prometheus.Gauge
+	RequestsMetrics   *prometheus.CounterVec
+	DatasetReloads    *prometheus.CounterVec
+	DatasetLastReload *prometheus.GaugeVec
+	RateLimitRejects  *prometheus.CounterVec
 }
 
 var metrics *PrometheusMetrics = nil
@@ -27,6 +30,21 @@ func InitPrometheusMetrics() *PrometheusMetrics {
 				Name:      "requests_total",
 				Help:      "Total http requests",
 			}, []string{"code"}),
+			DatasetReloads: promauto.NewCounterVec(prometheus.CounterOpts{
+				Namespace: Namespace,
+				Name:      "dataset_reloads_total",
+				Help:      "Reloads of CSV datasets replaced on disk by result",
+			}, []string{"dataset", "result"}),
+			DatasetLastReload: promauto.NewGaugeVec(prometheus.GaugeOpts{
+				Namespace: Namespace,
+				Name:      "dataset_last_reload_timestamp_seconds",
+				Help:      "Time of the last successful reload of a CSV dataset",
+			}, []string{"dataset"}),
+			RateLimitRejects: promauto.NewCounterVec(prometheus.CounterOpts{
+				Namespace: Namespace,
+				Name:      "rate_limit_rejections_total",
+				Help:      "Requests rejected by the rate limiter by route group and kind of client",
+			}, []string{"group", "client"}),
 		}
 	}
 
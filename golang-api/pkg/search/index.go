@@ -0,0 +1,321 @@
+package search
+
+import (
+	"html"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// BM25 parameters
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// Highlight markers around matched words in snippets, the same as ts_headline uses by default.
+// The text of snippets is HTML escaped, so the markers are the only markup in them.
+const (
+	StartSel = "<b>"
+	StopSel  = "</b>"
+)
+
+// Markers ts_headline is asked to put around matched words in place of StartSel and StopSel, so
+// the text can be escaped by Headline before they are swapped in. They are private use
+// characters, which the text of movies does not hold.
+const (
+	HeadlineStartSel = "\uE000"
+	HeadlineStopSel  = "\uE001"
+)
+
+// HeadlineOptions are the ts_headline options of snippets matching the ones of the index
+const HeadlineOptions = `StartSel="` + HeadlineStartSel + `", StopSel="` + HeadlineStopSel + `", MaxWords=30, MinWords=10`
+
+var headlineMarkers = strings.NewReplacer(HeadlineStartSel, StartSel, HeadlineStopSel, StopSel)
+
+// Headline turns a ts_headline result made with HeadlineOptions into a snippet like the ones of
+// the index. ok is false when nothing in it matched, ts_headline then returns the start of the text.
+func Headline(headline string) (string, bool) {
+	if !strings.Contains(headline, HeadlineStartSel) {
+		return "", false
+	}
+	return headlineMarkers.Replace(html.EscapeString(headline)), true
+}
+
+// snippetWords is the number of words a snippet of a long field is cut to
+const snippetWords = 30
+
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "by": true,
+	"for": true, "from": true, "has": true, "he": true, "in": true, "is": true, "it": true, "its": true,
+	"of": true, "on": true, "or": true, "she": true, "that": true, "the": true, "their": true, "they": true,
+	"this": true, "to": true, "was": true, "were": true, "will": true, "with": true,
+}
+
+// Field is a searchable field of the indexed documents with its weight in the score
+type Field struct {
+	Name   string
+	Weight float64
+}
+
+// Hit is a document matching a query
+type Hit struct {
+	ID    string
+	Score float64
+	// Snippets are the matched fields with matched words highlighted
+	Snippets map[string]string
+}
+
+type document struct {
+	fields  []string
+	lengths []int
+}
+
+// Index is an in-process inverted index over the fields of documents, ranked with BM25
+type Index struct {
+	mu     sync.RWMutex
+	fields []Field
+	docs   map[string]*document
+	// postings maps a term to the documents containing it and its count per field
+	postings    map[string]map[string][]int
+	totalLength []int
+}
+
+// NewIndex creates an empty index over fields
+func NewIndex(fields ...Field) *Index {
+	return &Index{
+		fields:      fields,
+		docs:        make(map[string]*document),
+		postings:    make(map[string]map[string][]int),
+		totalLength: make([]int, len(fields)),
+	}
+}
+
+// Put indexes the document id, values are given in the order of the index fields
+func (i *Index) Put(id string, values ...string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.remove(id)
+
+	doc := &document{fields: values, lengths: make([]int, len(i.fields))}
+	for f := range i.fields {
+		if f >= len(values) {
+			break
+		}
+		terms := Tokenize(values[f])
+		doc.lengths[f] = len(terms)
+		i.totalLength[f] += len(terms)
+
+		for _, term := range terms {
+			docs, ok := i.postings[term]
+			if !ok {
+				docs = make(map[string][]int)
+				i.postings[term] = docs
+			}
+			if docs[id] == nil {
+				docs[id] = make([]int, len(i.fields))
+			}
+			docs[id][f]++
+		}
+	}
+	i.docs[id] = doc
+}
+
+// Remove drops the document id from the index
+func (i *Index) Remove(id string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.remove(id)
+}
+
+func (i *Index) remove(id string) {
+	doc, ok := i.docs[id]
+	if !ok {
+		return
+	}
+
+	for f := range i.fields {
+		i.totalLength[f] -= doc.lengths[f]
+		if f >= len(doc.fields) {
+			continue
+		}
+		for _, term := range Tokenize(doc.fields[f]) {
+			delete(i.postings[term], id)
+			if len(i.postings[term]) == 0 {
+				delete(i.postings, term)
+			}
+		}
+	}
+	delete(i.docs, id)
+}
+
+// Search returns the documents matching any term of query, best first
+func (i *Index) Search(query string) []Hit {
+	terms := unique(Tokenize(query))
+	if len(terms) == 0 {
+		return nil
+	}
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	n := float64(len(i.docs))
+	avgLength := make([]float64, len(i.fields))
+	for f := range i.fields {
+		if n > 0 {
+			avgLength[f] = float64(i.totalLength[f]) / n
+		}
+	}
+
+	scores := make(map[string]float64)
+	for _, term := range terms {
+		docs := i.postings[term]
+		if len(docs) == 0 {
+			continue
+		}
+		df := float64(len(docs))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+
+		for id, counts := range docs {
+			doc := i.docs[id]
+			for f, field := range i.fields {
+				tf := float64(counts[f])
+				if tf == 0 || avgLength[f] == 0 {
+					continue
+				}
+				norm := tf + k1*(1-b+b*float64(doc.lengths[f])/avgLength[f])
+				scores[id] += field.Weight * idf * tf * (k1 + 1) / norm
+			}
+		}
+	}
+
+	matched := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		matched[term] = true
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for id, score := range scores {
+		hit := Hit{ID: id, Score: math.Round(score*10000) / 10000, Snippets: make(map[string]string)}
+		for f, field := range i.fields {
+			if f < len(i.docs[id].fields) {
+				if snippet, ok := highlight(i.docs[id].fields[f], matched); ok {
+					hit.Snippets[field.Name] = snippet
+				}
+			}
+		}
+		hits = append(hits, hit)
+	}
+
+	sort.Slice(hits, func(a, b int) bool {
+		if hits[a].Score != hits[b].Score {
+			return hits[a].Score > hits[b].Score
+		}
+		return hits[a].ID < hits[b].ID
+	})
+
+	return hits
+}
+
+// Tokenize splits text into lower cased, stemmed terms without stop words
+func Tokenize(text string) []string {
+	var terms []string
+	for _, word := range words(text) {
+		if term := normalize(word); term != "" {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+func words(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+}
+
+// normalize lower cases word and strips common English suffixes, stop words give ""
+func normalize(word string) string {
+	word = strings.ToLower(strings.Trim(word, "'"))
+	word = strings.TrimSuffix(word, "'s")
+	if word == "" || stopWords[word] {
+		return ""
+	}
+	return stem(word)
+}
+
+func stem(word string) string {
+	switch {
+	case len(word) > 4 && strings.HasSuffix(word, "ies"):
+		return word[:len(word)-3] + "y"
+	case len(word) > 5 && strings.HasSuffix(word, "ing"):
+		return word[:len(word)-3]
+	case len(word) > 4 && strings.HasSuffix(word, "ed"):
+		return word[:len(word)-2]
+	case len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss"):
+		return word[:len(word)-1]
+	}
+	return word
+}
+
+func unique(terms []string) []string {
+	seen := make(map[string]bool, len(terms))
+	var result []string
+	for _, term := range terms {
+		if !seen[term] {
+			seen[term] = true
+			result = append(result, term)
+		}
+	}
+	return result
+}
+
+// highlight HTML escapes text, wraps its words matching terms and cuts long text around the
+// first match
+func highlight(text string, terms map[string]bool) (string, bool) {
+	fields := strings.Fields(text)
+	first := -1
+	for w, field := range fields {
+		fields[w] = html.EscapeString(field)
+		for _, word := range words(field) {
+			if term := normalize(word); term != "" && terms[term] {
+				at := strings.Index(field, word)
+				fields[w] = html.EscapeString(field[:at]) + StartSel + html.EscapeString(word) + StopSel +
+					html.EscapeString(field[at+len(word):])
+				if first < 0 {
+					first = w
+				}
+				break
+			}
+		}
+	}
+
+	if first < 0 {
+		return "", false
+	}
+
+	start, end := 0, len(fields)
+	if len(fields) > snippetWords {
+		start = first - snippetWords/3
+		if start < 0 {
+			start = 0
+		}
+		end = start + snippetWords
+		if end > len(fields) {
+			end = len(fields)
+		}
+	}
+
+	snippet := strings.Join(fields[start:end], " ")
+	if start > 0 {
+		snippet = "... " + snippet
+	}
+	if end < len(fields) {
+		snippet += " ..."
+	}
+	return snippet, true
+}
@@ -10,6 +10,7 @@ import (
 	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/constants"
 	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/controllers"
 	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/middlewares"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/models"
 	pMetrics "git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/pkg/prometheus"
 	"github.com/gofiber/contrib/swagger"
 	"github.com/gofiber/fiber/v2"
@@ -18,32 +19,68 @@ import (
 var mu sync.Mutex
 
 // Setup func
-func Setup(app *fiber.App, logger *zap.Logger, config config.AppConfig, pMetrics *pMetrics.PrometheusMetrics) error {
+func Setup(app *fiber.App, repos *models.Repositories, logger *zap.Logger, config config.AppConfig, pMetrics *pMetrics.PrometheusMetrics) error {
 	mu.Lock()
 
 	app.Use(middlewares.LogHandler(logger, pMetrics))
 
+	auth, err := middlewares.NewAuth(config.Auth)
+	if err != nil {
+		logger.Error("Failed to initialize authentication", zap.Error(err))
+		return err
+	}
+
+	// Clients are limited by IP before their credentials are checked and by subject or IP after
+	if config.RateLimit.Enabled {
+		limiter, err := middlewares.NewRateLimiter(config, logger, pMetrics)
+		if err != nil {
+			logger.Error("Failed to initialize rate limiter", zap.Error(err))
+			return err
+		}
+		app.Use(limiter.Handler(), auth.Authenticate(), limiter.ClientHandler())
+	} else {
+		app.Use(auth.Authenticate())
+	}
+
 	app.Use(swagger.New(swagger.Config{
 		FilePath: "./assets/swagger.json",
 		Title:    "Swagger API Docs",
 	}))
 
-	err := setupMoviesController(app, logger)
+	cache := middlewares.NewResponseCache(config.Cache, repos.Revisions, config.Storage)
+	auditor := controllers.NewAuditor(logger, repos)
+
+	err = setupMoviesController(app, logger, repos, auth, cache, auditor)
+	if err != nil {
+		return err
+	}
+
+	err = setupRatingsController(app, logger, repos, auth, cache, auditor)
 	if err != nil {
 		return err
 	}
 
-	err = setupRatingsController(app, logger)
+	err = setupUsersController(app, logger, repos, cache)
 	if err != nil {
 		return err
 	}
 
-	err = setupCrewController(app, logger)
+	err = setupCrewController(app, logger, repos, auth, cache, auditor)
 	if err != nil {
 		return err
 	}
 
-	err = setupCastController(app, logger)
+	err = setupCastController(app, logger, repos, auth, cache, auditor)
+	if err != nil {
+		return err
+	}
+
+	err = setupPeopleController(app, logger, repos, cache)
+	if err != nil {
+		return err
+	}
+
+	err = setupAuditController(app, logger, repos, auth)
 	if err != nil {
 		return err
 	}
@@ -67,8 +104,8 @@ func metricsController(app *fiber.App, logger *zap.Logger, pMetrics *pMetrics.Pr
 	return nil
 }
 
-func setupMoviesController(app *fiber.App, logger *zap.Logger) error {
-	movieController, err := controllers.NewMovieController(logger)
+func setupMoviesController(app *fiber.App, logger *zap.Logger, repos *models.Repositories, auth *middlewares.Auth, cache *middlewares.ResponseCache, auditor *controllers.Auditor) error {
+	movieController, err := controllers.NewMovieController(logger, repos.Movies, auditor)
 	if err != nil {
 		logger.Error("Failed to initialize MovieController", zap.Error(err))
 		return err
@@ -76,18 +113,20 @@ func setupMoviesController(app *fiber.App, logger *zap.Logger) error {
 
 	// Register movie routes
 	movieRouter := app.Group("/movies")
-	movieRouter.Get("/", movieController.ListMovies)
-	movieRouter.Get(fmt.Sprintf("/:%s", constants.MovieId), movieController.GetMovieByID)
-	movieRouter.Post("/", movieController.AddMovie)
-	movieRouter.Delete(fmt.Sprintf("/:%s", constants.MovieId), movieController.DeleteMovieById)
-	movieRouter.Put(fmt.Sprintf("/:%s", constants.MovieId), movieController.UpdateMovie)
+	movieRouter.Get("/", cache.Handler(models.DatasetMovies), movieController.ListMovies)
+	movieRouter.Get("/search", cache.Handler(models.DatasetMovies), movieController.SearchMovies)
+	movieRouter.Get(fmt.Sprintf("/:%s", constants.MovieId), cache.Handler(models.DatasetMovies), movieController.GetMovieByID)
+	movieRouter.Post("/", auth.Require(middlewares.RoleEditor), movieController.AddMovie)
+	movieRouter.Delete(fmt.Sprintf("/:%s", constants.MovieId), auth.Require(middlewares.RoleAdmin), movieController.DeleteMovieById)
+	movieRouter.Post(fmt.Sprintf("/:%s/restore", constants.MovieId), auth.Require(middlewares.RoleAdmin), movieController.RestoreMovieById)
+	movieRouter.Put(fmt.Sprintf("/:%s", constants.MovieId), auth.Require(middlewares.RoleEditor), movieController.UpdateMovie)
 
 	return nil
 
 }
 
-func setupRatingsController(app *fiber.App, logger *zap.Logger) error {
-	ratingController, err := controllers.NewRatingsController(logger)
+func setupRatingsController(app *fiber.App, logger *zap.Logger, repos *models.Repositories, auth *middlewares.Auth, cache *middlewares.ResponseCache, auditor *controllers.Auditor) error {
+	ratingController, err := controllers.NewRatingsController(logger, repos.Ratings, repos.Movies, auditor)
 	if err != nil {
 		logger.Error("Failed to intialize RatingController", zap.Error(err))
 		return err
@@ -95,38 +134,79 @@ func setupRatingsController(app *fiber.App, logger *zap.Logger) error {
 
 	ratingRouter := app.Group("/ratings")
 
-	ratingRouter.Get("/", ratingController.ListAllMovieRatings)
-	ratingRouter.Get(fmt.Sprintf("/movies/:%s/ratings", constants.MovieId), ratingController.GetRatingsByMovieId)
-	ratingRouter.Post("/", ratingController.AddRating)
-	ratingRouter.Delete(fmt.Sprintf("/movies/:%s/user/:%s/ratings", constants.MovieId, constants.UserId), ratingController.DeleteRating)
-	ratingRouter.Put(fmt.Sprintf("/movies/:%s/user/:%s/ratings", constants.MovieId, constants.UserId), ratingController.UpdateRating)
+	ratingRouter.Get("/", cache.Handler(models.DatasetRatings), ratingController.ListAllMovieRatings)
+	ratingRouter.Get("/top", cache.Handler(models.DatasetMovies, models.DatasetRatings), ratingController.TopRatedMovies)
+	ratingRouter.Get(fmt.Sprintf("/movies/:%s/ratings", constants.MovieId), cache.Handler(models.DatasetRatings), ratingController.GetRatingsByMovieId)
+	ratingRouter.Get(fmt.Sprintf("/movies/:%s/user/:%s/ratings", constants.MovieId, constants.UserId), cache.Handler(models.DatasetRatings), ratingController.GetRating)
+	ratingRouter.Post("/", auth.Require(middlewares.RoleReader), ratingController.AddRating)
+	ratingRouter.Delete(fmt.Sprintf("/movies/:%s/user/:%s/ratings", constants.MovieId, constants.UserId), auth.Require(middlewares.RoleReader), ratingController.DeleteRating)
+	ratingRouter.Put(fmt.Sprintf("/movies/:%s/user/:%s/ratings", constants.MovieId, constants.UserId), auth.Require(middlewares.RoleReader), ratingController.UpdateRating)
 
 	return nil
 }
 
-func setupCastController(app *fiber.App, logger *zap.Logger) error {
-	castController, err := controllers.NewCastController(logger)
+func setupUsersController(app *fiber.App, logger *zap.Logger, repos *models.Repositories, cache *middlewares.ResponseCache) error {
+	userController, err := controllers.NewUsersController(logger, repos.Users)
+	if err != nil {
+		logger.Error("Failed to intialize UsersController", zap.Error(err))
+		return err
+	}
+
+	userRouter := app.Group("/users")
+	userRouter.Get(fmt.Sprintf("/:%s/ratings", constants.UserId), cache.Handler(models.DatasetRatings), userController.ListUserRatings)
+	userRouter.Get(fmt.Sprintf("/:%s/stats", constants.UserId), cache.Handler(models.DatasetRatings, models.DatasetMovies), userController.GetUserStats)
+
+	return nil
+}
+
+func setupCastController(app *fiber.App, logger *zap.Logger, repos *models.Repositories, auth *middlewares.Auth, cache *middlewares.ResponseCache, auditor *controllers.Auditor) error {
+	castController, err := controllers.NewCastController(logger, repos.Cast, auditor)
 	if err != nil {
 		logger.Error("Failed to intialize CastController", zap.Error(err))
 		return err
 	}
 
-	app.Get(fmt.Sprintf("/movies/:%s/casts", constants.MovieId), castController.ListCastMembers)
-	app.Get(fmt.Sprintf("/actor/:%s/cast", constants.CastId), castController.ListMoviesByCastId)
-	app.Put(fmt.Sprintf("/movies/:%s/casts/:%s", constants.MovieId, constants.CastId), castController.UpdateCastMember)
+	app.Get(fmt.Sprintf("/movies/:%s/casts", constants.MovieId), cache.Handler(models.DatasetCredits), castController.ListCastMembers)
+	app.Get(fmt.Sprintf("/actor/:%s/cast", constants.CastId), cache.Handler(models.DatasetCredits), castController.ListMoviesByCastId)
+	app.Put(fmt.Sprintf("/movies/:%s/casts/:%s", constants.MovieId, constants.CastId), auth.Require(middlewares.RoleEditor), castController.UpdateCastMember)
 
 	return nil
 }
 
-func setupCrewController(app *fiber.App, logger *zap.Logger) error {
-	crewController, err := controllers.NewCrewController(logger)
+func setupCrewController(app *fiber.App, logger *zap.Logger, repos *models.Repositories, auth *middlewares.Auth, cache *middlewares.ResponseCache, auditor *controllers.Auditor) error {
+	crewController, err := controllers.NewCrewController(logger, repos.Crew, auditor)
 	if err != nil {
 		logger.Error("Failed to intialize CrewController", zap.Error(err))
 		return err
 	}
 
-	app.Get(fmt.Sprintf("/movies/:%s/crew", constants.MovieId), crewController.ListCrewMembers)
-	app.Put(fmt.Sprintf("/movies/:%s/crew/:%s", constants.MovieId, constants.CrewId), crewController.UpdateCrewMember)
+	app.Get(fmt.Sprintf("/movies/:%s/crew", constants.MovieId), cache.Handler(models.DatasetCredits), crewController.ListCrewMembers)
+	app.Put(fmt.Sprintf("/movies/:%s/crew/:%s", constants.MovieId, constants.CrewId), auth.Require(middlewares.RoleEditor), crewController.UpdateCrewMember)
+
+	return nil
+}
+
+func setupPeopleController(app *fiber.App, logger *zap.Logger, repos *models.Repositories, cache *middlewares.ResponseCache) error {
+	peopleController, err := controllers.NewPeopleController(logger, repos.People)
+	if err != nil {
+		logger.Error("Failed to intialize PeopleController", zap.Error(err))
+		return err
+	}
+
+	app.Get(fmt.Sprintf("/people/:%s", constants.PersonId), cache.Handler(models.DatasetCredits, models.DatasetMovies, models.DatasetRatings), peopleController.GetPerson)
+
+	return nil
+}
+
+func setupAuditController(app *fiber.App, logger *zap.Logger, repos *models.Repositories, auth *middlewares.Auth) error {
+	auditController, err := controllers.NewAuditController(logger, repos.Audit)
+	if err != nil {
+		logger.Error("Failed to intialize AuditController", zap.Error(err))
+		return err
+	}
+
+	// The audit log names who changed what, it is not cached as writes to it bump no revision
+	app.Get("/audit", auth.Require(middlewares.RoleAdmin), auditController.ListAudit)
 
 	return nil
 }
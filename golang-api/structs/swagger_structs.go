@@ -1,15 +1,42 @@
 package structs
 
-import "git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/models"
+import (
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/models"
+	"git.pride.improwised.dev/Onboarding-2025/Krupanshi-Vaishnav/go-api/utils"
+)
 
 // swagger:parameters ListMovies
 type RequestListMovies struct {
 	// in: query
-	Page     int    `json:"page"`
-	Limit    int    `json:"limit"`
-	Name     string `json:"name"`
-	Genre    string `json:"genre"`
-	Language string `json:"language"`
+	// Cursor of the page, taken from the next or prev link of another page
+	Cursor string `json:"cursor"`
+	Limit  int    `json:"limit"`
+	// Count the movies matching the filters
+	Total bool   `json:"total"`
+	Name  string `json:"name"`
+	// Comma separated genres
+	Genre string `json:"genre"`
+	// Match any (or) or all (and) of the genres
+	// enum: or,and
+	GenreMode string `json:"genre_mode"`
+	Language  string `json:"language"`
+	Status    string `json:"status"`
+	// Comma separated fields out of id, title, release_date, runtime, popularity,
+	// vote_average and vote_count, prefixed with - for descending order
+	// example: -popularity,title
+	Sort string `json:"sort"`
+	// format: date
+	ReleaseDateMin string `json:"release_date_min"`
+	// format: date
+	ReleaseDateMax string  `json:"release_date_max"`
+	RuntimeMin     float64 `json:"runtime_min"`
+	RuntimeMax     float64 `json:"runtime_max"`
+	PopularityMin  float64 `json:"popularity_min"`
+	PopularityMax  float64 `json:"popularity_max"`
+	VoteAverageMin float64 `json:"vote_average_min"`
+	VoteAverageMax float64 `json:"vote_average_max"`
+	VoteCountMin   int     `json:"vote_count_min"`
+	VoteCountMax   int     `json:"vote_count_max"`
 }
 
 // swagger:response ResponseListMovies
@@ -17,8 +44,28 @@ type ResponseListMovies struct {
 	// in: body
 	Body struct {
 		// enum: success
-		Status string          `json:"status"`
-		Data   []models.Movies `json:"data"`
+		Status     string           `json:"status"`
+		Data       []models.Movies  `json:"data"`
+		Pagination utils.Pagination `json:"pagination"`
+	} `json:"body"`
+}
+
+// swagger:parameters SearchMovies
+type RequestSearchMovies struct {
+	// in: query
+	// required: true
+	Q     string `json:"q"`
+	Page  int    `json:"page"`
+	Limit int    `json:"limit"`
+}
+
+// swagger:response ResponseSearchMovies
+type ResponseSearchMovies struct {
+	// in: body
+	Body struct {
+		// enum: success
+		Status string                     `json:"status"`
+		Data   []models.MovieSearchResult `json:"data"`
 	} `json:"body"`
 }
 
@@ -66,11 +113,21 @@ type RequestDeleteMovieByID struct {
 	MovieID string `json:"movieId"`
 }
 
+// swagger:parameters RestoreMovieById
+type RequestRestoreMovieByID struct {
+	// in: path
+	// required: true
+	MovieID string `json:"movieId"`
+}
+
 // swagger:parameters ListAllMovieRatings
 type RequestListAllMovieRatings struct {
 	// in: query
-	Page  int `json:"page"`
-	Limit int `json:"limit"`
+	// Cursor of the page, taken from the next or prev link of another page
+	Cursor string `json:"cursor"`
+	Limit  int    `json:"limit"`
+	// Count the rated movies
+	Total bool `json:"total"`
 }
 
 // swagger:response ResponseListMovies
@@ -78,8 +135,31 @@ type ResponseListAllMovieRatings struct {
 	// in: body
 	Body struct {
 		// enum: success
-		Status string           `json:"status"`
-		Data   []models.Ratings `json:"data"`
+		Status     string                `json:"status"`
+		Data       []models.MovieRatings `json:"data"`
+		Pagination utils.Pagination      `json:"pagination"`
+	} `json:"body"`
+}
+
+// swagger:parameters TopRatedMovies
+type RequestTopRatedMovies struct {
+	// in: query
+	Genre    string `json:"genre"`
+	Language string `json:"language"`
+	// Release year
+	Year int `json:"year"`
+	// maximum: 100
+	// default: 10
+	Limit int `json:"limit"`
+}
+
+// swagger:response ResponseTopRatedMovies
+type ResponseTopRatedMovies struct {
+	// in: body
+	Body struct {
+		// enum: success
+		Status string                 `json:"status"`
+		Data   []models.TopRatedMovie `json:"data"`
 	} `json:"body"`
 }
 
@@ -92,6 +172,26 @@ type RequestGetRatingsByMovieId struct {
 
 // swagger:response ResponseGetRatingsByMovieId
 type ResponseGetRatingsByMovieId struct {
+	// in: body
+	Body struct {
+		// enum: success
+		Status string              `json:"status"`
+		Data   models.MovieRatings `json:"data"`
+	} `json:"body"`
+}
+
+// swagger:parameters GetRating
+type RequestGetRating struct {
+	// in: path
+	// required: true
+	MovieID string `json:"movieId"`
+	// in: path
+	// required: true
+	UserID string `json:"userId"`
+}
+
+// swagger:response ResponseGetRating
+type ResponseGetRating struct {
 	// in: body
 	Body struct {
 		// enum: success
@@ -133,11 +233,91 @@ type RequestUpdateRating struct {
 	}
 }
 
+// swagger:parameters ListUserRatings
+type RequestListUserRatings struct {
+	// in: path
+	// required: true
+	UserID string `json:"userId"`
+	// in: query
+	// timestamp or rating, prefixed with - for descending order
+	// default: -timestamp
+	Sort string `json:"sort"`
+	// in: query
+	// Cursor of the page, taken from the next or prev link of another page
+	Cursor string `json:"cursor"`
+	// in: query
+	Limit int `json:"limit"`
+	// in: query
+	// Count the ratings of the user
+	Total bool `json:"total"`
+}
+
+// swagger:response ResponseListUserRatings
+type ResponseListUserRatings struct {
+	// in: body
+	Body struct {
+		// enum: success
+		Status     string              `json:"status"`
+		Data       []models.UserRating `json:"data"`
+		Pagination utils.Pagination    `json:"pagination"`
+	} `json:"body"`
+}
+
+// swagger:parameters GetUserStats
+type RequestGetUserStats struct {
+	// in: path
+	// required: true
+	UserID string `json:"userId"`
+}
+
+// swagger:response ResponseGetUserStats
+type ResponseGetUserStats struct {
+	// in: body
+	Body struct {
+		// enum: success
+		Status string           `json:"status"`
+		Data   models.UserStats `json:"data"`
+	} `json:"body"`
+}
+
+// swagger:parameters ListAudit
+type RequestListAudit struct {
+	// in: query
+	// Entity written to: movie, rating, cast or crew
+	Entity string `json:"entity"`
+	// in: query
+	// Id of the entity, the id of a movie also matches its ratings, cast and crew
+	ID string `json:"id"`
+	// in: query
+	// RFC 3339 time of the oldest write listed
+	Since string `json:"since"`
+	// in: query
+	Limit int `json:"limit"`
+}
+
+// swagger:response ResponseListAudit
+type ResponseListAudit struct {
+	// in: body
+	Body struct {
+		// enum: success
+		Status string              `json:"status"`
+		Data   []models.AuditEntry `json:"data"`
+	} `json:"body"`
+}
+
 // swagger:parameters ListCrewMembers
 type RequestListCrewMembers struct {
 	// in: path
 	// required: true
 	MovieID string `json:"movieId"`
+	// in: query
+	// Cursor of the page, taken from the next or prev link of another page
+	Cursor string `json:"cursor"`
+	// in: query
+	Limit int `json:"limit"`
+	// in: query
+	// Count the items of the whole list
+	Total bool `json:"total"`
 }
 
 // swagger:response ResponseListCrewMembers
@@ -145,8 +325,9 @@ type ResponseListCrewMembers struct {
 	// in: body
 	Body struct {
 		// enum: success
-		Status string              `json:"status"`
-		Data   []models.CrewMember `json:"data"`
+		Status     string              `json:"status"`
+		Data       []models.CrewMember `json:"data"`
+		Pagination utils.Pagination    `json:"pagination"`
 	} `json:"body"`
 }
 
@@ -169,6 +350,14 @@ type RequestListCastMembers struct {
 	// in: path
 	// required: true
 	MovieID string `json:"movieId"`
+	// in: query
+	// Cursor of the page, taken from the next or prev link of another page
+	Cursor string `json:"cursor"`
+	// in: query
+	Limit int `json:"limit"`
+	// in: query
+	// Count the items of the whole list
+	Total bool `json:"total"`
 }
 
 // swagger:response ResponseListCastMembers
@@ -176,8 +365,9 @@ type ResponseListCastMembers struct {
 	// in: body
 	Body struct {
 		// enum: success
-		Status string              `json:"status"`
-		Data   []models.CastMember `json:"data"`
+		Status     string              `json:"status"`
+		Data       []models.CastMember `json:"data"`
+		Pagination utils.Pagination    `json:"pagination"`
 	} `json:"body"`
 }
 
@@ -186,6 +376,14 @@ type RequestListMoviesByCastId struct {
 	// in: path
 	// required: true
 	CastID string `json:"castId"`
+	// in: query
+	// Cursor of the page, taken from the next or prev link of another page
+	Cursor string `json:"cursor"`
+	// in: query
+	Limit int `json:"limit"`
+	// in: query
+	// Count the items of the whole list
+	Total bool `json:"total"`
 }
 
 // swagger:response ResponseListMoviesByCastId
@@ -193,8 +391,9 @@ type ResponseListMoviesByCastId struct {
 	// in: body
 	Body struct {
 		// enum: success
-		Status string   `json:"status"`
-		Data   []string `json:"data"`
+		Status     string           `json:"status"`
+		Data       []string         `json:"data"`
+		Pagination utils.Pagination `json:"pagination"`
 	} `json:"body"`
 }
 
@@ -212,6 +411,42 @@ type RequestUpdateCastMember struct {
 	}
 }
 
+// swagger:parameters GetPerson
+type RequestGetPerson struct {
+	// in: path
+	// required: true
+	PersonID string `json:"personId"`
+	// in: query
+	// cast or crew
+	Role string `json:"role"`
+	// in: query
+	// Department of the credits, Acting for the cast
+	Department string `json:"department"`
+	// in: query
+	// Comma separated year, title and rating, prefixed with - for descending order
+	// default: -year
+	Sort string `json:"sort"`
+	// in: query
+	// Cursor of the page, taken from the next or prev link of another page
+	Cursor string `json:"cursor"`
+	// in: query
+	Limit int `json:"limit"`
+	// in: query
+	// Count the credits of the filmography
+	Total bool `json:"total"`
+}
+
+// swagger:response ResponseGetPerson
+type ResponseGetPerson struct {
+	// in: body
+	Body struct {
+		// enum: success
+		Status     string                       `json:"status"`
+		Data       models.PersonWithFilmography `json:"data"`
+		Pagination utils.Pagination             `json:"pagination"`
+	} `json:"body"`
+}
+
 // swagger:response GenericSuccessResponse
 type GenericSuccessResponse struct {
 	// in: body
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"net/url"
+
 	"clevergo.tech/jsend"
 	"github.com/gofiber/fiber/v2"
 )
@@ -21,3 +23,42 @@ func JSONFail(c *fiber.Ctx, statusCode int, data interface{}) error {
 func JSONError(c *fiber.Ctx, statusCode int, err string) error {
 	return c.Status(statusCode).JSON(jsend.NewError(err, statusCode, nil))
 }
+
+// Pagination locates a page in its list, next and prev are links to the neighbouring pages
+type Pagination struct {
+	Limit int    `json:"limit"`
+	Next  string `json:"next,omitempty"`
+	Prev  string `json:"prev,omitempty"`
+	Total *int   `json:"total,omitempty"`
+}
+
+// PageBody is the jsend success body of a page of a list wrapped with its pagination
+type PageBody struct {
+	Status     string      `json:"status"`
+	Data       interface{} `json:"data"`
+	Pagination Pagination  `json:"pagination"`
+}
+
+// JSONPage is a success output writer for a page of a list, data holds the items of page
+func JSONPage[T any](c *fiber.Ctx, statusCode int, data interface{}, page Page[T]) error {
+	return c.Status(statusCode).JSON(PageBody{
+		Status: "success",
+		Data:   data,
+		Pagination: Pagination{
+			Limit: page.Limit,
+			Next:  pageLink(c, page.Next),
+			Prev:  pageLink(c, page.Prev),
+			Total: page.Total,
+		},
+	})
+}
+
+// pageLink returns the path of the request with its query pointed at cursor
+func pageLink(c *fiber.Ctx, cursor *Cursor) string {
+	if cursor == nil {
+		return ""
+	}
+	query, _ := url.ParseQuery(string(c.Request().URI().QueryString()))
+	query.Set("cursor", cursor.Encode())
+	return c.Path() + "?" + query.Encode()
+}
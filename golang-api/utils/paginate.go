@@ -1,6 +1,13 @@
 package utils
 
-import "fmt"
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+)
 
 func Paginate[T any](items []T, page, limit int) ([]T, error) {
 	if page <= 0 {
@@ -26,3 +33,119 @@ func Paginate[T any](items []T, page, limit int) ([]T, error) {
 	return items[start:end], nil
 
 }
+
+// ErrInvalidCursor is returned for a cursor token that cannot be decoded or does not fit the list
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is an opaque position in a sorted list, it holds the sort key of the item a page
+// starts after, or ends before when Before is set
+type Cursor struct {
+	Key    []string `json:"k"`
+	Before bool     `json:"b,omitempty"`
+}
+
+// Encode returns the token of the cursor handed out to clients
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a token returned by Cursor.Encode
+func DecodeCursor(token string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || len(cursor.Key) == 0 {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// PageRequest asks for the page of a list next to Cursor, or the first page without a cursor
+type PageRequest struct {
+	Cursor *Cursor
+	Limit  int
+	// Total asks for the number of items of the whole list
+	Total bool
+}
+
+// CheckKey validates the cursor of the request against a list sorted on size key columns
+func (r PageRequest) CheckKey(size int) error {
+	if r.Cursor != nil && len(r.Cursor.Key) != size {
+		return ErrInvalidCursor
+	}
+	return nil
+}
+
+// Page is a page of a list along with the cursors of its neighbours
+type Page[T any] struct {
+	Items []T
+	Limit int
+	Next  *Cursor
+	Prev  *Cursor
+	Total *int
+}
+
+// KeysetPaginate returns the page of items requested by req. items are sorted consistently
+// with compare over the keys returned by key, a nil compare keeps the order of items and
+// looks the cursor key up instead.
+func KeysetPaginate[T any](items []T, req PageRequest, key func(T) []string, compare func(a, b []string) int) (Page[T], error) {
+	if req.Limit <= 0 {
+		return Page[T]{}, fmt.Errorf("limit must be positive")
+	}
+	if req.Cursor != nil && len(items) > 0 && len(key(items[0])) != len(req.Cursor.Key) {
+		return Page[T]{}, ErrInvalidCursor
+	}
+
+	start, end := 0, len(items)
+	if req.Cursor != nil {
+		// pos is the index of the first item not before the cursor key
+		var pos int
+		if compare != nil {
+			pos = sort.Search(len(items), func(i int) bool {
+				return compare(key(items[i]), req.Cursor.Key) >= 0
+			})
+		} else {
+			pos = -1
+			for i, item := range items {
+				if slices.Equal(key(item), req.Cursor.Key) {
+					pos = i
+					break
+				}
+			}
+			if pos < 0 {
+				return Page[T]{}, ErrInvalidCursor
+			}
+		}
+
+		if req.Cursor.Before {
+			end = pos
+		} else {
+			start = pos
+			if start < len(items) && slices.Equal(key(items[start]), req.Cursor.Key) {
+				start++
+			}
+		}
+	}
+
+	if req.Cursor != nil && req.Cursor.Before {
+		start = max(end-req.Limit, 0)
+	} else {
+		end = min(start+req.Limit, len(items))
+	}
+
+	page := Page[T]{Items: items[start:end], Limit: req.Limit}
+	if end < len(items) && end > start {
+		page.Next = &Cursor{Key: key(items[end-1])}
+	}
+	if start > 0 && end > start {
+		page.Prev = &Cursor{Key: key(items[start]), Before: true}
+	}
+	if req.Total {
+		total := len(items)
+		page.Total = &total
+	}
+	return page, nil
+}
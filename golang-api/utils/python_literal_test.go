@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// sharedMarker starts the part of python_literal.go kept identical in golang-api-database
+const sharedMarker = "// The functions below are kept identical"
+
+func TestPythonLiteralCopiesMatch(t *testing.T) {
+	own, err := os.ReadFile("python_literal.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := os.ReadFile("../../golang-api-database/database/seed/python_literal.go")
+	if os.IsNotExist(err) {
+		t.Skip("golang-api-database is not checked out next to golang-api")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shared := func(src []byte) string {
+		i := strings.Index(string(src), sharedMarker)
+		if i < 0 {
+			t.Fatalf("python_literal.go lacks %q", sharedMarker)
+		}
+		return string(src[i:])
+	}
+	if shared(own) != shared(other) {
+		t.Error("pythonToJSON and pythonString differ from golang-api-database/database/seed/python_literal.go")
+	}
+}
+
+func TestDecodePythonLiteralEscapes(t *testing.T) {
+	for raw, want := range map[string]string{
+		`{'name': 'Toy Story Collection'}`: "Toy Story Collection",
+		`{'name': "Schindler's List"}`:     "Schindler's List",
+		`{'name': 'a\bb\fc'}`:              "a\bb\fc",
+		"{'name': 'line \\\ncontinued'}":   "line continued",
+		`{'name': 'caf\xe9 été'}`:          "café été",
+		`{'name': 'back\\slash \d'}`:       `back\slash \d`,
+	} {
+		var v struct {
+			Name string `json:"name"`
+		}
+		if err := DecodePythonLiteral(raw, &v); err != nil {
+			t.Errorf("%s: %v", raw, err)
+			continue
+		}
+		if v.Name != want {
+			t.Errorf("%s: name %q, want %q", raw, v.Name, want)
+		}
+	}
+}
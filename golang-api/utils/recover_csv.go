@@ -0,0 +1,216 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// RecoverCSV repairs a CSV file left behind by an interrupted write. Leftover temp files of
+// atomic rewrites are removed and a torn last record is cut off. A copy of a damaged file is
+// kept next to it before anything is truncated. A record which does not parse anywhere but at
+// the end of the file is not a torn write, the file is left alone and an error is returned so
+// the service does not start on part of the data. Pending changes are kept in the write-ahead
+// log of the file and are replayed by csvstore.Open.
+func RecoverCSV(fileName string, logger *zap.Logger) error {
+	filePath := fileName
+	if !filepath.IsAbs(filePath) {
+		workingDirPath, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("error getting working directory: %v", err)
+		}
+		filePath = filepath.Join(workingDirPath, fileName)
+	}
+
+	unlock := lockFile(filePath)
+	defer unlock()
+
+	temps, err := filepath.Glob(filepath.Join(filepath.Dir(filePath), tempPattern(filepath.Base(filePath))))
+	if err != nil {
+		return err
+	}
+	for _, temp := range temps {
+		logger.Warn("Removing temp file of an interrupted CSV rewrite", zap.String("file", temp))
+		if err := os.Remove(temp); err != nil {
+			return fmt.Errorf("error removing %s: %v", temp, err)
+		}
+	}
+
+	if err := repairTail(filePath, logger); err != nil {
+		return fmt.Errorf("error repairing %s: %v", fileName, err)
+	}
+
+	return nil
+}
+
+// repairTail cuts off a last record which does not parse or has fewer fields than the header and
+// was cut before its line break. It fails on a record which does not parse before the last one.
+func repairTail(filePath string, logger *zap.Logger) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+
+	reader := csv.NewReader(bufio.NewReader(file))
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	var (
+		headerLen     int
+		lastGood      int64
+		lastRecordLen int
+		lastStart     int64
+		readErr       error
+	)
+
+	for {
+		start := reader.InputOffset()
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			readErr = err
+			break
+		}
+
+		if headerLen == 0 {
+			headerLen = len(record)
+		}
+		lastStart, lastGood, lastRecordLen = start, reader.InputOffset(), len(record)
+	}
+	file.Close()
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return err
+	}
+
+	truncateAt := int64(-1)
+	switch {
+	case readErr != nil:
+		torn, err := isTornTail(filePath, info.Size(), readErr)
+		if err != nil {
+			return err
+		}
+		if !torn {
+			return fmt.Errorf("malformed record, fix or restore the file: %w", readErr)
+		}
+		truncateAt = lastGood
+	case lastRecordLen != headerLen && lastGood == info.Size() && !endsWithNewline(filePath, info.Size()):
+		// The last record was cut before its line break
+		truncateAt = lastStart
+	}
+
+	if truncateAt < 0 {
+		return nil
+	}
+
+	backup := fmt.Sprintf("%s.corrupt-%d", filePath, time.Now().Unix())
+	if err := copyFile(filePath, backup); err != nil {
+		return err
+	}
+
+	logger.Warn("Truncating torn CSV tail",
+		zap.String("file", filePath),
+		zap.Int64("offset", truncateAt),
+		zap.Int64("size", info.Size()),
+		zap.String("backup", backup),
+		zap.NamedError("parseError", readErr),
+	)
+
+	if err := os.Truncate(filePath, truncateAt); err != nil {
+		return err
+	}
+
+	file, err = os.OpenFile(filePath, os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return file.Sync()
+}
+
+// isTornTail reports whether readErr, which reading filePath of size bytes failed with, is at its
+// last line and that line was cut before its line break, as a write interrupted by a crash leaves
+// it. A record failing earlier is followed by records which a truncation would lose.
+func isTornTail(filePath string, size int64, readErr error) (bool, error) {
+	var parseErr *csv.ParseError
+	if !errors.As(readErr, &parseErr) || endsWithNewline(filePath, size) {
+		return false, nil
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	// The last line is the one after the last line break, the file not ending with one
+	lines := 1
+	reader := bufio.NewReader(file)
+	for {
+		b, err := reader.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return false, err
+		}
+		if b == '\n' {
+			lines++
+		}
+	}
+	return parseErr.Line >= lines, nil
+}
+
+func endsWithNewline(filePath string, size int64) bool {
+	if size == 0 {
+		return true
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	last := make([]byte, 1)
+	if _, err := file.ReadAt(last, size-1); err != nil {
+		return false
+	}
+	return last[0] == '\n'
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
@@ -5,8 +5,19 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 )
 
+// fileLocks serializes writers of the same CSV file
+var fileLocks sync.Map
+
+func lockFile(filePath string) func() {
+	value, _ := fileLocks.LoadOrStore(filePath, &sync.Mutex{})
+	mu := value.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
 func SaveToCSV(fileName string, updatedRows [][]string) error {
 	// Get the current working directory
 	workingDirPath, err := os.Getwd()
@@ -26,21 +37,79 @@ func SaveToCSV(fileName string, updatedRows [][]string) error {
 	return nil
 }
 
-func UpdateCSV(filepath string, data [][]string) error {
-	file, err := os.Create(filepath) // Overwrite the existing file
+// UpdateCSV replaces the content of the file with data. Rows are written to a temp file in the
+// same directory which is fsynced and then renamed over the live file, so readers and crashes
+// only ever see the old or the new content.
+func UpdateCSV(filePath string, data [][]string) error {
+	unlock := lockFile(filePath)
+	defer unlock()
+
+	return writeCSVAtomic(filePath, data)
+}
+
+func writeCSVAtomic(filePath string, data [][]string) error {
+	dir, base := filepath.Split(filePath)
+	if dir == "" {
+		dir = "."
+	}
+
+	file, err := os.CreateTemp(dir, tempPattern(base))
 	if err != nil {
 		return err
 	}
-	defer file.Close()
+	tempPath := file.Name()
 
-	writer := csv.NewWriter(file)
-	defer writer.Flush() // Ensure data is written to the file
+	// Remove the temp file on every failure path, after a successful rename it no longer exists
+	defer os.Remove(tempPath)
 
+	writer := csv.NewWriter(file)
 	for _, row := range data {
 		if err := writer.Write(row); err != nil {
+			file.Close()
 			return err
 		}
 	}
 
-	return nil
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	if info, err := os.Stat(filePath); err == nil {
+		if err := os.Chmod(tempPath, info.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Rename(tempPath, filePath); err != nil {
+		return err
+	}
+
+	return syncDir(dir)
+}
+
+// tempPattern is the os.CreateTemp pattern of temp files written for base
+func tempPattern(base string) string {
+	return "." + base + ".tmp-*"
+}
+
+// syncDir fsyncs a directory so that renames and removals inside it are durable
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
 }